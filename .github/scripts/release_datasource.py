@@ -1,7 +1,9 @@
 #!/usr/bin/env python3
+import hashlib
 import os
 import sys
 import tarfile
+import tempfile
 import subprocess
 from pathlib import Path
 from typing import Dict, List
@@ -80,6 +82,42 @@ class PackageBuilder:
             "version": self.version,
         }
 
+    def write_checksum(self) -> Path:
+        """Write the SHA-256 of the archive in sha256sum format, which ecos verifies before extraction."""
+        digest = hashlib.sha256(self.archive_path.read_bytes()).hexdigest()
+        checksum_path = self.archive_path.with_name(self.archive_name + ".sha256")
+        checksum_path.write_text(f"{digest}  {self.archive_name}\n")
+        print(f"🔒 sha256 {digest}")
+        return checksum_path
+
+    def sign(self, secret_key: str, password: str) -> Path:
+        """Sign the archive with minisign; ecos verifies the signature with the release public key."""
+        signature_path = self.archive_path.with_name(self.archive_name + ".minisig")
+        trusted_comment = f"ds/{self.datasource}/v{self.version} {self.archive_name}"
+        with tempfile.NamedTemporaryFile("w", suffix=".key", delete=False) as key_file:
+            key_file.write(secret_key)
+        try:
+            subprocess.run(
+                ["minisign", "-S", "-s", key_file.name, "-m", str(self.archive_path),
+                 "-x", str(signature_path), "-t", trusted_comment],
+                input=f"{password}\n", text=True, check=True, capture_output=True,
+            )
+        finally:
+            os.unlink(key_file.name)
+        print(f"🔏 Signed {self.archive_name}")
+        return signature_path
+
+    def verify(self, signature_path: Path, public_key: str):
+        """Verify the signature with the release public key built into ecos, so a package ecos cannot verify is never published."""
+        key = public_key.strip().splitlines()[-1].strip() if public_key.strip() else ""
+        if not key:
+            raise RuntimeError("MINISIGN_PUBLIC_KEY is empty: set the MODELS_MINISIGN_PUBLIC_KEY variable to the public key of MODELS_MINISIGN_SECRET_KEY")
+        subprocess.run(
+            ["minisign", "-V", "-P", key, "-m", str(self.archive_path), "-x", str(signature_path)],
+            check=True, capture_output=True, text=True,
+        )
+        print(f"✅ Verified {self.archive_name} with the release public key")
+
     def _track_files(self, path: Path):
         if path.is_file():
             self.package_files.append(str(path))
@@ -108,9 +146,11 @@ class GitHubReleaser:
 
         release_notes = self._generate_release_notes(artifact, builder.package_files, branch, builder)
         release = self._create_release(tag_name, artifact, release_notes)
-        self._upload_asset(release["upload_url"], archive)
+        self._upload_asset(release["upload_url"], archive, "application/gzip")
+        self._upload_asset(release["upload_url"], artifact["checksum"], "text/plain")
+        self._upload_asset(release["upload_url"], artifact["signature"], "text/plain")
 
-        print(f"✅ Uploaded {archive} to release {tag_name}")
+        print(f"✅ Uploaded {archive} with its checksum and signature to release {tag_name}")
 
     def _headers(self):
         return {"Authorization": f"token {self.token}", "Accept": "application/vnd.github+json"}
@@ -137,15 +177,15 @@ class GitHubReleaser:
         r.raise_for_status()
         return r.json()
 
-    def _upload_asset(self, upload_url: str, archive_path: str):
+    def _upload_asset(self, upload_url: str, asset_path: str, content_type: str):
         upload_url = upload_url.split("{")[0]
-        filename = Path(archive_path).name
-        with open(archive_path, "rb") as f:
+        filename = Path(asset_path).name
+        with open(asset_path, "rb") as f:
             r = requests.post(
                 f"{upload_url}?name={filename}",
                 headers={
                     "Authorization": f"token {self.token}",
-                    "Content-Type": "application/gzip",
+                    "Content-Type": content_type,
                 },
                 data=f,
             )
@@ -221,17 +261,25 @@ def main():
         print(f"❌ Datasource '{datasource}' not found in config")
         sys.exit(1)
 
-    builder = PackageBuilder(config, datasource)
-    builder.version = version_from_tag
-    artifact = builder.build()
-
     token = os.getenv("GITHUB_TOKEN")
     repo_name = os.getenv("GITHUB_REPOSITORY", "ecos-labs/ecos-core")
     branch = os.getenv("GITHUB_REF_NAME", "main")
+    secret_key = os.getenv("MINISIGN_SECRET_KEY")
 
     if not token or not repo_name:
         print("❌ Missing GITHUB_TOKEN or GITHUB_REPOSITORY environment variables")
         sys.exit(1)
+    if not secret_key:
+        print("❌ Missing MINISIGN_SECRET_KEY: ecos refuses unsigned packages")
+        sys.exit(1)
+
+    builder = PackageBuilder(config, datasource)
+    builder.version = version_from_tag
+    artifact = builder.build()
+    artifact["checksum"] = str(builder.write_checksum())
+    signature = builder.sign(secret_key, os.getenv("MINISIGN_PASSWORD", ""))
+    builder.verify(signature, os.getenv("MINISIGN_PUBLIC_KEY", ""))
+    artifact["signature"] = str(signature)
 
     releaser = GitHubReleaser(token, repo_name)
     releaser.release(artifact, builder, branch)
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+)
+
+// newAthenaQuerier returns a querier for the adhoc Athena workgroup and the project database
+func newAthenaQuerier(ctx context.Context, cfg *config.EcosConfig) (*query.AthenaQuerier, error) {
+	workgroup := cfg.AWS.AdhocWorkgroup
+	if workgroup == "" {
+		workgroup = cfg.AWS.DBTWorkgroup
+	}
+	if workgroup == "" {
+		return nil, errors.New("querying the models needs aws.adhoc_workgroup in .ecos.yaml")
+	}
+
+	awsCfg, err := awssession.Load(ctx, awssession.FromConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	querier := &query.AthenaQuerier{
+		Client:    awssession.NewAthenaClient(awsCfg, cfg.AWS.Endpoints),
+		Workgroup: workgroup,
+		Database:  cfg.AWS.Database,
+	}
+	if cfg.AWS.ResultsBucket != "" {
+		querier.OutputLocation = fmt.Sprintf("s3://%s/queries/", cfg.AWS.ResultsBucket)
+	}
+	return querier, nil
+}
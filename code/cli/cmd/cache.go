@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local cache of model release lists and packages",
+	Long: `Manage the local cache of model release lists and packages.
+
+ecos init and ecos models upgrade cache the release lists and the packages they
+download under the user cache directory (ECOS_CACHE_DIR overrides it), so later runs
+don't call the release source again. Release lists answer "latest" lookups for an
+hour; packages are kept until pruned and verified each time they are used.
+
+Available subcommands:
+  list     List the cached release lists and packages
+  prune    Remove cached release lists and packages
+
+Examples:
+  ecos cache list
+  ecos cache prune --older-than 720h`,
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached release lists and packages",
+	Long: `List the cached release lists and packages with their size and when they were
+last used.
+
+Examples:
+  ecos cache list`,
+	RunE: runCacheList,
+}
+
+// cachePruneCmd represents the cache prune command
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached release lists and packages",
+	Long: `Remove cached release lists and packages, all of them unless filtered by
+--datasource or --older-than.
+
+Examples:
+  ecos cache prune
+  ecos cache prune --datasource aws_cur
+  ecos cache prune --older-than 720h
+  ecos cache prune --dry-run`,
+	RunE: runCachePrune,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	cachePruneCmd.Flags().String("datasource", "", "only remove the entries of a datasource, e.g. aws_cur")
+	cachePruneCmd.Flags().Duration("older-than", 0, "only remove the entries not used for this long, e.g. 720h")
+}
+
+func runCacheList(_ *cobra.Command, _ []string) error {
+	utils.PrintHeader("ecos cache list")
+
+	cache, err := initUtils.NewModelCache()
+	if err != nil {
+		return err
+	}
+	entries, err := cache.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		utils.PrintInfo(fmt.Sprintf("The model cache is empty (%s)", cache.Dir))
+		return nil
+	}
+
+	var total int64
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, []string{e.Datasource, cacheEntryName(e), formatBytes(e.Size), e.LastUsed.Local().Format(time.DateTime)})
+		total += e.Size
+	}
+	utils.PrintTable([]string{"DATASOURCE", "VERSION", "SIZE", "LAST USED"}, rows)
+	fmt.Println()
+	utils.PrintInfo(fmt.Sprintf("%d entries, %s in %s", len(entries), formatBytes(total), cache.Dir))
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, _ []string) error {
+	datasource, _ := cmd.Flags().GetString("datasource")
+	olderThan, _ := cmd.Flags().GetDuration("older-than")
+
+	utils.PrintHeader("ecos cache prune")
+
+	cache, err := initUtils.NewModelCache()
+	if err != nil {
+		return err
+	}
+	entries, err := cache.List()
+	if err != nil {
+		return err
+	}
+	stale := pruneCandidates(entries, datasource, olderThan, time.Now())
+	if len(stale) == 0 {
+		utils.PrintInfo("Nothing to prune")
+		return nil
+	}
+
+	if dryRun {
+		utils.PrintDryRun("Would remove the following cache entries:")
+		for _, e := range stale {
+			utils.Print("  %s %s (%s)", e.Datasource, cacheEntryName(e), formatBytes(e.Size))
+		}
+		return nil
+	}
+
+	var freed int64
+	for _, e := range stale {
+		if err := cache.Remove(e); err != nil {
+			return err
+		}
+		utils.PrintDebug(fmt.Sprintf("Removed %s", e.Path))
+		freed += e.Size
+	}
+	utils.PrintSuccess(fmt.Sprintf("Removed %d cache entries, %s freed", len(stale), formatBytes(freed)))
+	return nil
+}
+
+// pruneCandidates returns the entries of datasource, or of all datasources when empty,
+// not used for olderThan
+func pruneCandidates(entries []initUtils.CacheEntry, datasource string, olderThan time.Duration, now time.Time) []initUtils.CacheEntry {
+	var stale []initUtils.CacheEntry
+	for _, e := range entries {
+		if datasource != "" && e.Datasource != datasource {
+			continue
+		}
+		if olderThan > 0 && now.Sub(e.LastUsed) < olderThan {
+			continue
+		}
+		stale = append(stale, e)
+	}
+	return stale
+}
+
+func cacheEntryName(e initUtils.CacheEntry) string {
+	if e.Version == "" {
+		return "(release list)"
+	}
+	return e.Version
+}
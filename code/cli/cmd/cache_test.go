@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	entries := []initUtils.CacheEntry{
+		{Datasource: "aws_cur", LastUsed: now.Add(-time.Hour)},
+		{Datasource: "aws_cur", Version: "v1.1.0", LastUsed: now.Add(-time.Hour)},
+		{Datasource: "aws_cur", Version: "v1.0.0", LastUsed: now.Add(-60 * 24 * time.Hour)},
+		{Datasource: "aws_focus", Version: "v2.0.0", LastUsed: now.Add(-60 * 24 * time.Hour)},
+	}
+
+	tests := []struct {
+		name       string
+		datasource string
+		olderThan  time.Duration
+		want       int
+	}{
+		{"everything", "", 0, 4},
+		{"datasource", "aws_focus", 0, 1},
+		{"older than 30 days", "", 30 * 24 * time.Hour, 2},
+		{"datasource older than 30 days", "aws_cur", 30 * 24 * time.Hour, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pruneCandidates(entries, tt.datasource, tt.olderThan, now); len(got) != tt.want {
+				t.Errorf("pruneCandidates() = %+v, want %d entries", got, tt.want)
+			}
+		})
+	}
+}
@@ -286,11 +286,12 @@ vars:
   iceberg_enabled: false
   billing_period_start: null
   billing_period_end: null
-  ecos_materialization_mode: "view"
-  ecos_layer_materializations:
+  materialization_mode: "view"
+  materialization_layer_overrides:
     bronze: "view"
     silver: "view"
     gold: "view"
+  materialization_overrides: {}
   ecos_use_iceberg: false
   ecos_enable_partitioning: true
 
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/iac"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelexport"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [model]",
+	Short: "Export serve models and ecos project artifacts",
+	Long: `Export a serve model to local files, or artifacts derived from your ecos project.
+
+'ecos export <model>' unloads the model through the adhoc Athena workgroup to the
+results bucket and downloads it to <out>/<model>/billing_period=YYYY-MM/, one
+partition per billing period, as Parquet, CSV or Excel (xlsx). Models without a
+billing period are exported whole. manifest.json in the model's directory lists
+the exported files; partitions it records as complete are skipped when the export
+is run again, unless --force is set.
+
+Exportable models:
+  serve_core__cost_service_account_daily
+  serve_meta__account_metadata
+
+Available subcommands:
+  iac       Render the project's cloud resources as Terraform or CloudFormation
+
+Examples:
+  ecos export serve_core__cost_service_account_daily --period 2025-05 --format parquet --out exports/
+  ecos export serve_core__cost_service_account_daily --period 2025-01..2025-06 --format xlsx
+  ecos export serve_meta__account_metadata --format csv
+  ecos export iac
+  ecos export iac --format cloudformation`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExportModel,
+}
+
+// exportIaCCmd represents the export iac command
+var exportIaCCmd = &cobra.Command{
+	Use:   "iac",
+	Short: "Render the project's cloud resources as infrastructure-as-code",
+	Long: `Render the S3 bucket, folders, lifecycle rules and Athena workgroups defined
+in .ecos.yaml as Terraform or CloudFormation.
+
+The generated files describe the same resources, names, settings and tags that
+'ecos init' creates through the AWS APIs, so they can be reviewed and applied
+through your own infrastructure pipeline.
+
+Examples:
+  ecos export iac
+  ecos export iac --format cloudformation
+  ecos export iac --format terraform --output-dir ./infra/ecos`,
+	RunE: runExportIaC,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportIaCCmd)
+
+	exportCmd.Flags().String("period", "", "billing period YYYY-MM or range YYYY-MM..YYYY-MM (default: previous month)")
+	exportCmd.Flags().String("format", string(modelexport.FormatParquet), "file format (parquet, csv, xlsx)")
+	exportCmd.Flags().String("out", "exports", "output directory, relative to the project directory")
+	exportCmd.Flags().Bool("force", false, "export partitions again that the manifest records as complete")
+	exportCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+
+	exportIaCCmd.Flags().StringP("format", "f", string(iac.FormatTerraform), "IaC format (terraform, cloudformation)")
+	exportIaCCmd.Flags().StringP("output-dir", "o", "", "output directory (default: infra/<format> in the project directory)")
+	exportIaCCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+func runExportIaC(cmd *cobra.Command, args []string) error {
+	formatFlag, _ := cmd.Flags().GetString("format")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos export iac")
+
+	format, err := iac.ParseFormat(formatFlag)
+	if err != nil {
+		return err
+	}
+
+	// Check if .ecos.yaml exists
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		utils.PrintError("No .ecos.yaml found in project directory")
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	resources := iac.NewAWSResources(
+		ecosConfig.ProjectName,
+		ecosConfig.AWS.Region,
+		ecosConfig.AWS.ResultsBucket,
+		ecosConfig.AWS.DBTWorkgroup,
+		ecosConfig.AWS.AdhocWorkgroup,
+	)
+
+	if outputDir == "" {
+		outputDir = filepath.Join(projectDir, "infra", string(format))
+	}
+
+	paths, err := iac.Write(format, resources, outputDir)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", format, err)
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("Rendered %s for project %s", format, ecosConfig.ProjectName))
+	for _, path := range paths {
+		fmt.Printf("  • %s\n", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelexport"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+func runExportModel(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Help()
+	}
+	periodFlag, _ := cmd.Flags().GetString("period")
+	format, _ := cmd.Flags().GetString("format")
+	out, _ := cmd.Flags().GetString("out")
+	force, _ := cmd.Flags().GetBool("force")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos export")
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	req, err := resolveExportRequest(ecosConfig, projectDir, args[0], periodFlag, format, out, force)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		for _, period := range exportPeriods(req) {
+			if period == "" {
+				utils.PrintDryRun(fmt.Sprintf("Would export %s as %s to %s", req.Model.Name, req.Format, filepath.Join(req.OutDir, req.Model.Name)))
+				continue
+			}
+			utils.PrintDryRun(fmt.Sprintf("Would export %s for %s as %s to %s", req.Model.Name, period, req.Format, filepath.Join(req.OutDir, req.Model.Name, "billing_period="+period)))
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	querier, err := newAthenaQuerier(ctx, ecosConfig)
+	if err != nil {
+		return err
+	}
+	awsCfg, err := awssession.Load(ctx, awssession.FromConfig(ecosConfig))
+	if err != nil {
+		return err
+	}
+	exporter := &modelexport.Exporter{
+		Executor: querier,
+		S3:       awssession.NewS3Client(awsCfg, ecosConfig.AWS.Endpoints),
+		Database: ecosConfig.AWS.Database,
+		Bucket:   ecosConfig.AWS.ResultsBucket,
+		Prefix:   modelexport.DefaultPrefix,
+		Progress: utils.PrintProgress,
+	}
+
+	started := time.Now()
+	result, err := exporter.Export(ctx, req)
+	if result != nil && len(result.Skipped) > 0 {
+		utils.PrintInfo(fmt.Sprintf("Skipped %s, already exported (use --force to export again)", strings.Join(result.Skipped, ", ")))
+	}
+	if err != nil {
+		if result != nil && len(result.Exported) > 0 {
+			utils.PrintWarning("Run the same command again to resume the export")
+		}
+		return err
+	}
+
+	utils.PrintInfo(fmt.Sprintf("Manifest written to %s", modelexport.ManifestPath(filepath.Join(req.OutDir, req.Model.Name))))
+	utils.PrintSuccess(fmt.Sprintf("Exported %d partitions of %s in %s", len(result.Exported), req.Model.Name, time.Since(started).Round(time.Second)))
+	return nil
+}
+
+// resolveExportRequest validates the model, billing periods and format of an export
+func resolveExportRequest(cfg *config.EcosConfig, projectDir, model, period, format, out string, force bool) (modelexport.Request, error) {
+	m, err := modelexport.FindModel(model)
+	if err != nil {
+		return modelexport.Request{}, err
+	}
+	f, err := modelexport.ParseFormat(format)
+	if err != nil {
+		return modelexport.Request{}, err
+	}
+
+	req := modelexport.Request{Model: m, Format: f, OutDir: out, Force: force}
+	if !filepath.IsAbs(req.OutDir) {
+		req.OutDir = filepath.Join(projectDir, req.OutDir)
+	}
+	if m.Partitioned {
+		if req.Periods, err = parsePeriodRange(period); err != nil {
+			return modelexport.Request{}, err
+		}
+	} else if period != "" {
+		return modelexport.Request{}, fmt.Errorf("%s has no billing periods, remove --period", m.Name)
+	}
+
+	if cfg.AWS.Database == "" {
+		return modelexport.Request{}, errors.New("export needs aws.database in .ecos.yaml")
+	}
+	if cfg.AWS.ResultsBucket == "" {
+		return modelexport.Request{}, errors.New("export needs aws.results_bucket in .ecos.yaml")
+	}
+	return req, nil
+}
+
+// parsePeriodRange expands YYYY-MM or YYYY-MM..YYYY-MM into its billing periods. An
+// empty value is the previous month.
+func parsePeriodRange(value string) ([]string, error) {
+	if value == "" {
+		return []string{time.Now().UTC().AddDate(0, -1, 0).Format(config.BillingPeriodLayout)}, nil
+	}
+
+	from, to, isRange := strings.Cut(value, "..")
+	if !isRange {
+		to = from
+	}
+	start, err := config.ParseBillingPeriod(from)
+	if err != nil {
+		return nil, fmt.Errorf("--period: %w", err)
+	}
+	end, err := config.ParseBillingPeriod(to)
+	if err != nil {
+		return nil, fmt.Errorf("--period: %w", err)
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("--period: %s is after %s", from, to)
+	}
+
+	var periods []string
+	for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+		periods = append(periods, m.Format(config.BillingPeriodLayout))
+	}
+	return periods, nil
+}
+
+// exportPeriods returns the billing periods of the request, one empty period for a
+// model exported whole
+func exportPeriods(req modelexport.Request) []string {
+	if !req.Model.Partitioned {
+		return []string{""}
+	}
+	return req.Periods
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelexport"
+	"github.com/spf13/cobra"
+)
+
+func newExportIaCTestCmd(projectDir, format string) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringP("format", "f", format, "")
+	cmd.Flags().StringP("output-dir", "o", "", "")
+	cmd.Flags().StringP("project-dir", "p", projectDir, "")
+	return cmd
+}
+
+func TestRunExportIaC_NoEcosYaml(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := runExportIaC(newExportIaCTestCmd(tmpDir, "terraform"), []string{}); err == nil {
+		t.Error("runExportIaC() expected error for missing .ecos.yaml, got nil")
+	}
+}
+
+func TestRunExportIaC_InvalidFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := runExportIaC(newExportIaCTestCmd(tmpDir, "pulumi"), []string{}); err == nil {
+		t.Error("runExportIaC() expected error for unsupported format, got nil")
+	}
+}
+
+func TestRunExportIaC_WithValidConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ecosConfig := `project_name: test-project
+aws:
+  region: us-east-1
+  database: test_database
+  dbt_workgroup: test-dbt
+  adhoc_workgroup: test-adhoc
+  results_bucket: test-bucket
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".ecos.yaml"), []byte(ecosConfig), 0o600); err != nil {
+		t.Fatalf("failed to write .ecos.yaml: %v", err)
+	}
+
+	tests := []struct {
+		format string
+		files  []string
+	}{
+		{"terraform", []string{"main.tf", "outputs.tf"}},
+		{"cloudformation", []string{"ecos-resources.yaml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := runExportIaC(newExportIaCTestCmd(tmpDir, tt.format), []string{}); err != nil {
+				t.Fatalf("runExportIaC() error = %v", err)
+			}
+			for _, file := range tt.files {
+				path := filepath.Join(tmpDir, "infra", tt.format, file)
+				if _, err := os.Stat(path); err != nil {
+					t.Errorf("expected %s to exist: %v", path, err)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveExportRequest(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.AWS.Database = "ecos"
+	cfg.AWS.ResultsBucket = "ecos-results"
+
+	req, err := resolveExportRequest(cfg, "proj", "serve_core__cost_service_account_daily", "2024-11..2025-02", "CSV", "exports", false)
+	if err != nil {
+		t.Fatalf("resolveExportRequest() error = %v", err)
+	}
+	if !reflect.DeepEqual(req.Periods, []string{"2024-11", "2024-12", "2025-01", "2025-02"}) || req.Format != modelexport.FormatCSV || req.OutDir != filepath.Join("proj", "exports") {
+		t.Errorf("request = %+v", req)
+	}
+
+	req, err = resolveExportRequest(cfg, "proj", "serve_meta__account_metadata", "", "xlsx", "/tmp/out", false)
+	if err != nil || req.Periods != nil || req.OutDir != "/tmp/out" {
+		t.Errorf("resolveExportRequest() = %+v, %v", req, err)
+	}
+
+	for _, tt := range []struct{ name, model, period, format string }{
+		{"unknown model", "gold_core__service_monthly", "", "parquet"},
+		{"unknown format", "serve_core__cost_service_account_daily", "", "json"},
+		{"malformed period", "serve_core__cost_service_account_daily", "2025-5", "parquet"},
+		{"reversed range", "serve_core__cost_service_account_daily", "2025-05..2025-01", "parquet"},
+		{"period of an unpartitioned model", "serve_meta__account_metadata", "2025-05", "parquet"},
+	} {
+		if _, err := resolveExportRequest(cfg, "proj", tt.model, tt.period, tt.format, "exports", false); err == nil {
+			t.Errorf("resolveExportRequest() expected error for %s", tt.name)
+		}
+	}
+
+	cfg.AWS.ResultsBucket = ""
+	if _, err := resolveExportRequest(cfg, "proj", "serve_meta__account_metadata", "", "csv", "exports", false); err == nil {
+		t.Error("resolveExportRequest() expected error without a results bucket")
+	}
+}
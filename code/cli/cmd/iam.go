@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/permissions"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// iamCmd represents the iam command
+var iamCmd = &cobra.Command{
+	Use:   "iam",
+	Short: "Inspect the AWS IAM permissions ecos needs",
+	Long: `Inspect the AWS IAM permissions ecos needs.
+
+Available subcommands:
+  policy    Generate a least-privilege IAM policy for an ecos command
+
+Examples:
+  ecos iam policy --for init
+  ecos iam policy --for transform --cur-bucket my-cur-bucket`,
+}
+
+// iamPolicyCmd represents the iam policy command
+var iamPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Generate a least-privilege IAM policy for an ecos command",
+	Long: `Generate a minimal IAM policy document for init, transform or destroy.
+
+The policy only contains the S3, Athena, Glue and STS actions the command calls
+and is scoped to the project's results bucket, Athena workgroups and Glue
+databases from .ecos.yaml. The policy JSON is printed to stdout unless --output
+is given.
+
+The AWS account ID is resolved from the current credentials unless --account-id
+is set. For transform, pass --cur-bucket so read access to the raw CUR data is
+scoped to its bucket; otherwise a placeholder is emitted.
+
+Examples:
+  ecos iam policy --for init
+  ecos iam policy --for transform --cur-bucket my-cur-bucket
+  ecos iam policy --for destroy --output destroy-policy.json`,
+	RunE: runIAMPolicy,
+}
+
+func init() {
+	rootCmd.AddCommand(iamCmd)
+	iamCmd.AddCommand(iamPolicyCmd)
+
+	iamPolicyCmd.Flags().String("for", "", "command to generate the policy for (init, transform, destroy)")
+	iamPolicyCmd.Flags().String("account-id", "", "AWS account ID (default: resolved from current credentials)")
+	iamPolicyCmd.Flags().String("cur-bucket", "", "S3 bucket holding the raw CUR data (transform only)")
+	iamPolicyCmd.Flags().StringP("output", "o", "", "write the policy to a file instead of stdout")
+	iamPolicyCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+	_ = iamPolicyCmd.MarkFlagRequired("for")
+}
+
+func runIAMPolicy(cmd *cobra.Command, args []string) error {
+	purposeFlag, _ := cmd.Flags().GetString("for")
+	accountID, _ := cmd.Flags().GetString("account-id")
+	curBucket, _ := cmd.Flags().GetString("cur-bucket")
+	outputPath, _ := cmd.Flags().GetString("output")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	purpose, err := permissions.ParsePurpose(purposeFlag)
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	if accountID == "" {
+		accountID, _, err = initUtils.GetAWSAccountAndRegionWithOptions(
+			context.Background(), 10*time.Second, awssession.FromConfig(ecosConfig))
+		if err != nil {
+			return fmt.Errorf("failed to resolve AWS account ID (use --account-id to set it): %w", err)
+		}
+	}
+
+	scope := policyScopeFromConfig(ecosConfig, accountID, curBucket)
+	policy, err := permissions.PolicyFor(purpose, scope)
+	if err != nil {
+		return err
+	}
+
+	data, err := policy.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to encode policy: %w", err)
+	}
+
+	if purpose == permissions.PurposeTransform && curBucket == "" {
+		fmt.Fprintln(os.Stderr, "! CUR data bucket unknown, replace REPLACE_WITH_CUR_BUCKET or pass --cur-bucket")
+	}
+
+	if outputPath == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write policy: %w", err)
+	}
+	utils.PrintSuccess(fmt.Sprintf("IAM policy for '%s' written to %s", purpose, outputPath))
+
+	return nil
+}
+
+// policyScopeFromConfig builds the policy scope from the project configuration
+func policyScopeFromConfig(cfg *config.EcosConfig, accountID, curBucket string) permissions.Scope {
+	scope := permissions.Scope{
+		AccountID:   accountID,
+		Region:      cfg.AWS.Region,
+		Bucket:      cfg.AWS.ResultsBucket,
+		Database:    cfg.AWS.Database,
+		CURDatabase: cfg.Transform.DBT.Vars["cur_schema"],
+		CURTable:    cfg.Transform.DBT.Vars["cur_table"],
+		CURBucket:   curBucket,
+	}
+	if cfg.AWS.DataExport != nil {
+		scope.DataExport = cfg.AWS.DataExport.Name
+		scope.DataExportDatabase = cfg.AWS.DataExport.GlueDatabase
+	}
+	for _, wg := range []string{cfg.AWS.DBTWorkgroup, cfg.AWS.AdhocWorkgroup} {
+		if wg != "" {
+			scope.Workgroups = append(scope.Workgroups, wg)
+		}
+	}
+	return scope
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newIAMPolicyTestCmd(projectDir, purpose, output string) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("for", purpose, "")
+	cmd.Flags().String("account-id", "123456789012", "")
+	cmd.Flags().String("cur-bucket", "", "")
+	cmd.Flags().StringP("output", "o", output, "")
+	cmd.Flags().StringP("project-dir", "p", projectDir, "")
+	return cmd
+}
+
+func TestRunIAMPolicy_InvalidPurpose(t *testing.T) {
+	if err := runIAMPolicy(newIAMPolicyTestCmd(t.TempDir(), "ingest", ""), []string{}); err == nil {
+		t.Error("runIAMPolicy() expected error for unsupported purpose, got nil")
+	}
+}
+
+func TestRunIAMPolicy_NoEcosYaml(t *testing.T) {
+	if err := runIAMPolicy(newIAMPolicyTestCmd(t.TempDir(), "init", ""), []string{}); err == nil {
+		t.Error("runIAMPolicy() expected error for missing .ecos.yaml, got nil")
+	}
+}
+
+func TestRunIAMPolicy_WritesPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ecosConfig := `project_name: test-project
+transform:
+  dbt:
+    vars:
+      cur_schema: "cur"
+      cur_table: "cur_data"
+aws:
+  region: eu-central-1
+  database: test_project
+  dbt_workgroup: test-dbt
+  adhoc_workgroup: test-adhoc
+  results_bucket: test-bucket
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".ecos.yaml"), []byte(ecosConfig), 0o600); err != nil {
+		t.Fatalf("failed to write .ecos.yaml: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "policy.json")
+	if err := runIAMPolicy(newIAMPolicyTestCmd(tmpDir, "destroy", outputPath), []string{}); err != nil {
+		t.Fatalf("runIAMPolicy() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("failed to read policy: %v", err)
+	}
+
+	var policy map[string]any
+	if err := json.Unmarshal(data, &policy); err != nil {
+		t.Fatalf("policy is not valid JSON: %v", err)
+	}
+	for _, want := range []string{
+		"arn:aws:s3:::test-bucket",
+		"arn:aws:athena:eu-central-1:123456789012:workgroup/test-dbt",
+		"athena:DeleteWorkGroup",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("policy missing %q", want)
+		}
+	}
+}
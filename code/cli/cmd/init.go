@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/ecos-labs/ecos/code/cli/config"
 	"github.com/ecos-labs/ecos/code/cli/plugins/registry"
 	"github.com/ecos-labs/ecos/code/cli/plugins/types"
 	"github.com/ecos-labs/ecos/code/cli/utils"
@@ -44,6 +45,18 @@ func init() {
 
 	initCmd.Flags().StringP("source", "s", "", "data source to configure (aws_cur, aws_focus)")
 	initCmd.Flags().StringP("model-version", "m", "latest", "version of ecos models to use")
+	initCmd.Flags().String("model-source", "", "where to download the models from: github://, https://, s3:// or file:// (default: ecos GitHub releases)")
+	initCmd.Flags().Bool("offline", false, "resolve the models from the local model cache only")
+	initCmd.Flags().Bool("allow-unsigned", false, "install model releases without signature verification: legacy unsigned releases, or any release without a trusted key")
+	initCmd.Flags().String("emit", "", "render cloud resources as IaC instead of creating them (terraform, cloudformation)")
+
+	// AWS access on top of the selected profile (e.g. a role in the payer account)
+	initCmd.Flags().String("role-arn", "", "IAM role to assume for all AWS calls")
+	initCmd.Flags().String("external-id", "", "external ID required by the assumed role's trust policy")
+	initCmd.Flags().String("role-session-name", "", "session name for the assumed role (default: ecos-cli)")
+	initCmd.Flags().String("role-duration", "", "assumed role session duration (e.g. 1h)")
+	initCmd.Flags().String("mfa-serial", "", "MFA device serial or ARN required to assume the role")
+	initCmd.Flags().String("credential-process", "", "external command that prints AWS credentials (credential_process)")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -53,6 +66,11 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	dataSource, _ := cmd.Flags().GetString("source")
 	modelVersion, _ := cmd.Flags().GetString("model-version")
+	modelSource, _ := cmd.Flags().GetString("model-source")
+	offline, _ := cmd.Flags().GetBool("offline")
+	allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+	emitFormat, _ := cmd.Flags().GetString("emit")
+	awsAccess := awsAccessFromFlags(cmd)
 
 	utils.PrintHeader("🚀 ecos init")
 
@@ -81,7 +99,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if dataSource == "" {
 		displayOptions := []string{
 			"aws_cur                   (AWS Cost and Usage Report - CUR legacy and CUR 2.0)",
-			"aws_focus                 (AWS FinOps Open Cost and Usage Report - FOCUS 1.2) - coming soon",
+			"aws_focus                 (AWS FinOps Open Cost and Usage Report - FOCUS 1.0) - data export only, models coming soon",
 		}
 
 		utils.PrintSubHeader("📊 Data Source Selection")
@@ -102,6 +120,48 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create plugin: %w", err)
 	}
 
+	// Switch resource provisioning to IaC rendering if requested
+	if emitFormat != "" {
+		emitter, ok := initPlugin.(types.IaCEmitter)
+		if !ok {
+			return fmt.Errorf("data source '%s' does not support --emit", dataSource)
+		}
+		if err := emitter.SetIaCFormat(emitFormat); err != nil {
+			return err
+		}
+	}
+
+	// Apply assume-role / credential_process settings before any AWS call
+	if awsAccess != (config.AWSAccessConfig{}) {
+		configurer, ok := initPlugin.(types.AWSAccessConfigurer)
+		if !ok {
+			return fmt.Errorf("data source '%s' does not support AWS role or credential_process settings", dataSource)
+		}
+		if err := configurer.SetAWSAccess(awsAccess); err != nil {
+			return err
+		}
+	}
+
+	// Download the models from a GitHub Enterprise, mirror, S3 or local source, or
+	// resolve them from the model cache only, and accept legacy unsigned releases
+	if modelSource != "" || offline || allowUnsigned {
+		configurer, ok := initPlugin.(types.ModelSourceConfigurer)
+		if !ok {
+			return fmt.Errorf("data source '%s' does not support --model-source, --offline or --allow-unsigned", dataSource)
+		}
+		if modelSource != "" {
+			if err := configurer.SetModelSource(modelSource); err != nil {
+				return err
+			}
+		}
+		if err := configurer.SetOffline(offline); err != nil {
+			return err
+		}
+		if err := configurer.SetAllowUnsigned(allowUnsigned); err != nil {
+			return err
+		}
+	}
+
 	// Run interactive setup - plugin fills its own config
 	if err := initPlugin.RunInteractiveSetup(); err != nil {
 		return fmt.Errorf("interactive setup failed: %w", err)
@@ -120,7 +180,29 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Step 4: Create project structure, configs, resources (provider-specific)
-	return runInitExecute(initPlugin)
+	if err := runInitExecute(initPlugin); err != nil {
+		return err
+	}
+
+	// Pin the installed models, dbt packages and dbt versions
+	if !dryRun && utils.FileExists(configPath) {
+		if ecosConfig, err := config.LoadConfig(configPath); err == nil {
+			writeProjectLock(cmd.Context(), ecosConfig, outputPath)
+		}
+	}
+	return nil
+}
+
+// awsAccessFromFlags collects the AWS access flags into the .ecos.yaml structure
+func awsAccessFromFlags(cmd *cobra.Command) config.AWSAccessConfig {
+	var access config.AWSAccessConfig
+	access.AssumeRole.RoleARN, _ = cmd.Flags().GetString("role-arn")
+	access.AssumeRole.ExternalID, _ = cmd.Flags().GetString("external-id")
+	access.AssumeRole.SessionName, _ = cmd.Flags().GetString("role-session-name")
+	access.AssumeRole.Duration, _ = cmd.Flags().GetString("role-duration")
+	access.AssumeRole.MFASerial, _ = cmd.Flags().GetString("mfa-serial")
+	access.CredentialProcess, _ = cmd.Flags().GetString("credential-process")
+	return access
 }
 
 func runInitExecute(plugin types.InitPlugin) error {
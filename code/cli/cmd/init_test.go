@@ -253,6 +253,51 @@ func TestInitCommandFlags(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:      "emit flag exists",
+			flagName:  "emit",
+			shorthand: "",
+			checkDefault: func(t *testing.T, cmd *cobra.Command) {
+				t.Helper()
+				val, err := cmd.Flags().GetString("emit")
+				if err != nil {
+					t.Errorf("failed to get emit flag: %v", err)
+				}
+				if val != "" {
+					t.Errorf("emit flag default = %q, want empty string", val)
+				}
+			},
+		},
+		{
+			name:      "role-arn flag exists",
+			flagName:  "role-arn",
+			shorthand: "",
+			checkDefault: func(t *testing.T, cmd *cobra.Command) {
+				t.Helper()
+				val, err := cmd.Flags().GetString("role-arn")
+				if err != nil {
+					t.Errorf("failed to get role-arn flag: %v", err)
+				}
+				if val != "" {
+					t.Errorf("role-arn flag default = %q, want empty string", val)
+				}
+			},
+		},
+		{
+			name:      "credential-process flag exists",
+			flagName:  "credential-process",
+			shorthand: "",
+			checkDefault: func(t *testing.T, cmd *cobra.Command) {
+				t.Helper()
+				val, err := cmd.Flags().GetString("credential-process")
+				if err != nil {
+					t.Errorf("failed to get credential-process flag: %v", err)
+				}
+				if val != "" {
+					t.Errorf("credential-process flag default = %q, want empty string", val)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/lock"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDBTAdapter is the adapter of the generated dbt profiles
+const defaultDBTAdapter = "athena"
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Manage the project lock file (.ecos.lock)",
+	Long: `Manage .ecos.lock, which pins what the transforms depend on: the model version and
+package digest, the dbt packages resolved in package-lock.yml, and the installed dbt
+Core and adapter versions.
+
+ecos init and ecos models upgrade write the lock file. Before every transform, ecos
+run and ecos watch the runtime is compared with it; lock.mode in .ecos.yaml sets
+whether differences warn (default), fail the command, or are not checked (off).
+
+Available subcommands:
+  update    Refresh .ecos.lock from the current project and runtime
+
+Examples:
+  ecos lock update`,
+}
+
+// lockUpdateCmd represents the lock update command
+var lockUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Refresh .ecos.lock from the current project and runtime",
+	Long: `Refresh .ecos.lock from the installed model package, package-lock.yml and the
+installed dbt Core and adapter versions, e.g. after upgrading dbt or running dbt deps
+on purpose. The differences with the previous lock are printed.
+
+Examples:
+  ecos lock update
+  ecos lock update -p ./my-project
+  ecos lock update --dry-run`,
+	RunE: runLockUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+	lockCmd.AddCommand(lockUpdateCmd)
+
+	lockUpdateCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+func runLockUpdate(cmd *cobra.Command, _ []string) error {
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos lock update")
+
+	ecosConfig, err := loadProjectConfig(projectDir)
+	if err != nil {
+		return err
+	}
+	previous, err := lock.Load(projectDir)
+	if err != nil {
+		return err
+	}
+	dbt, dbtErr := runtimeDBT(cmd.Context(), ecosConfig, projectDir)
+	if dbtErr != nil {
+		utils.PrintWarning(fmt.Sprintf("dbt versions not recorded: %v", dbtErr))
+	}
+	current, err := lock.Capture(dbtProjectDir(ecosConfig, projectDir), ecosConfig.DataSource, ecosConfig.ModelVersion, dbt)
+	if err != nil {
+		return err
+	}
+
+	if previous != nil {
+		diffs := previous.Diff(current)
+		if len(diffs) == 0 {
+			utils.PrintInfo("The project matches the lock")
+		}
+		for _, d := range diffs {
+			utils.PrintInfo(d)
+		}
+	}
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would write %s", lock.Path(projectDir)))
+		return nil
+	}
+	if err := current.Save(projectDir); err != nil {
+		return err
+	}
+	utils.PrintSuccess(fmt.Sprintf("Updated %s", lock.Path(projectDir)))
+	return nil
+}
+
+// runtimeDBT returns the dbt adapter of the project and the installed dbt Core and
+// adapter versions. The versions are empty, and the error says why, when dbt cannot be run.
+func runtimeDBT(ctx context.Context, cfg *config.EcosConfig, projectDir string) (lock.DBT, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	dbt := lock.DBT{Adapter: dbtAdapter(cfg, projectDir)}
+	core, adapterVersion, err := initUtils.DBTVersions(ctx, dbt.Adapter)
+	dbt.Core, dbt.AdapterVersion = core, adapterVersion
+	return dbt, err
+}
+
+// writeProjectLock refreshes .ecos.lock after an init or upgrade. Failures are warnings:
+// the lock must not undo a completed setup.
+func writeProjectLock(ctx context.Context, cfg *config.EcosConfig, projectDir string) {
+	dbt, dbtErr := runtimeDBT(ctx, cfg, projectDir)
+	if dbtErr != nil {
+		utils.PrintDebug(fmt.Sprintf("dbt versions not recorded: %v", dbtErr))
+	}
+	f, err := lock.Capture(dbtProjectDir(cfg, projectDir), cfg.DataSource, cfg.ModelVersion, dbt)
+	if err == nil {
+		err = f.Save(projectDir)
+	}
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to write %s: %v, run 'ecos lock update'", lock.Filename, err))
+		return
+	}
+	utils.PrintDebug(fmt.Sprintf("Wrote %s", lock.Path(projectDir)))
+}
+
+// checkProjectLock compares the runtime with .ecos.lock before a transform, run or
+// watch, and warns or fails on differences depending on lock.mode
+func checkProjectLock(ctx context.Context, cfg *config.EcosConfig, projectDir string) error {
+	mode := cfg.Lock.Mode
+	if mode == "off" {
+		return nil
+	}
+	locked, err := lock.Load(projectDir)
+	if err != nil {
+		return err
+	}
+	if locked == nil {
+		utils.PrintDebug(fmt.Sprintf("No %s, runtime not checked", lock.Filename))
+		return nil
+	}
+	dbt, dbtErr := runtimeDBT(ctx, cfg, projectDir)
+	if dbtErr != nil && (locked.DBT.Core != "" || locked.DBT.AdapterVersion != "") {
+		if mode == "fail" {
+			return fmt.Errorf("cannot compare the dbt versions with %s (lock.mode: fail): %w", lock.Filename, dbtErr)
+		}
+		utils.PrintWarning(fmt.Sprintf("dbt versions not checked against %s: %v", lock.Filename, dbtErr))
+	}
+	if dbtErr != nil {
+		// Unknown versions are not differences
+		dbt.Core, dbt.AdapterVersion = locked.DBT.Core, locked.DBT.AdapterVersion
+	}
+	current, err := lock.Capture(dbtProjectDir(cfg, projectDir), cfg.DataSource, cfg.ModelVersion, dbt)
+	if err != nil {
+		return err
+	}
+	diffs := locked.Diff(current)
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	utils.PrintWarning(fmt.Sprintf("The runtime differs from %s:", lock.Filename))
+	for _, d := range diffs {
+		utils.Print("  • %s", d)
+	}
+	utils.PrintInfo("Restore the locked versions, or run 'ecos lock update' to accept the changes")
+	if mode == "fail" {
+		return fmt.Errorf("runtime differs from %s (lock.mode: fail)", lock.Filename)
+	}
+	return nil
+}
+
+// dbtAdapter returns the adapter type of the dbt target in the project's profiles file
+func dbtAdapter(cfg *config.EcosConfig, projectDir string) string {
+	dbtCfg := cfg.Transform.DBT
+	profileDir := dbtCfg.ProfileDir
+	if profileDir == "" {
+		profileDir = dbtProjectDir(cfg, projectDir)
+	} else if !filepath.IsAbs(profileDir) {
+		profileDir = filepath.Join(projectDir, profileDir)
+	}
+	profileFile := dbtCfg.ProfileFile
+	if profileFile == "" {
+		profileFile = "profiles.yml"
+	}
+
+	adapter, err := profileAdapter(filepath.Join(profileDir, profileFile), dbtCfg.Profile, dbtCfg.Target)
+	if err != nil {
+		utils.PrintDebug(fmt.Sprintf("Using the %s adapter: %v", defaultDBTAdapter, err))
+		return defaultDBTAdapter
+	}
+	return adapter
+}
+
+// profileAdapter reads the type of a target of a dbt profile, the profile's default
+// target when target is empty
+func profileAdapter(path, profile, target string) (string, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+	var profiles map[string]struct {
+		Target  string `yaml:"target"`
+		Outputs map[string]struct {
+			Type string `yaml:"type"`
+		} `yaml:"outputs"`
+	}
+	if err := yaml.Unmarshal(data, &profiles); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	p, ok := profiles[profile]
+	if !ok {
+		return "", fmt.Errorf("profile '%s' not found in %s", profile, path)
+	}
+	if target == "" {
+		target = p.Target
+	}
+	if output, ok := p.Outputs[target]; ok && output.Type != "" {
+		return output.Type, nil
+	}
+	return "", errors.New("no adapter type for target '" + target + "'")
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/lock"
+)
+
+func TestDBTAdapter(t *testing.T) {
+	projectDir := t.TempDir()
+	dbtDir := filepath.Join(projectDir, "transform", "dbt")
+	if err := os.MkdirAll(dbtDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	profiles := `ecos:
+  target: dev
+  outputs:
+    dev:
+      type: athena
+    warehouse:
+      type: duckdb
+`
+	if err := os.WriteFile(filepath.Join(dbtDir, "profiles.yml"), []byte(profiles), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		target  string
+		want    string
+	}{
+		{"default target", "ecos", "", "athena"},
+		{"target", "ecos", "warehouse", "duckdb"},
+		{"unknown profile", "other", "", defaultDBTAdapter},
+		{"unknown target", "ecos", "prod", defaultDBTAdapter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewDefaultConfig()
+			cfg.Transform.DBT.ProjectDir = ""
+			cfg.Transform.DBT.ProfileDir = ""
+			cfg.Transform.DBT.ProfileFile = ""
+			cfg.Transform.DBT.Profile = tt.profile
+			cfg.Transform.DBT.Target = tt.target
+			if got := dbtAdapter(cfg, projectDir); got != tt.want {
+				t.Errorf("dbtAdapter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckProjectLock_DBTNotRunnable(t *testing.T) {
+	// No dbt on the PATH
+	t.Setenv("PATH", t.TempDir())
+	projectDir := t.TempDir()
+	locked := &lock.File{
+		Models: lock.Models{Version: "v1.0.0"},
+		DBT:    lock.DBT{Core: "1.8.7", Adapter: defaultDBTAdapter, AdapterVersion: "1.8.4"},
+	}
+	if err := locked.Save(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.NewDefaultConfig()
+	cfg.ModelVersion = "v1.0.0"
+
+	// Unknown dbt versions are not reported as differences
+	cfg.Lock.Mode = "warn"
+	if err := checkProjectLock(context.Background(), cfg, projectDir); err != nil {
+		t.Errorf("checkProjectLock() in warn mode error = %v", err)
+	}
+
+	cfg.Lock.Mode = "fail"
+	err := checkProjectLock(context.Background(), cfg, projectDir)
+	if err == nil || !strings.Contains(err.Error(), "dbt --version") || strings.Contains(err.Error(), "differs") {
+		t.Errorf("checkProjectLock() in fail mode error = %v, want the dbt error", err)
+	}
+
+	// Without locked dbt versions there is nothing to compare them with
+	locked.DBT = lock.DBT{}
+	if err := locked.Save(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkProjectLock(context.Background(), cfg, projectDir); err != nil {
+		t.Errorf("checkProjectLock() without locked dbt versions error = %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/mcp"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/serve"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/ecos-labs/ecos/code/cli/version"
+	"github.com/spf13/cobra"
+)
+
+// Defaults of the mcp section of .ecos.yaml
+const (
+	defaultMCPEngine       = "athena"
+	defaultMCPDuckDBSchema = "main"
+	defaultMCPMaxScanBytes = 10 << 30
+)
+
+// mcpInstructions tell the assistant how to use the tools
+const mcpInstructions = `ecos models AWS cost and usage data. Use list_models and describe_model to find
+the model and columns that answer a question, then query_models with a filter on
+billing_period (YYYY-MM). Costs are in USD.`
+
+// mcpCmd represents the mcp command
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve the models to AI assistants over MCP",
+	Long: `Run a Model Context Protocol server over stdio, so that AI assistants can explore
+and query the serve models of the project. The assistant starts the command itself;
+messages are exchanged on stdin and stdout and logs are written to stderr.
+
+Tools:
+  list_models                        the models that can be queried
+  describe_model                     the columns of a model, from the dbt model docs
+  query_models                       a read-only select against the models
+  get_optimization_recommendations   the savings found by the optimization models
+
+Queries run in the adhoc Athena workgroup against aws.database with the credentials
+of the configured profile, and stop once they scan more than mcp.max_scan_bytes
+(default 10 GiB). Only the documented serve models the profile can read are exposed.
+Results are limited to mcp.max_rows rows (default 500).
+
+With --duckdb, or mcp.engine: duckdb, the models are queried from a local DuckDB
+database with the duckdb CLI instead, e.g. a project built with dbt-duckdb.
+
+Example assistant configuration:
+  {"mcpServers": {"ecos": {"command": "ecos", "args": ["mcp", "-p", "/path/to/project"]}}}`,
+	RunE: runMCP,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+
+	mcpCmd.Flags().String("duckdb", "", "query this DuckDB database file instead of Athena")
+	mcpCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+// mcpOptions is the resolved configuration of 'ecos mcp'
+type mcpOptions struct {
+	engine       string
+	duckDBPath   string
+	database     string
+	maxScanBytes int64
+	maxRows      int
+	catalog      *catalog.Catalog
+}
+
+func runMCP(cmd *cobra.Command, _ []string) error {
+	duckDBPath, _ := cmd.Flags().GetString("duckdb")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	// stdout carries the protocol, so nothing else may be printed there
+	logf := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "ecos mcp: "+format+"\n", args...)
+	}
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	opts, err := resolveMCPOptions(ecosConfig, projectDir, duckDBPath)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		logf("would expose the models of %s through the %s engine, if readable:", opts.database, opts.engine)
+		for _, m := range opts.catalog.Models {
+			logf("  %s", m.Name)
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	engine, err := newMCPEngine(ctx, ecosConfig, opts)
+	if err != nil {
+		return err
+	}
+	readable, err := mcp.ReadableTables(ctx, engine, opts.database)
+	if err != nil {
+		return err
+	}
+	models := opts.catalog.Filter(func(m catalog.Model) bool { return slices.Contains(readable, m.Name) })
+	if len(models.Models) == 0 {
+		return fmt.Errorf("none of the serve models can be read in %s with the configured credentials", opts.database)
+	}
+
+	backend := &mcp.Backend{
+		Engine:   engine,
+		Database: opts.database,
+		Catalog:  models,
+		MaxRows:  opts.maxRows,
+	}
+	server := &mcp.Server{
+		Name:         "ecos",
+		Version:      version.GetInfo().Version,
+		Instructions: mcpInstructions,
+		Tools:        backend.Tools(),
+		Logf:         logf,
+	}
+	logf("exposing %d models of %s through the %s engine", len(models.Models), opts.database, opts.engine)
+	return server.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// resolveMCPOptions applies the defaults of the mcp section and loads the docs of the
+// serve models; duckDBPath, when set, selects the duckdb engine
+func resolveMCPOptions(cfg *config.EcosConfig, projectDir, duckDBPath string) (mcpOptions, error) {
+	opts := mcpOptions{
+		engine:       cfg.MCP.Engine,
+		duckDBPath:   cfg.MCP.DuckDBPath,
+		maxScanBytes: cfg.MCP.MaxScanBytes,
+		maxRows:      cfg.MCP.MaxRows,
+	}
+	if duckDBPath != "" {
+		opts.engine = "duckdb"
+		opts.duckDBPath = duckDBPath
+	}
+	if opts.engine == "" {
+		opts.engine = defaultMCPEngine
+	}
+	if opts.maxScanBytes == 0 {
+		opts.maxScanBytes = defaultMCPMaxScanBytes
+	}
+
+	switch opts.engine {
+	case "duckdb":
+		if !filepath.IsAbs(opts.duckDBPath) {
+			opts.duckDBPath = filepath.Join(projectDir, opts.duckDBPath)
+		}
+		if !utils.FileExists(opts.duckDBPath) {
+			return mcpOptions{}, fmt.Errorf("DuckDB database not found: %s", opts.duckDBPath)
+		}
+		opts.database = cfg.MCP.DuckDBSchema
+		if opts.database == "" {
+			opts.database = defaultMCPDuckDBSchema
+		}
+	default:
+		if cfg.AWS.Database == "" {
+			return mcpOptions{}, errors.New("querying the models needs aws.database in .ecos.yaml")
+		}
+		opts.database = cfg.AWS.Database
+	}
+
+	docs, err := catalog.Load(filepath.Join(dbtProjectDir(cfg, projectDir), catalog.ModelsDir))
+	if err != nil {
+		return mcpOptions{}, fmt.Errorf("failed to load the model docs: %w", err)
+	}
+	opts.catalog = docs.Filter(serve.Exposed)
+	if len(opts.catalog.Models) == 0 {
+		return mcpOptions{}, errors.New("the model package documents no serve models")
+	}
+	return opts, nil
+}
+
+// newMCPEngine returns the engine the tools query
+func newMCPEngine(ctx context.Context, cfg *config.EcosConfig, opts mcpOptions) (mcp.Engine, error) {
+	if opts.engine == "duckdb" {
+		return &query.DuckDBQuerier{Path: opts.duckDBPath}, nil
+	}
+	querier, err := newAthenaQuerier(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	querier.MaxScanBytes = opts.maxScanBytes
+	return querier, nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+func TestResolveMCPOptions(t *testing.T) {
+	projectDir := t.TempDir()
+	modelsDir := filepath.Join(projectDir, "transform", "dbt", "models")
+	if err := os.MkdirAll(modelsDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	docs := `version: 2
+models:
+  - name: serve_core__cost_service_account_daily
+  - name: gold_tag__tag_cost_monthly
+  - name: bronze_aws__cur_source
+`
+	if err := os.WriteFile(filepath.Join(modelsDir, "_models.yml"), []byte(docs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.EcosConfig{}
+	if _, err := resolveMCPOptions(cfg, projectDir, ""); err == nil {
+		t.Error("resolveMCPOptions() expected error without aws.database")
+	}
+
+	cfg.AWS.Database = "ecos"
+	opts, err := resolveMCPOptions(cfg, projectDir, "")
+	if err != nil {
+		t.Fatalf("resolveMCPOptions() error = %v", err)
+	}
+	if opts.engine != "athena" || opts.database != "ecos" || opts.maxScanBytes != defaultMCPMaxScanBytes {
+		t.Errorf("resolveMCPOptions() = %+v", opts)
+	}
+	if len(opts.catalog.Models) != 2 {
+		t.Errorf("exposed models = %+v", opts.catalog.Models)
+	}
+
+	if _, err := resolveMCPOptions(cfg, projectDir, "ecos.duckdb"); err == nil {
+		t.Error("resolveMCPOptions() expected error for a missing DuckDB database")
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "ecos.duckdb"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts, err = resolveMCPOptions(cfg, projectDir, "ecos.duckdb")
+	if err != nil {
+		t.Fatalf("resolveMCPOptions() error = %v", err)
+	}
+	if opts.engine != "duckdb" || opts.database != "main" || opts.duckDBPath != filepath.Join(projectDir, "ecos.duckdb") {
+		t.Errorf("resolveMCPOptions() with --duckdb = %+v", opts)
+	}
+}
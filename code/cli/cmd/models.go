@@ -0,0 +1,576 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/overlay"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// modelsCmd represents the models command
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "Manage the transform models of the project",
+	Long: `Manage the datasource model package installed in the dbt project.
+
+Available subcommands:
+  list        List the models of the project
+  describe    Show the columns, grain, lineage and build status of a model
+  upgrade     Upgrade the models to a new version, keeping local modifications
+  overrides   List the packaged models, macros and seeds shadowed by the custom overlay
+  package     Build a datasource model package from the dbt models`,
+}
+
+// modelsListCmd represents the models list command
+var modelsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the models of the project",
+	Long: `List the models of the dbt project with their layer, domain, materialization and
+last build status.
+
+The models are read from dbt's target/manifest.json when dbt has parsed the project, and
+from the model YAML files of the package otherwise. Without the manifest, materializations
+are resolved from transform.dbt.materialization in .ecos.yaml. The build status is the
+outcome of each model in the last dbt invocation (target/run_results.json).
+
+Examples:
+  ecos models list
+  ecos models list --layer gold
+  ecos models list --layer gold --domain compute`,
+	RunE: runModelsList,
+}
+
+// modelsDescribeCmd represents the models describe command
+var modelsDescribeCmd = &cobra.Command{
+	Use:   "describe <model>",
+	Short: "Show the columns, grain, lineage and build status of a model",
+	Long: `Show the description, columns, grain, upstream and downstream models,
+materialization and last build status of a model.
+
+The grain is the combination of columns a row is unique on, as tested by the model's
+unique_combination_of_columns test, and the period a row covers, as told by its name.
+
+Examples:
+  ecos models describe gold_compute__container_daily
+  ecos models describe serve_core__cost_service_account_daily -p ./my-project`,
+	Args: cobra.ExactArgs(1),
+	RunE: runModelsDescribe,
+}
+
+// modelsUpgradeCmd represents the models upgrade command
+var modelsUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the models to a new version, keeping local modifications",
+	Long: `Upgrade the model package of the project's data_source to a new release, by default
+the latest one, and print the changelog of the releases in between.
+
+Files are compared with the checksums recorded when the installed version was
+downloaded (transform/dbt/.ecos-models.json):
+  - unmodified files are replaced with the new version
+  - files modified locally and upstream are merged with the installed version as
+    common ancestor; overlapping changes are left with conflict markers
+  - files modified locally but unchanged upstream are kept
+  - files removed from the package are deleted unless modified locally
+
+model_version in .ecos.yaml is updated once all files are written, and
+dbt_project.yml and profiles.yml are regenerated from .ecos.yaml rather than merged.
+Conflicts must be resolved before the next upgrade.
+
+Packages are downloaded from model_source in .ecos.yaml, the ecos GitHub releases
+by default; --model-source overrides it for this upgrade. Release lists and packages
+are cached (see 'ecos cache'); --offline upgrades from the cache only. Packages
+must be signed; --allow-unsigned accepts releases published before package signing,
+and installs signed ones without signature verification when no trusted key is set.
+
+Examples:
+  ecos models upgrade
+  ecos models upgrade --to v1.2.0
+  ecos models upgrade --model-source s3://acme-artifacts/ecos
+  ecos models upgrade --to v1.2.0 --offline
+  ecos models upgrade --dry-run`,
+	RunE: runModelsUpgrade,
+}
+
+// modelsOverridesCmd represents the models overrides command
+var modelsOverridesCmd = &cobra.Command{
+	Use:   "overrides",
+	Short: "List the packaged models, macros and seeds shadowed by the custom overlay",
+	Long: `List what the custom overlay directory (transform.dbt.custom_dir) shadows in the
+packaged dbt project.
+
+The overlay holds models/, macros/ and seeds/ directories owned by the project. Before
+every transform they are merged into the dbt project under ecos_custom/ directories:
+  - a model or seed named like a packaged one replaces it
+  - a macro named like a packaged one replaces it; the other macros of the packaged
+    file stay available
+Packaged files are never modified, so the overlay survives 'ecos models upgrade' and
+'ecos init --force'.
+
+Examples:
+  ecos models overrides
+  ecos models overrides -p ./my-project`,
+	RunE: runModelsOverrides,
+}
+
+func init() {
+	rootCmd.AddCommand(modelsCmd)
+	modelsCmd.AddCommand(modelsListCmd)
+	modelsCmd.AddCommand(modelsDescribeCmd)
+	modelsCmd.AddCommand(modelsUpgradeCmd)
+	modelsCmd.AddCommand(modelsOverridesCmd)
+
+	modelsListCmd.Flags().String("layer", "", "only list the models of a layer: "+strings.Join(catalog.Layers, ", "))
+	modelsListCmd.Flags().String("domain", "", "only list the models of a domain, e.g. compute")
+	modelsListCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+	modelsDescribeCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+
+	modelsUpgradeCmd.Flags().String("to", "", "version to upgrade to (default: latest release)")
+	modelsUpgradeCmd.Flags().String("model-source", "", "where to download the models from (default: model_source in .ecos.yaml)")
+	modelsUpgradeCmd.Flags().Bool("offline", false, "resolve the models from the local model cache only")
+	modelsUpgradeCmd.Flags().Bool("allow-unsigned", false, "install model releases without signature verification: legacy unsigned releases, or any release without a trusted key")
+	modelsUpgradeCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+	modelsOverridesCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+// packageDownloader fetches datasource model packages and their release notes
+type packageDownloader interface {
+	DownloadTransformModels(ctx context.Context, datasource, modelVersion, destPath string) (string, error)
+	DatasourceReleases(ctx context.Context, datasource string) ([]initUtils.GitHubRelease, error)
+}
+
+func runModelsList(cmd *cobra.Command, _ []string) error {
+	layer, _ := cmd.Flags().GetString("layer")
+	domain, _ := cmd.Flags().GetString("domain")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos models list")
+
+	if layer != "" && !slices.Contains(catalog.Layers, layer) {
+		return fmt.Errorf("unknown layer %q, expected one of: %s", layer, strings.Join(catalog.Layers, ", "))
+	}
+	ecosConfig, err := loadProjectConfig(projectDir)
+	if err != nil {
+		return err
+	}
+	dbtDir := dbtProjectDir(ecosConfig, projectDir)
+	docs, err := loadModelCatalog(dbtDir)
+	if err != nil {
+		return err
+	}
+	results, err := catalog.LoadRunResults(dbtDir)
+	if err != nil {
+		return err
+	}
+
+	models := docs.Filter(func(m catalog.Model) bool {
+		return (layer == "" || m.Layer() == layer) && (domain == "" || m.Domain() == domain)
+	})
+	if len(models.Models) == 0 {
+		utils.PrintInfo("No models match")
+		return nil
+	}
+	rows := make([][]string, len(models.Models))
+	for i, m := range models.Models {
+		rows[i] = []string{m.Name, m.Layer(), m.Domain(), modelMaterialization(ecosConfig, docs, m), buildStatus(results, m.Name), truncateSummary(m.Summary(), 60)}
+	}
+	utils.PrintTable([]string{"Model", "Layer", "Domain", "Materialization", "Last Build", "Description"}, rows)
+	utils.PrintInfo(fmt.Sprintf("%d models", len(rows)))
+	return nil
+}
+
+func runModelsDescribe(cmd *cobra.Command, args []string) error {
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+	name := args[0]
+
+	utils.PrintHeader("ecos models describe")
+
+	ecosConfig, err := loadProjectConfig(projectDir)
+	if err != nil {
+		return err
+	}
+	dbtDir := dbtProjectDir(ecosConfig, projectDir)
+	docs, err := loadModelCatalog(dbtDir)
+	if err != nil {
+		return err
+	}
+	m, ok := docs.Model(name)
+	if !ok {
+		return fmt.Errorf("model %s not found, run 'ecos models list' to see the models", name)
+	}
+	results, err := catalog.LoadRunResults(dbtDir)
+	if err != nil {
+		return err
+	}
+
+	utils.PrintSubHeader(m.Name)
+	if m.Description != "" {
+		utils.Print("%s", m.Description)
+	}
+
+	utils.PrintSectionHeader("Details")
+	for _, detail := range [][2]string{
+		{"Layer", valueOrNone(m.Layer())},
+		{"Domain", valueOrNone(m.Domain())},
+		{"Grain", modelGrain(m)},
+		{"Materialization", modelMaterialization(ecosConfig, docs, m)},
+		{"Last build", buildStatus(results, m.Name)},
+		{"Documented in", valueOrNone(m.Path)},
+	} {
+		utils.Print("  %-16s %s", detail[0]+":", detail[1])
+	}
+
+	utils.PrintSectionHeader("Lineage")
+	utils.PrintTable([]string{"Upstream", "Downstream"}, lineageRows(m.Upstream, docs.Downstream(m.Name)))
+
+	utils.PrintSectionHeader("Columns")
+	rows := make([][]string, len(m.Columns))
+	for i, c := range m.Columns {
+		rows[i] = []string{c.Name, c.DataType, c.Description}
+	}
+	utils.PrintTable([]string{"Column", "Type", "Description"}, rows)
+	if r, ok := results[m.Name]; ok && r.Status != "success" && r.Message != "" {
+		utils.PrintWarning(fmt.Sprintf("Last build %s: %s", r.Status, r.Message))
+	}
+	return nil
+}
+
+// loadProjectConfig loads the .ecos.yaml of the project in projectDir
+func loadProjectConfig(projectDir string) (*config.EcosConfig, error) {
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return nil, fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+	return ecosConfig, nil
+}
+
+// loadModelCatalog reads the models of the dbt project from dbt's manifest, or from the
+// model YAML files when dbt has not parsed the project yet
+func loadModelCatalog(dbtDir string) (*catalog.Catalog, error) {
+	docs, err := catalog.LoadCompiled(dbtDir)
+	if err != nil || docs != nil {
+		return docs, err
+	}
+	return catalog.Load(filepath.Join(dbtDir, catalog.ModelsDir))
+}
+
+// modelMaterialization returns the materialization dbt resolved, or resolves the model's
+// default with the materialization settings of .ecos.yaml
+func modelMaterialization(cfg *config.EcosConfig, docs *catalog.Catalog, m catalog.Model) string {
+	if docs.Compiled {
+		return valueOrNone(m.Materialization)
+	}
+	return cfg.Transform.DBT.Materialization.Resolve(m.Name, m.Layer(), m.Materialization)
+}
+
+// modelGrain describes the period and the key columns of a row of the model
+func modelGrain(m catalog.Model) string {
+	var parts []string
+	if grain := m.TimeGrain(); grain != "" {
+		parts = append(parts, grain)
+	}
+	if len(m.Grain) > 0 {
+		parts = append(parts, "("+strings.Join(m.Grain, ", ")+")")
+	}
+	return valueOrNone(strings.Join(parts, " "))
+}
+
+// buildStatus describes the outcome of the model in the last dbt invocation
+func buildStatus(results map[string]catalog.BuildResult, name string) string {
+	r, ok := results[name]
+	if !ok {
+		return "-"
+	}
+	if r.CompletedAt.IsZero() {
+		return r.Status
+	}
+	return fmt.Sprintf("%s (%s)", r.Status, r.CompletedAt.Local().Format("2006-01-02 15:04"))
+}
+
+// lineageRows lays out the upstream and downstream models side by side
+func lineageRows(upstream, downstream []string) [][]string {
+	rows := make([][]string, max(len(upstream), len(downstream)))
+	for i := range rows {
+		rows[i] = []string{"", ""}
+		if i < len(upstream) {
+			rows[i][0] = upstream[i]
+		}
+		if i < len(downstream) {
+			rows[i][1] = downstream[i]
+		}
+	}
+	return rows
+}
+
+// truncateSummary shortens a model summary to width characters for a table column
+func truncateSummary(summary string, width int) string {
+	runes := []rune(summary)
+	if len(runes) <= width {
+		return summary
+	}
+	return strings.TrimSpace(string(runes[:width-1])) + "…"
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func runModelsUpgrade(cmd *cobra.Command, _ []string) error {
+	to, _ := cmd.Flags().GetString("to")
+	modelSource, _ := cmd.Flags().GetString("model-source")
+	offline, _ := cmd.Flags().GetBool("offline")
+	allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos models upgrade")
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if modelSource == "" {
+		modelSource = ecosConfig.ModelSource
+	}
+	downloader, err := initUtils.NewModelDownloader(modelSource, awssession.FromConfig(ecosConfig))
+	if err != nil {
+		return err
+	}
+	downloader.Offline = offline
+	downloader.AllowUnsigned = allowUnsigned
+	if err := upgradeModels(ctx, downloader, ecosConfig, configPath, dbtProjectDir(ecosConfig, projectDir), to); err != nil {
+		return err
+	}
+	if !dryRun {
+		// Reload to pick up the model_version the upgrade recorded
+		if upgraded, err := config.LoadConfig(configPath); err == nil {
+			ecosConfig = upgraded
+		}
+		writeProjectLock(ctx, ecosConfig, projectDir)
+	}
+	return nil
+}
+
+// upgradeModels upgrades the model package in dbtDir to version to and records it in
+// the .ecos.yaml at configPath. The generated dbt files are not merged but regenerated.
+func upgradeModels(ctx context.Context, downloader packageDownloader, cfg *config.EcosConfig, configPath, dbtDir, to string) error {
+	datasource := cfg.DataSource
+	if datasource == "" {
+		return errors.New("upgrading the models needs data_source in .ecos.yaml")
+	}
+	installed, err := modelpkg.LoadManifest(dbtDir)
+	if err != nil {
+		return err
+	}
+	from, err := installedModelVersion(cfg, installed)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "ecos-models-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	spinner := utils.NewSpinner("Downloading model packages")
+	spinner.Start()
+	nextVersion, err := downloader.DownloadTransformModels(ctx, datasource, to, filepath.Join(tmpDir, "next"))
+	if err != nil {
+		spinner.Error("Failed to download the new models")
+		return fmt.Errorf("failed to download the models to upgrade to: %w", err)
+	}
+	if initUtils.CompareVersions(nextVersion, from) == 0 {
+		spinner.Success(fmt.Sprintf("The models of %s are already at version %s", datasource, from))
+		return nil
+	}
+	if _, err := downloader.DownloadTransformModels(ctx, datasource, from, filepath.Join(tmpDir, "base")); err != nil {
+		spinner.Error("Failed to download the installed models")
+		return fmt.Errorf("failed to download the installed version %s: %w", from, err)
+	}
+	spinner.Success(fmt.Sprintf("Downloaded %s %s and %s", datasource, from, nextVersion))
+
+	base, err := modelpkg.LoadPackage(filepath.Join(tmpDir, "base"))
+	if err != nil {
+		return err
+	}
+	next, err := modelpkg.LoadPackage(filepath.Join(tmpDir, "next"))
+	if err != nil {
+		return err
+	}
+	plan, err := modelpkg.PlanUpgrade(dbtDir, installed, base, next)
+	if err != nil {
+		return err
+	}
+
+	if initUtils.CompareVersions(nextVersion, from) < 0 {
+		utils.PrintWarning(fmt.Sprintf("Downgrading the models from %s to %s", from, nextVersion))
+	} else {
+		printChangelog(ctx, downloader, datasource, from, nextVersion)
+	}
+	printUpgradePlan(plan)
+
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would upgrade the models from %s to %s", from, nextVersion))
+		return nil
+	}
+
+	if err := plan.Apply(); err != nil {
+		return fmt.Errorf("failed to upgrade the models: %w", err)
+	}
+	if err := config.SetConfigValue(configPath, "model_version", nextVersion); err != nil {
+		return fmt.Errorf("models upgraded, but failed to update model_version: %w", err)
+	}
+	if err := generateDBTFiles(cfg, filepath.Dir(configPath), dbtDir); err != nil {
+		return fmt.Errorf("models upgraded, but %w, run 'ecos config generate'", err)
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("Upgraded the models of %s from %s to %s", datasource, from, nextVersion))
+	if conflicts := plan.Manifest.Conflicts; len(conflicts) > 0 {
+		utils.PrintWarning(fmt.Sprintf("%d files have conflict markers to resolve:", len(conflicts)))
+		for _, name := range conflicts {
+			utils.Print("  %s", filepath.Join(dbtDir, filepath.FromSlash(name)))
+		}
+	}
+	return nil
+}
+
+// generateDBTFiles writes the dbt files generated from .ecos.yaml (modelpkg.GeneratedFiles)
+// to dbtDir
+func generateDBTFiles(cfg *config.EcosConfig, projectDir, dbtDir string) error {
+	projectData, profilesData, err := config.ExtractDBTDataFromEcosConfig(cfg, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to extract dbt data: %w", err)
+	}
+	if err := config.GenerateDBTProject(projectData, dbtDir); err != nil {
+		return fmt.Errorf("failed to generate dbt_project.yml: %w", err)
+	}
+	if err := config.GenerateDBTProfiles(profilesData, dbtDir); err != nil {
+		return fmt.Errorf("failed to generate profiles.yml: %w", err)
+	}
+	return nil
+}
+
+func runModelsOverrides(cmd *cobra.Command, _ []string) error {
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos models overrides")
+
+	ecosConfig, err := loadProjectConfig(projectDir)
+	if err != nil {
+		return err
+	}
+
+	customDir := customOverlayDir(ecosConfig, projectDir)
+	if customDir == "" {
+		utils.PrintInfo("No custom overlay configured, set transform.dbt.custom_dir in .ecos.yaml")
+		return nil
+	}
+	o, err := overlay.Load(dbtProjectDir(ecosConfig, projectDir), customDir)
+	if err != nil {
+		return err
+	}
+
+	utils.PrintInfo(fmt.Sprintf("Custom overlay %s: %d files", customDir, len(o.Files)))
+	if len(o.Overrides) == 0 {
+		utils.PrintInfo("Nothing packaged is shadowed")
+		return nil
+	}
+	rows := make([][]string, len(o.Overrides))
+	for i, ov := range o.Overrides {
+		rows[i] = []string{string(ov.Kind), ov.Name, ov.Custom, ov.Packaged}
+	}
+	utils.PrintTable([]string{"Kind", "Name", "Custom", "Shadowed"}, rows)
+	return nil
+}
+
+// customOverlayDir returns the custom overlay directory of the project, or "" when none
+// is configured
+func customOverlayDir(cfg *config.EcosConfig, projectDir string) string {
+	customDir := cfg.Transform.DBT.CustomDir
+	if customDir == "" || filepath.IsAbs(customDir) {
+		return customDir
+	}
+	return filepath.Join(projectDir, customDir)
+}
+
+// installedModelVersion returns the version recorded when the models were downloaded,
+// or model_version for projects without a manifest
+func installedModelVersion(cfg *config.EcosConfig, installed *modelpkg.Manifest) (string, error) {
+	if installed != nil {
+		return installed.Version, nil
+	}
+	version := cfg.ModelVersion
+	if version == "" || version == "latest" || version == "main" || version == "master" {
+		return "", errors.New("cannot tell which model version is installed, set model_version in .ecos.yaml to the installed release")
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version, nil
+}
+
+// printChangelog prints the release notes of the versions after from up to to; they
+// are informational, so a failure to fetch them is only a warning
+func printChangelog(ctx context.Context, downloader packageDownloader, datasource, from, to string) {
+	releases, err := downloader.DatasourceReleases(ctx, datasource)
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to fetch the changelog: %v", err))
+		return
+	}
+	between := initUtils.ReleasesBetween(releases, datasource, from, to)
+	if len(between) == 0 {
+		return
+	}
+	utils.PrintSubHeader(fmt.Sprintf("📜 Changelog %s → %s", from, to))
+	for _, release := range between {
+		utils.PrintSectionHeader(release.TagName)
+		if body := strings.TrimSpace(release.Body); body != "" {
+			utils.Print("%s", body)
+		}
+	}
+}
+
+func printUpgradePlan(plan *modelpkg.Plan) {
+	utils.PrintSubHeader("📦 File Changes")
+	if len(plan.Changes) == 0 {
+		utils.PrintInfo("No file changes")
+		return
+	}
+	rows := make([][]string, len(plan.Changes))
+	for i, c := range plan.Changes {
+		action := string(c.Action)
+		if c.Conflicts > 0 {
+			action = fmt.Sprintf("%s (%d)", action, c.Conflicts)
+		}
+		rows[i] = []string{c.Path, action}
+	}
+	utils.PrintTable([]string{"File", "Action"}, rows)
+}
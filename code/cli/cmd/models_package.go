@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// modelsPackageCmd represents the models package command
+var modelsPackageCmd = &cobra.Command{
+	Use:   "package",
+	Short: "Build a datasource model package from the dbt models",
+	Long: `Build the model package of a datasource from a checkout of the dbt models, e.g. a
+fork, the way releases are built.
+
+The package holds the common_assets and the model_paths of the datasource listed in
+datasources-release.yml, relative to its base_path, in the layout ecos extracts into
+the dbt project. The archive is written with its checksum file (<archive>.sha256).
+
+With --mirror, the output directory is laid out as a model source mirror: the files
+go under ds/<datasource>/v<version>/ and the release is added to releases.json, so
+the directory can be used as model_source: file:///path/to/output.
+
+ecos only installs signed packages. Sign the archive with minisign and install with
+ECOS_MODELS_PUBLIC_KEY set to your public key:
+  minisign -Sm dist/aws-cur-1.2.0-acme.tar.gz
+
+Examples:
+  ecos models package --datasource aws_cur --version 1.2.0-acme
+  ecos models package --datasource aws_cur --version 1.2.0-acme --mirror -o /srv/ecos-mirror
+  ecos models package --datasource aws_cur --release-config ../ecos/datasources-release.yml`,
+	RunE: runModelsPackage,
+}
+
+func init() {
+	modelsCmd.AddCommand(modelsPackageCmd)
+
+	modelsPackageCmd.Flags().String("datasource", "", "datasource to package, e.g. aws_cur")
+	modelsPackageCmd.Flags().String("version", "", "package version, e.g. 1.2.0-acme (default: version in the release configuration)")
+	modelsPackageCmd.Flags().String("release-config", modelpkg.ReleaseConfigFilename, "path to datasources-release.yml")
+	modelsPackageCmd.Flags().StringP("output", "o", "", "output directory (default: distribution_directory of the release configuration)")
+	modelsPackageCmd.Flags().Bool("mirror", false, "lay out the output directory as a model source mirror")
+	_ = modelsPackageCmd.MarkFlagRequired("datasource")
+}
+
+func runModelsPackage(cmd *cobra.Command, _ []string) error {
+	datasource, _ := cmd.Flags().GetString("datasource")
+	version, _ := cmd.Flags().GetString("version")
+	releaseConfig, _ := cmd.Flags().GetString("release-config")
+	outputDir, _ := cmd.Flags().GetString("output")
+	mirror, _ := cmd.Flags().GetBool("mirror")
+
+	utils.PrintHeader("ecos models package")
+
+	rc, err := modelpkg.LoadReleaseConfig(releaseConfig)
+	if err != nil {
+		return err
+	}
+	ds, err := rc.Datasource(datasource)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		version = ds.Version
+	}
+	version = strings.TrimPrefix(version, "v")
+	if version == "" {
+		return fmt.Errorf("no version for %s, set --version", datasource)
+	}
+	if outputDir == "" {
+		outputDir = rc.Release.DistributionDirectory
+	}
+	if outputDir == "" {
+		outputDir = "dist"
+	}
+
+	tag := fmt.Sprintf("ds/%s/v%s", datasource, version)
+	archiveName := rc.ArchiveName(ds, version)
+	if expected := initUtils.PackageFilename(datasource, version); archiveName != expected {
+		utils.PrintWarning(fmt.Sprintf("ecos downloads %s as %s, not %s: check package_name and archive_format", tag, expected, archiveName))
+	}
+	dir := outputDir
+	if mirror {
+		dir = filepath.Join(outputDir, filepath.FromSlash(tag))
+	}
+	archivePath := filepath.Join(dir, archiveName)
+
+	var buf bytes.Buffer
+	files, err := rc.BuildPackage(&buf, ds)
+	if err != nil {
+		return err
+	}
+	archive := buf.Bytes()
+	checksum := modelpkg.ChecksumFile(archive, archiveName)
+
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would write %s (%d files, %s) and its checksum file", archivePath, len(files), formatBytes(int64(len(archive)))))
+		if mirror {
+			utils.PrintDryRun(fmt.Sprintf("Would add %s to the release list of %s", tag, outputDir))
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(archivePath, archive, 0o600); err != nil {
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+	if err := os.WriteFile(archivePath+modelpkg.ChecksumSuffix, checksum, 0o600); err != nil {
+		return fmt.Errorf("failed to write checksum file: %w", err)
+	}
+	if mirror {
+		release := initUtils.GitHubRelease{TagName: tag, Body: ds.Description, PublishedAt: time.Now().UTC()}
+		if err := initUtils.AddMirrorRelease(outputDir, release); err != nil {
+			return err
+		}
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("Packaged %s v%s: %d files, %s", datasource, version, len(files), formatBytes(int64(len(archive)))))
+	utils.Print("  %-10s %s", "Archive", archivePath)
+	utils.Print("  %-10s %s", "Checksum", strings.Fields(string(checksum))[0])
+	fmt.Println()
+	utils.PrintInfo("Sign the archive, then install it with ECOS_MODELS_PUBLIC_KEY set to your public key:")
+	utils.Print("  minisign -Sm %s", archivePath)
+	source := archivePath
+	if mirror {
+		source = outputDir
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+	source = "file://" + filepath.ToSlash(source)
+	utils.Print("  ecos init --model-source %s --model-version v%s", source, version)
+	return nil
+}
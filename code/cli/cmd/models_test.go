@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+)
+
+// fakeDownloader extracts packages from memory, the way the model downloader records them
+type fakeDownloader struct {
+	latest   string
+	packages map[string]map[string]string
+}
+
+func (f *fakeDownloader) DownloadTransformModels(_ context.Context, datasource, version, dest string) (string, error) {
+	if version == "" {
+		version = f.latest
+	}
+	manifest := &modelpkg.Manifest{Datasource: datasource, Version: version, Files: map[string]string{}}
+	for name, content := range f.packages[version] {
+		path := filepath.Join(dest, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			return "", err
+		}
+		manifest.Files[name], _ = modelpkg.FileChecksum(path)
+	}
+	return version, manifest.Save(dest)
+}
+
+func (f *fakeDownloader) DatasourceReleases(context.Context, string) ([]initUtils.GitHubRelease, error) {
+	return []initUtils.GitHubRelease{{TagName: "ds/aws_cur/v1.1.0", Body: "- Add gp3 savings"}}, nil
+}
+
+func TestUpgradeModels(t *testing.T) {
+	downloader := &fakeDownloader{latest: "v1.1.0", packages: map[string]map[string]string{
+		"v1.0.0": {"models/a.sql": "select 1\n", "models/b.sql": "select\n  b\nfrom t\n", "dbt_project.yml": "name: ecos\n"},
+		"v1.1.0": {"models/a.sql": "select 2\n", "models/b.sql": "select\n  b\nfrom t\nwhere b > 0\n", "dbt_project.yml": "name: ecos\nversion: 1.1.0\n"},
+	}}
+
+	projectDir := t.TempDir()
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if err := os.WriteFile(configPath, []byte("project_name: demo\nmodel_version: v1.0.0\ndata_source: aws_cur\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dbtDir := filepath.Join(projectDir, "transform", "dbt")
+	if _, err := downloader.DownloadTransformModels(context.Background(), "aws_cur", "v1.0.0", dbtDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dbtDir, "models", "b.sql"), []byte("-- ours\nselect\n  b\nfrom t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// ecos init regenerates dbt_project.yml over the packaged one
+	if err := os.WriteFile(filepath.Join(dbtDir, "dbt_project.yml"), []byte("name: demo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.EcosConfig{ModelVersion: "v1.0.0", DataSource: "aws_cur"}
+	if err := upgradeModels(context.Background(), downloader, cfg, configPath, dbtDir, ""); err != nil {
+		t.Fatalf("upgradeModels() error = %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"a.sql": "select 2\n",
+		"b.sql": "-- ours\nselect\n  b\nfrom t\nwhere b > 0\n",
+	} {
+		if got, _ := os.ReadFile(filepath.Join(dbtDir, "models", name)); string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if data, _ := os.ReadFile(configPath); !strings.Contains(string(data), "model_version: v1.1.0") {
+		t.Errorf(".ecos.yaml = %s", data)
+	}
+	if manifest, err := modelpkg.LoadManifest(dbtDir); err != nil || manifest.Version != "v1.1.0" {
+		t.Errorf("manifest = %+v, %v", manifest, err)
+	}
+	// The generated files are regenerated from the configuration, not merged
+	project, _ := os.ReadFile(filepath.Join(dbtDir, "dbt_project.yml"))
+	if modelpkg.HasConflictMarkers(string(project)) || !strings.Contains(string(project), "profile:") {
+		t.Errorf("dbt_project.yml = %s", project)
+	}
+	if _, err := os.Stat(filepath.Join(dbtDir, "profiles.yml")); err != nil {
+		t.Errorf("profiles.yml not generated: %v", err)
+	}
+
+	// Upgrading again is a no-op
+	if err := upgradeModels(context.Background(), downloader, cfg, configPath, dbtDir, "v1.1.0"); err != nil {
+		t.Errorf("upgradeModels() to the installed version error = %v", err)
+	}
+}
+
+func TestInstalledModelVersion(t *testing.T) {
+	cfg := &config.EcosConfig{ModelVersion: "latest"}
+	if _, err := installedModelVersion(cfg, nil); err == nil {
+		t.Error("installedModelVersion() expected error for latest")
+	}
+	cfg.ModelVersion = "1.0.0"
+	if v, _ := installedModelVersion(cfg, nil); v != "v1.0.0" {
+		t.Errorf("installedModelVersion() = %s", v)
+	}
+	if v, _ := installedModelVersion(cfg, &modelpkg.Manifest{Version: "v0.9.0"}); v != "v0.9.0" {
+		t.Errorf("installedModelVersion() with a manifest = %s", v)
+	}
+}
+
+func TestCustomOverlayDir(t *testing.T) {
+	cfg := &config.EcosConfig{}
+	if got := customOverlayDir(cfg, "project"); got != "" {
+		t.Errorf("customOverlayDir() without custom_dir = %q", got)
+	}
+	cfg.Transform.DBT.CustomDir = "custom"
+	if got := customOverlayDir(cfg, "project"); got != filepath.Join("project", "custom") {
+		t.Errorf("customOverlayDir() = %q", got)
+	}
+	cfg.Transform.DBT.CustomDir = "/srv/custom"
+	if got := customOverlayDir(cfg, "project"); got != "/srv/custom" {
+		t.Errorf("customOverlayDir() with an absolute path = %q", got)
+	}
+}
+
+func TestModelMaterialization(t *testing.T) {
+	cfg := &config.EcosConfig{}
+	cfg.Transform.DBT.Materialization = &config.MaterializationConfig{Mode: "incremental"}
+	m := catalog.Model{Name: "gold_core__service_daily", Materialization: "incremental"}
+
+	if got := modelMaterialization(cfg, &catalog.Catalog{}, m); got != "incremental" {
+		t.Errorf("resolved materialization = %q", got)
+	}
+	// dbt already resolved the materialization of a compiled catalog
+	m.Materialization = "table"
+	if got := modelMaterialization(cfg, &catalog.Catalog{Compiled: true}, m); got != "table" {
+		t.Errorf("compiled materialization = %q", got)
+	}
+	if got := modelGrain(catalog.Model{Name: "gold_core__service_daily", Grain: []string{"usage_date", "service_code"}}); got != "daily (usage_date, service_code)" {
+		t.Errorf("modelGrain() = %q", got)
+	}
+	if got := lineageRows([]string{"a", "b"}, []string{"c"}); len(got) != 2 || got[1][1] != "" {
+		t.Errorf("lineageRows() = %v", got)
+	}
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// defaultTableRows bounds the rows printed as a table unless --limit is set
+const defaultTableRows = 100
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query [sql]",
+	Short: "Run SQL against the models",
+	Long: `Run a SQL query in the adhoc Athena workgroup against the project database.
+
+The query is given as an argument, read from a file with --file, or selected by
+name from the saved queries shipped with the model package (see --list). Table
+names resolve to the models in aws.database.
+
+Results are read page by page and printed as a table, or written as CSV, JSON or
+Parquet. Tables show the first 100 rows unless --limit is set; the other formats
+write every row. CSV and JSON go to stdout without --output; Parquet needs it.
+
+Examples:
+  ecos query "select service_name, sum(total_effective_cost) from serve_core__cost_service_account_daily group by 1"
+  ecos query -f queries/top_accounts.sql --format csv --output top_accounts.csv
+  ecos query --list
+  ecos query --name cost_by_service_last_month --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runQueryCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringP("file", "f", "", "file containing the SQL query")
+	queryCmd.Flags().StringP("name", "n", "", "saved query to run (see --list)")
+	queryCmd.Flags().Bool("list", false, "list the saved queries of the model package")
+	queryCmd.Flags().String("format", string(query.FormatTable), "output format: table, csv, json, parquet")
+	queryCmd.Flags().StringP("output", "o", "", "file to write the results to (default: stdout)")
+	queryCmd.Flags().Int("limit", 0, "maximum number of rows to output (default: 100 for table, all otherwise)")
+	queryCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+// queryOptions selects what 'ecos query' runs and how it outputs the results
+type queryOptions struct {
+	sql    string
+	format query.Format
+	output string
+	limit  int
+}
+
+func runQueryCmd(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	name, _ := cmd.Flags().GetString("name")
+	list, _ := cmd.Flags().GetBool("list")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	limit, _ := cmd.Flags().GetInt("limit")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+	savedDir := savedQueriesDir(ecosConfig, projectDir)
+
+	if list {
+		return listSavedQueries(savedDir)
+	}
+
+	var sql string
+	if len(args) == 1 {
+		sql = args[0]
+	}
+	opts, err := resolveQueryOptions(sql, file, name, savedDir, format, output, limit)
+	if err != nil {
+		return err
+	}
+
+	// CSV and JSON on stdout stay parseable: status goes to stderr
+	status := io.Writer(os.Stdout)
+	if opts.output == "" && opts.format != query.FormatTable {
+		status = os.Stderr
+	} else {
+		utils.PrintHeader("ecos query")
+	}
+
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would run in the adhoc workgroup against %s:\n%s", ecosConfig.AWS.Database, opts.sql))
+		if opts.output != "" {
+			utils.PrintDryRun(fmt.Sprintf("Would write the results as %s to %s", opts.format, opts.output))
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	querier, err := newAthenaQuerier(ctx, ecosConfig)
+	if err != nil {
+		return err
+	}
+	querier.Progress = func(s query.Status) {
+		if utils.IsTerminal() {
+			fmt.Fprintf(status, "\r\033[K%s[•]%s Running query... %s scanned, %s", utils.ColorYellow, utils.ColorReset, formatBytes(s.DataScannedBytes), s.Elapsed.Round(time.Second))
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	var outFile *os.File
+	if opts.output != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.output), 0o750); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if outFile, err = os.OpenFile(opts.output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600); err != nil {
+			return fmt.Errorf("failed to create %s: %w", opts.output, err)
+		}
+		out = outFile
+	}
+
+	result, err := executeQuery(ctx, querier, opts, out)
+	if utils.IsTerminal() {
+		fmt.Fprint(status, "\r\033[K")
+	}
+	if outFile != nil {
+		if cerr := outFile.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to write %s: %w", opts.output, cerr)
+		}
+		if err != nil {
+			_ = os.Remove(opts.output)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.format == query.FormatTable {
+		utils.PrintTable(columnNames(result.columns), result.rows)
+		if len(result.rows) == 0 {
+			utils.PrintInfo("The query returned no rows")
+		}
+	}
+	summary := fmt.Sprintf("%d rows, %s scanned in %s (query %s)", result.written, formatBytes(result.execution.DataScannedBytes), result.execution.Elapsed.Round(time.Millisecond), result.execution.ID)
+	if result.truncated {
+		summary += fmt.Sprintf("; output stopped after %d rows, raise --limit for more", opts.limit)
+	}
+	fmt.Fprintf(status, "%s%s%s %s\n", utils.ColorGreen, utils.CheckHeavy, utils.ColorReset, summary)
+	if opts.output != "" {
+		fmt.Fprintf(status, "%si%s Results written to %s\n", utils.ColorBlue, utils.ColorReset, opts.output)
+	}
+	return nil
+}
+
+// resolveQueryOptions reads the SQL from exactly one of the argument, the file or the
+// saved query and validates the output format
+func resolveQueryOptions(sql, file, name, savedDir, format, output string, limit int) (queryOptions, error) {
+	given := 0
+	for _, s := range []string{sql, file, name} {
+		if s != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return queryOptions{}, errors.New("pass the SQL as an argument, --file or --name (see 'ecos query --list')")
+	}
+
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file) // #nosec G304
+		if err != nil {
+			return queryOptions{}, fmt.Errorf("failed to read query file: %w", err)
+		}
+		sql = string(data)
+	case name != "":
+		saved, err := query.FindSaved(savedDir, name)
+		if err != nil {
+			return queryOptions{}, err
+		}
+		sql = saved.SQL
+	}
+	// Athena runs a single statement and rejects a trailing semicolon
+	sql = strings.TrimRight(strings.TrimSpace(sql), "; \t\n")
+	if sql == "" {
+		return queryOptions{}, errors.New("the query is empty")
+	}
+
+	f, err := query.ParseFormat(format)
+	if err != nil {
+		return queryOptions{}, err
+	}
+	if f == query.FormatParquet && output == "" {
+		return queryOptions{}, errors.New("parquet output needs --output")
+	}
+	if f == query.FormatTable && output != "" {
+		return queryOptions{}, errors.New("--output needs --format csv, json or parquet")
+	}
+	if limit < 0 {
+		return queryOptions{}, errors.New("--limit must not be negative")
+	}
+	if limit == 0 && f == query.FormatTable {
+		limit = defaultTableRows
+	}
+	return queryOptions{sql: sql, format: f, output: output, limit: limit}, nil
+}
+
+// queryResult is a finished query and the rows it output
+type queryResult struct {
+	execution *query.Execution
+	columns   []query.Column
+	// rows holds the rows of table output, which is printed once all pages are read
+	rows      [][]string
+	written   int
+	truncated bool
+}
+
+// executeQuery runs the query and writes its results to w page by page. Table rows are
+// returned instead. Reading stops once opts.limit rows are output.
+func executeQuery(ctx context.Context, executor query.Executor, opts queryOptions, w io.Writer) (*queryResult, error) {
+	result := &queryResult{}
+	var writer query.ResultWriter
+	exec, err := executor.Execute(ctx, opts.sql, func(columns []query.Column, rows [][]string) error {
+		result.columns = columns
+		if opts.limit > 0 && result.written+len(rows) > opts.limit {
+			rows = rows[:opts.limit-result.written]
+			result.truncated = true
+		}
+		result.written += len(rows)
+
+		if opts.format == query.FormatTable {
+			result.rows = append(result.rows, rows...)
+		} else {
+			if writer == nil {
+				var err error
+				if writer, err = query.NewResultWriter(w, opts.format, columns); err != nil {
+					return err
+				}
+			}
+			if err := writer.Write(rows); err != nil {
+				return fmt.Errorf("failed to write results: %w", err)
+			}
+		}
+
+		if result.truncated {
+			return query.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if writer != nil {
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write results: %w", err)
+		}
+	}
+	result.execution = exec
+	return result, nil
+}
+
+// savedQueriesDir returns the saved queries directory of the project's model package
+func savedQueriesDir(cfg *config.EcosConfig, projectDir string) string {
+	return filepath.Join(dbtProjectDir(cfg, projectDir), query.SavedQueriesDir)
+}
+
+// dbtProjectDir returns the dbt project of the model package, transform/dbt unless
+// transform.dbt.project_dir is set
+func dbtProjectDir(cfg *config.EcosConfig, projectDir string) string {
+	dbtDir := cfg.Transform.DBT.ProjectDir
+	if dbtDir == "" {
+		return filepath.Join(projectDir, "transform", "dbt")
+	}
+	if !filepath.IsAbs(dbtDir) {
+		return filepath.Join(projectDir, dbtDir)
+	}
+	return dbtDir
+}
+
+func listSavedQueries(dir string) error {
+	queries, err := query.LoadSaved(dir)
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		utils.PrintInfo(fmt.Sprintf("No saved queries found in %s", dir))
+		return nil
+	}
+
+	utils.PrintHeader("Saved queries")
+	rows := make([][]string, len(queries))
+	for i, q := range queries {
+		rows[i] = []string{q.Name, q.Description}
+	}
+	utils.PrintTable([]string{"Name", "Description"}, rows)
+	fmt.Println()
+	utils.PrintInfo("Run one with 'ecos query --name <name>'")
+	return nil
+}
+
+func columnNames(columns []query.Column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
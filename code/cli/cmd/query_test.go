@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+)
+
+// pagedExecutor returns its pages as if read from Athena one at a time
+type pagedExecutor struct {
+	pages [][][]string
+	read  int
+}
+
+func (p *pagedExecutor) Execute(_ context.Context, _ string, page func([]query.Column, [][]string) error) (*query.Execution, error) {
+	columns := []query.Column{{Name: "service_name", Type: "varchar"}, {Name: "cost", Type: "double"}}
+	for _, rows := range p.pages {
+		p.read++
+		if err := page(columns, rows); err != nil {
+			if errors.Is(err, query.ErrStop) {
+				break
+			}
+			return nil, err
+		}
+	}
+	return &query.Execution{ID: "q-1", Columns: columns, DataScannedBytes: 1536}, nil
+}
+
+func TestResolveQueryOptions(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "top.sql")
+	if err := os.WriteFile(file, []byte("select 1;\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	saved := filepath.Join(dir, "queries")
+	if err := os.MkdirAll(saved, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(saved, "cost_by_service_last_month.sql"), []byte("-- Cost by service\nselect 2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := resolveQueryOptions("", file, "", saved, "table", "", 0)
+	if err != nil || opts.sql != "select 1" || opts.format != query.FormatTable || opts.limit != defaultTableRows {
+		t.Errorf("resolveQueryOptions(file) = %+v, %v", opts, err)
+	}
+	opts, err = resolveQueryOptions("", "", "cost-by-service-last-month", saved, "csv", "", 0)
+	if err != nil || opts.sql != "-- Cost by service\nselect 2" || opts.limit != 0 {
+		t.Errorf("resolveQueryOptions(name) = %+v, %v", opts, err)
+	}
+
+	for _, tt := range []struct {
+		name             string
+		sql, file, saved string
+		format, output   string
+	}{
+		{name: "no query", format: "table"},
+		{name: "two queries", sql: "select 1", file: file, format: "table"},
+		{name: "empty query", sql: " ; ", format: "table"},
+		{name: "unknown format", sql: "select 1", format: "xlsx"},
+		{name: "parquet to stdout", sql: "select 1", format: "parquet"},
+		{name: "table to a file", sql: "select 1", format: "table", output: "out.txt"},
+		{name: "unknown saved query", saved: "cost_by_team", format: "table"},
+	} {
+		if _, err := resolveQueryOptions(tt.sql, tt.file, tt.saved, saved, tt.format, tt.output, 0); err == nil {
+			t.Errorf("resolveQueryOptions() expected error for %s", tt.name)
+		}
+	}
+}
+
+func TestExecuteQuery(t *testing.T) {
+	pages := [][][]string{{{"Amazon EC2", "700"}, {"Amazon S3", "250"}}, {{"AWS Lambda", "20"}}, {{"Amazon SQS", "1"}}}
+
+	executor := &pagedExecutor{pages: pages}
+	result, err := executeQuery(context.Background(), executor, queryOptions{sql: "select 1", format: query.FormatTable, limit: 2}, nil)
+	if err != nil {
+		t.Fatalf("executeQuery() error = %v", err)
+	}
+	if len(result.rows) != 2 || !result.truncated || executor.read != 2 {
+		t.Errorf("table result = %+v after reading %d pages, want 2 rows and to stop reading", result, executor.read)
+	}
+
+	var buf bytes.Buffer
+	result, err = executeQuery(context.Background(), &pagedExecutor{pages: pages}, queryOptions{sql: "select 1", format: query.FormatCSV}, &buf)
+	if err != nil {
+		t.Fatalf("executeQuery() error = %v", err)
+	}
+	want := "service_name,cost\nAmazon EC2,700\nAmazon S3,250\nAWS Lambda,20\nAmazon SQS,1\n"
+	if buf.String() != want || result.written != 4 || result.truncated || result.rows != nil {
+		t.Errorf("csv output = %q, result %+v", buf.String(), result)
+	}
+}
+
+func TestSavedQueriesDir(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Transform.DBT.ProjectDir = "./transform/dbt"
+	if got, want := savedQueriesDir(cfg, "proj"), filepath.Join("proj", "transform", "dbt", "queries"); got != want {
+		t.Errorf("savedQueriesDir() = %s, want %s", got, want)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 10 << 30: "10.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/report"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// reportFormatTable prints the report instead of writing a file
+	reportFormatTable = "table"
+	// reportPluginAthena is the only report.plugin: the models are queried through Athena
+	reportPluginAthena = "athena"
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Render the monthly cost report",
+	Long: `Render the cost report of a billing period from the serve and gold models.
+
+The report compares the effective cost of the billing period with the month before:
+  - the total and the top services by cost
+  - the top movers by service, account and tag
+  - the potential savings found by the optimization models
+
+It is written to report.output_path as cost-report-<period>.<ext> in each
+requested format (html, markdown, csv or json); table prints it instead. The
+queries run in the adhoc Athena workgroup.
+
+Examples:
+  ecos report
+  ecos report --period 2025-06 --format html,csv
+  ecos report --format markdown --output ./slides`,
+	RunE: runReport,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().String("period", "", "billing period to report (YYYY-MM, default: previous month)")
+	reportCmd.Flags().StringSlice("format", nil, "output formats: html, markdown, csv, json, table (default: report.format)")
+	reportCmd.Flags().String("output", "", "output directory (default: report.output_path)")
+	reportCmd.Flags().Int("top", report.DefaultTop, "number of services, accounts and tags per section")
+	reportCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+// reportOptions selects what 'ecos report' renders and where
+type reportOptions struct {
+	period    string
+	formats   []string
+	outputDir string
+	top       int
+}
+
+func runReport(cmd *cobra.Command, _ []string) error {
+	period, _ := cmd.Flags().GetString("period")
+	formats, _ := cmd.Flags().GetStringSlice("format")
+	output, _ := cmd.Flags().GetString("output")
+	top, _ := cmd.Flags().GetInt("top")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos report")
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	opts, err := resolveReportOptions(ecosConfig, projectDir, period, formats, output, top)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would report billing period %s from the models in %s", opts.period, ecosConfig.AWS.Database))
+		for _, format := range opts.formats {
+			if format == reportFormatTable {
+				utils.PrintDryRun("Would print the report")
+				continue
+			}
+			f, _ := report.ParseFormat(format)
+			utils.PrintDryRun(fmt.Sprintf("Would write %s", filepath.Join(opts.outputDir, report.Filename(opts.period, f))))
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	querier, err := newAthenaQuerier(ctx, ecosConfig)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	paths, err := generateReport(ctx, ecosConfig, querier, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		utils.PrintInfo(fmt.Sprintf("Report written to %s", path))
+	}
+	utils.PrintSuccess(fmt.Sprintf("Report for %s completed in %s", opts.period, time.Since(started).Round(time.Second)))
+	return nil
+}
+
+// resolveReportOptions applies the configured defaults and validates the formats
+func resolveReportOptions(cfg *config.EcosConfig, projectDir, period string, formats []string, output string, top int) (reportOptions, error) {
+	if period == "" {
+		period = time.Now().UTC().AddDate(0, -1, 0).Format(config.BillingPeriodLayout)
+	}
+	if _, err := config.ParseBillingPeriod(period); err != nil {
+		return reportOptions{}, fmt.Errorf("--period: %w", err)
+	}
+
+	if len(formats) == 0 {
+		formats = []string{cfg.Report.Format}
+	}
+	for i, format := range formats {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format != reportFormatTable {
+			f, err := report.ParseFormat(format)
+			if err != nil {
+				return reportOptions{}, err
+			}
+			format = string(f)
+		}
+		formats[i] = format
+	}
+
+	if output == "" {
+		output = cfg.Report.OutputPath
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(projectDir, output)
+	}
+	if cfg.AWS.Database == "" {
+		return reportOptions{}, errors.New("report needs aws.database in .ecos.yaml")
+	}
+	// The report is built from the models in aws.database through Athena only
+	if plugin := cfg.Report.Plugin; plugin != "" && plugin != reportPluginAthena {
+		return reportOptions{}, fmt.Errorf("unsupported report.plugin '%s', the report only supports %s", plugin, reportPluginAthena)
+	}
+	if len(cfg.Report.DataSources) > 0 {
+		return reportOptions{}, errors.New("report.data_sources is not supported, the report queries the models in aws.database")
+	}
+	return reportOptions{period: period, formats: formats, outputDir: output, top: top}, nil
+}
+
+// generateReport builds the report and writes or prints it in every format. It returns
+// the paths of the files written.
+func generateReport(ctx context.Context, cfg *config.EcosConfig, querier query.Querier, opts reportOptions) ([]string, error) {
+	utils.PrintProgress(fmt.Sprintf("Querying the models for billing period %s", opts.period))
+	r, err := report.Build(ctx, querier, cfg.AWS.Database, opts.period, opts.top)
+	if err != nil {
+		return nil, err
+	}
+	r.ProjectName = cfg.ProjectName
+	if len(r.Unavailable) > 0 {
+		utils.PrintWarning(fmt.Sprintf("Skipped optimization models that could not be queried: %s", strings.Join(r.Unavailable, ", ")))
+	}
+
+	var paths []string
+	for _, format := range opts.formats {
+		if format == reportFormatTable {
+			printReport(r)
+			continue
+		}
+
+		f, _ := report.ParseFormat(format)
+		var buf bytes.Buffer
+		if err := report.Render(&buf, r, f); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(opts.outputDir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create report directory: %w", err)
+		}
+		path := filepath.Join(opts.outputDir, report.Filename(opts.period, f))
+		if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write %s report: %w", format, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// printReport prints the report sections as tables
+func printReport(r *report.Report) {
+	utils.PrintSectionHeader(fmt.Sprintf("Cost Report %s", r.BillingPeriod))
+	utils.PrintTable([]string{"", r.BillingPeriod, r.PreviousPeriod, "Change"}, [][]string{{
+		"Total",
+		fmt.Sprintf("%.2f", r.Summary.Cost),
+		fmt.Sprintf("%.2f", r.Summary.PreviousCost),
+		fmt.Sprintf("%+.2f", r.Summary.Change),
+	}})
+
+	for _, section := range []struct {
+		title  string
+		label  string
+		movers []report.Mover
+	}{
+		{"Top Services", "Service", r.TopServices},
+		{"Top Movers by Service", "Service", r.ServiceMovers},
+		{"Top Movers by Account", "Account", r.AccountMovers},
+		{"Top Movers by Tag", "Tag", r.TagMovers},
+	} {
+		utils.PrintSectionHeader(section.title)
+		var rows [][]string
+		for _, m := range section.movers {
+			rows = append(rows, []string{m.Name, fmt.Sprintf("%.2f", m.Cost), fmt.Sprintf("%.2f", m.PreviousCost), fmt.Sprintf("%+.2f", m.Change)})
+		}
+		utils.PrintTable([]string{section.label, r.BillingPeriod, r.PreviousPeriod, "Change"}, rows)
+	}
+
+	utils.PrintSectionHeader("Optimization Opportunities")
+	var rows [][]string
+	for _, o := range r.Opportunities {
+		rows = append(rows, []string{o.Name, fmt.Sprintf("%.2f", o.Savings), fmt.Sprint(o.Accounts)})
+	}
+	utils.PrintTable([]string{"Opportunity", "Potential monthly savings", "Accounts"}, rows)
+	fmt.Println()
+}
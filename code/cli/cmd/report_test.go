@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+// staticReportQuerier returns the same service costs for every query
+type staticReportQuerier struct{}
+
+func (staticReportQuerier) Query(context.Context, string) ([][]string, error) {
+	return [][]string{{"Amazon EC2", "2025-06", "100"}, {"Amazon EC2", "2025-05", "80"}}, nil
+}
+
+func TestResolveReportOptions(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.AWS.Database = "ecos"
+
+	opts, err := resolveReportOptions(cfg, "proj", "2025-06", nil, "", 5)
+	if err != nil {
+		t.Fatalf("resolveReportOptions() error = %v", err)
+	}
+	if !reflect.DeepEqual(opts.formats, []string{"html"}) || opts.outputDir != filepath.Join("proj", "reports") {
+		t.Errorf("options = %+v, want the configured format and output path", opts)
+	}
+
+	opts, err = resolveReportOptions(cfg, "proj", "2025-06", []string{"MD", "table"}, "/tmp/slides", 5)
+	if err != nil || !reflect.DeepEqual(opts.formats, []string{"markdown", "table"}) || opts.outputDir != "/tmp/slides" {
+		t.Errorf("resolveReportOptions() = %+v, %v", opts, err)
+	}
+
+	if _, err := resolveReportOptions(cfg, "proj", "2025-06", []string{"pdf"}, "", 5); err == nil {
+		t.Error("resolveReportOptions() expected error for an unsupported format")
+	}
+	if _, err := resolveReportOptions(cfg, "proj", "June", nil, "", 5); err == nil {
+		t.Error("resolveReportOptions() expected error for a malformed period")
+	}
+
+	cfg.Report.Plugin = "athena"
+	if _, err := resolveReportOptions(cfg, "proj", "2025-06", nil, "", 5); err != nil {
+		t.Errorf("resolveReportOptions() with the athena plugin error = %v", err)
+	}
+	cfg.Report.Plugin = "looker"
+	if _, err := resolveReportOptions(cfg, "proj", "2025-06", nil, "", 5); err == nil {
+		t.Error("resolveReportOptions() expected error for an unsupported plugin")
+	}
+	cfg.Report.Plugin = ""
+	cfg.Report.DataSources = []config.DataSourceConfig{{Name: "billing", Type: "bigquery"}}
+	if _, err := resolveReportOptions(cfg, "proj", "2025-06", nil, "", 5); err == nil {
+		t.Error("resolveReportOptions() expected error for data_sources")
+	}
+}
+
+func TestGenerateReport(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.AWS.Database = "ecos"
+	dir := t.TempDir()
+	opts := reportOptions{period: "2025-06", formats: []string{"html", "csv", "table"}, outputDir: filepath.Join(dir, "reports"), top: 5}
+
+	paths, err := generateReport(context.Background(), cfg, staticReportQuerier{}, opts)
+	if err != nil {
+		t.Fatalf("generateReport() error = %v", err)
+	}
+	want := []string{filepath.Join(dir, "reports", "cost-report-2025-06.html"), filepath.Join(dir, "reports", "cost-report-2025-06.csv")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("report %s not written: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/pipeline"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/report"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/verify"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// defaultPipelineReport is where 'ecos run' writes its report, relative to the project directory
+var defaultPipelineReport = filepath.Join("output", "pipeline-report.json")
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the ingest, transform, verify and report pipeline",
+	Long: `Run the pipeline of stages defined under 'pipeline' in .ecos.yaml.
+
+Each stage runs an ingest, transform, verify or report step. Stages run after the
+stages they depend on and only when those succeeded. A failed stage is retried up
+to 'retries' times, and each attempt can be bounded by a 'timeout'. After a failure,
+on_failure: stop (default) skips every remaining stage, while on_failure: continue
+skips only the stages depending on the failed one.
+
+The stage results are written as one JSON report (default: output/pipeline-report.json).
+Without a pipeline section, 'ecos run' runs the transform models.
+
+Example .ecos.yaml:
+  pipeline:
+    on_failure: stop
+    stages:
+      - name: models
+        type: transform
+        command: run
+        retries: 2
+        retry_delay: 1m
+        timeout: 2h
+      - name: tests
+        type: transform
+        command: test
+        depends_on: [models]
+
+Examples:
+  ecos run
+  ecos run --report ./reports/nightly.json
+  ecos run --dry-run`,
+	RunE: runPipeline,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().String("report", "", "path of the JSON report (default: pipeline.report_path or output/pipeline-report.json)")
+	runCmd.Flags().Bool("ignore-drift", false, "ignore configuration drift and proceed anyway")
+	runCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+func runPipeline(cmd *cobra.Command, args []string) error {
+	reportPath, _ := cmd.Flags().GetString("report")
+	ignoreDrift, _ := cmd.Flags().GetBool("ignore-drift")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos run")
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+	if !ignoreDrift {
+		if err := checkConfigDrift(projectDir); err != nil {
+			return err
+		}
+	}
+	// Check the runtime against .ecos.lock (lock.mode)
+	if err := checkProjectLock(cmd.Context(), ecosConfig, projectDir); err != nil {
+		return err
+	}
+
+	stages, err := pipelineStages(ecosConfig, projectDir)
+	if err != nil {
+		return err
+	}
+	p, err := pipeline.New(stages, ecosConfig.Pipeline.OnFailure)
+	if err != nil {
+		return fmt.Errorf("invalid pipeline: %w", err)
+	}
+
+	if reportPath == "" {
+		reportPath = pipelineReportPath(ecosConfig, projectDir)
+	}
+
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would run %d stage(s), on_failure: %s", len(p.Stages), p.OnFailure))
+		for i, s := range p.Stages {
+			utils.PrintDryRun(fmt.Sprintf("  %d. %s", i+1, describeStage(s, ecosConfig)))
+		}
+		utils.PrintDryRun(fmt.Sprintf("Would write the report to %s", reportPath))
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result := p.Run(ctx)
+
+	fmt.Println()
+	var rows [][]string
+	for _, r := range pipeline.StageResults(result) {
+		status := "✅ succeeded"
+		switch r.Status {
+		case pipeline.StageFailed:
+			status = "❌ failed"
+		case pipeline.StageSkipped:
+			status = "⏭️  skipped"
+		}
+		duration := ""
+		if r.Duration > 0 {
+			duration = r.Duration.Round(time.Second).String()
+		}
+		rows = append(rows, []string{r.Name, string(r.Type), status, fmt.Sprint(r.Attempts), duration})
+	}
+	utils.PrintTable([]string{"Stage", "Type", "Status", "Attempts", "Duration"}, rows)
+
+	if err := pipeline.WriteReport(result, reportPath); err != nil {
+		return err
+	}
+	utils.PrintInfo(fmt.Sprintf("Report written to %s", reportPath))
+
+	if !result.Success {
+		return fmt.Errorf("pipeline failed: %s", result.Message)
+	}
+	utils.PrintSuccess(fmt.Sprintf("Pipeline completed in %s", result.Duration.Round(time.Second)))
+	return nil
+}
+
+// pipelineReportPath returns the configured report path, relative to the project directory
+func pipelineReportPath(cfg *config.EcosConfig, projectDir string) string {
+	path := cfg.Pipeline.ReportPath
+	if path == "" {
+		path = defaultPipelineReport
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectDir, path)
+}
+
+// pipelineStages builds the stages of the configured pipeline, or a single transform
+// stage when .ecos.yaml has none
+func pipelineStages(cfg *config.EcosConfig, projectDir string) ([]pipeline.Stage, error) {
+	stageConfigs := cfg.Pipeline.Stages
+	if len(stageConfigs) == 0 {
+		stageConfigs = []config.PipelineStageConfig{{Name: "transform", Type: string(types.PluginTypeTransform), Command: "run"}}
+	}
+
+	stages := make([]pipeline.Stage, 0, len(stageConfigs))
+	for _, sc := range stageConfigs {
+		stage := pipeline.Stage{
+			Name:      sc.Name,
+			Type:      types.PluginType(sc.Type),
+			DependsOn: sc.DependsOn,
+			Retries:   sc.Retries,
+		}
+		// Durations were validated when .ecos.yaml was loaded
+		if sc.RetryDelay != "" {
+			stage.RetryDelay, _ = time.ParseDuration(sc.RetryDelay)
+		}
+		if sc.Timeout != "" {
+			stage.Timeout, _ = time.ParseDuration(sc.Timeout)
+		}
+
+		run, err := stageRunner(cfg, sc, projectDir)
+		if err != nil {
+			return nil, fmt.Errorf("stage '%s': %w", sc.Name, err)
+		}
+		stage.Run = run
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// stageRunner returns the function running one attempt of a stage
+func stageRunner(cfg *config.EcosConfig, sc config.PipelineStageConfig, projectDir string) (func(context.Context) (*types.PluginResult, error), error) {
+	switch types.PluginType(sc.Type) {
+	case types.PluginTypeTransform:
+		command := sc.Command
+		if command == "" {
+			command = "run"
+		}
+		if _, err := newTransformPlugin(transformPluginName(cfg)); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (*types.PluginResult, error) {
+			// A fresh plugin per attempt re-resolves AWS credentials for long pipelines
+			plugin, err := newTransformPlugin(transformPluginName(cfg))
+			if err != nil {
+				return nil, err
+			}
+			pluginConfig := plugin.BuildConfig(cfg, command, sc.Args)
+			pluginConfig["project_dir"] = projectDir
+			pluginConfig["verbose"] = IsVerbose()
+
+			utils.PrintProgress(fmt.Sprintf("Stage %s: %s %s", sc.Name, plugin.Name(), strings.Join(append([]string{command}, sc.Args...), " ")))
+			if err := plugin.Validate(pluginConfig); err != nil {
+				return nil, fmt.Errorf("plugin validation failed: %w", err)
+			}
+			return plugin.Execute(ctx, pluginConfig)
+		}, nil
+	case types.PluginTypeVerify:
+		// Verify the billing periods the transform stages process
+		opts, err := resolveVerifyOptions(cfg, cfg.Transform.DBT.BillingPeriodStart, cfg.Transform.DBT.BillingPeriodEnd, nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := verifyTables(cfg); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (*types.PluginResult, error) {
+			utils.PrintProgress(fmt.Sprintf("Stage %s: verify", sc.Name))
+			querier, err := newAthenaQuerier(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			result, err := runVerification(ctx, cfg, querier, opts)
+			if err != nil {
+				return nil, err
+			}
+			if err := verify.WriteReport(result, verifyReportPath(cfg, projectDir)); err != nil {
+				return nil, err
+			}
+			if !result.Success {
+				result.Message = result.Error
+			}
+			return result, nil
+		}, nil
+	case types.PluginTypeReport:
+		opts, err := resolveReportOptions(cfg, projectDir, "", nil, "", report.DefaultTop)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (*types.PluginResult, error) {
+			utils.PrintProgress(fmt.Sprintf("Stage %s: report", sc.Name))
+			started := time.Now()
+			querier, err := newAthenaQuerier(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			paths, err := generateReport(ctx, cfg, querier, opts)
+			if err != nil {
+				return nil, err
+			}
+			return &types.PluginResult{
+				Success:  true,
+				Message:  fmt.Sprintf("report for %s written to %s", opts.period, strings.Join(paths, ", ")),
+				Duration: time.Since(started),
+				Metadata: map[string]any{"files": paths},
+			}, nil
+		}, nil
+	case types.PluginTypeIngest:
+		return nil, fmt.Errorf("no %s plugin is available in this version of ecos", sc.Type)
+	default:
+		return nil, fmt.Errorf("unsupported stage type %s", sc.Type)
+	}
+}
+
+// transformPluginName returns the configured transform plugin, dbt by default
+func transformPluginName(cfg *config.EcosConfig) string {
+	if cfg.Transform.Plugin == "" {
+		return "dbt"
+	}
+	return cfg.Transform.Plugin
+}
+
+// describeStage summarizes a stage for the dry-run plan
+func describeStage(s pipeline.Stage, cfg *config.EcosConfig) string {
+	desc := fmt.Sprintf("%s (%s)", s.Name, s.Type)
+	for _, sc := range cfg.Pipeline.Stages {
+		if sc.Name == s.Name && sc.Command != "" {
+			desc = fmt.Sprintf("%s (%s %s)", s.Name, s.Type, strings.Join(append([]string{sc.Command}, sc.Args...), " "))
+		}
+	}
+	var opts []string
+	if len(s.DependsOn) > 0 {
+		opts = append(opts, "after "+strings.Join(s.DependsOn, ", "))
+	}
+	if s.Retries > 0 {
+		opts = append(opts, fmt.Sprintf("%d retries", s.Retries))
+	}
+	if s.Timeout > 0 {
+		opts = append(opts, "timeout "+s.Timeout.String())
+	}
+	if len(opts) > 0 {
+		desc += ": " + strings.Join(opts, ", ")
+	}
+	return desc
+}
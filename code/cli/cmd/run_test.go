@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/lock"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+	"github.com/spf13/cobra"
+)
+
+func TestPipelineStages(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+
+	// Without a pipeline section the models are run
+	stages, err := pipelineStages(cfg, ".")
+	if err != nil {
+		t.Fatalf("pipelineStages() error = %v", err)
+	}
+	if len(stages) != 1 || stages[0].Name != "transform" || stages[0].Type != types.PluginTypeTransform {
+		t.Errorf("default stages = %+v", stages)
+	}
+
+	cfg.Pipeline.Stages = []config.PipelineStageConfig{
+		{Name: "models", Type: "transform", Command: "run", Retries: 1, RetryDelay: "1m", Timeout: "2h"},
+		{Name: "tests", Type: "transform", Command: "test", DependsOn: []string{"models"}},
+	}
+	stages, err = pipelineStages(cfg, ".")
+	if err != nil {
+		t.Fatalf("pipelineStages() error = %v", err)
+	}
+	if stages[0].RetryDelay != time.Minute || stages[0].Timeout != 2*time.Hour || stages[1].DependsOn[0] != "models" {
+		t.Errorf("stages = %+v", stages)
+	}
+
+	cfg.Pipeline.Stages = append(cfg.Pipeline.Stages, config.PipelineStageConfig{Name: "load", Type: "ingest"})
+	if _, err := pipelineStages(cfg, "."); err == nil || !strings.Contains(err.Error(), "stage 'load'") {
+		t.Errorf("pipelineStages() error = %v, want an error for the unavailable ingest plugin", err)
+	}
+}
+
+func TestPipelineReportPath(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	if got := pipelineReportPath(cfg, "proj"); got != filepath.Join("proj", "output", "pipeline-report.json") {
+		t.Errorf("default report path = %s", got)
+	}
+
+	cfg.Pipeline.ReportPath = "reports/run.json"
+	if got := pipelineReportPath(cfg, "proj"); got != filepath.Join("proj", "reports", "run.json") {
+		t.Errorf("relative report path = %s", got)
+	}
+}
+
+func TestRunPipeline_LockFail(t *testing.T) {
+	projectDir := t.TempDir()
+	ecosConfig := `project_name: test-project
+model_version: v1.1.0
+lock:
+  mode: fail
+`
+	if err := os.WriteFile(filepath.Join(projectDir, config.ConfigFilename), []byte(ecosConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	locked := &lock.File{Models: lock.Models{Datasource: "aws_cur", Version: "v1.0.0"}}
+	if err := locked.Save(projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("report", filepath.Join(projectDir, "report.json"), "")
+	cmd.Flags().Bool("ignore-drift", true, "")
+	cmd.Flags().StringP("project-dir", "p", projectDir, "")
+
+	err := runPipeline(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), lock.Filename) {
+		t.Fatalf("runPipeline() error = %v, want the lock check to fail", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "report.json")); !os.IsNotExist(err) {
+		t.Error("pipeline ran despite lock.mode: fail")
+	}
+}
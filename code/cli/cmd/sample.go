@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/sample"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// sampleCmd represents the sample command
+var sampleCmd = &cobra.Command{
+	Use:   "sample",
+	Short: "Work with sample billing data",
+	Long: `Work with sample billing data that is safe to share.
+
+Available subcommands:
+  generate    Generate synthetic CUR 2.0 or FOCUS data
+  anonymize   Pseudonymize real CUR or FOCUS files for sharing
+
+Examples:
+  ecos sample generate --source aws_cur --months 3 --accounts 5
+  ecos sample generate --source aws_focus --format csv --table duckdb
+  ecos sample anonymize ./cur2 --output ./cur2-shared`,
+}
+
+// sampleGenerateCmd represents the sample generate command
+var sampleGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate synthetic CUR 2.0 or FOCUS data",
+	Long: `Generate synthetic AWS billing data for developing models and demos.
+
+The rows use the CUR 2.0 (aws_cur) or FOCUS 1.0 (aws_focus) columns of a BCM Data
+Export and cover EC2, EBS, S3, RDS, data transfer, a Compute Savings Plan, credits
+and cost allocation tags across the requested number of accounts. The same --seed
+and --end-month always produce the same data.
+
+Files are written as <output>/data/BILLING_PERIOD=YYYY-MM/, the layout AWS delivers
+exports in. Use --table to also write a table definition:
+  duckdb    a view over the local files (<output>/<table>.duckdb.sql)
+  glue      a Glue TableInput for the files once uploaded to --s3-location
+            (<output>/<table>.glue.json, Parquet only)
+
+Examples:
+  ecos sample generate --source aws_cur --months 3 --accounts 5 --format parquet
+  ecos sample generate --source aws_focus --format csv --table duckdb
+  ecos sample generate --table glue --s3-location s3://my-bucket/sample/cur2/`,
+	RunE: runSampleGenerate,
+}
+
+// sampleAnonymizeCmd represents the sample anonymize command
+var sampleAnonymizeCmd = &cobra.Command{
+	Use:   "anonymize <input>",
+	Short: "Pseudonymize real CUR or FOCUS files for sharing",
+	Long: `Pseudonymize CUR 2.0, legacy CUR or FOCUS files so they can be shared as
+reproducer data.
+
+<input> is a Parquet, CSV or gzipped CSV file, or a directory of them such as a
+downloaded export. Account IDs and names, ARNs, resource IDs, tag and cost category
+values, and the line item descriptions containing them are replaced with pseudonyms
+derived from a keyed hash (HMAC-SHA256). The same key always yields the same
+pseudonyms, so joins between files and columns keep working, and account IDs stay
+12 digits and resource IDs keep their prefix (i-, vol-, arn:aws:ec2:...).
+
+Each file keeps its schema, format and relative path, so the output can be queried
+with the same table definition and transformed unchanged. Use --scale-costs to
+multiply every cost, rate and price by a factor.
+
+The key is read from --key or the ECOS_ANONYMIZE_KEY environment variable. Without
+one, a random key is used and pseudonyms differ between runs.
+
+Examples:
+  ecos sample anonymize ./cur2 --output ./cur2-shared
+  ecos sample anonymize cur2-00001.parquet --scale-costs 0.37
+  ECOS_ANONYMIZE_KEY=team-secret ecos sample anonymize ./focus`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSampleAnonymize,
+}
+
+func init() {
+	rootCmd.AddCommand(sampleCmd)
+	sampleCmd.AddCommand(sampleGenerateCmd)
+
+	sampleGenerateCmd.Flags().StringP("source", "s", sample.SourceCUR, "data source to generate (aws_cur, aws_focus)")
+	sampleGenerateCmd.Flags().Int("months", 3, "number of billing months to generate")
+	sampleGenerateCmd.Flags().Int("accounts", 5, "number of linked accounts")
+	sampleGenerateCmd.Flags().String("format", sample.FormatParquet, "output format (parquet, csv)")
+	sampleGenerateCmd.Flags().Uint64("seed", 1, "random seed; the same seed produces the same data")
+	sampleGenerateCmd.Flags().String("end-month", "", "last billing month as YYYY-MM (default: previous month)")
+	sampleGenerateCmd.Flags().StringP("output", "o", "sample-data", "output directory")
+	sampleGenerateCmd.Flags().String("table", "", "also write a table definition (glue, duckdb)")
+	sampleGenerateCmd.Flags().String("s3-location", "", "S3 location the files will be uploaded to (required for --table glue)")
+
+	sampleCmd.AddCommand(sampleAnonymizeCmd)
+	sampleAnonymizeCmd.Flags().StringP("output", "o", "", "output directory (default: <input>-anonymized)")
+	sampleAnonymizeCmd.Flags().String("key", "", "key for the pseudonyms (default: $"+anonymizeKeyEnv+" or a random key)")
+	sampleAnonymizeCmd.Flags().Float64("scale-costs", 1, "factor to multiply costs, rates and prices by")
+}
+
+// anonymizeKeyEnv holds the default anonymization key
+const anonymizeKeyEnv = "ECOS_ANONYMIZE_KEY"
+
+func runSampleGenerate(cmd *cobra.Command, args []string) error {
+	source, _ := cmd.Flags().GetString("source")
+	months, _ := cmd.Flags().GetInt("months")
+	accounts, _ := cmd.Flags().GetInt("accounts")
+	format, _ := cmd.Flags().GetString("format")
+	seed, _ := cmd.Flags().GetUint64("seed")
+	endMonthFlag, _ := cmd.Flags().GetString("end-month")
+	outputDir, _ := cmd.Flags().GetString("output")
+	table, _ := cmd.Flags().GetString("table")
+	s3Location, _ := cmd.Flags().GetString("s3-location")
+
+	if err := sample.ValidateFormat(format); err != nil {
+		return err
+	}
+	if table != "" {
+		if err := sample.ValidateTable(table); err != nil {
+			return err
+		}
+		if table == sample.TableGlue && s3Location == "" {
+			return fmt.Errorf("--s3-location is required with --table %s", sample.TableGlue)
+		}
+		if table == sample.TableGlue && format != sample.FormatParquet {
+			return fmt.Errorf("--table %s requires --format %s", sample.TableGlue, sample.FormatParquet)
+		}
+	}
+
+	endMonth := time.Now().UTC().AddDate(0, -1, 0)
+	if endMonthFlag != "" {
+		parsed, err := time.Parse("2006-01", endMonthFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --end-month '%s', expected YYYY-MM", endMonthFlag)
+		}
+		endMonth = parsed
+	}
+
+	spinner := utils.NewSpinner("Generating sample data")
+	spinner.Start()
+
+	ds, err := sample.Generate(sample.Options{
+		Source:   source,
+		Months:   months,
+		Accounts: accounts,
+		Seed:     seed,
+		EndMonth: endMonth,
+	})
+	if err != nil {
+		spinner.Error("Sample generation failed")
+		return err
+	}
+
+	paths, err := sample.WriteDataset(ds, outputDir, format)
+	if err != nil {
+		spinner.Error("Sample generation failed")
+		return err
+	}
+	spinner.Success(fmt.Sprintf("Generated %d %s rows in %d file(s)", ds.RowCount(), ds.ExportType, len(paths)))
+
+	for _, path := range paths {
+		fmt.Printf("  • %s\n", path)
+	}
+
+	switch table {
+	case sample.TableDuckDB:
+		statement, err := sample.DuckDBView(ds.ExportType, format, outputDir)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(outputDir, ds.ExportType+".duckdb.sql")
+		if err := os.WriteFile(path, []byte(statement), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		utils.PrintSuccess(fmt.Sprintf("DuckDB view written to %s", path))
+		utils.PrintInfo(fmt.Sprintf("Load it with: duckdb sample.duckdb < %s", path))
+	case sample.TableGlue:
+		data, err := sample.GlueTableInput(ds.ExportType, format, s3Location)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(outputDir, ds.ExportType+".glue.json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		utils.PrintSuccess(fmt.Sprintf("Glue table definition written to %s", path))
+		utils.PrintInfo("Upload the files and create the table:")
+		fmt.Printf("  aws s3 sync %s %s\n", filepath.Join(outputDir, "data"), strings.TrimSuffix(s3Location, "/")+"/data/")
+		fmt.Printf("  aws glue create-table --database-name <database> --table-input file://%s\n", path)
+		utils.PrintInfo(fmt.Sprintf("Then set transform.dbt.vars.cur_schema to <database> and cur_table to %s in .ecos.yaml", ds.ExportType))
+	}
+
+	return nil
+}
+
+func runSampleAnonymize(cmd *cobra.Command, args []string) error {
+	input := filepath.Clean(args[0])
+	outputDir, _ := cmd.Flags().GetString("output")
+	key, _ := cmd.Flags().GetString("key")
+	scale, _ := cmd.Flags().GetFloat64("scale-costs")
+
+	if outputDir == "" {
+		outputDir = strings.TrimSuffix(input, filepath.Ext(input)) + "-anonymized"
+	}
+	if key == "" {
+		key = os.Getenv(anonymizeKeyEnv)
+	}
+	if key == "" {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return fmt.Errorf("failed to generate anonymization key: %w", err)
+		}
+		key = hex.EncodeToString(random)
+		utils.PrintWarning(fmt.Sprintf("No --key or %s set, using a random key: pseudonyms will differ between runs", anonymizeKeyEnv))
+	}
+
+	anonymizer, err := sample.NewAnonymizer([]byte(key), scale)
+	if err != nil {
+		return err
+	}
+
+	spinner := utils.NewSpinner(fmt.Sprintf("Anonymizing %s", input))
+	spinner.Start()
+
+	files, err := sample.AnonymizeTree(anonymizer, input, outputDir)
+	if err != nil {
+		spinner.Error("Anonymization failed")
+		return err
+	}
+
+	var rows int64
+	for _, f := range files {
+		rows += f.Rows
+	}
+	spinner.Success(fmt.Sprintf("Anonymized %d rows in %d file(s)", rows, len(files)))
+
+	for _, f := range files {
+		fmt.Printf("  • %s\n", f.Path)
+	}
+	if scale != 1 {
+		utils.PrintInfo(fmt.Sprintf("Costs, rates and prices were multiplied by %v", scale))
+	}
+	utils.PrintInfo("Review the output before sharing it: free-text columns other than line item descriptions are not rewritten")
+
+	return nil
+}
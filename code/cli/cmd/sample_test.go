@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newSampleGenerateTestCmd(output, format, table, s3Location string) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringP("source", "s", "aws_cur", "")
+	cmd.Flags().Int("months", 1, "")
+	cmd.Flags().Int("accounts", 2, "")
+	cmd.Flags().String("format", format, "")
+	cmd.Flags().Uint64("seed", 1, "")
+	cmd.Flags().String("end-month", "2025-01", "")
+	cmd.Flags().StringP("output", "o", output, "")
+	cmd.Flags().String("table", table, "")
+	cmd.Flags().String("s3-location", s3Location, "")
+	return cmd
+}
+
+func TestRunSampleGenerate_InvalidFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		table      string
+		s3Location string
+	}{
+		{"unknown format", "json", "", ""},
+		{"unknown table", "parquet", "hive", ""},
+		{"glue without s3 location", "parquet", "glue", ""},
+		{"glue with csv", "csv", "glue", "s3://bucket/sample/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "out")
+			if err := runSampleGenerate(newSampleGenerateTestCmd(output, tt.format, tt.table, tt.s3Location), []string{}); err == nil {
+				t.Error("runSampleGenerate() expected error, got nil")
+			}
+			if _, err := os.Stat(output); !os.IsNotExist(err) {
+				t.Error("runSampleGenerate() wrote files despite invalid flags")
+			}
+		})
+	}
+}
+
+func TestRunSampleGenerate_WritesDuckDBView(t *testing.T) {
+	output := t.TempDir()
+	if err := runSampleGenerate(newSampleGenerateTestCmd(output, "csv", "duckdb", ""), []string{}); err != nil {
+		t.Fatalf("runSampleGenerate() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(output, "data", "BILLING_PERIOD=2025-01", "cur2-00001.csv")); err != nil {
+		t.Errorf("expected generated csv file: %v", err)
+	}
+	view, err := os.ReadFile(filepath.Join(output, "cur2.duckdb.sql"))
+	if err != nil {
+		t.Fatalf("expected DuckDB view file: %v", err)
+	}
+	if !strings.Contains(string(view), "read_csv(") {
+		t.Errorf("unexpected DuckDB view: %s", view)
+	}
+}
+
+func newSampleAnonymizeTestCmd(output, key string, scale float64) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringP("output", "o", output, "")
+	cmd.Flags().String("key", key, "")
+	cmd.Flags().Float64("scale-costs", scale, "")
+	return cmd
+}
+
+func TestRunSampleAnonymize(t *testing.T) {
+	input := t.TempDir()
+	if err := runSampleGenerate(newSampleGenerateTestCmd(input, "parquet", "", ""), []string{}); err != nil {
+		t.Fatalf("runSampleGenerate() error = %v", err)
+	}
+
+	output := filepath.Join(t.TempDir(), "shared")
+	if err := runSampleAnonymize(newSampleAnonymizeTestCmd(output, "secret", 1), []string{input}); err != nil {
+		t.Fatalf("runSampleAnonymize() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "data", "BILLING_PERIOD=2025-01", "cur2-00001.parquet")); err != nil {
+		t.Errorf("expected anonymized parquet file: %v", err)
+	}
+
+	if err := runSampleAnonymize(newSampleAnonymizeTestCmd(output, "secret", 0), []string{input}); err == nil {
+		t.Error("runSampleAnonymize() expected error for --scale-costs 0, got nil")
+	}
+	if err := runSampleAnonymize(newSampleAnonymizeTestCmd(filepath.Join(input, "out"), "secret", 1), []string{input}); err == nil {
+		t.Error("runSampleAnonymize() expected error for an output inside the input, got nil")
+	}
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/serve"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/ecos-labs/ecos/code/cli/version"
+	"github.com/spf13/cobra"
+)
+
+// Defaults of the serve section of .ecos.yaml
+const (
+	defaultServeListen     = "127.0.0.1:8080"
+	defaultServeAPIKeysEnv = "ECOS_API_KEYS"
+	defaultCacheTTL        = 15 * time.Minute
+	defaultClosedCacheTTL  = 24 * time.Hour
+)
+
+// serveShutdownTimeout bounds the wait for in-flight requests on shutdown
+const serveShutdownTimeout = 30 * time.Second
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the models to other tools",
+	Long: `Serve the models of the project to other tools over a local server.
+
+Available subcommands:
+  api       Serve the models as a read-only REST/JSON API`,
+}
+
+// serveAPICmd represents the serve api command
+var serveAPICmd = &cobra.Command{
+	Use:   "api",
+	Short: "Serve the models as a read-only REST/JSON API",
+	Long: `Serve the serve models, and gold_tag__tag_cost_monthly for tag breakdowns, as a
+read-only HTTP API. Queries run in the adhoc Athena workgroup against aws.database.
+
+Endpoints:
+  GET /v1/models           the served models and the filters they support
+  GET /v1/models/<model>   rows of a model, as JSON
+  GET /openapi.json        OpenAPI document generated from the dbt model docs
+  GET /healthz             health check
+
+Model endpoints accept the filters whose column the model has:
+  period    billing period YYYY-MM or range YYYY-MM..YYYY-MM (default: previous month)
+  account   comma-separated account IDs
+  service   comma-separated service names or codes
+  tag       tag key, or key=value
+and group_by=<columns> to sum the metrics by those columns, and limit=<rows>.
+
+Requests to /v1 need one of the comma-separated keys of the ECOS_API_KEYS
+environment variable (see serve.api_keys_env), sent in the X-API-Key header or as
+a bearer token. The server does not start without keys.
+
+Responses are cached in memory by billing period: closed billing periods for
+serve.closed_cache_ttl (default 24h), the current one for serve.cache_ttl
+(default 15m). The X-Cache response header tells whether a response was cached.
+
+Examples:
+  ECOS_API_KEYS=$(openssl rand -hex 24) ecos serve api
+  ecos serve api --listen 0.0.0.0:9000
+  curl -H "X-API-Key: $KEY" "localhost:8080/v1/models/serve_core__cost_service_account_daily?period=2025-05&group_by=service_name"`,
+	RunE: runServeAPI,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.AddCommand(serveAPICmd)
+
+	serveAPICmd.Flags().String("listen", "", "address to listen on (default: serve.listen, or "+defaultServeListen+")")
+	serveAPICmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+// serveOptions is the resolved configuration of 'ecos serve api'
+type serveOptions struct {
+	listen         string
+	keys           []string
+	cacheTTL       time.Duration
+	closedCacheTTL time.Duration
+	maxRows        int
+	catalog        *catalog.Catalog
+}
+
+func runServeAPI(cmd *cobra.Command, _ []string) error {
+	listen, _ := cmd.Flags().GetString("listen")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos serve api")
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	opts, err := resolveServeOptions(ecosConfig, projectDir, listen)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would serve %d models on http://%s", len(opts.catalog.Models), opts.listen))
+		for _, m := range opts.catalog.Models {
+			utils.PrintDryRun(fmt.Sprintf("  /v1/models/%s", m.Name))
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	querier, err := newAthenaQuerier(ctx, ecosConfig)
+	if err != nil {
+		return err
+	}
+	server := &serve.Server{
+		Executor: querier,
+		Catalog:  opts.catalog,
+		Database: ecosConfig.AWS.Database,
+		Keys:     opts.keys,
+		Cache:    &serve.Cache{OpenTTL: opts.cacheTTL, ClosedTTL: opts.closedCacheTTL},
+		MaxRows:  opts.maxRows,
+		Version:  version.GetInfo().Version,
+		Logf:     utils.Print,
+	}
+	httpServer := &http.Server{
+		Addr:              opts.listen,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	utils.PrintSuccess(fmt.Sprintf("Serving %d models on http://%s (OpenAPI document at /openapi.json)", len(opts.catalog.Models), opts.listen))
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to serve the API: %w", err)
+	case <-ctx.Done():
+	}
+
+	utils.PrintInfo("Shutting down, waiting for running requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down the API: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// resolveServeOptions applies the defaults of the serve section, reads the API keys and
+// loads the docs of the served models
+func resolveServeOptions(cfg *config.EcosConfig, projectDir, listen string) (serveOptions, error) {
+	opts := serveOptions{
+		listen:         listen,
+		cacheTTL:       defaultCacheTTL,
+		closedCacheTTL: defaultClosedCacheTTL,
+		maxRows:        cfg.Serve.MaxRows,
+	}
+	if opts.listen == "" {
+		opts.listen = cfg.Serve.Listen
+	}
+	if opts.listen == "" {
+		opts.listen = defaultServeListen
+	}
+	// The TTLs were validated when the config was loaded
+	if cfg.Serve.CacheTTL != "" {
+		opts.cacheTTL, _ = time.ParseDuration(cfg.Serve.CacheTTL)
+	}
+	if cfg.Serve.ClosedCacheTTL != "" {
+		opts.closedCacheTTL, _ = time.ParseDuration(cfg.Serve.ClosedCacheTTL)
+	}
+
+	keysEnv := cfg.Serve.APIKeysEnv
+	if keysEnv == "" {
+		keysEnv = defaultServeAPIKeysEnv
+	}
+	if opts.keys = serve.ParseKeys(os.Getenv(keysEnv)); len(opts.keys) == 0 {
+		return serveOptions{}, fmt.Errorf("no API keys configured, set %s to a comma-separated list of keys", keysEnv)
+	}
+
+	if cfg.AWS.Database == "" {
+		return serveOptions{}, errors.New("serving the models needs aws.database in .ecos.yaml")
+	}
+
+	docs, err := catalog.Load(filepath.Join(dbtProjectDir(cfg, projectDir), catalog.ModelsDir))
+	if err != nil {
+		return serveOptions{}, fmt.Errorf("failed to load the model docs: %w", err)
+	}
+	opts.catalog = docs.Filter(serve.Exposed)
+	if len(opts.catalog.Models) == 0 {
+		return serveOptions{}, errors.New("the model package documents no serve models")
+	}
+	return opts, nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+func TestResolveServeOptions(t *testing.T) {
+	projectDir := t.TempDir()
+	modelsDir := filepath.Join(projectDir, "transform", "dbt", "models")
+	if err := os.MkdirAll(modelsDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	docs := `version: 2
+models:
+  - name: serve_meta__account_metadata
+    columns:
+      - name: account_id
+        data_type: varchar
+  - name: gold_core__service_monthly
+`
+	if err := os.WriteFile(filepath.Join(modelsDir, "_models.yml"), []byte(docs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.EcosConfig{}
+	cfg.AWS.Database = "ecos"
+	cfg.Serve = config.ServeConfig{APIKeysEnv: "TEST_ECOS_KEYS", ClosedCacheTTL: "1h"}
+
+	if _, err := resolveServeOptions(cfg, projectDir, ""); err == nil {
+		t.Error("resolveServeOptions() expected error without API keys")
+	}
+
+	t.Setenv("TEST_ECOS_KEYS", "key-1, key-2")
+	opts, err := resolveServeOptions(cfg, projectDir, "")
+	if err != nil {
+		t.Fatalf("resolveServeOptions() error = %v", err)
+	}
+	if opts.listen != defaultServeListen || len(opts.keys) != 2 || opts.cacheTTL != defaultCacheTTL || opts.closedCacheTTL != time.Hour {
+		t.Errorf("resolveServeOptions() = %+v", opts)
+	}
+	if len(opts.catalog.Models) != 1 || opts.catalog.Models[0].Name != "serve_meta__account_metadata" {
+		t.Errorf("served models = %+v", opts.catalog.Models)
+	}
+
+	if opts, _ := resolveServeOptions(cfg, projectDir, ":9000"); opts.listen != ":9000" {
+		t.Errorf("--listen not applied: %s", opts.listen)
+	}
+
+	cfg.AWS.Database = ""
+	if _, err := resolveServeOptions(cfg, projectDir, ""); err == nil {
+		t.Error("resolveServeOptions() expected error without aws.database")
+	}
+}
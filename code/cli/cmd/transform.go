@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ecos-labs/ecos/code/cli/config"
 	"github.com/ecos-labs/ecos/code/cli/plugins/core/transform"
@@ -31,6 +32,7 @@ Examples:
   ecos transform run --select my_model
   ecos transform test --models tag:daily
   ecos transform seed --full-refresh
+  ecos transform backfill --from 2024-01 --to 2025-06 --chunk 1m
 
 The transform command automatically detects the transformation tool configured
 in your .ecos.yaml file and delegates to the appropriate plugin.`
@@ -138,6 +140,10 @@ func runTransformCommand(args []string) error {
 			}
 		}
 
+		if command == "backfill" {
+			utils.PrintInfo(backfillHelpText)
+			return nil
+		}
 		if command != "" {
 			return showCommandHelp(command, projectDir)
 		}
@@ -167,6 +173,11 @@ func runTransformCommand(args []string) error {
 				return err
 			}
 		}
+
+		// Check the runtime against .ecos.lock (lock.mode)
+		if err := checkProjectLock(context.Background(), ecosConfig, parsedArgs.ProjectDir); err != nil {
+			return err
+		}
 	} else {
 		utils.PrintWarning("No .ecos.yaml found, using default configuration")
 		ecosConfig = config.NewDefaultConfig()
@@ -178,13 +189,9 @@ func runTransformCommand(args []string) error {
 		pluginName = "dbt" // Default fallback
 	}
 
-	// Instantiate the transform plugin directly
-	var plugin types.TransformPlugin
-	switch strings.ToLower(pluginName) {
-	case "dbt":
-		plugin = &transform.DBTTransformPlugin{}
-	default:
-		return fmt.Errorf("unsupported transform plugin: %s. Available plugins: dbt", pluginName)
+	plugin, err := newTransformPlugin(pluginName)
+	if err != nil {
+		return err
 	}
 
 	// Build configuration for the plugin
@@ -192,6 +199,15 @@ func runTransformCommand(args []string) error {
 	pluginConfig["project_dir"] = parsedArgs.ProjectDir
 	pluginConfig["verbose"] = IsVerbose()
 
+	// Backfill runs the models once per chunk of billing periods
+	if parsedArgs.Command == "backfill" {
+		opts, err := parseBackfillArgs(parsedArgs.FilteredArgs[1:], time.Now())
+		if err != nil {
+			return err
+		}
+		return runTransformBackfill(plugin, opts, parsedArgs.ProjectDir, ecosConfig, pluginConfig, parsedArgs.IsDryRun)
+	}
+
 	// Handle dry run case
 	if parsedArgs.IsDryRun {
 		return runTransformDryRun(plugin, parsedArgs.FilteredArgs, pluginConfig)
@@ -201,6 +217,16 @@ func runTransformCommand(args []string) error {
 	return runTransformExecute(plugin, parsedArgs.FilteredArgs, pluginConfig)
 }
 
+// newTransformPlugin instantiates the transform plugin configured in .ecos.yaml
+func newTransformPlugin(pluginName string) (types.TransformPlugin, error) {
+	switch strings.ToLower(pluginName) {
+	case "dbt":
+		return &transform.DBTTransformPlugin{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported transform plugin: %s. Available plugins: dbt", pluginName)
+	}
+}
+
 func showTransformHelp() {
 	// Print the help text from the constant
 	utils.PrintInfo(transformHelpText)
@@ -243,13 +269,9 @@ func showCommandHelp(command string, projectDir string) error {
 		pluginName = "dbt" // Default fallback
 	}
 
-	// Instantiate the transform plugin directly
-	var plugin types.TransformPlugin
-	switch strings.ToLower(pluginName) {
-	case "dbt":
-		plugin = &transform.DBTTransformPlugin{}
-	default:
-		return fmt.Errorf("unsupported transform plugin: %s. Available plugins: dbt", pluginName)
+	plugin, err := newTransformPlugin(pluginName)
+	if err != nil {
+		return err
 	}
 
 	return plugin.ShowCommandHelp(command)
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/transform"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+)
+
+const backfillHelpText = `Re-process historical billing periods in chunks.
+
+Runs the selected models once per chunk of billing periods by setting the
+billing_period_start and billing_period_end vars, so a long history is processed
+in several short queries instead of one that times out. Completed chunks are
+recorded in .ecos-backfill.json; after a failure or interruption, rerun the same
+command with --resume to continue where it stopped.
+
+Backfill is meant for incremental models: a model materialized as a table is
+rebuilt by every chunk and ends up holding only the last one.
+
+Usage:
+  ecos transform backfill --from YYYY-MM [--to YYYY-MM] [flags] [dbt-flags...]
+
+Flags:
+  --from YYYY-MM     first billing period (required)
+  --to YYYY-MM       last billing period (default: current month)
+  --chunk SIZE       billing periods per run, e.g. 1m, 3m or 1y (default: 1m)
+  --parallel N       number of chunks to run at once (default: 1)
+  --resume           skip the chunks completed by the previous backfill
+  --dry-run          show the chunks without running them
+
+Other flags such as --select, --exclude and --vars are passed to every run.
+
+Examples:
+  ecos transform backfill --from 2024-01 --to 2025-06 --chunk 1m
+  ecos transform backfill --from 2024-01 --to 2025-06 --select tag:cur --parallel 3
+  ecos transform backfill --from 2024-01 --to 2025-06 --resume`
+
+// backfillCommand is the transform command each chunk runs
+const backfillCommand = "run"
+
+// maxBackfillParallel bounds --parallel to keep Athena concurrency quotas in reach
+const maxBackfillParallel = 10
+
+// backfillOptions holds the parsed flags of 'ecos transform backfill'
+type backfillOptions struct {
+	From     string
+	To       string
+	Chunk    string
+	Months   int
+	Parallel int
+	Resume   bool
+	Args     []string // passed through to every run
+}
+
+// parseBackfillArgs extracts the backfill flags from the transform arguments that
+// follow the backfill command and returns the rest for the transform tool
+func parseBackfillArgs(args []string, now time.Time) (*backfillOptions, error) {
+	opts := &backfillOptions{
+		To:       now.UTC().Format(config.BillingPeriodLayout),
+		Chunk:    "1m",
+		Parallel: 1,
+	}
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		takeValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch name {
+		case "--from":
+			opts.From, err = takeValue()
+		case "--to":
+			opts.To, err = takeValue()
+		case "--chunk":
+			opts.Chunk, err = takeValue()
+		case "--parallel":
+			var raw string
+			if raw, err = takeValue(); err == nil {
+				opts.Parallel, err = strconv.Atoi(raw)
+				if err != nil || opts.Parallel < 1 || opts.Parallel > maxBackfillParallel {
+					err = fmt.Errorf("invalid --parallel '%s', expected 1 to %d", raw, maxBackfillParallel)
+				}
+			}
+		case "--resume":
+			opts.Resume = true
+		case "--full-refresh":
+			return nil, errors.New("--full-refresh processes all billing periods at once and cannot be used with backfill")
+		default:
+			opts.Args = append(opts.Args, args[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.From == "" {
+		return nil, errors.New("--from is required (YYYY-MM)")
+	}
+	months, err := transform.ParseChunkSize(opts.Chunk)
+	if err != nil {
+		return nil, err
+	}
+	opts.Months = months
+	return opts, nil
+}
+
+// runTransformBackfill runs the backfill chunks through the plugin
+func runTransformBackfill(plugin types.TransformPlugin, opts *backfillOptions, projectDir string, ecosConfig *config.EcosConfig, pluginConfig map[string]any, dryRun bool) error {
+	runner, ok := plugin.(types.BillingPeriodRunner)
+	if !ok {
+		return fmt.Errorf("transform plugin %s does not support backfill", plugin.Name())
+	}
+
+	chunks, err := transform.PlanChunks(opts.From, opts.To, opts.Months)
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := backfillCheckpoint(opts, projectDir)
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, chunk := range chunks {
+		if !checkpoint.IsCompleted(chunk) {
+			pending++
+		}
+	}
+
+	if dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would run %s %s for %d chunk(s) of %s, %d at a time", plugin.Name(), backfillCommand, len(chunks), opts.Chunk, opts.Parallel))
+		for _, chunk := range chunks {
+			status := "pending"
+			if checkpoint.IsCompleted(chunk) {
+				status = "completed, skipped"
+			}
+			utils.PrintDryRun(fmt.Sprintf("  %s (%s)", chunk, status))
+		}
+		return nil
+	}
+
+	if pending == 0 {
+		utils.PrintSuccess("All chunks were completed by the previous backfill")
+		return checkpoint.Remove()
+	}
+	if !hasIncrementalModels(ecosConfig) {
+		utils.PrintWarning("No layer is materialized as incremental: views and tables are rebuilt by every chunk, so backfill only helps incremental models")
+	}
+
+	spinner := utils.NewSpinner("Validating plugin configuration")
+	spinner.Start()
+	if err := plugin.Validate(pluginConfig); err != nil {
+		spinner.Error("Plugin validation failed")
+		return fmt.Errorf("plugin validation failed: %w", err)
+	}
+	spinner.Success("Plugin configuration validated")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := plugin.PrepareEnvironment(ctx, pluginConfig); err != nil {
+		return fmt.Errorf("failed to prepare %s environment: %w", plugin.Name(), err)
+	}
+	if err := checkpoint.Save(); err != nil {
+		return err
+	}
+
+	utils.PrintProgress(fmt.Sprintf("Backfilling %s to %s: %d of %d chunk(s), %d at a time", opts.From, opts.To, pending, len(chunks), opts.Parallel))
+
+	isolated := opts.Parallel > 1
+	var outputs sync.Map
+	results := transform.RunChunks(ctx, chunks, opts.Parallel, checkpoint, func(ctx context.Context, chunk transform.Chunk) error {
+		run := types.BillingPeriodRun{Start: chunk.Start, End: chunk.End, Isolated: isolated}
+		if isolated {
+			// Interleaved output of concurrent runs is unreadable; keep it for failures
+			output := &bytes.Buffer{}
+			outputs.Store(chunk, output)
+			run.Output = &lockedWriter{w: output}
+			utils.PrintProgress(fmt.Sprintf("Chunk %s started", chunk))
+		} else {
+			utils.PrintProgress(fmt.Sprintf("Chunk %s", chunk))
+		}
+
+		if err := runner.RunBillingPeriods(ctx, backfillCommand, opts.Args, run, pluginConfig); err != nil {
+			return err
+		}
+		if isolated {
+			utils.PrintSuccess(fmt.Sprintf("Chunk %s completed", chunk))
+		}
+		return nil
+	})
+
+	return summarizeBackfill(results, &outputs, checkpoint)
+}
+
+// backfillCheckpoint returns the checkpoint to continue with --resume, or a new one
+func backfillCheckpoint(opts *backfillOptions, projectDir string) (*transform.Checkpoint, error) {
+	path := transform.CheckpointPath(projectDir)
+	current := transform.NewCheckpoint(path, opts.From, opts.To, opts.Chunk, backfillCommand, opts.Args)
+
+	previous, err := transform.LoadCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Resume {
+		if previous == nil {
+			return nil, fmt.Errorf("no backfill to resume: %s not found", path)
+		}
+		if !previous.Matches(current) {
+			return nil, fmt.Errorf("the backfill in %s ran --from %s --to %s --chunk %s with args [%s]; rerun it with the same flags to resume, or without --resume to start over",
+				path, previous.From, previous.To, previous.Chunk, strings.Join(previous.Args, " "))
+		}
+		return previous, nil
+	}
+
+	if previous != nil && len(previous.Completed) > 0 {
+		utils.PrintWarning(fmt.Sprintf("Starting over: the previous backfill (%s to %s) had %d completed chunk(s); use --resume to continue it instead",
+			previous.From, previous.To, len(previous.Completed)))
+	}
+	return current, nil
+}
+
+// summarizeBackfill prints the chunk results and removes the checkpoint once all chunks completed
+func summarizeBackfill(results []transform.ChunkResult, outputs *sync.Map, checkpoint *transform.Checkpoint) error {
+	var rows [][]string
+	var failed []transform.ChunkResult
+	notRun := 0
+	for _, r := range results {
+		status := "✅ completed"
+		switch {
+		case r.Skipped:
+			status = "⏭️  skipped (completed earlier)"
+		case r.NotRun:
+			status = "⏸️  not run"
+			notRun++
+		case r.Err != nil:
+			status = "❌ failed"
+			failed = append(failed, r)
+		}
+
+		duration := ""
+		if r.Duration > 0 {
+			duration = r.Duration.Round(time.Second).String()
+		}
+		rows = append(rows, []string{r.Chunk.String(), status, duration})
+	}
+
+	fmt.Println()
+	utils.PrintTable([]string{"Chunk", "Status", "Duration"}, rows)
+
+	for _, r := range failed {
+		fmt.Println()
+		utils.PrintError(fmt.Sprintf("Chunk %s failed: %v", r.Chunk, r.Err))
+		if output, ok := outputs.Load(r.Chunk); ok {
+			fmt.Println(lastLines(output.(*bytes.Buffer).String(), 20))
+		}
+	}
+
+	if len(failed) > 0 || notRun > 0 {
+		fmt.Println()
+		utils.PrintInfo("Rerun the same command with --resume to continue with the remaining chunks")
+		if len(failed) > 0 {
+			return fmt.Errorf("backfill failed for %d chunk(s)", len(failed))
+		}
+		return errors.New("backfill interrupted")
+	}
+
+	utils.PrintSuccess("Backfill completed")
+	return checkpoint.Remove()
+}
+
+// hasIncrementalModels reports whether any model may be materialized incrementally: a
+// model override resolves to incremental, or a model of some layer declaring incremental
+// keeps it
+func hasIncrementalModels(cfg *config.EcosConfig) bool {
+	m := cfg.Transform.DBT.Materialization
+	if m != nil {
+		for model := range m.ModelOverrides {
+			if m.Resolve(model, "", "view") == "incremental" {
+				return true
+			}
+		}
+	}
+	return slices.ContainsFunc(catalog.Layers, func(layer string) bool {
+		return m.Resolve("", layer, "incremental") == "incremental"
+	})
+}
+
+// lastLines returns the last n lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lockedWriter serializes the writes of a run's stdout and stderr into one buffer
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
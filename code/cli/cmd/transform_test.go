@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ecos-labs/ecos/code/cli/config"
+	initPlugin "github.com/ecos-labs/ecos/code/cli/plugins/core/init"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/transform"
 	"github.com/ecos-labs/ecos/code/cli/plugins/types"
 	"github.com/ecos-labs/ecos/code/cli/plugins/types/mocks"
+	"github.com/ecos-labs/ecos/code/cli/utils"
 	"go.uber.org/mock/gomock"
 )
 
@@ -506,3 +513,168 @@ func TestTransformCommandDescription(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBackfillArgs(t *testing.T) {
+	now := time.Date(2025, time.July, 10, 0, 0, 0, 0, time.UTC)
+
+	opts, err := parseBackfillArgs([]string{"--from", "2024-01", "--chunk=3m", "--parallel", "2", "--resume", "--select", "tag:cur"}, now)
+	if err != nil {
+		t.Fatalf("parseBackfillArgs() error = %v", err)
+	}
+	if opts.From != "2024-01" || opts.To != "2025-07" || opts.Months != 3 || opts.Parallel != 2 || !opts.Resume {
+		t.Errorf("parseBackfillArgs() = %+v", opts)
+	}
+	if strings.Join(opts.Args, " ") != "--select tag:cur" {
+		t.Errorf("pass-through args = %v, want [--select tag:cur]", opts.Args)
+	}
+
+	for _, args := range [][]string{
+		{"--to", "2025-06"},
+		{"--from", "2024-01", "--chunk", "2w"},
+		{"--from", "2024-01", "--parallel", "0"},
+		{"--from", "2024-01", "--parallel", "50"},
+		{"--from", "2024-01", "--full-refresh"},
+		{"--from"},
+	} {
+		if _, err := parseBackfillArgs(args, now); err == nil {
+			t.Errorf("parseBackfillArgs(%v) expected error, got nil", args)
+		}
+	}
+}
+
+// backfillTestPlugin is a transform plugin that can run billing periods
+type backfillTestPlugin struct {
+	*mocks.MockTransformPlugin
+	*mocks.MockBillingPeriodRunner
+}
+
+func TestRunTransformBackfill_Resume(t *testing.T) {
+	projectDir := t.TempDir()
+	cfg := config.NewDefaultConfig()
+	opts := &backfillOptions{From: "2024-01", To: "2024-04", Chunk: "1m", Months: 1, Parallel: 1}
+
+	ctrl := gomock.NewController(t)
+	transformMock := mocks.NewMockTransformPlugin(ctrl)
+	runnerMock := mocks.NewMockBillingPeriodRunner(ctrl)
+	plugin := backfillTestPlugin{transformMock, runnerMock}
+
+	transformMock.EXPECT().Name().Return("dbt").AnyTimes()
+	transformMock.EXPECT().Validate(gomock.Any()).Return(nil).Times(2)
+	transformMock.EXPECT().PrepareEnvironment(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	// First run: 2024-03 fails, so 2024-04 is not started
+	var started []string
+	runnerMock.EXPECT().RunBillingPeriods(gomock.Any(), "run", gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ []string, run types.BillingPeriodRun, _ map[string]any) error {
+			started = append(started, run.Start)
+			if run.Start == "2024-03" {
+				return errors.New("query timed out")
+			}
+			return nil
+		}).Times(3)
+
+	if err := runTransformBackfill(plugin, opts, projectDir, cfg, map[string]any{}, false); err == nil {
+		t.Fatal("runTransformBackfill() expected error for a failed chunk, got nil")
+	}
+	if strings.Join(started, ",") != "2024-01,2024-02,2024-03" {
+		t.Errorf("first run started %v", started)
+	}
+
+	// Resume: only the failed and the remaining chunk run
+	started = nil
+	runnerMock.EXPECT().RunBillingPeriods(gomock.Any(), "run", gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ []string, run types.BillingPeriodRun, _ map[string]any) error {
+			started = append(started, run.Start)
+			return nil
+		}).Times(2)
+
+	opts.Resume = true
+	if err := runTransformBackfill(plugin, opts, projectDir, cfg, map[string]any{}, false); err != nil {
+		t.Fatalf("runTransformBackfill() resume error = %v", err)
+	}
+	if strings.Join(started, ",") != "2024-03,2024-04" {
+		t.Errorf("resume started %v, want 2024-03 and 2024-04", started)
+	}
+	if utils.FileExists(filepath.Join(projectDir, ".ecos-backfill.json")) {
+		t.Error("checkpoint not removed after the backfill completed")
+	}
+
+	// Nothing left to resume
+	if err := runTransformBackfill(plugin, opts, projectDir, cfg, map[string]any{}, false); err == nil {
+		t.Error("runTransformBackfill() expected error when resuming without a checkpoint")
+	}
+}
+
+func TestRunTransformBackfill_ResumeMismatch(t *testing.T) {
+	projectDir := t.TempDir()
+	checkpoint := transform.NewCheckpoint(transform.CheckpointPath(projectDir), "2024-01", "2024-06", "1m", "run", nil)
+	if err := checkpoint.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	ctrl := gomock.NewController(t)
+	plugin := backfillTestPlugin{mocks.NewMockTransformPlugin(ctrl), mocks.NewMockBillingPeriodRunner(ctrl)}
+
+	opts := &backfillOptions{From: "2024-01", To: "2024-06", Chunk: "3m", Months: 3, Parallel: 1, Resume: true}
+	err := runTransformBackfill(plugin, opts, projectDir, config.NewDefaultConfig(), map[string]any{}, false)
+	if err == nil || !strings.Contains(err.Error(), "same flags") {
+		t.Errorf("runTransformBackfill() error = %v, want a mismatch error", err)
+	}
+}
+
+func TestRunTransformBackfill_Unsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	plugin := mocks.NewMockTransformPlugin(ctrl)
+	plugin.EXPECT().Name().Return("sql").AnyTimes()
+
+	opts := &backfillOptions{From: "2024-01", To: "2024-02", Chunk: "1m", Months: 1, Parallel: 1}
+	if err := runTransformBackfill(plugin, opts, t.TempDir(), config.NewDefaultConfig(), map[string]any{}, false); err == nil {
+		t.Error("runTransformBackfill() expected error for a plugin without billing period support")
+	}
+}
+
+func TestHasIncrementalModels(t *testing.T) {
+	// The .ecos.yaml ecos init generates
+	projectDir := t.TempDir()
+	mat := initPlugin.DefaultMaterializationConfig()
+	data := config.EcosConfigTemplate{
+		ProjectName:           "test-project",
+		ProjectDir:            "transform/dbt",
+		MaterializationMode:   mat.Mode,
+		BronzeMaterialization: mat.Bronze,
+		SilverMaterialization: mat.Silver,
+		GoldMaterialization:   mat.Gold,
+	}
+	if err := config.GenerateEcosConfig(data, projectDir); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(filepath.Join(projectDir, config.ConfigFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := cfg.Transform.DBT.Materialization
+	if m == nil {
+		t.Fatal("generated .ecos.yaml has no materialization")
+	}
+
+	if hasIncrementalModels(cfg) {
+		t.Error("hasIncrementalModels() = true in view mode")
+	}
+	m.Mode = "smart"
+	if !hasIncrementalModels(cfg) {
+		t.Error("hasIncrementalModels() = false in smart mode, the incremental models keep their default")
+	}
+	m.LayerOverrides = map[string]string{"bronze": "view", "silver": "view", "gold": "table", "serve": "view"}
+	if hasIncrementalModels(cfg) {
+		t.Error("hasIncrementalModels() = true with every layer overridden")
+	}
+	m.ModelOverrides = map[string]string{"gold_core__service_daily": "incremental"}
+	if !hasIncrementalModels(cfg) {
+		t.Error("hasIncrementalModels() = false with an incremental model override")
+	}
+
+	cfg.Transform.DBT.Materialization = nil
+	if hasIncrementalModels(cfg) {
+		t.Error("hasIncrementalModels() = true without materialization settings")
+	}
+}
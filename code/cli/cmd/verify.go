@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/verify"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// defaultVerifyReport is where 'ecos verify' writes its report, relative to the project directory
+var defaultVerifyReport = filepath.Join("output", "verify-report.json")
+
+// defaultVerifyPeriods is the number of billing periods verified when --start is not set
+const defaultVerifyPeriods = 3
+
+// maxMismatchedAccounts bounds the accounts printed per failed check; the report has all of them
+const maxMismatchedAccounts = 10
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Reconcile costs between the CUR table and the models",
+	Long: `Reconcile the cost totals per billing period between the raw CUR table and the
+model layers, so the numbers in the dashboards tie back to the invoice.
+
+Checks:
+  unblended_cost  unblended cost of the CUR table vs bronze_aws__cur_source,
+                  per usage account
+  billed_cost     net unblended cost (unblended cost when AWS reports no net cost)
+                  of the CUR table vs bronze_aws__cur_source vs
+                  gold_core__invoice_monthly, per payer account
+  effective_cost  effective cost of gold_core__invoice_monthly vs
+                  gold_core__service_monthly, per payer account
+
+Each total is compared with the first source of its check. A difference passes
+when it is within verify.tolerance_absolute (default 0.01) or verify.tolerance_percent
+of that total. On a mismatch, the accounts that do not reconcile are listed.
+
+The queries run in the adhoc Athena workgroup. The results are written as one JSON
+report (default: output/verify-report.json), and ecos exits with a non-zero code
+when a check fails.
+
+Examples:
+  ecos verify
+  ecos verify --start 2025-01 --end 2025-06
+  ecos verify --check billed_cost --report ./reports/close-2025-06.json`,
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().String("start", "", "first billing period to verify (YYYY-MM, default: two months before --end)")
+	verifyCmd.Flags().String("end", "", "last billing period to verify (YYYY-MM, default: current month)")
+	verifyCmd.Flags().StringSlice("check", nil, "checks to run (default: verify.checks or all)")
+	verifyCmd.Flags().String("report", "", "path of the JSON report (default: verify.report_path or output/verify-report.json)")
+	verifyCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+// verifyOptions selects what 'ecos verify' reconciles
+type verifyOptions struct {
+	start  string
+	end    string
+	checks []string
+}
+
+func runVerify(cmd *cobra.Command, _ []string) error {
+	start, _ := cmd.Flags().GetString("start")
+	end, _ := cmd.Flags().GetString("end")
+	checks, _ := cmd.Flags().GetStringSlice("check")
+	reportPath, _ := cmd.Flags().GetString("report")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos verify")
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+
+	opts, err := resolveVerifyOptions(ecosConfig, start, end, checks)
+	if err != nil {
+		return err
+	}
+	tables, err := verifyTables(ecosConfig)
+	if err != nil {
+		return err
+	}
+	if reportPath == "" {
+		reportPath = verifyReportPath(ecosConfig, projectDir)
+	}
+
+	if dryRun {
+		planned, err := verify.Checks(tables, nil, opts.checks)
+		if err != nil {
+			return err
+		}
+		utils.PrintDryRun(fmt.Sprintf("Would verify billing periods %s to %s", opts.start, opts.end))
+		for _, check := range planned {
+			var sources []string
+			for _, s := range check.Sources {
+				sources = append(sources, s.Name)
+			}
+			utils.PrintDryRun(fmt.Sprintf("  %s: %s", check.Name, strings.Join(sources, " vs ")))
+		}
+		utils.PrintDryRun(fmt.Sprintf("Would write the report to %s", reportPath))
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	querier, err := newAthenaQuerier(ctx, ecosConfig)
+	if err != nil {
+		return err
+	}
+	result, err := runVerification(ctx, ecosConfig, querier, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := verify.WriteReport(result, reportPath); err != nil {
+		return err
+	}
+	utils.PrintInfo(fmt.Sprintf("Report written to %s", reportPath))
+
+	if !result.Success {
+		return fmt.Errorf("verification failed: %s", result.Error)
+	}
+	utils.PrintSuccess(result.Message)
+	return nil
+}
+
+// resolveVerifyOptions applies the defaults to the billing periods and checks
+func resolveVerifyOptions(cfg *config.EcosConfig, start, end string, checks []string) (verifyOptions, error) {
+	if end == "" {
+		end = time.Now().UTC().Format(config.BillingPeriodLayout)
+	}
+	endMonth, err := config.ParseBillingPeriod(end)
+	if err != nil {
+		return verifyOptions{}, fmt.Errorf("--end: %w", err)
+	}
+	if start == "" {
+		start = endMonth.AddDate(0, -(defaultVerifyPeriods - 1), 0).Format(config.BillingPeriodLayout)
+	}
+	if _, err := config.ParseBillingPeriod(start); err != nil {
+		return verifyOptions{}, fmt.Errorf("--start: %w", err)
+	}
+	if start > end {
+		return verifyOptions{}, fmt.Errorf("--start %s is after --end %s", start, end)
+	}
+	if len(checks) == 0 {
+		checks = cfg.Verify.Checks
+	}
+	return verifyOptions{start: start, end: end, checks: checks}, nil
+}
+
+// verifyTables locates the CUR table and the models from .ecos.yaml
+func verifyTables(cfg *config.EcosConfig) (verify.Tables, error) {
+	tables := verify.Tables{
+		CURDatabase:   cfg.Transform.DBT.Vars["cur_schema"],
+		CURTable:      cfg.Transform.DBT.Vars["cur_table"],
+		ModelDatabase: cfg.AWS.Database,
+	}
+	if tables.CURDatabase == "" || tables.CURTable == "" {
+		return tables, errors.New("verify needs transform.dbt.vars cur_schema and cur_table in .ecos.yaml")
+	}
+	if tables.ModelDatabase == "" {
+		return tables, errors.New("verify needs aws.database in .ecos.yaml")
+	}
+	return tables, nil
+}
+
+// verifyReportPath returns the configured report path, relative to the project directory
+func verifyReportPath(cfg *config.EcosConfig, projectDir string) string {
+	path := cfg.Verify.ReportPath
+	if path == "" {
+		path = defaultVerifyReport
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectDir, path)
+}
+
+// runVerification runs the checks and prints their results
+func runVerification(ctx context.Context, cfg *config.EcosConfig, querier query.Querier, opts verifyOptions) (*types.PluginResult, error) {
+	started := time.Now()
+	tables, err := verifyTables(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.PrintProgress(fmt.Sprintf("Reconciling billing periods %s to %s", opts.start, opts.end))
+	columns, err := verify.CURColumns(ctx, querier, tables.CURDatabase, tables.CURTable)
+	if err != nil {
+		return nil, err
+	}
+	checks, err := verify.Checks(tables, columns, opts.checks)
+	if err != nil {
+		return nil, err
+	}
+	tolerance := verify.ToleranceFromConfig(cfg.Verify)
+	results, err := verify.Run(ctx, querier, checks, opts.start, opts.end, tolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	printVerifyResults(results)
+	return verify.Result(results, time.Since(started), tolerance), nil
+}
+
+// printVerifyResults prints one row per check and billing period, and the accounts of
+// every mismatch
+func printVerifyResults(results []verify.PeriodResult) {
+	if len(results) == 0 {
+		utils.PrintWarning("No billing periods found in the CUR table or the models")
+		return
+	}
+
+	fmt.Println()
+	var rows [][]string
+	for _, r := range results {
+		status := "✅ passed"
+		if !r.Passed {
+			status = "❌ mismatch"
+		}
+		for i, t := range r.Totals {
+			row := []string{"", "", t.Source, fmt.Sprintf("%.4f", t.Cost), "", ""}
+			if i == 0 {
+				row[0], row[1], row[5] = r.Check, r.BillingPeriod, status
+			} else {
+				row[4] = fmt.Sprintf("%+.4f", t.Difference)
+			}
+			rows = append(rows, row)
+		}
+	}
+	utils.PrintTable([]string{"Check", "Billing Period", "Source", "Cost", "Difference", "Status"}, rows)
+
+	for _, r := range results {
+		if r.Passed {
+			continue
+		}
+		fmt.Println()
+		utils.PrintWarning(fmt.Sprintf("%s %s: %d account(s) do not reconcile", r.Check, r.BillingPeriod, len(r.Accounts)))
+		headers := []string{"Account"}
+		for _, t := range r.Totals {
+			headers = append(headers, t.Source)
+		}
+		headers = append(headers, "Difference")
+
+		var accountRows [][]string
+		for i, a := range r.Accounts {
+			if i == maxMismatchedAccounts {
+				break
+			}
+			row := []string{a.Account}
+			for _, cost := range a.Costs {
+				row = append(row, fmt.Sprintf("%.4f", cost))
+			}
+			accountRows = append(accountRows, append(row, fmt.Sprintf("%+.4f", a.Difference)))
+		}
+		utils.PrintTable(headers, accountRows)
+		if len(r.Accounts) > maxMismatchedAccounts {
+			utils.PrintInfo(fmt.Sprintf("%d more account(s) in the report", len(r.Accounts)-maxMismatchedAccounts))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/verify"
+)
+
+// staticVerifyQuerier answers the column lookup and returns the same costs for every table
+type staticVerifyQuerier struct {
+	costs [][]string
+}
+
+func (q *staticVerifyQuerier) Query(_ context.Context, sql string) ([][]string, error) {
+	if strings.Contains(sql, "information_schema.columns") {
+		return [][]string{{"line_item_unblended_cost"}, {"billing_period"}}, nil
+	}
+	return q.costs, nil
+}
+
+func verifyTestConfig() *config.EcosConfig {
+	cfg := config.NewDefaultConfig()
+	cfg.Transform.DBT.Vars = map[string]string{"cur_schema": "cur", "cur_table": "cur_data"}
+	cfg.AWS.Database = "ecos"
+	return cfg
+}
+
+func TestResolveVerifyOptions(t *testing.T) {
+	cfg := verifyTestConfig()
+	cfg.Verify.Checks = []string{verify.CheckBilledCost}
+
+	opts, err := resolveVerifyOptions(cfg, "", "2025-02", nil)
+	if err != nil {
+		t.Fatalf("resolveVerifyOptions() error = %v", err)
+	}
+	if opts.start != "2024-12" || opts.end != "2025-02" || len(opts.checks) != 1 {
+		t.Errorf("options = %+v, want 2024-12..2025-02 and the configured checks", opts)
+	}
+
+	if _, err := resolveVerifyOptions(cfg, "2025-03", "2025-02", nil); err == nil {
+		t.Error("resolveVerifyOptions() expected error for --start after --end")
+	}
+	if _, err := resolveVerifyOptions(cfg, "", "2025-2", nil); err == nil {
+		t.Error("resolveVerifyOptions() expected error for a malformed --end")
+	}
+}
+
+func TestVerifyTables(t *testing.T) {
+	if _, err := verifyTables(config.NewDefaultConfig()); err == nil {
+		t.Error("verifyTables() expected error without cur_schema and cur_table")
+	}
+	tables, err := verifyTables(verifyTestConfig())
+	if err != nil || tables.CURTable != "cur_data" || tables.ModelDatabase != "ecos" {
+		t.Errorf("verifyTables() = %+v, %v", tables, err)
+	}
+}
+
+func TestRunVerification(t *testing.T) {
+	cfg := verifyTestConfig()
+	opts := verifyOptions{start: "2025-01", end: "2025-01"}
+
+	reconciled := &staticVerifyQuerier{costs: [][]string{{"2025-01", "111111111111", "42.5"}}}
+	result, err := runVerification(context.Background(), cfg, reconciled, opts)
+	if err != nil {
+		t.Fatalf("runVerification() error = %v", err)
+	}
+	if !result.Success || len(verify.Results(result)) != len(config.VerifyChecks) {
+		t.Errorf("result = %+v, want every check to pass", result)
+	}
+
+	empty := &staticVerifyQuerier{}
+	result, err = runVerification(context.Background(), cfg, empty, opts)
+	if err != nil || !result.Success || len(verify.Results(result)) != 0 {
+		t.Errorf("runVerification() = %+v, %v, want no billing periods to compare", result, err)
+	}
+}
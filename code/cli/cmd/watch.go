@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/transform"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/watch"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// Sources 'ecos watch' can poll
+const (
+	watchSourceAuto     = "auto"
+	watchSourceManifest = "manifest"
+	watchSourceGlue     = "glue"
+)
+
+// minWatchInterval keeps polling from turning into a tight loop of S3 or Glue calls
+const minWatchInterval = time.Minute
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch [flags] [-- dbt-flags...]",
+	Short: "Transform new CUR deliveries as they arrive",
+	Long: `Run as a long-lived process that transforms new and restated billing periods
+as AWS delivers them.
+
+Every --interval, ecos checks the export for deliveries it has not transformed yet
+and runs the models for only those billing periods, one run per range of
+consecutive months, by setting the billing_period_start and billing_period_end
+vars. Deliveries are detected from:
+  manifest  the manifests BCM Data Exports write next to every delivery
+            (default when .ecos.yaml has an aws.data_export)
+  glue      the partitions of the CUR table (transform.dbt.vars cur_schema and
+            cur_table) maintained by a Glue crawler
+
+Transformed deliveries are recorded in .ecos-watch.json, so a restarted watcher
+only processes what arrived since. On the first start, billing periods before
+--since are recorded without being transformed. A failed run is retried on the
+next check.
+
+On SIGINT or SIGTERM, ecos stops checking and waits for the running transform to
+finish; a second signal aborts it.
+
+Flags after -- are passed to every run, e.g. -- --select tag:cur.
+
+Examples:
+  ecos watch
+  ecos watch --interval 30m --since 2025-01
+  ecos watch --source glue -- --select tag:cur
+  ecos watch --once`,
+	RunE: runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().Duration("interval", 15*time.Minute, "time between checks for new deliveries")
+	watchCmd.Flags().String("source", watchSourceAuto, "how to detect deliveries (auto, manifest, glue)")
+	watchCmd.Flags().String("since", "", "on the first start, transform billing periods from this month (YYYY-MM, default: current month)")
+	watchCmd.Flags().Bool("once", false, "check once, transform what changed and exit")
+	watchCmd.Flags().Bool("ignore-drift", false, "ignore configuration drift and proceed anyway")
+	watchCmd.Flags().StringP("project-dir", "p", ".", "ecos project directory path")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	sourceKind, _ := cmd.Flags().GetString("source")
+	since, _ := cmd.Flags().GetString("since")
+	once, _ := cmd.Flags().GetBool("once")
+	ignoreDrift, _ := cmd.Flags().GetBool("ignore-drift")
+	projectDir, _ := cmd.Flags().GetString("project-dir")
+
+	utils.PrintHeader("ecos watch")
+
+	if !once && interval < minWatchInterval {
+		return fmt.Errorf("--interval must be at least %s", minWatchInterval)
+	}
+	if since == "" {
+		since = time.Now().UTC().Format(config.BillingPeriodLayout)
+	}
+	if _, err := config.ParseBillingPeriod(since); err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	if slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "--full-refresh") }) {
+		return errors.New("--full-refresh processes all billing periods at once and cannot be used with watch")
+	}
+
+	configPath := filepath.Join(projectDir, config.ConfigFilename)
+	if !utils.FileExists(configPath) {
+		return fmt.Errorf(".ecos.yaml not found in %s", projectDir)
+	}
+	ecosConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load .ecos.yaml: %w", err)
+	}
+	if !ignoreDrift {
+		if err := checkConfigDrift(projectDir); err != nil {
+			return err
+		}
+	}
+	// Check the runtime against .ecos.lock (lock.mode)
+	if err := checkProjectLock(cmd.Context(), ecosConfig, projectDir); err != nil {
+		return err
+	}
+
+	awsCfg, err := awssession.Load(context.Background(), awssession.FromConfig(ecosConfig))
+	if err != nil {
+		return err
+	}
+	source, err := newWatchSource(ecosConfig, awsCfg, sourceKind)
+	if err != nil {
+		return err
+	}
+
+	// Stop checking on the first signal; abort the running transform on the second
+	stopCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	runCtx, abort := context.WithCancel(context.Background())
+	defer abort()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+		case <-stopCtx.Done():
+			return
+		}
+		utils.PrintWarning("Shutting down after the running transform finishes, signal again to abort it")
+		stop()
+		select {
+		case <-signals:
+			abort()
+		case <-runCtx.Done():
+		}
+	}()
+
+	w := &watcher{
+		source:     source,
+		projectDir: projectDir,
+		since:      since,
+		args:       args,
+		dryRun:     dryRun,
+		ecosConfig: ecosConfig,
+		newPlugin: func() (types.TransformPlugin, error) {
+			return newTransformPlugin(transformPluginName(ecosConfig))
+		},
+	}
+
+	utils.PrintInfo(fmt.Sprintf("Watching %s", source))
+	for {
+		err := w.check(stopCtx, runCtx)
+		if once || dryRun {
+			return err
+		}
+		if err != nil {
+			utils.PrintError(err.Error())
+		}
+
+		select {
+		case <-stopCtx.Done():
+			utils.PrintSuccess("Stopped watching")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// newWatchSource returns the delivery source for the project
+func newWatchSource(cfg *config.EcosConfig, awsCfg aws.Config, kind string) (watch.Source, error) {
+	endpoints := cfg.AWS.Endpoints
+	if kind == watchSourceAuto {
+		kind = watchSourceGlue
+		if cfg.AWS.DataExport != nil {
+			kind = watchSourceManifest
+		}
+	}
+
+	switch kind {
+	case watchSourceManifest:
+		if cfg.AWS.DataExport == nil || cfg.AWS.ResultsBucket == "" {
+			return nil, errors.New("the manifest source needs aws.data_export and aws.results_bucket in .ecos.yaml")
+		}
+		return watch.NewManifestSource(awssession.NewS3Client(awsCfg, endpoints), *cfg.AWS.DataExport, cfg.AWS.ResultsBucket), nil
+	case watchSourceGlue:
+		database, table := cfg.Transform.DBT.Vars["cur_schema"], cfg.Transform.DBT.Vars["cur_table"]
+		if database == "" || table == "" {
+			return nil, errors.New("the glue source needs transform.dbt.vars cur_schema and cur_table in .ecos.yaml")
+		}
+		return &watch.GluePartitionSource{
+			Client:   awssession.NewGlueClient(awsCfg, endpoints),
+			Database: database,
+			Table:    table,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source '%s' (supported: %s, %s, %s)", kind, watchSourceAuto, watchSourceManifest, watchSourceGlue)
+	}
+}
+
+// watcher transforms the deliveries of one source
+type watcher struct {
+	source     watch.Source
+	projectDir string
+	since      string
+	args       []string
+	dryRun     bool
+	ecosConfig *config.EcosConfig
+	// newPlugin returns a fresh plugin per check, so resolved AWS credentials do not
+	// outlive their session in a long-running watcher
+	newPlugin func() (types.TransformPlugin, error)
+
+	cursor *watch.Cursor
+}
+
+// check transforms the deliveries that arrived since the last check. stopCtx ends
+// the check before the next run starts; runCtx aborts a running transform.
+func (w *watcher) check(stopCtx, runCtx context.Context) error {
+	if w.cursor == nil {
+		cursor, err := w.loadCursor(stopCtx)
+		if err != nil {
+			return err
+		}
+		w.cursor = cursor
+	}
+
+	if w.dryRun {
+		snapshot, err := w.source.Snapshot(stopCtx)
+		if err != nil {
+			return err
+		}
+		ranges, err := watch.Ranges(w.cursor.Changed(snapshot))
+		if err != nil {
+			return err
+		}
+		if len(ranges) == 0 {
+			utils.PrintDryRun("No new deliveries to transform")
+		}
+		for _, r := range ranges {
+			utils.PrintDryRun(fmt.Sprintf("Would transform billing periods %s", r))
+		}
+		return nil
+	}
+
+	var (
+		plugin       types.TransformPlugin
+		runner       types.BillingPeriodRunner
+		pluginConfig map[string]any
+	)
+	results, err := watch.Poll(stopCtx, w.source, w.cursor, func(r transform.Chunk) error {
+		if runner == nil {
+			var err error
+			if plugin, runner, pluginConfig, err = w.preparePlugin(runCtx); err != nil {
+				return err
+			}
+		}
+		utils.PrintProgress(fmt.Sprintf("[%s] New delivery, transforming billing periods %s", time.Now().Format(time.DateTime), r))
+		if err := runner.RunBillingPeriods(runCtx, backfillCommand, w.args, types.BillingPeriodRun{Start: r.Start, End: r.End}, pluginConfig); err != nil {
+			return fmt.Errorf("%s %s failed: %w", plugin.Name(), backfillCommand, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		utils.PrintDebug("No new deliveries")
+		return nil
+	}
+	var failed []string
+	for _, r := range results {
+		switch {
+		case r.NotRun:
+			utils.PrintInfo(fmt.Sprintf("Billing periods %s not transformed, they run on the next start", r.Chunk))
+		case r.Err != nil:
+			failed = append(failed, r.Chunk.String())
+			utils.PrintError(fmt.Sprintf("Billing periods %s: %v", r.Chunk, r.Err))
+		default:
+			utils.PrintSuccess(fmt.Sprintf("Billing periods %s transformed in %s", r.Chunk, r.Duration.Round(time.Second)))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("transform failed for billing periods %s, retrying on the next check", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// loadCursor loads the cursor, or records a baseline of the billing periods before
+// --since on the first start
+func (w *watcher) loadCursor(ctx context.Context) (*watch.Cursor, error) {
+	path := watch.CursorPath(w.projectDir)
+	cursor, err := watch.LoadCursor(path)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil && cursor.Source == w.source.String() {
+		return cursor, nil
+	}
+	if cursor != nil {
+		utils.PrintWarning(fmt.Sprintf("%s was recorded for %s, starting over", path, cursor.Source))
+	}
+
+	cursor = watch.NewCursor(path, w.source.String())
+	snapshot, err := w.source.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	baseline := cursor.Baseline(snapshot, w.since)
+	if w.dryRun {
+		utils.PrintDryRun(fmt.Sprintf("Would record %d billing period(s) before %s as transformed", baseline, w.since))
+		return cursor, nil
+	}
+	if err := cursor.Save(); err != nil {
+		return nil, err
+	}
+	utils.PrintInfo(fmt.Sprintf("First start: %d billing period(s) before %s recorded as transformed", baseline, w.since))
+	return cursor, nil
+}
+
+// preparePlugin creates, validates and prepares the transform plugin for a check
+func (w *watcher) preparePlugin(ctx context.Context) (types.TransformPlugin, types.BillingPeriodRunner, map[string]any, error) {
+	plugin, err := w.newPlugin()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	runner, ok := plugin.(types.BillingPeriodRunner)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("transform plugin %s does not support watch", plugin.Name())
+	}
+
+	pluginConfig := plugin.BuildConfig(w.ecosConfig, backfillCommand, w.args)
+	pluginConfig["project_dir"] = w.projectDir
+	pluginConfig["verbose"] = IsVerbose()
+
+	if err := plugin.Validate(pluginConfig); err != nil {
+		return nil, nil, nil, fmt.Errorf("plugin validation failed: %w", err)
+	}
+	if err := plugin.PrepareEnvironment(ctx, pluginConfig); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to prepare %s environment: %w", plugin.Name(), err)
+	}
+	return plugin, runner, pluginConfig, nil
+}
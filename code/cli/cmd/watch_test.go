@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/watch"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// staticWatchSource returns the snapshot it holds
+type staticWatchSource struct {
+	snapshot watch.Snapshot
+}
+
+func (s *staticWatchSource) String() string { return "static" }
+
+func (s *staticWatchSource) Snapshot(_ context.Context) (watch.Snapshot, error) {
+	return s.snapshot, nil
+}
+
+func TestWatcherCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	transformMock := mocks.NewMockTransformPlugin(ctrl)
+	runnerMock := mocks.NewMockBillingPeriodRunner(ctrl)
+	plugin := backfillTestPlugin{transformMock, runnerMock}
+
+	transformMock.EXPECT().Name().Return("dbt").AnyTimes()
+	transformMock.EXPECT().BuildConfig(gomock.Any(), "run", gomock.Any()).Return(map[string]any{}).Times(2)
+	transformMock.EXPECT().Validate(gomock.Any()).Return(nil).Times(2)
+	transformMock.EXPECT().PrepareEnvironment(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	var ran []string
+	runnerMock.EXPECT().RunBillingPeriods(gomock.Any(), "run", []string{"--select", "tag:cur"}, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ []string, run types.BillingPeriodRun, _ map[string]any) error {
+			ran = append(ran, run.Start+".."+run.End)
+			return nil
+		}).Times(2)
+
+	source := &staticWatchSource{snapshot: watch.Snapshot{"2024-12": "a", "2025-01": "b", "2025-02": "c"}}
+	w := &watcher{
+		source:     source,
+		projectDir: t.TempDir(),
+		since:      "2025-01",
+		args:       []string{"--select", "tag:cur"},
+		ecosConfig: config.NewDefaultConfig(),
+		newPlugin:  func() (types.TransformPlugin, error) { return plugin, nil },
+	}
+	ctx := context.Background()
+
+	// First start: 2024-12 is recorded as baseline, 2025-01..2025-02 is transformed
+	if err := w.check(ctx, ctx); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+	// Nothing changed
+	if err := w.check(ctx, ctx); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+	// 2024-12 is restated; a restarted watcher picks it up from the cursor
+	source.snapshot["2024-12"] = "a2"
+	w.cursor = nil
+	if err := w.check(ctx, ctx); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+
+	if strings.Join(ran, ",") != "2025-01..2025-02,2024-12..2024-12" {
+		t.Errorf("ran %v", ran)
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed templates/*.tmpl
@@ -20,6 +23,9 @@ var templateFS embed.FS
 // ConfigFilename is the default configuration filename
 const ConfigFilename = ".ecos.yaml"
 
+// BillingPeriodLayout is the time layout of a billing period (YYYY-MM)
+const BillingPeriodLayout = "2006-01"
+
 // LoadConfig loads configuration from the specified file
 func LoadConfig(configFile string) (*EcosConfig, error) {
 	var config EcosConfig
@@ -136,9 +142,8 @@ func NewDefaultConfig() *EcosConfig {
 				Target:      "prod",
 			},
 		},
-		// TODO: Report section is intentionally minimal as it's not implemented yet
 		Report: ReportConfig{
-			Format:     "table",
+			Format:     "html",
 			OutputPath: "./reports",
 		},
 	}
@@ -178,7 +183,7 @@ func (c *EcosConfig) SetDefaults() {
 
 	// Report defaults
 	if c.Report.Format == "" {
-		c.Report.Format = "table"
+		c.Report.Format = "html"
 	}
 	if c.Report.OutputPath == "" {
 		c.Report.OutputPath = "./reports"
@@ -187,6 +192,10 @@ func (c *EcosConfig) SetDefaults() {
 
 // Validate validates the entire EcosConfig structure
 func (c *EcosConfig) Validate() error {
+	if err := validateModelSource(c.ModelSource); err != nil {
+		return err
+	}
+
 	if err := validateGlobalConfig(&c.Global); err != nil {
 		return fmt.Errorf("global config validation failed: %w", err)
 	}
@@ -203,6 +212,42 @@ func (c *EcosConfig) Validate() error {
 		return fmt.Errorf("report config validation failed: %w", err)
 	}
 
+	if err := validateVerifyConfig(&c.Verify); err != nil {
+		return fmt.Errorf("verify config validation failed: %w", err)
+	}
+
+	if err := validatePipelineConfig(&c.Pipeline); err != nil {
+		return fmt.Errorf("pipeline config validation failed: %w", err)
+	}
+
+	if err := validateServeConfig(&c.Serve); err != nil {
+		return fmt.Errorf("serve config validation failed: %w", err)
+	}
+
+	if err := validateMCPConfig(&c.MCP); err != nil {
+		return fmt.Errorf("mcp config validation failed: %w", err)
+	}
+
+	if c.Lock.Mode != "" && !slices.Contains(LockModes, c.Lock.Mode) {
+		return fmt.Errorf("lock config validation failed: invalid mode '%s', must be one of: %v", c.Lock.Mode, LockModes)
+	}
+
+	return nil
+}
+
+// ModelSourceSchemes are the URL schemes model_source accepts
+var ModelSourceSchemes = []string{"github", "https", "http", "s3", "file"}
+
+// validateModelSource checks the scheme of model_source; the rest of the URL is
+// checked when the models are downloaded
+func validateModelSource(source string) error {
+	if source == "" {
+		return nil
+	}
+	scheme, _, ok := strings.Cut(source, "://")
+	if !ok || !slices.Contains(ModelSourceSchemes, scheme) {
+		return fmt.Errorf("invalid model_source '%s', must start with one of: github://, https://, http://, s3://, file://", source)
+	}
 	return nil
 }
 
@@ -246,6 +291,9 @@ func validateTransformConfig(t *TransformConfig) error {
 		if t.DBT.ProjectDir == "" {
 			return errors.New("DBT project directory must be specified for dbt plugin")
 		}
+		if err := validateBillingPeriodRange(t.DBT.BillingPeriodStart, t.DBT.BillingPeriodEnd); err != nil {
+			return err
+		}
 	case "sql":
 		if t.SQL.ConnectionString == "" {
 			return errors.New("SQL connection string must be specified for sql plugin")
@@ -255,22 +303,136 @@ func validateTransformConfig(t *TransformConfig) error {
 	return nil
 }
 
+// ParseBillingPeriod parses a billing period in the YYYY-MM format the models use
+func ParseBillingPeriod(period string) (time.Time, error) {
+	t, err := time.Parse(BillingPeriodLayout, period)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid billing period '%s', expected YYYY-MM", period)
+	}
+	return t, nil
+}
+
+// validateBillingPeriodRange checks the optional transform.dbt billing period bounds
+func validateBillingPeriodRange(start, end string) error {
+	var startTime, endTime time.Time
+	var err error
+	if start != "" {
+		if startTime, err = ParseBillingPeriod(start); err != nil {
+			return fmt.Errorf("billing_period_start: %w", err)
+		}
+	}
+	if end != "" {
+		if endTime, err = ParseBillingPeriod(end); err != nil {
+			return fmt.Errorf("billing_period_end: %w", err)
+		}
+	}
+	if start != "" && end != "" && startTime.After(endTime) {
+		return fmt.Errorf("billing_period_start '%s' is after billing_period_end '%s'", start, end)
+	}
+	return nil
+}
+
+// ReportFormats are the formats 'ecos report' renders; table prints the report instead
+// of writing a file
+var ReportFormats = []string{"html", "markdown", "csv", "json", "table"}
+
 // validateReportConfig validates ReportConfig
 func validateReportConfig(r *ReportConfig) error {
-	if r.Format != "" {
-		validFormats := []string{"json", "csv", "table", "yaml"}
-		valid := false
-		for _, format := range validFormats {
-			if r.Format == format {
-				valid = true
-				break
+	if r.Format != "" && !slices.Contains(ReportFormats, r.Format) {
+		return fmt.Errorf("invalid report format '%s', must be one of: %v", r.Format, ReportFormats)
+	}
+
+	return nil
+}
+
+// VerifyChecks are the reconciliation checks 'ecos verify' can run
+var VerifyChecks = []string{"unblended_cost", "billed_cost", "effective_cost"}
+
+// validateVerifyConfig validates VerifyConfig
+func validateVerifyConfig(v *VerifyConfig) error {
+	if v.ToleranceAbsolute < 0 {
+		return errors.New("tolerance_absolute cannot be negative")
+	}
+	if v.TolerancePercent < 0 || v.TolerancePercent > 100 {
+		return fmt.Errorf("invalid tolerance_percent %v, must be between 0 and 100", v.TolerancePercent)
+	}
+	for _, check := range v.Checks {
+		if !slices.Contains(VerifyChecks, check) {
+			return fmt.Errorf("unknown check '%s', must be one of: %v", check, VerifyChecks)
+		}
+	}
+	return nil
+}
+
+// PipelineStageTypes are the stage types a pipeline can run
+var PipelineStageTypes = []string{"ingest", "transform", "verify", "report"}
+
+// validatePipelineConfig validates the fields of PipelineConfig; stage dependencies
+// are checked when the pipeline is built
+func validatePipelineConfig(p *PipelineConfig) error {
+	if p.OnFailure != "" && p.OnFailure != "stop" && p.OnFailure != "continue" {
+		return fmt.Errorf("invalid on_failure '%s', must be stop or continue", p.OnFailure)
+	}
+
+	for i, stage := range p.Stages {
+		if stage.Name == "" {
+			return fmt.Errorf("stage %d has no name", i+1)
+		}
+		if !slices.Contains(PipelineStageTypes, stage.Type) {
+			return fmt.Errorf("stage '%s': invalid type '%s', must be one of: %v", stage.Name, stage.Type, PipelineStageTypes)
+		}
+		if stage.Retries < 0 {
+			return fmt.Errorf("stage '%s': retries cannot be negative", stage.Name)
+		}
+		for field, value := range map[string]string{"retry_delay": stage.RetryDelay, "timeout": stage.Timeout} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+				return fmt.Errorf("stage '%s': invalid %s '%s', expected a duration such as 30s or 2h", stage.Name, field, value)
 			}
 		}
-		if !valid {
-			return fmt.Errorf("invalid report format '%s', must be one of: %v", r.Format, validFormats)
+	}
+
+	return nil
+}
+
+// validateServeConfig validates ServeConfig
+func validateServeConfig(s *ServeConfig) error {
+	if s.MaxRows < 0 {
+		return errors.New("max_rows cannot be negative")
+	}
+	for field, value := range map[string]string{"cache_ttl": s.CacheTTL, "closed_cache_ttl": s.ClosedCacheTTL} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("invalid %s '%s', expected a duration such as 15m or 24h", field, value)
 		}
 	}
+	return nil
+}
+
+// LockModes are how transforms react to a runtime differing from .ecos.lock
+var LockModes = []string{"warn", "fail", "off"}
 
+// MCPEngines are the engines 'ecos mcp' can query
+var MCPEngines = []string{"athena", "duckdb"}
+
+// validateMCPConfig validates MCPConfig
+func validateMCPConfig(m *MCPConfig) error {
+	if m.Engine != "" && !slices.Contains(MCPEngines, m.Engine) {
+		return fmt.Errorf("invalid engine '%s', must be one of: %v", m.Engine, MCPEngines)
+	}
+	if m.Engine == "duckdb" && m.DuckDBPath == "" {
+		return errors.New("the duckdb engine needs duckdb_path")
+	}
+	if m.MaxScanBytes < 0 {
+		return errors.New("max_scan_bytes cannot be negative")
+	}
+	if m.MaxRows < 0 {
+		return errors.New("max_rows cannot be negative")
+	}
 	return nil
 }
 
@@ -351,3 +513,60 @@ func WriteConfigFile(content, targetDir, filename string) error {
 
 	return nil
 }
+
+// SetConfigValue sets a top-level scalar setting of the .ecos.yaml at configPath, such
+// as model_version, keeping the rest of the file and its comments. The file is replaced
+// through a temporary file, so it is either fully updated or left untouched.
+func SetConfigValue(configPath, key, value string) error {
+	data, err := os.ReadFile(configPath) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", configPath)
+	}
+
+	root := doc.Content[0]
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1].SetString(value)
+			found = true
+			break
+		}
+	}
+	if !found {
+		var k, v yaml.Node
+		k.SetString(key)
+		v.SetString(value)
+		root.Content = append(root.Content, &k, &v)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", configPath, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", configPath, err)
+	}
+
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(configPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := configPath + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+	if err := os.Rename(tmp, configPath); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+	return nil
+}
@@ -58,6 +58,56 @@ transform:
 	}
 }
 
+func TestLoadConfig_AWSAccessRoundTrip(t *testing.T) {
+	content, err := generateEcosConfigFromTemplate(EcosConfigTemplate{
+		ProjectName:   "my-test",
+		ModelSource:   "s3://acme-mirror/ecos",
+		ProjectDir:    "transform/dbt",
+		AWSRegion:     "us-east-1",
+		ResultsBucket: "my-bucket",
+		AWSAccess: AWSAccessConfig{
+			AssumeRole: AssumeRoleConfig{RoleARN: "arn:aws:iam::111122223333:role/ecos"},
+			Endpoints:  AWSEndpointsConfig{S3: "http://localhost:4566", S3UsePathStyle: true},
+		},
+		DataExport: &DataExportConfig{
+			Name:         "my-test-cur2",
+			Type:         "cur2",
+			S3Prefix:     "data-exports",
+			GlueDatabase: "my_test_data_exports",
+			GlueTable:    "cur2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfgFile := filepath.Join(t.TempDir(), ".ecos.yaml")
+	if err := os.WriteFile(cfgFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.ModelSource != "s3://acme-mirror/ecos" {
+		t.Errorf("ModelSource = %q", cfg.ModelSource)
+	}
+	if cfg.AWS.AssumeRole.RoleARN != "arn:aws:iam::111122223333:role/ecos" {
+		t.Errorf("RoleARN = %q", cfg.AWS.AssumeRole.RoleARN)
+	}
+	if cfg.AWS.Endpoints.S3 != "http://localhost:4566" || !cfg.AWS.Endpoints.S3UsePathStyle {
+		t.Errorf("Endpoints = %+v", cfg.AWS.Endpoints)
+	}
+	if cfg.AWS.ResultsBucket != "my-bucket" {
+		t.Errorf("ResultsBucket = %q", cfg.AWS.ResultsBucket)
+	}
+	if cfg.AWS.DataExport == nil || cfg.AWS.DataExport.Name != "my-test-cur2" || cfg.AWS.DataExport.GlueTable != "cur2" {
+		t.Errorf("DataExport = %+v", cfg.AWS.DataExport)
+	}
+}
+
 func TestFindConfigFile_Found(t *testing.T) {
 	root := t.TempDir()
 
@@ -159,6 +209,140 @@ func TestValidate_GlobalConfig(t *testing.T) {
 	}
 }
 
+func TestValidate_PipelineConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline PipelineConfig
+		wantErr  bool
+	}{
+		{"empty", PipelineConfig{}, false},
+		{"valid", PipelineConfig{OnFailure: "continue", Stages: []PipelineStageConfig{
+			{Name: "models", Type: "transform", Retries: 2, RetryDelay: "30s", Timeout: "2h"},
+		}}, false},
+		{"invalid on_failure", PipelineConfig{OnFailure: "retry"}, true},
+		{"missing name", PipelineConfig{Stages: []PipelineStageConfig{{Type: "transform"}}}, true},
+		{"invalid type", PipelineConfig{Stages: []PipelineStageConfig{{Name: "x", Type: "deploy"}}}, true},
+		{"negative retries", PipelineConfig{Stages: []PipelineStageConfig{{Name: "x", Type: "transform", Retries: -1}}}, true},
+		{"invalid timeout", PipelineConfig{Stages: []PipelineStageConfig{{Name: "x", Type: "transform", Timeout: "2 hours"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.Pipeline = tt.pipeline
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_VerifyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		verify  VerifyConfig
+		wantErr bool
+	}{
+		{"empty", VerifyConfig{}, false},
+		{"valid", VerifyConfig{ToleranceAbsolute: 1, TolerancePercent: 0.5, Checks: []string{"billed_cost"}}, false},
+		{"negative absolute", VerifyConfig{ToleranceAbsolute: -1}, true},
+		{"percent above 100", VerifyConfig{TolerancePercent: 150}, true},
+		{"unknown check", VerifyConfig{Checks: []string{"blended_cost"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.Verify = tt.verify
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_ServeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		serve   ServeConfig
+		wantErr bool
+	}{
+		{"empty", ServeConfig{}, false},
+		{"valid", ServeConfig{Listen: ":9000", CacheTTL: "5m", ClosedCacheTTL: "168h", MaxRows: 500}, false},
+		{"negative max rows", ServeConfig{MaxRows: -1}, true},
+		{"invalid ttl", ServeConfig{CacheTTL: "soon"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.Serve = tt.serve
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_MCPConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		mcp     MCPConfig
+		wantErr bool
+	}{
+		{"empty", MCPConfig{}, false},
+		{"duckdb", MCPConfig{Engine: "duckdb", DuckDBPath: "ecos.duckdb", MaxRows: 100}, false},
+		{"unknown engine", MCPConfig{Engine: "bigquery"}, true},
+		{"duckdb without path", MCPConfig{Engine: "duckdb"}, true},
+		{"negative scan limit", MCPConfig{MaxScanBytes: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.MCP = tt.mcp
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_ModelSource(t *testing.T) {
+	tests := []struct {
+		source  string
+		wantErr bool
+	}{
+		{"", false},
+		{"github://ecos-labs/ecos", false},
+		{"https://artifacts.example.com/ecos", false},
+		{"s3://acme-mirror/ecos", false},
+		{"file:///opt/ecos/aws-cur-1.2.0.tar.gz", false},
+		{"ftp://example.com/ecos", true},
+		{"ecos-labs/ecos", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.ModelSource = tt.source
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_LockConfig(t *testing.T) {
+	for mode, wantErr := range map[string]bool{"": false, "warn": false, "fail": false, "off": false, "strict": true} {
+		cfg := NewDefaultConfig()
+		cfg.Lock.Mode = mode
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("Validate() mode %q error = %v, wantErr %v", mode, err, wantErr)
+		}
+	}
+}
+
 func TestValidate_TransformConfig(t *testing.T) {
 	cfg := NewDefaultConfig()
 
@@ -169,6 +353,19 @@ func TestValidate_TransformConfig(t *testing.T) {
 		t.Errorf("expected valid dbt config, got %v", err)
 	}
 
+	// Invalid: malformed or reversed billing periods
+	cfg.Transform.DBT.BillingPeriodStart = "2024-1"
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("expected error for malformed billing_period_start")
+	}
+	cfg.Transform.DBT.BillingPeriodStart = "2025-06"
+	cfg.Transform.DBT.BillingPeriodEnd = "2024-01"
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("expected error for billing_period_start after billing_period_end")
+	}
+	cfg.Transform.DBT.BillingPeriodStart = ""
+	cfg.Transform.DBT.BillingPeriodEnd = ""
+
 	// Invalid: SQL plugin without connection string
 	cfg.Transform.Plugin = "sql"
 	cfg.Transform.SQL.ConnectionString = ""
@@ -177,3 +374,27 @@ func TestValidate_TransformConfig(t *testing.T) {
 		t.Errorf("expected error for missing SQL connection string")
 	}
 }
+
+func TestSetConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFilename)
+	content := "# ecos project\nproject_name: demo\nmodel_version: v1.0.0 # pinned\naws:\n  region: us-east-1\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetConfigValue(path, "model_version", "v1.1.0"); err != nil {
+		t.Fatalf("SetConfigValue() error = %v", err)
+	}
+	if err := SetConfigValue(path, "data_source", "aws_cur"); err != nil {
+		t.Fatalf("SetConfigValue() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# ecos project\nproject_name: demo\nmodel_version: v1.1.0 # pinned\naws:\n  region: us-east-1\ndata_source: aws_cur\n"
+	if string(data) != want {
+		t.Errorf("SetConfigValue() wrote %q, want %q", data, want)
+	}
+}
@@ -168,26 +168,20 @@ func ExtractDBTDataFromEcosConfig(ecosConfig *EcosConfig, outputPath string) (DB
 	})
 
 	// Extract materialization settings
+	// Layers without an override are left out, so the mode applies to them
 	matMode := "view"
-	bronzeMat := "view"
-	silverMat := "view"
-	goldMat := "view"
+	var bronzeMat, silverMat, goldMat string
+	var modelMats map[string]string
 
 	if ecosConfig.Transform.DBT.Materialization != nil {
 		if ecosConfig.Transform.DBT.Materialization.Mode != "" {
 			matMode = ecosConfig.Transform.DBT.Materialization.Mode
 		}
-		if ecosConfig.Transform.DBT.Materialization.LayerOverrides != nil {
-			if val, ok := ecosConfig.Transform.DBT.Materialization.LayerOverrides["bronze"]; ok {
-				bronzeMat = val
-			}
-			if val, ok := ecosConfig.Transform.DBT.Materialization.LayerOverrides["silver"]; ok {
-				silverMat = val
-			}
-			if val, ok := ecosConfig.Transform.DBT.Materialization.LayerOverrides["gold"]; ok {
-				goldMat = val
-			}
-		}
+		layerOverrides := ecosConfig.Transform.DBT.Materialization.LayerOverrides
+		bronzeMat = layerOverrides["bronze"]
+		silverMat = layerOverrides["silver"]
+		goldMat = layerOverrides["gold"]
+		modelMats = ecosConfig.Transform.DBT.Materialization.ModelOverrides
 	}
 
 	// Build DBTProjectTemplate
@@ -195,12 +189,13 @@ func ExtractDBTDataFromEcosConfig(ecosConfig *EcosConfig, outputPath string) (DB
 		Profile:               ecosConfig.Transform.DBT.Profile,
 		DatasourceVars:        datasourceVars,
 		IcebergEnabled:        false, // Default value
-		BillingPeriodStart:    nil,
-		BillingPeriodEnd:      nil,
+		BillingPeriodStart:    optionalString(ecosConfig.Transform.DBT.BillingPeriodStart),
+		BillingPeriodEnd:      optionalString(ecosConfig.Transform.DBT.BillingPeriodEnd),
 		MaterializationMode:   matMode,
 		BronzeMaterialization: bronzeMat,
 		SilverMaterialization: silverMat,
 		GoldMaterialization:   goldMat,
+		ModelMaterializations: modelMats,
 		UseIceberg:            false,
 		EnablePartitioning:    true,
 	}
@@ -226,11 +221,22 @@ func ExtractDBTDataFromEcosConfig(ecosConfig *EcosConfig, outputPath string) (DB
 		ResultsBucket: ecosConfig.AWS.ResultsBucket,
 		Database:      ecosConfig.AWS.Database,
 		Workgroup:     ecosConfig.AWS.DBTWorkgroup,
+
+		InjectedCredentials: ecosConfig.AWS.UsesInjectedCredentials(),
+		AthenaEndpointURL:   ecosConfig.AWS.Endpoints.Athena,
 	}
 
 	return dbtProjectData, dbtProfilesData, nil
 }
 
+// optionalString returns nil for an empty value, which the templates render as null
+func optionalString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
 // compareFileWithExpected compares an existing file with expected content and returns a diff report
 func compareFileWithExpected(existingPath, expectedContent, filename string) (*FileDiffReport, error) {
 	report := &FileDiffReport{
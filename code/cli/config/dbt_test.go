@@ -3,8 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestGenerateDBTProfilesFromTemplate(t *testing.T) {
@@ -31,6 +34,50 @@ func TestGenerateDBTProfilesFromTemplate(t *testing.T) {
 	}
 }
 
+func TestGenerateDBTProfilesFromTemplate_InjectedCredentials(t *testing.T) {
+	data := DBTProfilesTemplate{
+		Profile:             "ecos-athena",
+		Target:              "prod",
+		AWSProfile:          "payer",
+		AWSRegion:           "us-east-1",
+		ResultsBucket:       "my-bucket",
+		Database:            "my_db",
+		Workgroup:           "my-wg",
+		InjectedCredentials: true,
+	}
+
+	out, err := generateDBTProfilesFromTemplate(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(out, "aws_profile_name") {
+		t.Errorf("expected aws_profile_name to be omitted when credentials are injected")
+	}
+}
+
+func TestGenerateDBTProfilesFromTemplate_EndpointURL(t *testing.T) {
+	data := DBTProfilesTemplate{
+		Profile:           "ecos-athena",
+		Target:            "prod",
+		AWSProfile:        "default",
+		AWSRegion:         "us-east-1",
+		ResultsBucket:     "my-bucket",
+		Database:          "my_db",
+		Workgroup:         "my-wg",
+		AthenaEndpointURL: "http://localhost:4566",
+	}
+
+	out, err := generateDBTProfilesFromTemplate(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(out, "endpoint_url: http://localhost:4566") {
+		t.Errorf("expected athena endpoint_url in output")
+	}
+}
+
 func TestGenerateDBTProjectFromTemplate(t *testing.T) {
 	data := DBTProjectTemplate{
 		Profile:               "ecos-athena",
@@ -102,6 +149,28 @@ func TestGenerateDBTProject_FileOutput(t *testing.T) {
 	}
 }
 
+func TestGenerateDBTProjectFromTemplate_BillingPeriod(t *testing.T) {
+	out, err := generateDBTProjectFromTemplate(DBTProjectTemplate{Profile: "ecos-athena"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "billing_period_start: null") || !strings.Contains(out, "billing_period_end: null") {
+		t.Errorf("expected null billing periods when unset")
+	}
+
+	out, err = generateDBTProjectFromTemplate(DBTProjectTemplate{
+		Profile:            "ecos-athena",
+		BillingPeriodStart: "2024-01",
+		BillingPeriodEnd:   "2025-06",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, `billing_period_start: "2024-01"`) || !strings.Contains(out, `billing_period_end: "2025-06"`) {
+		t.Errorf("expected quoted billing periods in output")
+	}
+}
+
 func TestExtractDBTDataFromEcosConfig(t *testing.T) {
 	cfg := &EcosConfig{
 		Transform: TransformConfig{
@@ -119,6 +188,7 @@ func TestExtractDBTDataFromEcosConfig(t *testing.T) {
 						"gold":   "table",
 					},
 				},
+				BillingPeriodStart: "2024-01",
 			},
 		},
 		AWS: AWSRootConfig{
@@ -137,6 +207,9 @@ func TestExtractDBTDataFromEcosConfig(t *testing.T) {
 	if project.MaterializationMode != "table" {
 		t.Errorf("expected table mode")
 	}
+	if project.BillingPeriodStart != "2024-01" || project.BillingPeriodEnd != nil {
+		t.Errorf("billing periods = %v, %v, want 2024-01 and nil", project.BillingPeriodStart, project.BillingPeriodEnd)
+	}
 	if profiles.Workgroup != "wg-x" {
 		t.Errorf("workgroup mismatch")
 	}
@@ -224,13 +297,10 @@ transform:
 
 	// Generate expected files
 	projectData := DBTProjectTemplate{
-		Profile:               "ecos-athena",
-		DatasourceVars:        []DatasourceVar{},
-		MaterializationMode:   "view",
-		BronzeMaterialization: "view",
-		SilverMaterialization: "view",
-		GoldMaterialization:   "view",
-		EnablePartitioning:    true,
+		Profile:             "ecos-athena",
+		DatasourceVars:      []DatasourceVar{},
+		MaterializationMode: "view",
+		EnablePartitioning:  true,
 	}
 	profilesData := DBTProfilesTemplate{
 		Profile:       "ecos-athena",
@@ -310,3 +380,128 @@ transform:
 		t.Fatalf("expected dbt_project.yml report")
 	}
 }
+
+func TestMaterializationConfig_Resolve(t *testing.T) {
+	smart := &MaterializationConfig{
+		Mode:           "smart",
+		LayerOverrides: map[string]string{"silver": "table"},
+		ModelOverrides: map[string]string{"silver_aws__cur_enhanced": "incremental", "gold_core__service_daily": "bogus"},
+	}
+	tests := []struct {
+		name         string
+		cfg          *MaterializationConfig
+		model        string
+		layer        string
+		modelDefault string
+		want         string
+	}{
+		{"no config is views", nil, "gold_a", "gold", "incremental", "view"},
+		{"view mode", &MaterializationConfig{Mode: "view"}, "gold_a", "gold", "incremental", "view"},
+		{"other modes keep the model default", smart, "gold_a", "gold", "incremental", "incremental"},
+		{"models without a default are views", smart, "bronze_a", "bronze", "", "view"},
+		{"layer override wins over the mode", smart, "silver_a", "silver", "incremental", "table"},
+		{"override of a view mode", &MaterializationConfig{LayerOverrides: map[string]string{"gold": "table"}}, "gold_a", "gold", "view", "table"},
+		{"model override wins over the layer", smart, "silver_aws__cur_enhanced", "silver", "view", "incremental"},
+		{"invalid values are views", smart, "gold_core__service_daily", "gold", "table", "view"},
+		{"serve follows the same rules", &MaterializationConfig{Mode: "smart"}, "serve_meta__account_metadata", "serve", "table", "table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Resolve(tt.model, tt.layer, tt.modelDefault); got != tt.want {
+				t.Errorf("Resolve(%q, %q, %q) = %q, want %q", tt.model, tt.layer, tt.modelDefault, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMaterializationVars checks that dbt_project.yml sets the vars the models'
+// get_materialization_type macro reads, so .ecos.yaml settings reach dbt
+func TestMaterializationVars(t *testing.T) {
+	macro, err := os.ReadFile(filepath.Join("..", "..", "dbt", "macros", "materialization", "utils", "_get_materialization_type.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := regexp.MustCompile(`var\('(\w+)'`).FindAllStringSubmatch(string(macro), -1)
+	if len(names) == 0 {
+		t.Fatal("no vars found in get_materialization_type")
+	}
+
+	tmp := t.TempDir()
+	data := DBTProjectTemplate{
+		Profile:               "ecos-athena",
+		MaterializationMode:   "smart",
+		GoldMaterialization:   "table",
+		ModelMaterializations: map[string]string{"silver_aws__cur_enhanced": "incremental"},
+	}
+	if err := GenerateDBTProject(data, tmp); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(filepath.Join(tmp, "dbt_project.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var project struct {
+		Vars map[string]any `yaml:"vars"`
+	}
+	if err := yaml.Unmarshal(raw, &project); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if _, ok := project.Vars[name[1]]; !ok {
+			t.Errorf("dbt_project.yml does not set var %q read by get_materialization_type", name[1])
+		}
+	}
+	if got := project.Vars["materialization_overrides"].(map[string]any)["silver_aws__cur_enhanced"]; got != "incremental" {
+		t.Errorf("materialization_overrides = %v", project.Vars["materialization_overrides"])
+	}
+	if layers := project.Vars["materialization_layer_overrides"].(map[string]any); len(layers) != 1 || layers["gold"] != "table" {
+		t.Errorf("materialization_layer_overrides = %v, want gold only", layers)
+	}
+}
+
+func TestGeneratedConfig_SmartMode(t *testing.T) {
+	content, err := generateEcosConfigFromTemplate(EcosConfigTemplate{
+		ProjectName:         "my-test",
+		ProjectDir:          "transform/dbt",
+		AWSRegion:           "us-east-1",
+		ResultsBucket:       "my-bucket",
+		MaterializationMode: "view",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The user switches the generated config to smart mode, and nothing else
+	smart := strings.Replace(content, "mode: view", "mode: smart", 1)
+	if smart == content {
+		t.Fatal("generated .ecos.yaml has no 'mode: view'")
+	}
+	tmp := t.TempDir()
+	cfgFile := filepath.Join(tmp, ".ecos.yaml")
+	if err := os.WriteFile(cfgFile, []byte(smart), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := cfg.Transform.DBT.Materialization
+	if m == nil || len(m.LayerOverrides) != 0 {
+		t.Fatalf("generated materialization = %+v, want no layer overrides", m)
+	}
+	if got := m.Resolve("gold_core__service_daily", "gold", "incremental"); got != "incremental" {
+		t.Errorf("Resolve() in smart mode = %q, want the model default", got)
+	}
+
+	project, _, err := ExtractDBTDataFromEcosConfig(cfg, tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := generateDBTProjectFromTemplate(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rendered, `materialization_mode: "smart"`) || !strings.Contains(rendered, "materialization_layer_overrides: {}") {
+		t.Errorf("dbt_project.yml vars in smart mode:\n%s", rendered)
+	}
+}
@@ -4,13 +4,24 @@ package config
 type EcosConfig struct {
 	ProjectName  string `yaml:"project_name,omitempty" mapstructure:"project_name"`
 	ModelVersion string `yaml:"model_version,omitempty" mapstructure:"model_version"`
+	ModelSource  string `yaml:"model_source,omitempty" mapstructure:"model_source"`
 	DataSource   string `yaml:"data_source,omitempty" mapstructure:"data_source"`
 
 	Global    GlobalConfig    `yaml:"global" mapstructure:"global"`
 	Ingest    IngestConfig    `yaml:"ingest" mapstructure:"ingest"`
 	Transform TransformConfig `yaml:"transform" mapstructure:"transform"`
 	Report    ReportConfig    `yaml:"report" mapstructure:"report"`
+	Verify    VerifyConfig    `yaml:"verify,omitempty" mapstructure:"verify"`
+	Pipeline  PipelineConfig  `yaml:"pipeline,omitempty" mapstructure:"pipeline"`
+	Serve     ServeConfig     `yaml:"serve,omitempty" mapstructure:"serve"`
+	MCP       MCPConfig       `yaml:"mcp,omitempty" mapstructure:"mcp"`
 	AWS       AWSRootConfig   `yaml:"aws,omitempty" mapstructure:"aws"`
+	Lock      LockConfig      `yaml:"lock,omitempty" mapstructure:"lock"`
+}
+
+// LockConfig controls the check of the runtime against .ecos.lock before transforms
+type LockConfig struct {
+	Mode string `yaml:"mode,omitempty" mapstructure:"mode"` // warn (default), fail or off
 }
 
 // GlobalConfig contains global settings that apply across all commands
@@ -53,6 +64,56 @@ type ReportConfig struct {
 	DataSources []DataSourceConfig     `yaml:"data_sources,omitempty" mapstructure:"data_sources"`
 }
 
+// VerifyConfig contains the tolerances of the reconciliation checks run by 'ecos verify'.
+// A difference passes when it is within either tolerance.
+type VerifyConfig struct {
+	ToleranceAbsolute float64  `yaml:"tolerance_absolute,omitempty" mapstructure:"tolerance_absolute"` // in the billing currency, default 0.01
+	TolerancePercent  float64  `yaml:"tolerance_percent,omitempty" mapstructure:"tolerance_percent"`   // of the CUR total, e.g. 0.1
+	Checks            []string `yaml:"checks,omitempty" mapstructure:"checks"`                         // default: all checks
+	ReportPath        string   `yaml:"report_path,omitempty" mapstructure:"report_path"`
+}
+
+// PipelineConfig describes the stages 'ecos run' executes
+type PipelineConfig struct {
+	// OnFailure is "stop" (default) to skip every remaining stage after a failure, or
+	// "continue" to skip only the stages that depend on the failed one
+	OnFailure  string                `yaml:"on_failure,omitempty" mapstructure:"on_failure"`
+	ReportPath string                `yaml:"report_path,omitempty" mapstructure:"report_path"`
+	Stages     []PipelineStageConfig `yaml:"stages,omitempty" mapstructure:"stages"`
+}
+
+// PipelineStageConfig is one stage of the pipeline
+type PipelineStageConfig struct {
+	Name       string   `yaml:"name" mapstructure:"name"`
+	Type       string   `yaml:"type" mapstructure:"type"`                         // ingest, transform, verify or report
+	Command    string   `yaml:"command,omitempty" mapstructure:"command"`         // e.g. run or test for transform stages
+	Args       []string `yaml:"args,omitempty" mapstructure:"args"`               // passed through to the tool
+	DependsOn  []string `yaml:"depends_on,omitempty" mapstructure:"depends_on"`   // stages that must succeed first
+	Retries    int      `yaml:"retries,omitempty" mapstructure:"retries"`         // extra attempts after a failure
+	RetryDelay string   `yaml:"retry_delay,omitempty" mapstructure:"retry_delay"` // e.g. "30s"
+	Timeout    string   `yaml:"timeout,omitempty" mapstructure:"timeout"`         // per attempt, e.g. "2h"
+}
+
+// ServeConfig contains the settings of the HTTP API served by 'ecos serve api'. The API
+// keys are read from an environment variable so they are never written to .ecos.yaml.
+type ServeConfig struct {
+	Listen         string `yaml:"listen,omitempty" mapstructure:"listen"`                     // host:port, default 127.0.0.1:8080
+	APIKeysEnv     string `yaml:"api_keys_env,omitempty" mapstructure:"api_keys_env"`         // comma-separated keys, default ECOS_API_KEYS
+	CacheTTL       string `yaml:"cache_ttl,omitempty" mapstructure:"cache_ttl"`               // results of the open billing period, default 15m
+	ClosedCacheTTL string `yaml:"closed_cache_ttl,omitempty" mapstructure:"closed_cache_ttl"` // results of closed billing periods, default 24h
+	MaxRows        int    `yaml:"max_rows,omitempty" mapstructure:"max_rows"`                 // per response, default 10000
+}
+
+// MCPConfig contains the settings of the MCP server run by 'ecos mcp'. The duckdb engine
+// queries a local database built from the same models, e.g. for testing.
+type MCPConfig struct {
+	Engine       string `yaml:"engine,omitempty" mapstructure:"engine"`                 // athena (default) or duckdb
+	DuckDBPath   string `yaml:"duckdb_path,omitempty" mapstructure:"duckdb_path"`       // database file of the duckdb engine
+	DuckDBSchema string `yaml:"duckdb_schema,omitempty" mapstructure:"duckdb_schema"`   // schema of the models, default main
+	MaxScanBytes int64  `yaml:"max_scan_bytes,omitempty" mapstructure:"max_scan_bytes"` // per Athena query, default 10 GiB
+	MaxRows      int    `yaml:"max_rows,omitempty" mapstructure:"max_rows"`             // per query result, default 500
+}
+
 // AWSConfig contains AWS-specific configuration settings (used in IngestConfig).
 type AWSConfig struct {
 	Bucket    string `yaml:"bucket,omitempty" mapstructure:"bucket"`
@@ -69,6 +130,56 @@ type AWSRootConfig struct {
 	DBTWorkgroup   string `yaml:"dbt_workgroup,omitempty" mapstructure:"dbt_workgroup"`
 	AdhocWorkgroup string `yaml:"adhoc_workgroup,omitempty" mapstructure:"adhoc_workgroup"`
 	ResultsBucket  string `yaml:"results_bucket,omitempty" mapstructure:"results_bucket"`
+
+	// DataExport records the BCM Data Export created by 'ecos init', so 'ecos destroy' can remove it
+	DataExport *DataExportConfig `yaml:"data_export,omitempty" mapstructure:"data_export"`
+
+	AWSAccessConfig `yaml:",inline" mapstructure:",squash"`
+}
+
+// DataExportConfig describes an ecos-created BCM Data Export (CUR 2.0 or FOCUS) and
+// the Glue table defined over its Parquet files in the results bucket
+type DataExportConfig struct {
+	Name         string `yaml:"name" mapstructure:"name"`
+	Type         string `yaml:"type" mapstructure:"type"` // cur2 or focus
+	S3Prefix     string `yaml:"s3_prefix" mapstructure:"s3_prefix"`
+	GlueDatabase string `yaml:"glue_database" mapstructure:"glue_database"`
+	GlueTable    string `yaml:"glue_table" mapstructure:"glue_table"`
+}
+
+// AWSAccessConfig contains credential and endpoint settings applied on top of transform.dbt.aws_profile.
+// SSO and MFA settings that live in the named profile itself are honored as well.
+type AWSAccessConfig struct {
+	AssumeRole        AssumeRoleConfig   `yaml:"assume_role,omitempty" mapstructure:"assume_role"`
+	CredentialProcess string             `yaml:"credential_process,omitempty" mapstructure:"credential_process"`
+	Endpoints         AWSEndpointsConfig `yaml:"endpoints,omitempty" mapstructure:"endpoints"`
+}
+
+// AWSEndpointsConfig overrides the endpoint_url of individual AWS services
+// (e.g. LocalStack or VPC interface endpoints). Empty values use the AWS default.
+type AWSEndpointsConfig struct {
+	S3             string `yaml:"s3,omitempty" mapstructure:"s3"`
+	Athena         string `yaml:"athena,omitempty" mapstructure:"athena"`
+	Glue           string `yaml:"glue,omitempty" mapstructure:"glue"`
+	STS            string `yaml:"sts,omitempty" mapstructure:"sts"`
+	IAM            string `yaml:"iam,omitempty" mapstructure:"iam"`
+	BCMDataExports string `yaml:"bcm_data_exports,omitempty" mapstructure:"bcm_data_exports"`
+	S3UsePathStyle bool   `yaml:"s3_use_path_style,omitempty" mapstructure:"s3_use_path_style"`
+}
+
+// AssumeRoleConfig describes an IAM role ecos assumes (e.g. in a payer account)
+type AssumeRoleConfig struct {
+	RoleARN     string `yaml:"role_arn,omitempty" mapstructure:"role_arn"`
+	ExternalID  string `yaml:"external_id,omitempty" mapstructure:"external_id"`
+	SessionName string `yaml:"session_name,omitempty" mapstructure:"session_name"`
+	Duration    string `yaml:"duration,omitempty" mapstructure:"duration"` // e.g. "1h"
+	MFASerial   string `yaml:"mfa_serial,omitempty" mapstructure:"mfa_serial"`
+}
+
+// UsesInjectedCredentials reports whether ecos resolves credentials itself (role or
+// credential_process) and hands them to dbt, instead of dbt reading the named profile
+func (a AWSAccessConfig) UsesInjectedCredentials() bool {
+	return a.AssumeRole.RoleARN != "" || a.CredentialProcess != ""
 }
 
 // GCPConfig contains Google Cloud Platform-specific configuration settings
@@ -97,12 +208,57 @@ type DBTConfig struct {
 	AWSProfile      string                 `yaml:"aws_profile,omitempty" mapstructure:"aws_profile"`
 	Vars            map[string]string      `yaml:"vars,omitempty" mapstructure:"vars"`
 	Materialization *MaterializationConfig `yaml:"materialization,omitempty" mapstructure:"materialization"`
+
+	// CustomDir is a user-owned overlay of models, macros and seeds merged into the dbt
+	// project before every transform, relative to the ecos project directory
+	CustomDir string `yaml:"custom_dir,omitempty" mapstructure:"custom_dir"`
+
+	// BillingPeriodStart and BillingPeriodEnd (YYYY-MM) bound the billing periods the models
+	// process. Empty values use the models' lookback window and the current month.
+	BillingPeriodStart string `yaml:"billing_period_start,omitempty" mapstructure:"billing_period_start"`
+	BillingPeriodEnd   string `yaml:"billing_period_end,omitempty" mapstructure:"billing_period_end"`
 }
 
-// MaterializationConfig contains materialization settings for dbt models
+// MaterializationConfig contains materialization settings for dbt models. They are
+// written to dbt_project.yml as the vars the get_materialization_type macro reads.
 type MaterializationConfig struct {
 	Mode           string            `yaml:"mode,omitempty" mapstructure:"mode"`
 	LayerOverrides map[string]string `yaml:"layer_overrides,omitempty" mapstructure:"layer_overrides"`
+	ModelOverrides map[string]string `yaml:"model_overrides,omitempty" mapstructure:"model_overrides"`
+}
+
+// Resolve returns the materialization of a model of the layer whose SQL declares
+// modelDefault, the way the get_materialization_type macro does: a model override wins,
+// then a layer override; otherwise the view mode, the default, makes every model a view
+// and other modes keep the model's default. Invalid values resolve to a view.
+func (m *MaterializationConfig) Resolve(model, layer, modelDefault string) string {
+	if modelDefault == "" {
+		modelDefault = "view"
+	}
+	mode := "view"
+	result := ""
+	if m != nil {
+		if m.Mode != "" {
+			mode = m.Mode
+		}
+		if override, ok := m.ModelOverrides[model]; ok {
+			result = override
+		} else if override := m.LayerOverrides[layer]; override != "" {
+			result = override
+		}
+	}
+	if result == "" {
+		result = modelDefault
+		if mode == "view" {
+			result = "view"
+		}
+	}
+	switch result {
+	case "view", "table", "incremental":
+		return result
+	default:
+		return "view"
+	}
 }
 
 // SQLConfig contains SQL-based transformation configuration settings
@@ -135,13 +291,17 @@ type DatasourceVar struct {
 
 // DBTProfilesTemplate represents template data for dbt profiles.yml
 type DBTProfilesTemplate struct {
-	Profile       string
-	Target        string
-	AWSProfile    string
-	AWSRegion     string
-	ResultsBucket string
-	Database      string
-	Workgroup     string
+	Profile    string
+	Target     string
+	AWSProfile string
+	// InjectedCredentials omits aws_profile_name; ecos passes resolved credentials to dbt instead
+	InjectedCredentials bool
+	// AthenaEndpointURL is the optional Athena endpoint_url for dbt-athena
+	AthenaEndpointURL string
+	AWSRegion         string
+	ResultsBucket     string
+	Database          string
+	Workgroup         string
 }
 
 // DBTProjectTemplate represents template data for dbt_project.yml
@@ -155,6 +315,8 @@ type DBTProjectTemplate struct {
 	BronzeMaterialization string
 	SilverMaterialization string
 	GoldMaterialization   string
+	// ModelMaterializations are the per-model overrides (materialization_overrides)
+	ModelMaterializations map[string]string
 	UseIceberg            bool
 	EnablePartitioning    bool
 }
@@ -163,6 +325,7 @@ type DBTProjectTemplate struct {
 type EcosConfigTemplate struct {
 	ProjectName           string
 	ModelVersion          string
+	ModelSource           string
 	DataSource            string
 	ProjectDir            string
 	ProfileDir            string
@@ -171,6 +334,8 @@ type EcosConfigTemplate struct {
 	AWSProfile            string
 	DatasourceVars        []DatasourceVar
 	AWSRegion             string
+	AWSAccess             AWSAccessConfig
+	DataExport            *DataExportConfig
 	Database              string
 	DBTWorkgroup          string
 	AdhocWorkgroup        string
@@ -0,0 +1,197 @@
+// Package awssession builds AWS SDK configurations for ecos from .ecos.yaml settings,
+// so every client (init, transform, destroy) authenticates the same way.
+package awssession
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/processcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+)
+
+// DefaultSessionName is used for assumed-role sessions when none is configured
+const DefaultSessionName = "ecos-cli"
+
+// ErrSSOSessionExpired is returned when the cached SSO token is missing or expired
+var ErrSSOSessionExpired = errors.New("AWS SSO session has expired or is not logged in")
+
+// Options describes how to build an AWS configuration
+type Options struct {
+	Region  string
+	Profile string
+	Access  config.AWSAccessConfig
+}
+
+// mfaTokenProvider prompts for an MFA code; replaceable in tests
+var mfaTokenProvider = func(serial string) func() (string, error) {
+	return func() (string, error) {
+		return utils.Input(fmt.Sprintf("MFA code for %s", serial), "", true, true, nil)
+	}
+}
+
+// FromConfig returns the options for a project's .ecos.yaml
+func FromConfig(cfg *config.EcosConfig) Options {
+	return Options{
+		Region:  cfg.AWS.Region,
+		Profile: cfg.Transform.DBT.AWSProfile,
+		Access:  cfg.AWS.AWSAccessConfig,
+	}
+}
+
+// profileName returns the shared-config profile to use ("default" means the SDK default chain)
+func (o Options) profileName() string {
+	if o.Profile == "default" {
+		return ""
+	}
+	return o.Profile
+}
+
+// Load builds an AWS configuration from the options. Credentials are resolved
+// eagerly so expired SSO sessions, MFA prompts and role errors surface here with
+// a clear message rather than on the first API call.
+func Load(ctx context.Context, opts Options) (aws.Config, error) {
+	if err := ValidateAccess(opts.Access); err != nil {
+		return aws.Config{}, err
+	}
+	if err := ValidateEndpoints(opts.Access.Endpoints); err != nil {
+		return aws.Config{}, err
+	}
+
+	loadOpts := []func(*awsconfig.LoadOptions) error{
+		// Profiles with role_arn + mfa_serial in ~/.aws/config prompt for the code
+		awsconfig.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
+			if o.SerialNumber != nil {
+				o.TokenProvider = mfaTokenProvider(aws.ToString(o.SerialNumber))
+			}
+		}),
+	}
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(opts.Region))
+	}
+	if profile := opts.profileName(); profile != "" {
+		loadOpts = append(loadOpts, awsconfig.WithSharedConfigProfile(profile))
+	}
+	if opts.Access.CredentialProcess != "" {
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(
+			aws.NewCredentialsCache(processcreds.NewProvider(opts.Access.CredentialProcess))))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, ExplainError(fmt.Errorf("failed to load AWS config: %w", err), opts.Profile)
+	}
+
+	if role := opts.Access.AssumeRole; role.RoleARN != "" {
+		cfg.Credentials = aws.NewCredentialsCache(assumeRoleProvider(cfg, role, opts.Access.Endpoints))
+	}
+
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		return aws.Config{}, ExplainError(fmt.Errorf("failed to retrieve AWS credentials: %w", err), opts.Profile)
+	}
+
+	return cfg, nil
+}
+
+// ValidateAccess checks that the assume-role settings are complete and well-formed
+func ValidateAccess(access config.AWSAccessConfig) error {
+	role := access.AssumeRole
+	if role.RoleARN == "" {
+		if role.ExternalID != "" || role.SessionName != "" || role.Duration != "" || role.MFASerial != "" {
+			return errors.New("aws.assume_role.role_arn is required when other assume_role settings are set")
+		}
+		return nil
+	}
+	if !strings.HasPrefix(role.RoleARN, "arn:") || !strings.Contains(role.RoleARN, ":role/") {
+		return fmt.Errorf("invalid aws.assume_role.role_arn '%s', expected arn:aws:iam::<account>:role/<name>", role.RoleARN)
+	}
+	if role.Duration != "" {
+		if _, err := time.ParseDuration(role.Duration); err != nil {
+			return fmt.Errorf("invalid aws.assume_role.duration '%s': %w", role.Duration, err)
+		}
+	}
+	return nil
+}
+
+// assumeRoleProvider builds an STS assume-role provider on top of the base credentials.
+// The role settings must have passed ValidateAccess.
+func assumeRoleProvider(base aws.Config, role config.AssumeRoleConfig, endpoints config.AWSEndpointsConfig) *stscreds.AssumeRoleProvider {
+	duration, _ := time.ParseDuration(role.Duration)
+
+	sessionName := role.SessionName
+	if sessionName == "" {
+		sessionName = DefaultSessionName
+	}
+
+	return stscreds.NewAssumeRoleProvider(NewSTSClient(base, endpoints), role.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		if role.ExternalID != "" {
+			o.ExternalID = aws.String(role.ExternalID)
+		}
+		if duration > 0 {
+			o.Duration = duration
+		}
+		if role.MFASerial != "" {
+			o.SerialNumber = aws.String(role.MFASerial)
+			o.TokenProvider = mfaTokenProvider(role.MFASerial)
+		}
+	})
+}
+
+// ExplainError rewrites credential errors the user can fix themselves (such as an
+// expired SSO session) into actionable messages. Other errors are returned unchanged.
+func ExplainError(err error, profile string) error {
+	if err == nil || !isSSOTokenError(err) {
+		return err
+	}
+
+	login := "aws sso login"
+	if profile != "" && profile != "default" {
+		login += " --profile " + profile
+	}
+	return fmt.Errorf("%w: run '%s' and try again (%v)", ErrSSOSessionExpired, login, err)
+}
+
+func isSSOTokenError(err error) bool {
+	var invalidToken *ssocreds.InvalidTokenError
+	if errors.As(err, &invalidToken) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "cached SSO token is expired") ||
+		strings.Contains(msg, "refresh cached SSO token failed") ||
+		strings.Contains(msg, "failed to read cached SSO token file")
+}
+
+// CredentialEnv resolves the credentials of cfg into AWS_* environment variables,
+// used to hand assumed-role or credential_process credentials to dbt. expires is zero
+// when the credentials do not expire.
+func CredentialEnv(ctx context.Context, cfg aws.Config) (env []string, expires time.Time, err error) {
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	if creds.CanExpire {
+		expires = creds.Expires
+	}
+
+	env = []string{
+		"AWS_ACCESS_KEY_ID=" + creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + creds.SecretAccessKey,
+	}
+	if creds.SessionToken != "" {
+		env = append(env, "AWS_SESSION_TOKEN="+creds.SessionToken)
+	}
+	if cfg.Region != "" {
+		env = append(env, "AWS_REGION="+cfg.Region, "AWS_DEFAULT_REGION="+cfg.Region)
+	}
+	return env, expires, nil
+}
@@ -0,0 +1,163 @@
+package awssession
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.EcosConfig{}
+	cfg.AWS.Region = "eu-west-1"
+	cfg.Transform.DBT.AWSProfile = "tooling"
+	cfg.AWS.AssumeRole.RoleARN = "arn:aws:iam::111122223333:role/ecos"
+
+	opts := FromConfig(cfg)
+	if opts.Region != "eu-west-1" || opts.Profile != "tooling" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+	if opts.Access.AssumeRole.RoleARN != "arn:aws:iam::111122223333:role/ecos" {
+		t.Errorf("assume role not carried over: %+v", opts.Access)
+	}
+}
+
+func TestValidateAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		access  config.AWSAccessConfig
+		wantErr bool
+	}{
+		{"empty", config.AWSAccessConfig{}, false},
+		{"credential process only", config.AWSAccessConfig{CredentialProcess: "vault-aws"}, false},
+		{
+			name: "full role",
+			access: config.AWSAccessConfig{AssumeRole: config.AssumeRoleConfig{
+				RoleARN:     "arn:aws:iam::111122223333:role/ecos",
+				ExternalID:  "ext",
+				SessionName: "ci",
+				Duration:    "1h",
+				MFASerial:   "arn:aws:iam::444455556666:mfa/alice",
+			}},
+			wantErr: false,
+		},
+		{
+			name:    "external id without role",
+			access:  config.AWSAccessConfig{AssumeRole: config.AssumeRoleConfig{ExternalID: "ext"}},
+			wantErr: true,
+		},
+		{
+			name:    "malformed role arn",
+			access:  config.AWSAccessConfig{AssumeRole: config.AssumeRoleConfig{RoleARN: "ecos-role"}},
+			wantErr: true,
+		},
+		{
+			name: "invalid duration",
+			access: config.AWSAccessConfig{AssumeRole: config.AssumeRoleConfig{
+				RoleARN:  "arn:aws:iam::111122223333:role/ecos",
+				Duration: "an hour",
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAccess(tt.access)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExplainError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		profile   string
+		wantSSO   bool
+		wantLogin string
+	}{
+		{
+			name:      "invalid token type",
+			err:       fmt.Errorf("retrieve: %w", &ssocreds.InvalidTokenError{}),
+			profile:   "payer",
+			wantSSO:   true,
+			wantLogin: "aws sso login --profile payer",
+		},
+		{
+			name:      "expired cached token message",
+			err:       errors.New("cached SSO token is expired, or not present, and cannot be refreshed"),
+			profile:   "default",
+			wantSSO:   true,
+			wantLogin: "run 'aws sso login'",
+		},
+		{
+			name:    "unrelated error",
+			err:     errors.New("AccessDenied"),
+			wantSSO: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExplainError(tt.err, tt.profile)
+			if errors.Is(got, ErrSSOSessionExpired) != tt.wantSSO {
+				t.Fatalf("ExplainError() = %v, wantSSO %v", got, tt.wantSSO)
+			}
+			if !tt.wantSSO && !errors.Is(got, tt.err) {
+				t.Errorf("ExplainError() should return unrelated errors unchanged, got %v", got)
+			}
+			if tt.wantLogin != "" && !strings.Contains(got.Error(), tt.wantLogin) {
+				t.Errorf("ExplainError() = %q, want it to contain %q", got.Error(), tt.wantLogin)
+			}
+		})
+	}
+
+	if ExplainError(nil, "x") != nil {
+		t.Error("ExplainError(nil) should be nil")
+	}
+}
+
+func TestCredentialEnv(t *testing.T) {
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "TOKEN"),
+	}
+
+	env, expires, err := CredentialEnv(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("CredentialEnv() error = %v", err)
+	}
+	if !expires.IsZero() {
+		t.Errorf("CredentialEnv() expires = %v for static credentials", expires)
+	}
+
+	for _, want := range []string{
+		"AWS_ACCESS_KEY_ID=AKID",
+		"AWS_SECRET_ACCESS_KEY=SECRET",
+		"AWS_SESSION_TOKEN=TOKEN",
+		"AWS_REGION=us-east-1",
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("CredentialEnv() missing %q", want)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidAccess(t *testing.T) {
+	_, err := Load(context.Background(), Options{
+		Access: config.AWSAccessConfig{AssumeRole: config.AssumeRoleConfig{MFASerial: "arn:aws:iam::1:mfa/x"}},
+	})
+	if err == nil {
+		t.Error("Load() expected error for assume_role settings without role_arn, got nil")
+	}
+}
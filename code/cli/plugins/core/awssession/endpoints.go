@@ -0,0 +1,153 @@
+package awssession
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/aws-sdk-go-v2/service/bcmdataexports"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+// EndpointURLEnv overrides the endpoint of every AWS service (e.g. http://localhost:4566
+// for LocalStack). S3 is addressed path-style when it is set.
+const EndpointURLEnv = "ECOS_AWS_ENDPOINT_URL"
+
+// AWS services ecos talks to
+const (
+	ServiceS3     = "s3"
+	ServiceAthena = "athena"
+	ServiceGlue   = "glue"
+	ServiceSTS    = "sts"
+	ServiceIAM    = "iam"
+
+	ServiceBCMDataExports = "bcm-data-exports"
+)
+
+// BCMDataExportsRegion is the only region serving the BCM Data Exports API
+const BCMDataExportsRegion = "us-east-1"
+
+// EndpointURL returns the endpoint configured for service, or "" for the AWS default.
+// ECOS_AWS_ENDPOINT_URL takes precedence over the per-service settings.
+func EndpointURL(endpoints config.AWSEndpointsConfig, service string) string {
+	if override := strings.TrimSpace(os.Getenv(EndpointURLEnv)); override != "" {
+		return override
+	}
+
+	switch service {
+	case ServiceS3:
+		return endpoints.S3
+	case ServiceAthena:
+		return endpoints.Athena
+	case ServiceGlue:
+		return endpoints.Glue
+	case ServiceSTS:
+		return endpoints.STS
+	case ServiceIAM:
+		return endpoints.IAM
+	case ServiceBCMDataExports:
+		return endpoints.BCMDataExports
+	default:
+		return ""
+	}
+}
+
+// ValidateEndpoints checks that every configured endpoint is an absolute http(s) URL
+func ValidateEndpoints(endpoints config.AWSEndpointsConfig) error {
+	for _, service := range []string{ServiceS3, ServiceAthena, ServiceGlue, ServiceSTS, ServiceIAM, ServiceBCMDataExports} {
+		raw := EndpointURL(endpoints, service)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s endpoint URL '%s', expected http(s)://host[:port]", service, raw)
+		}
+	}
+	return nil
+}
+
+// s3UsePathStyle reports whether S3 requests should use path-style addressing
+func s3UsePathStyle(endpoints config.AWSEndpointsConfig) bool {
+	return endpoints.S3UsePathStyle || os.Getenv(EndpointURLEnv) != ""
+}
+
+// NewS3Client creates an S3 client honoring the configured endpoint
+func NewS3Client(cfg aws.Config, endpoints config.AWSEndpointsConfig) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := EndpointURL(endpoints, ServiceS3); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = s3UsePathStyle(endpoints)
+	})
+}
+
+// NewAthenaClient creates an Athena client honoring the configured endpoint
+func NewAthenaClient(cfg aws.Config, endpoints config.AWSEndpointsConfig) *athena.Client {
+	return athena.NewFromConfig(cfg, func(o *athena.Options) {
+		if endpoint := EndpointURL(endpoints, ServiceAthena); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+}
+
+// NewGlueClient creates a Glue client honoring the configured endpoint
+func NewGlueClient(cfg aws.Config, endpoints config.AWSEndpointsConfig) *glue.Client {
+	return glue.NewFromConfig(cfg, func(o *glue.Options) {
+		if endpoint := EndpointURL(endpoints, ServiceGlue); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+}
+
+// NewSTSClient creates an STS client honoring the configured endpoint
+func NewSTSClient(cfg aws.Config, endpoints config.AWSEndpointsConfig) *sts.Client {
+	return sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if endpoint := EndpointURL(endpoints, ServiceSTS); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+}
+
+// NewIAMClient creates an IAM client honoring the configured endpoint
+func NewIAMClient(cfg aws.Config, endpoints config.AWSEndpointsConfig) *iam.Client {
+	return iam.NewFromConfig(cfg, func(o *iam.Options) {
+		if endpoint := EndpointURL(endpoints, ServiceIAM); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+}
+
+// NewBCMDataExportsClient creates a BCM Data Exports client honoring the configured
+// endpoint. The client always targets BCMDataExportsRegion.
+func NewBCMDataExportsClient(cfg aws.Config, endpoints config.AWSEndpointsConfig) *bcmdataexports.Client {
+	return bcmdataexports.NewFromConfig(cfg, func(o *bcmdataexports.Options) {
+		o.Region = BCMDataExportsRegion
+		if endpoint := EndpointURL(endpoints, ServiceBCMDataExports); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+}
+
+// EndpointEnv returns AWS_ENDPOINT_URL_* variables so boto3-based tools such as dbt
+// reach the same endpoints as ecos
+func EndpointEnv(endpoints config.AWSEndpointsConfig) []string {
+	if override := strings.TrimSpace(os.Getenv(EndpointURLEnv)); override != "" {
+		return []string{"AWS_ENDPOINT_URL=" + override}
+	}
+
+	var env []string
+	for _, service := range []string{ServiceS3, ServiceAthena, ServiceGlue, ServiceSTS} {
+		if endpoint := EndpointURL(endpoints, service); endpoint != "" {
+			env = append(env, "AWS_ENDPOINT_URL_"+strings.ToUpper(service)+"="+endpoint)
+		}
+	}
+	return env
+}
@@ -0,0 +1,105 @@
+package awssession
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+func TestEndpointURL(t *testing.T) {
+	endpoints := config.AWSEndpointsConfig{
+		S3:     "https://bucket.vpce-123.s3.us-east-1.vpce.amazonaws.com",
+		Athena: "https://vpce-456.athena.us-east-1.vpce.amazonaws.com",
+	}
+
+	tests := []struct {
+		name     string
+		env      string
+		service  string
+		expected string
+	}{
+		{"per-service s3", "", ServiceS3, endpoints.S3},
+		{"per-service athena", "", ServiceAthena, endpoints.Athena},
+		{"unset service uses default", "", ServiceGlue, ""},
+		{"unknown service", "", "ec2", ""},
+		{"env override wins", "http://localhost:4566", ServiceS3, "http://localhost:4566"},
+		{"env override applies to unset services", "http://localhost:4566", ServiceSTS, "http://localhost:4566"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EndpointURLEnv, tt.env)
+			if got := EndpointURL(endpoints, tt.service); got != tt.expected {
+				t.Errorf("EndpointURL(%q) = %q, want %q", tt.service, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateEndpoints(t *testing.T) {
+	t.Setenv(EndpointURLEnv, "")
+
+	tests := []struct {
+		name      string
+		endpoints config.AWSEndpointsConfig
+		wantErr   bool
+	}{
+		{"empty", config.AWSEndpointsConfig{}, false},
+		{"valid", config.AWSEndpointsConfig{S3: "http://localhost:4566", Glue: "https://glue.example.internal"}, false},
+		{"missing scheme", config.AWSEndpointsConfig{Athena: "localhost:4566"}, true},
+		{"unsupported scheme", config.AWSEndpointsConfig{STS: "ftp://localhost"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEndpoints(tt.endpoints)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEndpoints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewS3ClientEndpoint(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1"}
+
+	t.Run("configured", func(t *testing.T) {
+		t.Setenv(EndpointURLEnv, "")
+		opts := NewS3Client(cfg, config.AWSEndpointsConfig{S3: "http://minio:9000", S3UsePathStyle: true}).Options()
+		if aws.ToString(opts.BaseEndpoint) != "http://minio:9000" || !opts.UsePathStyle {
+			t.Errorf("unexpected S3 options: endpoint=%v pathStyle=%v", aws.ToString(opts.BaseEndpoint), opts.UsePathStyle)
+		}
+	})
+
+	t.Run("env override forces path style", func(t *testing.T) {
+		t.Setenv(EndpointURLEnv, "http://localhost:4566")
+		opts := NewS3Client(cfg, config.AWSEndpointsConfig{}).Options()
+		if aws.ToString(opts.BaseEndpoint) != "http://localhost:4566" || !opts.UsePathStyle {
+			t.Errorf("unexpected S3 options: endpoint=%v pathStyle=%v", aws.ToString(opts.BaseEndpoint), opts.UsePathStyle)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Setenv(EndpointURLEnv, "")
+		opts := NewAthenaClient(cfg, config.AWSEndpointsConfig{}).Options()
+		if opts.BaseEndpoint != nil {
+			t.Errorf("expected default Athena endpoint, got %v", aws.ToString(opts.BaseEndpoint))
+		}
+	})
+}
+
+func TestEndpointEnv(t *testing.T) {
+	t.Setenv(EndpointURLEnv, "")
+	env := EndpointEnv(config.AWSEndpointsConfig{Athena: "http://localhost:4566", IAM: "http://localhost:4566"})
+	if !slices.Equal(env, []string{"AWS_ENDPOINT_URL_ATHENA=http://localhost:4566"}) {
+		t.Errorf("EndpointEnv() = %v", env)
+	}
+
+	t.Setenv(EndpointURLEnv, "http://localhost:4566")
+	env = EndpointEnv(config.AWSEndpointsConfig{Athena: "http://other:1"})
+	if !slices.Equal(env, []string{"AWS_ENDPOINT_URL=http://localhost:4566"}) {
+		t.Errorf("EndpointEnv() with override = %v", env)
+	}
+}
@@ -0,0 +1,173 @@
+// Package catalog reads the model documentation of the model package: the models,
+// their descriptions and their columns, as declared in the dbt YAML files, or as
+// compiled by dbt in its manifest.
+package catalog
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelsDir is the directory of the dbt project holding the models and their docs
+const ModelsDir = "models"
+
+// Model is a documented model
+type Model struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description"`
+	Columns     []Column `yaml:"columns" json:"columns"`
+	// Path is the YAML file documenting the model
+	Path string `yaml:"-" json:"-"`
+
+	// Grain is the columns a row is unique on, from the model's
+	// unique_combination_of_columns test
+	Grain []string `yaml:"-" json:"-"`
+	// Upstream are the models, and sources as source:<schema>.<table>, the model
+	// selects from
+	Upstream []string `yaml:"-" json:"-"`
+	// Materialization is the default the model's SQL declares, or the materialization
+	// dbt resolved when the catalog is compiled
+	Materialization string `yaml:"-" json:"-"`
+}
+
+// Column is a documented model column
+type Column struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	DataType    string `yaml:"data_type" json:"data_type"`
+}
+
+// Catalog is the documented models, sorted by name
+type Catalog struct {
+	Models []Model
+	// Compiled reports whether the catalog was read from dbt's manifest, whose
+	// materializations account for the project's configuration
+	Compiled bool
+}
+
+// Load reads the model docs of the YAML files under dir, the models directory of a dbt
+// project
+func Load(dir string) (*Catalog, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("model docs not found: %w", err)
+	}
+
+	c := &Catalog{}
+	sqlFiles := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".sql" {
+			sqlFiles[strings.TrimSuffix(d.Name(), ".sql")] = path
+		}
+		if d.IsDir() || (filepath.Ext(path) != ".yml" && filepath.Ext(path) != ".yaml") {
+			return nil
+		}
+		data, err := os.ReadFile(path) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to read model docs: %w", err)
+		}
+
+		var doc struct {
+			Models []struct {
+				Model     `yaml:",inline"`
+				DataTests []any `yaml:"data_tests"`
+				Tests     []any `yaml:"tests"`
+			} `yaml:"models"`
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse model docs %s: %w", path, err)
+		}
+		for _, documented := range doc.Models {
+			m := documented.Model
+			if m.Name == "" {
+				continue
+			}
+			m.Grain = grainColumns(append(documented.DataTests, documented.Tests...))
+			if existing, ok := c.Model(m.Name); ok {
+				return fmt.Errorf("model %s is documented in both %s and %s", m.Name, existing.Path, path)
+			}
+			m.Path = path
+			m.Description = strings.TrimSpace(m.Description)
+			for i := range m.Columns {
+				m.Columns[i].Description = strings.TrimSpace(m.Columns[i].Description)
+			}
+			c.Models = append(c.Models, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range c.Models {
+		if path, ok := sqlFiles[m.Name]; ok {
+			if err := c.Models[i].readSQL(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	slices.SortFunc(c.Models, func(a, b Model) int { return strings.Compare(a.Name, b.Name) })
+	return c, nil
+}
+
+// Model returns the documented model of that name
+func (c *Catalog) Model(name string) (Model, bool) {
+	i := slices.IndexFunc(c.Models, func(m Model) bool { return m.Name == name })
+	if i < 0 {
+		return Model{}, false
+	}
+	return c.Models[i], true
+}
+
+// Filter returns the catalog of the models keep returns true for
+func (c *Catalog) Filter(keep func(Model) bool) *Catalog {
+	filtered := &Catalog{Compiled: c.Compiled}
+	for _, m := range c.Models {
+		if keep(m) {
+			filtered.Models = append(filtered.Models, m)
+		}
+	}
+	return filtered
+}
+
+// Column returns the documented column of that name
+func (m Model) Column(name string) (Column, bool) {
+	i := slices.IndexFunc(m.Columns, func(c Column) bool { return c.Name == name })
+	if i < 0 {
+		return Column{}, false
+	}
+	return m.Columns[i], true
+}
+
+// HasColumns reports whether the model documents every column
+func (m Model) HasColumns(names ...string) bool {
+	for _, name := range names {
+		if _, ok := m.Column(name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary returns the first line of the model description
+func (m Model) Summary() string {
+	summary, _, _ := strings.Cut(m.Description, "\n")
+	return strings.TrimSpace(summary)
+}
+
+// IsMetric reports whether the column holds an additive number, e.g. a cost or count
+func (c Column) IsMetric() bool {
+	base, _, _ := strings.Cut(strings.ToLower(c.DataType), "(")
+	switch base {
+	case "decimal", "double", "float", "real", "bigint", "integer", "int":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const serveDocs = `version: 2
+
+models:
+  - name: serve_meta__account_metadata
+    description: |
+      Consolidated metadata for all AWS accounts.
+
+      Key features:
+      - Distinguishes between linked and payer accounts
+    columns:
+      - name: account_id
+        description: AWS account identifier.
+        data_type: varchar
+        tests:
+          - not_null
+      - name: last_updated
+        data_type: string
+  - name: serve_core__cost_service_account_daily
+    description: Daily cost by service and account.
+    columns:
+      - name: billing_period
+        data_type: varchar
+      - name: total_effective_cost
+        data_type: decimal(38,10)
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "4_serve"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "4_serve", "_serve_models.yml"), []byte(serveDocs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "4_serve", "model.sql"), []byte("select 1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(c.Models) != 2 || c.Models[0].Name != "serve_core__cost_service_account_daily" {
+		t.Fatalf("Load() models = %+v", c.Models)
+	}
+
+	m, ok := c.Model("serve_meta__account_metadata")
+	if !ok || m.Summary() != "Consolidated metadata for all AWS accounts." || len(m.Columns) != 2 {
+		t.Errorf("Model() = %+v, %v", m, ok)
+	}
+	if col, _ := m.Column("account_id"); col.Description != "AWS account identifier." || col.IsMetric() {
+		t.Errorf("Column(account_id) = %+v", col)
+	}
+	daily, _ := c.Model("serve_core__cost_service_account_daily")
+	if col, _ := daily.Column("total_effective_cost"); !col.IsMetric() {
+		t.Error("IsMetric() = false for a decimal column")
+	}
+	if !daily.HasColumns("billing_period") || daily.HasColumns("billing_period", "account_id") {
+		t.Error("HasColumns() does not require every column")
+	}
+
+	filtered := c.Filter(func(m Model) bool { return m.Name == "serve_meta__account_metadata" })
+	if len(filtered.Models) != 1 {
+		t.Errorf("Filter() = %+v", filtered.Models)
+	}
+
+	// A model documented twice is an error
+	if err := os.WriteFile(filepath.Join(dir, "_copy.yml"), []byte(serveDocs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Load() expected error for a model documented twice")
+	}
+	if _, err := Load(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Load() expected error for a missing directory")
+	}
+}
@@ -0,0 +1,311 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TargetDir is the directory of the dbt project where dbt writes its artifacts
+const TargetDir = "target"
+
+// Layers are the model layers, in the order data flows through them
+var Layers = []string{"bronze", "silver", "gold", "serve"}
+
+// timeGrains are the model name suffixes telling the period a row covers
+var timeGrains = []string{"hourly", "daily", "monthly", "current"}
+
+var (
+	refCall         = regexp.MustCompile(`\bref\(\s*['"]([^'"]+)['"]\s*\)`)
+	sourceCall      = regexp.MustCompile(`\bsource\(\s*['"]([^'"]+)['"]\s*,\s*['"]([^'"]+)['"]\s*\)`)
+	modelConfigCall = regexp.MustCompile(`get_model_config\(\s*(?:default_materialization\s*=\s*)?['"](\w+)['"]`)
+	materializedArg = regexp.MustCompile(`materialized\s*=\s*['"](\w+)['"]`)
+)
+
+// Layer returns the layer of the model, the first part of its name, e.g. gold for
+// gold_compute__container_daily
+func (m Model) Layer() string {
+	layer, _, _ := strings.Cut(m.Name, "_")
+	if !slices.Contains(Layers, layer) {
+		return ""
+	}
+	return layer
+}
+
+// Domain returns the domain of the model, the part of its name between the layer and
+// the entity, e.g. compute for gold_compute__container_daily
+func (m Model) Domain() string {
+	prefix, _, ok := strings.Cut(m.Name, "__")
+	if !ok {
+		return ""
+	}
+	_, domain, _ := strings.Cut(prefix, "_")
+	return domain
+}
+
+// TimeGrain returns the period a row of the model covers, from the suffix of its name,
+// or "" when the name does not tell
+func (m Model) TimeGrain() string {
+	i := strings.LastIndex(m.Name, "_")
+	if i < 0 || !slices.Contains(timeGrains, m.Name[i+1:]) {
+		return ""
+	}
+	return m.Name[i+1:]
+}
+
+// readSQL reads the upstream models and sources and the default materialization of the
+// model from its SQL file
+func (m *Model) readSQL(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to read model %s: %w", m.Name, err)
+	}
+	sql := string(data)
+
+	var upstream []string
+	for _, match := range refCall.FindAllStringSubmatch(sql, -1) {
+		upstream = append(upstream, match[1])
+	}
+	for _, match := range sourceCall.FindAllStringSubmatch(sql, -1) {
+		upstream = append(upstream, sourceName(match[1], match[2]))
+	}
+	slices.Sort(upstream)
+	m.Upstream = slices.Compact(upstream)
+
+	if match := modelConfigCall.FindStringSubmatch(sql); match != nil {
+		m.Materialization = match[1]
+	} else if match := materializedArg.FindStringSubmatch(sql); match != nil {
+		m.Materialization = match[1]
+	}
+	return nil
+}
+
+// Downstream returns the models of the catalog selecting from the model
+func (c *Catalog) Downstream(name string) []string {
+	var downstream []string
+	for _, m := range c.Models {
+		if slices.Contains(m.Upstream, name) {
+			downstream = append(downstream, m.Name)
+		}
+	}
+	return downstream
+}
+
+// grainColumns returns the columns of the unique_combination_of_columns test among the
+// model-level tests of a YAML doc
+func grainColumns(tests []any) []string {
+	for _, test := range tests {
+		definition, ok := test.(map[string]any)
+		if !ok {
+			continue
+		}
+		for name, value := range definition {
+			if name != "unique_combination_of_columns" && !strings.HasSuffix(name, ".unique_combination_of_columns") {
+				continue
+			}
+			args, _ := value.(map[string]any)
+			// dbt 1.10 nests test arguments under arguments
+			if nested, ok := args["arguments"].(map[string]any); ok {
+				args = nested
+			}
+			columns, _ := args["combination_of_columns"].([]any)
+			var grain []string
+			for _, column := range columns {
+				if s, ok := column.(string); ok {
+					grain = append(grain, s)
+				}
+			}
+			return grain
+		}
+	}
+	return nil
+}
+
+func sourceName(source, table string) string {
+	return "source:" + source + "." + table
+}
+
+// compiledManifest is the part of dbt's manifest.json the catalog reads
+type compiledManifest struct {
+	Metadata struct {
+		ProjectName string `json:"project_name"`
+	} `json:"metadata"`
+	Nodes   map[string]compiledNode `json:"nodes"`
+	Sources map[string]struct {
+		SourceName string `json:"source_name"`
+		Name       string `json:"name"`
+	} `json:"sources"`
+}
+
+type compiledNode struct {
+	ResourceType string          `json:"resource_type"`
+	PackageName  string          `json:"package_name"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	PatchPath    string          `json:"patch_path"`
+	Columns      json.RawMessage `json:"columns"`
+	Config       struct {
+		Materialized string `json:"materialized"`
+	} `json:"config"`
+	DependsOn struct {
+		Nodes []string `json:"nodes"`
+	} `json:"depends_on"`
+	AttachedNode string `json:"attached_node"`
+	TestMetadata *struct {
+		Name   string `json:"name"`
+		Kwargs struct {
+			CombinationOfColumns []string `json:"combination_of_columns"`
+		} `json:"kwargs"`
+	} `json:"test_metadata"`
+}
+
+// LoadCompiled reads the models of the dbt project in dbtDir from the manifest dbt wrote
+// at its last parse. It returns nil without an error when there is none.
+func LoadCompiled(dbtDir string) (*Catalog, error) {
+	path := filepath.Join(dbtDir, TargetDir, "manifest.json")
+	data, err := os.ReadFile(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dbt manifest: %w", err)
+	}
+
+	var manifest compiledManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse dbt manifest %s: %w", path, err)
+	}
+
+	grains := map[string][]string{}
+	for _, node := range manifest.Nodes {
+		if node.ResourceType == "test" && node.TestMetadata != nil && node.TestMetadata.Name == "unique_combination_of_columns" && node.AttachedNode != "" {
+			grains[node.AttachedNode] = node.TestMetadata.Kwargs.CombinationOfColumns
+		}
+	}
+
+	c := &Catalog{Compiled: true}
+	for id, node := range manifest.Nodes {
+		if node.ResourceType != "model" || (manifest.Metadata.ProjectName != "" && node.PackageName != manifest.Metadata.ProjectName) {
+			continue
+		}
+		columns, err := orderedColumns(node.Columns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the columns of %s in the dbt manifest: %w", node.Name, err)
+		}
+		m := Model{
+			Name:            node.Name,
+			Description:     strings.TrimSpace(node.Description),
+			Columns:         columns,
+			Grain:           grains[id],
+			Materialization: node.Config.Materialized,
+		}
+		if _, patch, ok := strings.Cut(node.PatchPath, "://"); ok {
+			m.Path = filepath.Join(dbtDir, filepath.FromSlash(patch))
+		}
+		for _, upstream := range node.DependsOn.Nodes {
+			kind, _, _ := strings.Cut(upstream, ".")
+			switch kind {
+			case "model", "seed", "snapshot":
+				m.Upstream = append(m.Upstream, upstream[strings.LastIndex(upstream, ".")+1:])
+			case "source":
+				if source, ok := manifest.Sources[upstream]; ok {
+					m.Upstream = append(m.Upstream, sourceName(source.SourceName, source.Name))
+				}
+			}
+		}
+		slices.Sort(m.Upstream)
+		m.Upstream = slices.Compact(m.Upstream)
+		c.Models = append(c.Models, m)
+	}
+	slices.SortFunc(c.Models, func(a, b Model) int { return strings.Compare(a.Name, b.Name) })
+	return c, nil
+}
+
+// orderedColumns decodes the columns object of a manifest node in the order the model
+// docs declare them, which a map would lose
+func orderedColumns(raw json.RawMessage) ([]Column, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var columns []Column
+	for dec.More() {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		var column Column
+		if err := dec.Decode(&column); err != nil {
+			return nil, err
+		}
+		column.Description = strings.TrimSpace(column.Description)
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// BuildResult is the outcome of a model in the last dbt invocation
+type BuildResult struct {
+	Status        string
+	Message       string
+	CompletedAt   time.Time
+	ExecutionTime time.Duration
+}
+
+// LoadRunResults reads the outcome of the models in the last dbt invocation of the dbt
+// project in dbtDir, keyed by model name. It returns nil without an error when dbt has
+// not run yet.
+func LoadRunResults(dbtDir string) (map[string]BuildResult, error) {
+	path := filepath.Join(dbtDir, TargetDir, "run_results.json")
+	data, err := os.ReadFile(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dbt run results: %w", err)
+	}
+
+	var runResults struct {
+		Metadata struct {
+			GeneratedAt time.Time `json:"generated_at"`
+		} `json:"metadata"`
+		Results []struct {
+			UniqueID      string  `json:"unique_id"`
+			Status        string  `json:"status"`
+			Message       string  `json:"message"`
+			ExecutionTime float64 `json:"execution_time"`
+			Timing        []struct {
+				CompletedAt time.Time `json:"completed_at"`
+			} `json:"timing"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &runResults); err != nil {
+		return nil, fmt.Errorf("failed to parse dbt run results %s: %w", path, err)
+	}
+
+	results := map[string]BuildResult{}
+	for _, r := range runResults.Results {
+		if !strings.HasPrefix(r.UniqueID, "model.") {
+			continue
+		}
+		result := BuildResult{
+			Status:        r.Status,
+			Message:       r.Message,
+			CompletedAt:   runResults.Metadata.GeneratedAt,
+			ExecutionTime: time.Duration(r.ExecutionTime * float64(time.Second)),
+		}
+		if n := len(r.Timing); n > 0 && !r.Timing[n-1].CompletedAt.IsZero() {
+			result.CompletedAt = r.Timing[n-1].CompletedAt
+		}
+		results[r.UniqueID[strings.LastIndex(r.UniqueID, ".")+1:]] = result
+	}
+	return results, nil
+}
@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const goldDocs = `version: 2
+models:
+  - name: gold_compute__container_daily
+    description: Daily container costs.
+    data_tests:
+      - dbt_utils.unique_combination_of_columns:
+          combination_of_columns:
+            - usage_date
+            - cluster_name
+    columns:
+      - name: usage_date
+      - name: cluster_name
+  - name: serve_core__cost_service_account_daily
+    description: Daily cost by service and account.
+`
+
+const goldSQL = `{{ config(**get_model_config('incremental')) }}
+
+select * from {{ ref('silver_aws__container_daily') }}
+join {{ ref("silver_aws__container_daily") }}
+join {{ source('cur', 'cur_table') }}
+`
+
+func writeTestFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_ProjectDetails(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "3_gold", "compute", "_compute_models.yml"), goldDocs)
+	writeTestFile(t, filepath.Join(dir, "3_gold", "compute", "gold_compute__container_daily.sql"), goldSQL)
+	writeTestFile(t, filepath.Join(dir, "4_serve", "serve_core__cost_service_account_daily.sql"),
+		"{{ config(materialized='view') }}\nselect * from {{ ref('gold_compute__container_daily') }}\n")
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	gold, _ := c.Model("gold_compute__container_daily")
+	if gold.Layer() != "gold" || gold.Domain() != "compute" || gold.TimeGrain() != "daily" {
+		t.Errorf("Layer/Domain/TimeGrain = %q, %q, %q", gold.Layer(), gold.Domain(), gold.TimeGrain())
+	}
+	if !slices.Equal(gold.Grain, []string{"usage_date", "cluster_name"}) {
+		t.Errorf("Grain = %v", gold.Grain)
+	}
+	if !slices.Equal(gold.Upstream, []string{"silver_aws__container_daily", "source:cur.cur_table"}) {
+		t.Errorf("Upstream = %v", gold.Upstream)
+	}
+	if gold.Materialization != "incremental" {
+		t.Errorf("Materialization = %q", gold.Materialization)
+	}
+	serve, _ := c.Model("serve_core__cost_service_account_daily")
+	if serve.Materialization != "view" || serve.Grain != nil {
+		t.Errorf("serve model = %+v", serve)
+	}
+	if got := c.Downstream("gold_compute__container_daily"); !slices.Equal(got, []string{"serve_core__cost_service_account_daily"}) {
+		t.Errorf("Downstream() = %v", got)
+	}
+	if (Model{Name: "adhoc"}).Layer() != "" || (Model{Name: "adhoc"}).Domain() != "" {
+		t.Error("a model outside the naming convention has a layer or domain")
+	}
+}
+
+const compiledManifestJSON = `{
+  "metadata": {"project_name": "ecos"},
+  "nodes": {
+    "model.ecos.gold_core__service_daily": {
+      "resource_type": "model", "package_name": "ecos", "name": "gold_core__service_daily",
+      "description": " Daily cost by service. ",
+      "patch_path": "ecos://models/3_gold/_core_models.yml",
+      "columns": {
+        "usage_date": {"name": "usage_date", "description": "Day", "data_type": "date"},
+        "service_code": {"name": "service_code", "description": "Service"},
+        "effective_cost": {"name": "effective_cost", "data_type": "decimal(38,10)"}
+      },
+      "config": {"materialized": "table"},
+      "depends_on": {"nodes": ["model.ecos.silver_aws__cost_daily", "source.ecos.cur.cur_table", "macro.ecos.get_model_config"]}
+    },
+    "model.dbt_utils.helper": {"resource_type": "model", "package_name": "dbt_utils", "name": "helper"},
+    "test.ecos.unique_combination": {
+      "resource_type": "test", "package_name": "ecos", "name": "unique_combination",
+      "attached_node": "model.ecos.gold_core__service_daily",
+      "test_metadata": {"name": "unique_combination_of_columns", "kwargs": {"combination_of_columns": ["usage_date", "service_code"]}}
+    }
+  },
+  "sources": {"source.ecos.cur.cur_table": {"source_name": "cur", "name": "cur_table"}}
+}`
+
+const runResultsJSON = `{
+  "metadata": {"generated_at": "2026-10-01T06:00:00Z"},
+  "results": [
+    {"unique_id": "model.ecos.gold_core__service_daily", "status": "success", "message": "OK", "execution_time": 1.5,
+     "timing": [{"name": "compile", "completed_at": "2026-10-01T05:58:00Z"}, {"name": "execute", "completed_at": "2026-10-01T05:59:00Z"}]},
+    {"unique_id": "model.ecos.silver_aws__cost_daily", "status": "error", "message": "boom", "execution_time": 0.2, "timing": []},
+    {"unique_id": "test.ecos.unique_combination", "status": "pass"}
+  ]
+}`
+
+func TestLoadCompiled(t *testing.T) {
+	dir := t.TempDir()
+	if c, err := LoadCompiled(dir); c != nil || err != nil {
+		t.Fatalf("LoadCompiled() without a manifest = %v, %v", c, err)
+	}
+	writeTestFile(t, filepath.Join(dir, TargetDir, "manifest.json"), compiledManifestJSON)
+
+	c, err := LoadCompiled(dir)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if !c.Compiled || len(c.Models) != 1 {
+		t.Fatalf("LoadCompiled() = %+v", c)
+	}
+	m := c.Models[0]
+	if m.Description != "Daily cost by service." || m.Materialization != "table" {
+		t.Errorf("model = %+v", m)
+	}
+	if len(m.Columns) != 3 || m.Columns[0].Name != "usage_date" || m.Columns[2].Name != "effective_cost" {
+		t.Errorf("Columns = %+v, want the declared order", m.Columns)
+	}
+	if !slices.Equal(m.Grain, []string{"usage_date", "service_code"}) {
+		t.Errorf("Grain = %v", m.Grain)
+	}
+	if !slices.Equal(m.Upstream, []string{"silver_aws__cost_daily", "source:cur.cur_table"}) {
+		t.Errorf("Upstream = %v", m.Upstream)
+	}
+	if m.Path != filepath.Join(dir, "models", "3_gold", "_core_models.yml") {
+		t.Errorf("Path = %q", m.Path)
+	}
+}
+
+func TestLoadRunResults(t *testing.T) {
+	dir := t.TempDir()
+	if results, err := LoadRunResults(dir); results != nil || err != nil {
+		t.Fatalf("LoadRunResults() without run results = %v, %v", results, err)
+	}
+	writeTestFile(t, filepath.Join(dir, TargetDir, "run_results.json"), runResultsJSON)
+
+	results, err := LoadRunResults(dir)
+	if err != nil {
+		t.Fatalf("LoadRunResults() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("LoadRunResults() = %+v, want the models only", results)
+	}
+	gold := results["gold_core__service_daily"]
+	if gold.Status != "success" || gold.ExecutionTime != 1500*time.Millisecond || !gold.CompletedAt.Equal(time.Date(2026, 10, 1, 5, 59, 0, 0, time.UTC)) {
+		t.Errorf("gold result = %+v", gold)
+	}
+	silver := results["silver_aws__cost_daily"]
+	if silver.Status != "error" || !silver.CompletedAt.Equal(time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("silver result = %+v", silver)
+	}
+}
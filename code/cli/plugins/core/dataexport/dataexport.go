@@ -0,0 +1,289 @@
+// Package dataexport creates and removes the BCM Data Export (CUR 2.0 or FOCUS 1.0)
+// that 'ecos init' can set up for accounts without an existing CUR table, together
+// with the bucket policy that lets AWS deliver into the results bucket and a Glue
+// table over the delivered Parquet files.
+package dataexport
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	glueTypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+)
+
+//go:embed columns/*.txt
+var columnsFS embed.FS
+
+// Export types
+const (
+	TypeCUR2  = "cur2"
+	TypeFOCUS = "focus"
+)
+
+const (
+	// DefaultS3Prefix is the prefix inside the results bucket exports are delivered to
+	DefaultS3Prefix = "data-exports"
+	// BucketPolicySid identifies the statement ecos adds to the results bucket policy
+	BucketPolicySid = "EcosDataExportsDelivery"
+	// partitionColumn is the Hive partition written by BCM Data Exports
+	partitionColumn = "billing_period"
+	// firstBillingPeriod bounds the partition projection range
+	firstBillingPeriod = "2020-01"
+)
+
+// Column is a column of the exported table
+type Column struct {
+	Name string
+	Type string
+}
+
+// tableFor returns the Data Exports source table and its table configuration
+func tableFor(exportType string) (string, map[string]map[string]string, error) {
+	switch exportType {
+	case TypeCUR2:
+		return "COST_AND_USAGE_REPORT", map[string]map[string]string{
+			"COST_AND_USAGE_REPORT": {
+				"TIME_GRANULARITY":                      "DAILY",
+				"INCLUDE_RESOURCES":                     "TRUE",
+				"INCLUDE_SPLIT_COST_ALLOCATION_DATA":    "FALSE",
+				"INCLUDE_MANUAL_DISCOUNT_COMPATIBILITY": "FALSE",
+			},
+		}, nil
+	case TypeFOCUS:
+		return "FOCUS_1_0_AWS", nil, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported data export type '%s' (supported: %s, %s)", exportType, TypeCUR2, TypeFOCUS)
+	}
+}
+
+// ValidateType checks that exportType is a supported export type
+func ValidateType(exportType string) error {
+	_, _, err := tableFor(exportType)
+	return err
+}
+
+// Label returns the display name of an export type (e.g. CUR 2.0)
+func Label(exportType string) string {
+	switch exportType {
+	case TypeCUR2:
+		return "CUR 2.0"
+	case TypeFOCUS:
+		return "FOCUS 1.0"
+	default:
+		return exportType
+	}
+}
+
+// Columns returns the columns selected for the export type
+func Columns(exportType string) ([]Column, error) {
+	if err := ValidateType(exportType); err != nil {
+		return nil, err
+	}
+
+	data, err := columnsFS.ReadFile("columns/" + exportType + ".txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", exportType, err)
+	}
+
+	var columns []Column
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, colType, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid column definition %q", line)
+		}
+		columns = append(columns, Column{Name: name, Type: strings.TrimSpace(colType)})
+	}
+	return columns, scanner.Err()
+}
+
+// Query returns the Data Exports SQL statement and table configuration for the export type
+func Query(exportType string) (string, map[string]map[string]string, error) {
+	table, tableConfig, err := tableFor(exportType)
+	if err != nil {
+		return "", nil, err
+	}
+	columns, err := Columns(exportType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	return fmt.Sprintf("SELECT %s FROM %s", strings.Join(names, ", "), table), tableConfig, nil
+}
+
+// NewConfig returns the export, Glue database and table names for a project
+func NewConfig(projectName, exportType string) (config.DataExportConfig, error) {
+	if err := ValidateType(exportType); err != nil {
+		return config.DataExportConfig{}, err
+	}
+
+	slug := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(projectName), " ", "-"))
+	return config.DataExportConfig{
+		Name:         fmt.Sprintf("%s-%s", slug, exportType),
+		Type:         exportType,
+		S3Prefix:     DefaultS3Prefix,
+		GlueDatabase: strings.ReplaceAll(slug, "-", "_") + "_data_exports",
+		GlueTable:    exportType,
+	}, nil
+}
+
+// DataLocation returns the S3 location AWS delivers the export's Parquet files to
+func DataLocation(cfg config.DataExportConfig, bucket string) string {
+	return fmt.Sprintf("s3://%s/%s/%s/data/", bucket, strings.Trim(cfg.S3Prefix, "/"), cfg.Name)
+}
+
+// TableInput returns the Glue table definition over the delivered Parquet files.
+// Partition projection on billing_period avoids the need for a crawler.
+func TableInput(cfg config.DataExportConfig, bucket string) (*glueTypes.TableInput, error) {
+	return TableInputAt(cfg, DataLocation(cfg, bucket))
+}
+
+// TableInputAt returns the Glue table definition for export files stored under location,
+// laid out as BILLING_PERIOD=YYYY-MM/ partitions like a delivered export
+func TableInputAt(cfg config.DataExportConfig, location string) (*glueTypes.TableInput, error) {
+	columns, err := Columns(cfg.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	glueColumns := make([]glueTypes.Column, 0, len(columns))
+	for _, c := range columns {
+		glueColumns = append(glueColumns, glueTypes.Column{
+			Name: aws.String(strings.ToLower(c.Name)),
+			Type: aws.String(c.Type),
+		})
+	}
+
+	if !strings.HasSuffix(location, "/") {
+		location += "/"
+	}
+	return &glueTypes.TableInput{
+		Name:      aws.String(cfg.GlueTable),
+		TableType: aws.String("EXTERNAL_TABLE"),
+		Parameters: map[string]string{
+			"classification":     "parquet",
+			"EXTERNAL":           "TRUE",
+			"ecos:managed":       "true",
+			"projection.enabled": "true",
+			"projection." + partitionColumn + ".type":          "date",
+			"projection." + partitionColumn + ".format":        "yyyy-MM",
+			"projection." + partitionColumn + ".range":         firstBillingPeriod + ",NOW",
+			"projection." + partitionColumn + ".interval":      "1",
+			"projection." + partitionColumn + ".interval.unit": "MONTHS",
+			"storage.location.template":                        location + "BILLING_PERIOD=${" + partitionColumn + "}",
+		},
+		PartitionKeys: []glueTypes.Column{
+			{Name: aws.String(partitionColumn), Type: aws.String("string")},
+		},
+		StorageDescriptor: &glueTypes.StorageDescriptor{
+			Columns:      glueColumns,
+			Location:     aws.String(location),
+			InputFormat:  aws.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat"),
+			OutputFormat: aws.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat"),
+			SerdeInfo: &glueTypes.SerDeInfo{
+				SerializationLibrary: aws.String("org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe"),
+			},
+		},
+	}, nil
+}
+
+// policyDocument is a minimal S3 bucket policy; statements are kept as raw JSON so
+// statements ecos does not own are preserved unchanged
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	ID        string            `json:"Id,omitempty"`
+	Statement []json.RawMessage `json:"Statement"`
+}
+
+// BucketPolicyStatement returns the statement allowing AWS billing services to deliver
+// exports of accountID into bucket
+func BucketPolicyStatement(partition, bucket, accountID string) map[string]any {
+	return map[string]any{
+		"Sid":    BucketPolicySid,
+		"Effect": "Allow",
+		"Principal": map[string]any{
+			"Service": []string{"billingreports.amazonaws.com", "bcm-data-exports.amazonaws.com"},
+		},
+		"Action": []string{"s3:PutObject", "s3:GetBucketPolicy"},
+		"Resource": []string{
+			fmt.Sprintf("arn:%s:s3:::%s", partition, bucket),
+			fmt.Sprintf("arn:%s:s3:::%s/*", partition, bucket),
+		},
+		"Condition": map[string]any{
+			"StringLike": map[string]any{
+				"aws:SourceAccount": accountID,
+				"aws:SourceArn": []string{
+					fmt.Sprintf("arn:%s:cur:%s:%s:definition/*", partition, awssession.BCMDataExportsRegion, accountID),
+					fmt.Sprintf("arn:%s:bcm-data-exports:%s:%s:export/*", partition, awssession.BCMDataExportsRegion, accountID),
+				},
+			},
+		},
+	}
+}
+
+// MergeBucketPolicy adds (or replaces) the ecos delivery statement in an existing
+// bucket policy. An empty existing policy yields a new document.
+func MergeBucketPolicy(existing string, statement map[string]any) (string, error) {
+	doc := policyDocument{Version: "2012-10-17"}
+	if strings.TrimSpace(existing) != "" {
+		var raw struct {
+			Version   string          `json:"Version"`
+			ID        string          `json:"Id,omitempty"`
+			Statement json.RawMessage `json:"Statement"`
+		}
+		if err := json.Unmarshal([]byte(existing), &raw); err != nil {
+			return "", fmt.Errorf("failed to parse existing bucket policy: %w", err)
+		}
+		doc.Version, doc.ID = raw.Version, raw.ID
+
+		// Statement may be a single object or an array
+		trimmed := bytes.TrimSpace(raw.Statement)
+		switch {
+		case len(trimmed) == 0:
+		case trimmed[0] == '[':
+			if err := json.Unmarshal(trimmed, &doc.Statement); err != nil {
+				return "", fmt.Errorf("failed to parse existing bucket policy statements: %w", err)
+			}
+		default:
+			doc.Statement = []json.RawMessage{trimmed}
+		}
+	}
+
+	kept := doc.Statement[:0]
+	for _, st := range doc.Statement {
+		var sid struct {
+			Sid string `json:"Sid"`
+		}
+		if err := json.Unmarshal(st, &sid); err == nil && sid.Sid == BucketPolicySid {
+			continue
+		}
+		kept = append(kept, st)
+	}
+
+	data, err := json.Marshal(statement)
+	if err != nil {
+		return "", err
+	}
+	doc.Statement = append(kept, data)
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
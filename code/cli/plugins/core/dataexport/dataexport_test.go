@@ -0,0 +1,416 @@
+package dataexport
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bcmdataexports"
+	bcmTypes "github.com/aws/aws-sdk-go-v2/service/bcmdataexports/types"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	glueTypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/iac"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		exportType string
+		wantTable  string
+		wantConfig bool
+	}{
+		{TypeCUR2, "FROM COST_AND_USAGE_REPORT", true},
+		{TypeFOCUS, "FROM FOCUS_1_0_AWS", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exportType, func(t *testing.T) {
+			statement, tableConfig, err := Query(tt.exportType)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if !strings.HasPrefix(statement, "SELECT ") || !strings.HasSuffix(statement, tt.wantTable) {
+				t.Errorf("Query() statement = %q", statement)
+			}
+			if (tableConfig != nil) != tt.wantConfig {
+				t.Errorf("Query() table config = %v, want present = %v", tableConfig, tt.wantConfig)
+			}
+
+			columns, err := Columns(tt.exportType)
+			if err != nil {
+				t.Fatalf("Columns() error = %v", err)
+			}
+			if len(columns) == 0 {
+				t.Fatal("Columns() returned no columns")
+			}
+			for _, c := range columns {
+				if c.Name == "" || c.Type == "" {
+					t.Errorf("invalid column %+v", c)
+				}
+			}
+		})
+	}
+
+	if _, _, err := Query("cur1"); err == nil {
+		t.Error("Query(\"cur1\") expected error, got nil")
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	cfg, err := NewConfig("My Project", TypeCUR2)
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	want := config.DataExportConfig{
+		Name:         "my-project-cur2",
+		Type:         TypeCUR2,
+		S3Prefix:     DefaultS3Prefix,
+		GlueDatabase: "my_project_data_exports",
+		GlueTable:    TypeCUR2,
+	}
+	if cfg != want {
+		t.Errorf("NewConfig() = %+v, want %+v", cfg, want)
+	}
+
+	if got := DataLocation(cfg, "bucket"); got != "s3://bucket/data-exports/my-project-cur2/data/" {
+		t.Errorf("DataLocation() = %q", got)
+	}
+}
+
+func TestTableInput(t *testing.T) {
+	cfg, _ := NewConfig("demo", TypeCUR2)
+	input, err := TableInput(cfg, "bucket")
+	if err != nil {
+		t.Fatalf("TableInput() error = %v", err)
+	}
+
+	if aws.ToString(input.Name) != TypeCUR2 {
+		t.Errorf("Name = %q, want %q", aws.ToString(input.Name), TypeCUR2)
+	}
+	if len(input.PartitionKeys) != 1 || aws.ToString(input.PartitionKeys[0].Name) != "billing_period" {
+		t.Errorf("PartitionKeys = %+v, want billing_period", input.PartitionKeys)
+	}
+	if input.Parameters["projection.enabled"] != "true" || input.Parameters["ecos:managed"] != "true" {
+		t.Errorf("Parameters = %v, want projection enabled and ecos:managed", input.Parameters)
+	}
+	wantTemplate := "s3://bucket/data-exports/demo-cur2/data/BILLING_PERIOD=${billing_period}"
+	if got := input.Parameters["storage.location.template"]; got != wantTemplate {
+		t.Errorf("storage.location.template = %q, want %q", got, wantTemplate)
+	}
+}
+
+func TestMergeBucketPolicy(t *testing.T) {
+	statement := BucketPolicyStatement("aws", "bucket", "123456789012")
+
+	tests := []struct {
+		name     string
+		existing string
+		wantSids []string
+		wantID   string
+	}{
+		{
+			name:     "no existing policy",
+			wantSids: []string{BucketPolicySid},
+		},
+		{
+			name:     "single statement object",
+			existing: `{"Version":"2012-10-17","Id":"p1","Statement":{"Sid":"Other","Effect":"Deny"}}`,
+			wantSids: []string{"Other", BucketPolicySid},
+			wantID:   "p1",
+		},
+		{
+			name:     "replaces previous ecos statement",
+			existing: `{"Version":"2012-10-17","Statement":[{"Sid":"` + BucketPolicySid + `","Effect":"Allow"},{"Sid":"Keep","Effect":"Allow"}]}`,
+			wantSids: []string{"Keep", BucketPolicySid},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeBucketPolicy(tt.existing, statement)
+			if err != nil {
+				t.Fatalf("MergeBucketPolicy() error = %v", err)
+			}
+
+			var doc struct {
+				Version   string
+				ID        string `json:"Id"`
+				Statement []struct{ Sid string }
+			}
+			if err := json.Unmarshal([]byte(merged), &doc); err != nil {
+				t.Fatalf("merged policy is not valid JSON: %v", err)
+			}
+			if doc.ID != tt.wantID {
+				t.Errorf("Id = %q, want %q", doc.ID, tt.wantID)
+			}
+			var sids []string
+			for _, st := range doc.Statement {
+				sids = append(sids, st.Sid)
+			}
+			if strings.Join(sids, ",") != strings.Join(tt.wantSids, ",") {
+				t.Errorf("statement Sids = %v, want %v", sids, tt.wantSids)
+			}
+		})
+	}
+
+	if _, err := MergeBucketPolicy("not json", statement); err == nil {
+		t.Error("MergeBucketPolicy() expected error for invalid policy, got nil")
+	}
+}
+
+type fakeExports struct {
+	exports map[string]string // name -> ARN
+	tags    map[string]string
+	created *bcmdataexports.CreateExportInput
+	deleted []string
+}
+
+func (f *fakeExports) ListExports(_ context.Context, _ *bcmdataexports.ListExportsInput, _ ...func(*bcmdataexports.Options)) (*bcmdataexports.ListExportsOutput, error) {
+	out := &bcmdataexports.ListExportsOutput{}
+	for name, arn := range f.exports {
+		out.Exports = append(out.Exports, bcmTypes.ExportReference{ExportName: aws.String(name), ExportArn: aws.String(arn)})
+	}
+	return out, nil
+}
+
+func (f *fakeExports) CreateExport(_ context.Context, in *bcmdataexports.CreateExportInput, _ ...func(*bcmdataexports.Options)) (*bcmdataexports.CreateExportOutput, error) {
+	f.created = in
+	return &bcmdataexports.CreateExportOutput{}, nil
+}
+
+func (f *fakeExports) DeleteExport(_ context.Context, in *bcmdataexports.DeleteExportInput, _ ...func(*bcmdataexports.Options)) (*bcmdataexports.DeleteExportOutput, error) {
+	f.deleted = append(f.deleted, aws.ToString(in.ExportArn))
+	return &bcmdataexports.DeleteExportOutput{}, nil
+}
+
+func (f *fakeExports) ListTagsForResource(_ context.Context, _ *bcmdataexports.ListTagsForResourceInput, _ ...func(*bcmdataexports.Options)) (*bcmdataexports.ListTagsForResourceOutput, error) {
+	out := &bcmdataexports.ListTagsForResourceOutput{}
+	for k, v := range f.tags {
+		out.ResourceTags = append(out.ResourceTags, bcmTypes.ResourceTag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, nil
+}
+
+type fakeBucketPolicy struct {
+	policy string
+}
+
+func (f *fakeBucketPolicy) GetBucketPolicy(_ context.Context, _ *s3.GetBucketPolicyInput, _ ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	if f.policy == "" {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchBucketPolicy"}
+	}
+	return &s3.GetBucketPolicyOutput{Policy: aws.String(f.policy)}, nil
+}
+
+func (f *fakeBucketPolicy) PutBucketPolicy(_ context.Context, in *s3.PutBucketPolicyInput, _ ...func(*s3.Options)) (*s3.PutBucketPolicyOutput, error) {
+	f.policy = aws.ToString(in.Policy)
+	return &s3.PutBucketPolicyOutput{}, nil
+}
+
+type fakeGlue struct {
+	databases map[string]bool
+	tables    map[string]bool
+	// unmanaged holds the databases and tables not created by ecos
+	unmanaged map[string]bool
+}
+
+func (f *fakeGlue) parameters(name string) map[string]string {
+	if f.unmanaged[name] {
+		return map[string]string{}
+	}
+	return map[string]string{"ecos:managed": "true"}
+}
+
+func (f *fakeGlue) CreateDatabase(_ context.Context, in *glue.CreateDatabaseInput, _ ...func(*glue.Options)) (*glue.CreateDatabaseOutput, error) {
+	name := aws.ToString(in.DatabaseInput.Name)
+	if f.databases[name] {
+		return nil, &glueTypes.AlreadyExistsException{}
+	}
+	f.databases[name] = true
+	return &glue.CreateDatabaseOutput{}, nil
+}
+
+func (f *fakeGlue) CreateTable(_ context.Context, in *glue.CreateTableInput, _ ...func(*glue.Options)) (*glue.CreateTableOutput, error) {
+	name := aws.ToString(in.DatabaseName) + "." + aws.ToString(in.TableInput.Name)
+	if f.tables[name] {
+		return nil, &glueTypes.AlreadyExistsException{}
+	}
+	f.tables[name] = true
+	return &glue.CreateTableOutput{}, nil
+}
+
+func (f *fakeGlue) GetDatabase(_ context.Context, in *glue.GetDatabaseInput, _ ...func(*glue.Options)) (*glue.GetDatabaseOutput, error) {
+	if !f.databases[aws.ToString(in.Name)] {
+		return nil, &glueTypes.EntityNotFoundException{}
+	}
+	return &glue.GetDatabaseOutput{Database: &glueTypes.Database{Parameters: f.parameters(aws.ToString(in.Name))}}, nil
+}
+
+func (f *fakeGlue) GetTable(_ context.Context, in *glue.GetTableInput, _ ...func(*glue.Options)) (*glue.GetTableOutput, error) {
+	name := aws.ToString(in.DatabaseName) + "." + aws.ToString(in.Name)
+	if !f.tables[name] {
+		return nil, &glueTypes.EntityNotFoundException{}
+	}
+	return &glue.GetTableOutput{Table: &glueTypes.Table{Name: in.Name, Parameters: f.parameters(name)}}, nil
+}
+
+func (f *fakeGlue) GetTables(_ context.Context, in *glue.GetTablesInput, _ ...func(*glue.Options)) (*glue.GetTablesOutput, error) {
+	out := &glue.GetTablesOutput{}
+	for name := range f.tables {
+		database, table, _ := strings.Cut(name, ".")
+		if database == aws.ToString(in.DatabaseName) {
+			out.TableList = append(out.TableList, glueTypes.Table{Name: aws.String(table), Parameters: f.parameters(name)})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeGlue) DeleteTable(_ context.Context, in *glue.DeleteTableInput, _ ...func(*glue.Options)) (*glue.DeleteTableOutput, error) {
+	name := aws.ToString(in.DatabaseName) + "." + aws.ToString(in.Name)
+	if !f.tables[name] {
+		return nil, &glueTypes.EntityNotFoundException{}
+	}
+	delete(f.tables, name)
+	return &glue.DeleteTableOutput{}, nil
+}
+
+func (f *fakeGlue) DeleteDatabase(_ context.Context, in *glue.DeleteDatabaseInput, _ ...func(*glue.Options)) (*glue.DeleteDatabaseOutput, error) {
+	name := aws.ToString(in.Name)
+	if !f.databases[name] {
+		return nil, &glueTypes.EntityNotFoundException{}
+	}
+	delete(f.databases, name)
+	return &glue.DeleteDatabaseOutput{}, nil
+}
+
+func TestProvisionAndTeardown(t *testing.T) {
+	ctx := context.Background()
+	exportCfg, _ := NewConfig("demo", TypeCUR2)
+	exports := &fakeExports{exports: map[string]string{}, tags: map[string]string{"ecos:managed": "true"}}
+	bucketPolicy := &fakeBucketPolicy{}
+	glueClient := &fakeGlue{databases: map[string]bool{}, tables: map[string]bool{}, unmanaged: map[string]bool{}}
+	clients := Clients{Exports: exports, S3: bucketPolicy, Glue: glueClient}
+
+	input := ProvisionInput{
+		Export:    exportCfg,
+		Bucket:    "demo-bucket",
+		Region:    "eu-west-1",
+		AccountID: "123456789012",
+		Partition: "aws",
+		Tags:      iac.ManagedTags("demo"),
+	}
+
+	for _, res := range Provision(ctx, clients, input) {
+		if res.Status != types.InitStatusCreated {
+			t.Errorf("%s %s status = %s (%s), want created", res.Kind, res.Name, res.Status, res.Error)
+		}
+	}
+	if exports.created == nil {
+		t.Fatal("CreateExport was not called")
+	}
+	dest := exports.created.Export.DestinationConfigurations.S3Destination
+	if aws.ToString(dest.S3Bucket) != "demo-bucket" || aws.ToString(dest.S3Region) != "eu-west-1" {
+		t.Errorf("S3 destination = %s in %s", aws.ToString(dest.S3Bucket), aws.ToString(dest.S3Region))
+	}
+	if !strings.Contains(bucketPolicy.policy, BucketPolicySid) {
+		t.Errorf("bucket policy missing %s: %s", BucketPolicySid, bucketPolicy.policy)
+	}
+
+	// Re-running init skips resources that already exist
+	exports.exports[exportCfg.Name] = "arn:aws:bcm-data-exports:us-east-1:123456789012:export/demo-cur2-abc"
+	for _, res := range Provision(ctx, clients, input)[1:] {
+		if res.Status != types.InitStatusSkipped {
+			t.Errorf("%s %s status = %s on re-run, want skipped", res.Kind, res.Name, res.Status)
+		}
+	}
+
+	for _, preview := range Describe(ctx, clients, exportCfg) {
+		if !preview.Managed || preview.Error != "" {
+			t.Errorf("Describe() %s %s = managed %v, error %q", preview.Kind, preview.Name, preview.Managed, preview.Error)
+		}
+	}
+
+	for _, res := range Teardown(ctx, clients, exportCfg) {
+		if res.Status != types.DestroyStatusDeleted {
+			t.Errorf("%s %s status = %s (%s), want deleted", res.Kind, res.Name, res.Status, res.Error)
+		}
+	}
+	if len(exports.deleted) != 1 {
+		t.Errorf("DeleteExport calls = %v, want 1", exports.deleted)
+	}
+
+	// Tearing down again reports everything as already gone
+	delete(exports.exports, exportCfg.Name)
+	for _, res := range Teardown(ctx, clients, exportCfg) {
+		if res.Status != types.DestroyStatusSkipped {
+			t.Errorf("%s %s status = %s on second teardown, want skipped", res.Kind, res.Name, res.Status)
+		}
+	}
+}
+
+func TestTeardownSkipsUnmanaged(t *testing.T) {
+	ctx := context.Background()
+	exportCfg, _ := NewConfig("demo", TypeCUR2)
+	table := exportCfg.GlueDatabase + "." + exportCfg.GlueTable
+	arn := "arn:aws:bcm-data-exports:us-east-1:123456789012:export/demo-cur2-abc"
+
+	tests := []struct {
+		name       string
+		exportTags map[string]string
+		unmanaged  []string
+		otherTable string
+		want       map[string]types.DestroyStatus
+	}{
+		{
+			name:       "user database",
+			exportTags: map[string]string{"ecos:managed": "true"},
+			unmanaged:  []string{exportCfg.GlueDatabase},
+			want:       map[string]types.DestroyStatus{KindExport: types.DestroyStatusDeleted, KindGlueTable: types.DestroyStatusDeleted, KindGlueDatabase: types.DestroyStatusSkipped},
+		},
+		{
+			name:       "database with user tables",
+			exportTags: map[string]string{"ecos:managed": "true"},
+			otherTable: exportCfg.GlueDatabase + ".invoices",
+			want:       map[string]types.DestroyStatus{KindExport: types.DestroyStatusDeleted, KindGlueTable: types.DestroyStatusDeleted, KindGlueDatabase: types.DestroyStatusSkipped},
+		},
+		{
+			name:      "user export and table",
+			unmanaged: []string{table},
+			want:      map[string]types.DestroyStatus{KindExport: types.DestroyStatusSkipped, KindGlueTable: types.DestroyStatusSkipped, KindGlueDatabase: types.DestroyStatusSkipped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exports := &fakeExports{exports: map[string]string{exportCfg.Name: arn}, tags: tt.exportTags}
+			glueClient := &fakeGlue{
+				databases: map[string]bool{exportCfg.GlueDatabase: true},
+				tables:    map[string]bool{table: true},
+				unmanaged: map[string]bool{},
+			}
+			for _, name := range tt.unmanaged {
+				glueClient.unmanaged[name] = true
+			}
+			if tt.otherTable != "" {
+				glueClient.tables[tt.otherTable] = true
+				glueClient.unmanaged[tt.otherTable] = true
+			}
+
+			for _, res := range Teardown(ctx, Clients{Exports: exports, Glue: glueClient}, exportCfg) {
+				if res.Status != tt.want[res.Kind] {
+					t.Errorf("%s %s status = %s (%s), want %s", res.Kind, res.Name, res.Status, res.Error, tt.want[res.Kind])
+				}
+			}
+			if !glueClient.databases[exportCfg.GlueDatabase] {
+				t.Error("Teardown() deleted a database ecos must keep")
+			}
+			if tt.otherTable != "" && !glueClient.tables[tt.otherTable] {
+				t.Errorf("Teardown() deleted %s", tt.otherTable)
+			}
+		})
+	}
+}
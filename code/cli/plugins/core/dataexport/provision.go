@@ -0,0 +1,449 @@
+package dataexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bcmdataexports"
+	bcmTypes "github.com/aws/aws-sdk-go-v2/service/bcmdataexports/types"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	glueTypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/iac"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+)
+
+// Resource kinds reported in init and destroy summaries
+const (
+	KindBucketPolicy = "S3 Bucket Policy"
+	KindExport       = "Data Export"
+	KindGlueDatabase = "Glue Database"
+	KindGlueTable    = "Glue Table"
+)
+
+// ExportsAPI is the subset of the BCM Data Exports client ecos uses
+type ExportsAPI interface {
+	bcmdataexports.ListExportsAPIClient
+	CreateExport(ctx context.Context, params *bcmdataexports.CreateExportInput, optFns ...func(*bcmdataexports.Options)) (*bcmdataexports.CreateExportOutput, error)
+	DeleteExport(ctx context.Context, params *bcmdataexports.DeleteExportInput, optFns ...func(*bcmdataexports.Options)) (*bcmdataexports.DeleteExportOutput, error)
+	ListTagsForResource(ctx context.Context, params *bcmdataexports.ListTagsForResourceInput, optFns ...func(*bcmdataexports.Options)) (*bcmdataexports.ListTagsForResourceOutput, error)
+}
+
+// BucketPolicyAPI is the subset of the S3 client ecos uses for the bucket policy
+type BucketPolicyAPI interface {
+	GetBucketPolicy(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error)
+	PutBucketPolicy(ctx context.Context, params *s3.PutBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.PutBucketPolicyOutput, error)
+}
+
+// GlueAPI is the subset of the Glue client ecos uses for the export table
+type GlueAPI interface {
+	CreateDatabase(ctx context.Context, params *glue.CreateDatabaseInput, optFns ...func(*glue.Options)) (*glue.CreateDatabaseOutput, error)
+	CreateTable(ctx context.Context, params *glue.CreateTableInput, optFns ...func(*glue.Options)) (*glue.CreateTableOutput, error)
+	GetDatabase(ctx context.Context, params *glue.GetDatabaseInput, optFns ...func(*glue.Options)) (*glue.GetDatabaseOutput, error)
+	GetTable(ctx context.Context, params *glue.GetTableInput, optFns ...func(*glue.Options)) (*glue.GetTableOutput, error)
+	GetTables(ctx context.Context, params *glue.GetTablesInput, optFns ...func(*glue.Options)) (*glue.GetTablesOutput, error)
+	DeleteTable(ctx context.Context, params *glue.DeleteTableInput, optFns ...func(*glue.Options)) (*glue.DeleteTableOutput, error)
+	DeleteDatabase(ctx context.Context, params *glue.DeleteDatabaseInput, optFns ...func(*glue.Options)) (*glue.DeleteDatabaseOutput, error)
+}
+
+// Clients bundles the AWS clients used to manage a data export
+type Clients struct {
+	Exports ExportsAPI
+	S3      BucketPolicyAPI
+	Glue    GlueAPI
+}
+
+// NewClients creates the clients for a loaded AWS config
+func NewClients(cfg aws.Config, endpoints config.AWSEndpointsConfig) Clients {
+	return Clients{
+		Exports: awssession.NewBCMDataExportsClient(cfg, endpoints),
+		S3:      awssession.NewS3Client(cfg, endpoints),
+		Glue:    awssession.NewGlueClient(cfg, endpoints),
+	}
+}
+
+// ProvisionInput describes where the export is delivered
+type ProvisionInput struct {
+	Export    config.DataExportConfig
+	Bucket    string
+	Region    string
+	AccountID string
+	Partition string
+	Tags      []iac.Tag
+}
+
+// Provision creates the bucket policy statement, the export and the Glue database and
+// table. Existing resources are reported as skipped, so re-running init is safe.
+func Provision(ctx context.Context, c Clients, in ProvisionInput) []types.InitResourceResult {
+	results := []types.InitResourceResult{putBucketPolicy(ctx, c.S3, in)}
+
+	if results[0].Status == types.InitStatusFailed {
+		results = append(results, types.InitResourceResult{
+			Kind:   KindExport,
+			Name:   in.Export.Name,
+			Status: types.InitStatusFailed,
+			Error:  "skipped because the bucket policy could not be updated",
+		})
+	} else {
+		results = append(results, createExport(ctx, c.Exports, in))
+	}
+
+	results = append(results, createGlueDatabase(ctx, c.Glue, in.Export))
+	results = append(results, createGlueTable(ctx, c.Glue, in))
+	return results
+}
+
+func putBucketPolicy(ctx context.Context, client BucketPolicyAPI, in ProvisionInput) types.InitResourceResult {
+	result := types.InitResourceResult{Kind: KindBucketPolicy, Name: in.Bucket}
+
+	var existing string
+	out, err := client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(in.Bucket)})
+	switch {
+	case err == nil:
+		existing = aws.ToString(out.Policy)
+	case errorCode(err) == "NoSuchBucketPolicy":
+	default:
+		result.Status = types.InitStatusFailed
+		result.Error = fmt.Sprintf("failed to read bucket policy: %v", err)
+		return result
+	}
+
+	policy, err := MergeBucketPolicy(existing, BucketPolicyStatement(in.Partition, in.Bucket, in.AccountID))
+	if err != nil {
+		result.Status = types.InitStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	if _, err := client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(in.Bucket),
+		Policy: aws.String(policy),
+	}); err != nil {
+		result.Status = types.InitStatusFailed
+		result.Error = fmt.Sprintf("failed to update bucket policy: %v", err)
+		return result
+	}
+
+	result.Status = types.InitStatusCreated
+	return result
+}
+
+func createExport(ctx context.Context, client ExportsAPI, in ProvisionInput) types.InitResourceResult {
+	result := types.InitResourceResult{Kind: KindExport, Name: in.Export.Name}
+
+	arn, err := FindExportARN(ctx, client, in.Export.Name)
+	if err != nil {
+		result.Status = types.InitStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+	if arn != "" {
+		result.Status = types.InitStatusSkipped
+		return result
+	}
+
+	statement, tableConfig, err := Query(in.Export.Type)
+	if err != nil {
+		result.Status = types.InitStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	tags := make([]bcmTypes.ResourceTag, 0, len(in.Tags))
+	for _, t := range in.Tags {
+		tags = append(tags, bcmTypes.ResourceTag{Key: aws.String(t.Key), Value: aws.String(t.Value)})
+	}
+
+	_, err = client.CreateExport(ctx, &bcmdataexports.CreateExportInput{
+		Export: &bcmTypes.Export{
+			Name:        aws.String(in.Export.Name),
+			Description: aws.String("Created by ecos init"),
+			DataQuery: &bcmTypes.DataQuery{
+				QueryStatement:      aws.String(statement),
+				TableConfigurations: tableConfig,
+			},
+			DestinationConfigurations: &bcmTypes.DestinationConfigurations{
+				S3Destination: &bcmTypes.S3Destination{
+					S3Bucket: aws.String(in.Bucket),
+					S3Prefix: aws.String(in.Export.S3Prefix),
+					S3Region: aws.String(in.Region),
+					S3OutputConfigurations: &bcmTypes.S3OutputConfigurations{
+						Compression: bcmTypes.CompressionOptionParquet,
+						Format:      bcmTypes.FormatOptionParquet,
+						OutputType:  bcmTypes.S3OutputTypeCustom,
+						Overwrite:   bcmTypes.OverwriteOptionOverwriteReport,
+					},
+				},
+			},
+			RefreshCadence: &bcmTypes.RefreshCadence{Frequency: bcmTypes.FrequencyOptionSynchronous},
+		},
+		ResourceTags: tags,
+	})
+	if err != nil {
+		result.Status = types.InitStatusFailed
+		result.Error = fmt.Sprintf("failed to create data export: %v", err)
+		return result
+	}
+
+	result.Status = types.InitStatusCreated
+	return result
+}
+
+func createGlueDatabase(ctx context.Context, client GlueAPI, export config.DataExportConfig) types.InitResourceResult {
+	result := types.InitResourceResult{Kind: KindGlueDatabase, Name: export.GlueDatabase}
+
+	_, err := client.CreateDatabase(ctx, &glue.CreateDatabaseInput{
+		DatabaseInput: &glueTypes.DatabaseInput{
+			Name:        aws.String(export.GlueDatabase),
+			Description: aws.String("Data exports delivered for ecos"),
+			Parameters:  map[string]string{"ecos:managed": "true"},
+		},
+	})
+	switch {
+	case err == nil:
+		result.Status = types.InitStatusCreated
+	case errorCode(err) == "AlreadyExistsException":
+		result.Status = types.InitStatusSkipped
+	default:
+		result.Status = types.InitStatusFailed
+		result.Error = fmt.Sprintf("failed to create Glue database: %v", err)
+	}
+	return result
+}
+
+func createGlueTable(ctx context.Context, client GlueAPI, in ProvisionInput) types.InitResourceResult {
+	result := types.InitResourceResult{Kind: KindGlueTable, Name: in.Export.GlueDatabase + "." + in.Export.GlueTable}
+
+	tableInput, err := TableInput(in.Export, in.Bucket)
+	if err != nil {
+		result.Status = types.InitStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	_, err = client.CreateTable(ctx, &glue.CreateTableInput{
+		DatabaseName: aws.String(in.Export.GlueDatabase),
+		TableInput:   tableInput,
+	})
+	switch {
+	case err == nil:
+		result.Status = types.InitStatusCreated
+	case errorCode(err) == "AlreadyExistsException":
+		result.Status = types.InitStatusSkipped
+	default:
+		result.Status = types.InitStatusFailed
+		result.Error = fmt.Sprintf("failed to create Glue table: %v", err)
+	}
+	return result
+}
+
+// FindExportARN returns the ARN of the export named name, or "" when it does not exist
+func FindExportARN(ctx context.Context, client ExportsAPI, name string) (string, error) {
+	paginator := bcmdataexports.NewListExportsPaginator(client, &bcmdataexports.ListExportsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list data exports: %w", err)
+		}
+		for _, ref := range page.Exports {
+			if aws.ToString(ref.ExportName) == name {
+				return aws.ToString(ref.ExportArn), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Describe previews the export resources 'ecos destroy' would remove
+func Describe(ctx context.Context, c Clients, export config.DataExportConfig) []types.DestroyResourcePreview {
+	exportPreview := types.DestroyResourcePreview{Kind: KindExport, Name: export.Name}
+	if arn, err := FindExportARN(ctx, c.Exports, export.Name); err != nil {
+		exportPreview.Error = errorCodeOr(err)
+	} else if arn != "" {
+		exportPreview.Managed, err = exportManaged(ctx, c.Exports, arn)
+		if err != nil {
+			exportPreview.Error = errorCodeOr(err)
+		}
+	}
+
+	tablePreview := types.DestroyResourcePreview{Kind: KindGlueTable, Name: export.GlueDatabase + "." + export.GlueTable}
+	if out, err := c.Glue.GetTable(ctx, &glue.GetTableInput{
+		DatabaseName: aws.String(export.GlueDatabase),
+		Name:         aws.String(export.GlueTable),
+	}); err != nil {
+		tablePreview.Error = errorCodeOr(err)
+	} else {
+		tablePreview.Managed = out.Table.Parameters["ecos:managed"] == "true"
+	}
+
+	dbPreview := types.DestroyResourcePreview{Kind: KindGlueDatabase, Name: export.GlueDatabase}
+	if out, err := c.Glue.GetDatabase(ctx, &glue.GetDatabaseInput{Name: aws.String(export.GlueDatabase)}); err != nil {
+		dbPreview.Error = errorCodeOr(err)
+	} else {
+		dbPreview.Managed = out.Database.Parameters["ecos:managed"] == "true"
+	}
+
+	return []types.DestroyResourcePreview{exportPreview, tablePreview, dbPreview}
+}
+
+func exportManaged(ctx context.Context, client ExportsAPI, arn string) (bool, error) {
+	out, err := client.ListTagsForResource(ctx, &bcmdataexports.ListTagsForResourceInput{ResourceArn: aws.String(arn)})
+	if err != nil {
+		return false, err
+	}
+	for _, t := range out.ResourceTags {
+		if aws.ToString(t.Key) == "ecos:managed" && aws.ToString(t.Value) == "true" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Teardown deletes the export, its Glue table and database. Only resources ecos created,
+// tagged ecos:managed, are deleted: others are skipped, as is the database while it holds
+// tables ecos does not own. Missing resources are skipped.
+func Teardown(ctx context.Context, c Clients, export config.DataExportConfig) []types.DestroyResourceResult {
+	return []types.DestroyResourceResult{
+		deleteExport(ctx, c.Exports, export.Name),
+		deleteGlueTable(ctx, c.Glue, export),
+		deleteGlueDatabase(ctx, c.Glue, export.GlueDatabase),
+	}
+}
+
+func deleteExport(ctx context.Context, client ExportsAPI, name string) types.DestroyResourceResult {
+	arn, err := FindExportARN(ctx, client, name)
+	if err != nil {
+		return types.DestroyResourceResult{Kind: KindExport, Name: name, Status: types.DestroyStatusFailed, Error: err.Error()}
+	}
+	if arn == "" {
+		return types.DestroyResourceResult{
+			Kind:   KindExport,
+			Name:   name,
+			Status: types.DestroyStatusSkipped,
+			Error:  "Data export already deleted or does not exist",
+		}
+	}
+	managed, err := exportManaged(ctx, client, arn)
+	if err != nil {
+		return types.DestroyResourceResult{Kind: KindExport, Name: name, Status: types.DestroyStatusFailed, Error: err.Error()}
+	}
+	if !managed {
+		return notManagedResult(KindExport, name)
+	}
+
+	_, err = client.DeleteExport(ctx, &bcmdataexports.DeleteExportInput{ExportArn: aws.String(arn)})
+	return destroyResult(KindExport, name, err)
+}
+
+func deleteGlueTable(ctx context.Context, client GlueAPI, export config.DataExportConfig) types.DestroyResourceResult {
+	name := export.GlueDatabase + "." + export.GlueTable
+	out, err := client.GetTable(ctx, &glue.GetTableInput{
+		DatabaseName: aws.String(export.GlueDatabase),
+		Name:         aws.String(export.GlueTable),
+	})
+	if err != nil {
+		return destroyResult(KindGlueTable, name, err)
+	}
+	if out.Table.Parameters["ecos:managed"] != "true" {
+		return notManagedResult(KindGlueTable, name)
+	}
+
+	_, err = client.DeleteTable(ctx, &glue.DeleteTableInput{
+		DatabaseName: aws.String(export.GlueDatabase),
+		Name:         aws.String(export.GlueTable),
+	})
+	return destroyResult(KindGlueTable, name, err)
+}
+
+func deleteGlueDatabase(ctx context.Context, client GlueAPI, database string) types.DestroyResourceResult {
+	out, err := client.GetDatabase(ctx, &glue.GetDatabaseInput{Name: aws.String(database)})
+	if err != nil {
+		return destroyResult(KindGlueDatabase, database, err)
+	}
+	if out.Database.Parameters["ecos:managed"] != "true" {
+		return notManagedResult(KindGlueDatabase, database)
+	}
+
+	// Deleting a database deletes its tables: keep it while it holds tables of others
+	foreign, err := unmanagedTables(ctx, client, database)
+	if err != nil {
+		return types.DestroyResourceResult{Kind: KindGlueDatabase, Name: database, Status: types.DestroyStatusFailed, Error: err.Error()}
+	}
+	if len(foreign) > 0 {
+		return types.DestroyResourceResult{
+			Kind:   KindGlueDatabase,
+			Name:   database,
+			Status: types.DestroyStatusSkipped,
+			Error:  fmt.Sprintf("Glue database holds tables not created by ecos: %s", strings.Join(foreign, ", ")),
+		}
+	}
+
+	_, err = client.DeleteDatabase(ctx, &glue.DeleteDatabaseInput{Name: aws.String(database)})
+	return destroyResult(KindGlueDatabase, database, err)
+}
+
+// unmanagedTables lists the tables of a Glue database not tagged ecos:managed
+func unmanagedTables(ctx context.Context, client GlueAPI, database string) ([]string, error) {
+	var names []string
+	paginator := glue.NewGetTablesPaginator(client, &glue.GetTablesInput{DatabaseName: aws.String(database)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Glue tables: %w", err)
+		}
+		for _, table := range page.TableList {
+			if table.Parameters["ecos:managed"] != "true" {
+				names = append(names, aws.ToString(table.Name))
+			}
+		}
+	}
+	return names, nil
+}
+
+func notManagedResult(kind, name string) types.DestroyResourceResult {
+	return types.DestroyResourceResult{
+		Kind:   kind,
+		Name:   name,
+		Status: types.DestroyStatusSkipped,
+		Error:  kind + " was not created by ecos",
+	}
+}
+
+func destroyResult(kind, name string, err error) types.DestroyResourceResult {
+	switch {
+	case err == nil:
+		return types.DestroyResourceResult{Kind: kind, Name: name, Status: types.DestroyStatusDeleted}
+	case errorCode(err) == "EntityNotFoundException" || errorCode(err) == "ResourceNotFoundException":
+		return types.DestroyResourceResult{
+			Kind:   kind,
+			Name:   name,
+			Status: types.DestroyStatusSkipped,
+			Error:  kind + " already deleted or does not exist",
+		}
+	default:
+		return types.DestroyResourceResult{Kind: kind, Name: name, Status: types.DestroyStatusFailed, Error: err.Error()}
+	}
+}
+
+// errorCode returns the AWS API error code of err, or "" for other errors
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+// errorCodeOr returns the AWS API error code of err, falling back to its message
+func errorCodeOr(err error) string {
+	if code := errorCode(err); code != "" {
+		return code
+	}
+	return err.Error()
+}
@@ -8,12 +8,14 @@ import (
 	"time"
 
 	cliConfig "github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/permissions"
 	"github.com/ecos-labs/ecos/code/cli/plugins/registry"
 	"github.com/ecos-labs/ecos/code/cli/plugins/types"
 	"github.com/ecos-labs/ecos/code/cli/utils"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/athena"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -27,7 +29,13 @@ type AwsCurDestroyPlugin struct {
 	dbtWorkgroup   string
 	adhocWorkgroup string
 	awsProfile     string
+	awsAccess      cliConfig.AWSAccessConfig
 	accountID      string
+	// dataExport is the BCM Data Export created by 'ecos init', if any
+	dataExport *cliConfig.DataExportConfig
+
+	// awsCfg caches the loaded AWS config so MFA codes are only requested once
+	awsCfg *aws.Config
 }
 
 func NewAwsCurDestroy() types.DestroyPlugin {
@@ -52,6 +60,8 @@ func (p *AwsCurDestroyPlugin) LoadFromConfig(cfg *cliConfig.EcosConfig) error {
 	p.adhocWorkgroup = cfg.AWS.AdhocWorkgroup
 
 	p.awsProfile = cfg.Transform.DBT.AWSProfile
+	p.awsAccess = cfg.AWS.AWSAccessConfig
+	p.dataExport = cfg.AWS.DataExport
 
 	if p.region == "" {
 		return errors.New("aws.region missing in .ecos.yaml")
@@ -79,12 +89,7 @@ func (p *AwsCurDestroyPlugin) DescribeDestruction() []types.DestroyResourcePrevi
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(p.region)}
-	if p.awsProfile != "" {
-		opts = append(opts, awsconfig.WithSharedConfigProfile(p.awsProfile))
-	}
-
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	cfg, err := p.loadAWSConfig()
 	if err != nil {
 		// If we can't load AWS config, return error preview for all resources
 		return []types.DestroyResourcePreview{
@@ -103,8 +108,8 @@ func (p *AwsCurDestroyPlugin) DescribeDestruction() []types.DestroyResourcePrevi
 		}
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
-	athClient := athena.NewFromConfig(cfg)
+	s3Client := awssession.NewS3Client(cfg, p.awsAccess.Endpoints)
+	athClient := awssession.NewAthenaClient(cfg, p.awsAccess.Endpoints)
 
 	results := []types.DestroyResourcePreview{}
 
@@ -146,6 +151,12 @@ func (p *AwsCurDestroyPlugin) DescribeDestruction() []types.DestroyResourcePrevi
 		results = append(results, adhocPreview)
 	}
 
+	// 4. Data export, its Glue table and database
+	if p.dataExport != nil {
+		clients := dataexport.NewClients(cfg, p.awsAccess.Endpoints)
+		results = append(results, dataexport.Describe(ctx, clients, *p.dataExport)...)
+	}
+
 	return results
 }
 
@@ -153,45 +164,85 @@ func (p *AwsCurDestroyPlugin) ValidatePrerequisites() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	opts := []func(*awsconfig.LoadOptions) error{
-		awsconfig.WithRegion(p.region),
-	}
-	if p.awsProfile != "" {
-		opts = append(opts, awsconfig.WithSharedConfigProfile(p.awsProfile))
-	}
-
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	cfg, err := p.loadAWSConfig()
 	if err != nil {
-		return fmt.Errorf("LoadDefaultConfig failed: %w", err)
+		return err
 	}
 
-	stsClient := sts.NewFromConfig(cfg)
+	stsClient := awssession.NewSTSClient(cfg, p.awsAccess.Endpoints)
 	ident, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
 		return fmt.Errorf("STS GetCallerIdentity failed: %w", err)
 	}
 
 	p.accountID = *ident.Account
+
+	// Report denied actions up front instead of failing halfway through destruction
+	scope := permissions.Scope{
+		AccountID:  p.accountID,
+		Region:     p.region,
+		Bucket:     p.bucket,
+		Workgroups: p.workgroups(),
+	}
+	if p.dataExport != nil {
+		scope.DataExport = p.dataExport.Name
+		scope.DataExportDatabase = p.dataExport.GlueDatabase
+	}
+	denials, err := permissions.Preflight(ctx, cfg, p.awsAccess.Endpoints, permissions.PurposeDestroy, scope)
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("IAM permission check skipped: %v", err))
+		return nil
+	}
+	if len(denials) > 0 {
+		utils.PrintError("Missing IAM permissions:")
+		for _, line := range permissions.FormatDenials(denials) {
+			fmt.Printf("  • %s\n", line)
+		}
+		utils.PrintInfo("Run 'ecos iam policy --for destroy' to generate the required policy")
+		return fmt.Errorf("%d required IAM actions are denied for the current identity", len(denials))
+	}
+
 	return nil
 }
 
-func (p *AwsCurDestroyPlugin) DestroyResources() ([]types.DestroyResourceResult, error) {
-	ctx := context.Background()
-
-	opts := []func(*awsconfig.LoadOptions) error{
-		awsconfig.WithRegion(p.region),
+// loadAWSConfig loads the AWS config for the project's profile, role and region once.
+// A background context is used so an MFA prompt is not cut short by a call timeout.
+func (p *AwsCurDestroyPlugin) loadAWSConfig() (aws.Config, error) {
+	if p.awsCfg == nil {
+		cfg, err := awssession.Load(context.Background(), awssession.Options{
+			Region:  p.region,
+			Profile: p.awsProfile,
+			Access:  p.awsAccess,
+		})
+		if err != nil {
+			return aws.Config{}, err
+		}
+		p.awsCfg = &cfg
 	}
-	if p.awsProfile != "" {
-		opts = append(opts, awsconfig.WithSharedConfigProfile(p.awsProfile))
+	return *p.awsCfg, nil
+}
+
+// workgroups returns the configured workgroup names
+func (p *AwsCurDestroyPlugin) workgroups() []string {
+	var wgs []string
+	for _, wg := range []string{p.dbtWorkgroup, p.adhocWorkgroup} {
+		if wg != "" {
+			wgs = append(wgs, wg)
+		}
 	}
+	return wgs
+}
 
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+func (p *AwsCurDestroyPlugin) DestroyResources() ([]types.DestroyResourceResult, error) {
+	ctx := context.Background()
+
+	cfg, err := p.loadAWSConfig()
 	if err != nil {
-		return nil, fmt.Errorf("LoadDefaultConfig failed: %w", err)
+		return nil, err
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
-	athClient := athena.NewFromConfig(cfg)
+	s3Client := awssession.NewS3Client(cfg, p.awsAccess.Endpoints)
+	athClient := awssession.NewAthenaClient(cfg, p.awsAccess.Endpoints)
 
 	var results []types.DestroyResourceResult
 	isEmpty, err := p.isBucketEmpty(ctx, s3Client, p.bucket)
@@ -214,6 +265,12 @@ func (p *AwsCurDestroyPlugin) DestroyResources() ([]types.DestroyResourceResult,
 	spinner := utils.NewSpinner("Destroying aws_cur resources...")
 	spinner.Start()
 
+	// Data export first, so AWS stops delivering into the bucket before it is deleted
+	if p.dataExport != nil {
+		clients := dataexport.NewClients(cfg, p.awsAccess.Endpoints)
+		results = append(results, dataexport.Teardown(ctx, clients, *p.dataExport)...)
+	}
+
 	// Bucket
 	bucketRes := p.destroyBucket(ctx, s3Client, p.bucket)
 	results = append(results, bucketRes)
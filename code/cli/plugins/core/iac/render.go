@@ -0,0 +1,175 @@
+package iac
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Format identifies an infrastructure-as-code output format
+type Format string
+
+const (
+	// FormatTerraform renders HCL for the hashicorp/aws provider.
+	FormatTerraform Format = "terraform"
+	// FormatCloudFormation renders a CloudFormation YAML template.
+	FormatCloudFormation Format = "cloudformation"
+)
+
+// SupportedFormats lists the formats accepted by ParseFormat
+var SupportedFormats = []Format{FormatTerraform, FormatCloudFormation}
+
+// formatFiles maps each format to the files it renders and their templates
+var formatFiles = map[Format]map[string]string{
+	FormatTerraform: {
+		"main.tf":    "terraform_main.tf.tmpl",
+		"outputs.tf": "terraform_outputs.tf.tmpl",
+	},
+	FormatCloudFormation: {
+		"ecos-resources.yaml": "cloudformation.yaml.tmpl",
+	},
+}
+
+// ParseFormat validates a user-supplied format name. "tf" and "cfn" are accepted as aliases.
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "terraform", "tf":
+		return FormatTerraform, nil
+	case "cloudformation", "cfn":
+		return FormatCloudFormation, nil
+	default:
+		return "", fmt.Errorf("unsupported IaC format '%s', must be one of: %v", value, SupportedFormats)
+	}
+}
+
+// Render renders the resource definitions and returns file contents keyed by filename
+func Render(format Format, res *AWSResources) (map[string]string, error) {
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+
+	files, ok := formatFiles[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported IaC format '%s'", format)
+	}
+
+	funcs := sprig.TxtFuncMap()
+	funcs["logicalID"] = logicalID
+	funcs["folderID"] = folderID
+	funcs["yamlString"] = yamlString
+	funcs["hclString"] = hclString
+
+	rendered := make(map[string]string, len(files))
+	for filename, tmplName := range files {
+		tmpl, err := template.New(tmplName).
+			Funcs(funcs).
+			ParseFS(templateFS, "templates/"+tmplName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", tmplName, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, res); err != nil {
+			return nil, fmt.Errorf("failed to execute %s template: %w", tmplName, err)
+		}
+		rendered[filename] = buf.String()
+	}
+
+	return rendered, nil
+}
+
+// Write renders the resource definitions into destDir and returns the written paths in sorted order
+func Write(format Format, res *AWSResources, destDir string) ([]string, error) {
+	rendered, err := Render(format, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Clean(destDir), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", destDir, err)
+	}
+
+	var paths []string
+	for filename, content := range rendered {
+		path := filepath.Join(destDir, filename)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// Validate checks that the definitions contain everything the renderers need
+func (r *AWSResources) Validate() error {
+	if r == nil {
+		return errors.New("no resource definitions provided")
+	}
+	if r.Region == "" {
+		return errors.New("aws region is required to render IaC")
+	}
+	if r.Bucket == "" {
+		return errors.New("results bucket name is required to render IaC")
+	}
+	if len(r.Workgroups) == 0 {
+		return errors.New("at least one Athena workgroup is required to render IaC")
+	}
+	return nil
+}
+
+// yamlString quotes s as a YAML double-quoted scalar, so names, descriptions and tag
+// values cannot break the CloudFormation template whatever characters they hold
+func yamlString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // encoding a string cannot fail
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// hclString quotes s as an HCL string literal. Template sequences are escaped so
+// Terraform takes the value literally instead of interpolating it.
+func hclString(s string) string {
+	quoted := yamlString(s)
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
+
+// logicalID converts a workgroup role (e.g. "dbt") into a CloudFormation logical ID prefix ("Dbt")
+func logicalID(role string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range role {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// folderID converts an S3 prefix (e.g. "dbt/") into a Terraform resource name suffix ("dbt")
+func folderID(folder string) string {
+	id := strings.Trim(folder, "/")
+	return strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(id)
+}
@@ -0,0 +1,201 @@
+package iac
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func testResources() *AWSResources {
+	return NewAWSResources("demo", "eu-west-1", "demo-bucket", "demo-dbt", "demo-adhoc")
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{"terraform", FormatTerraform, false},
+		{"tf", FormatTerraform, false},
+		{"CloudFormation", FormatCloudFormation, false},
+		{"cfn", FormatCloudFormation, false},
+		{"pulumi", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAWSResources(t *testing.T) {
+	res := testResources()
+
+	if len(res.Workgroups) != 2 {
+		t.Fatalf("expected 2 workgroups, got %d", len(res.Workgroups))
+	}
+	if res.Workgroups[0].OutputLocation != "s3://demo-bucket/demo-dbt/" {
+		t.Errorf("unexpected dbt output location %q", res.Workgroups[0].OutputLocation)
+	}
+
+	noAdhoc := NewAWSResources("demo", "eu-west-1", "demo-bucket", "demo-dbt", "")
+	if len(noAdhoc.Workgroups) != 1 {
+		t.Errorf("expected empty adhoc workgroup to be skipped, got %d workgroups", len(noAdhoc.Workgroups))
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		file     string
+		contains []string
+	}{
+		{
+			name:   "terraform main",
+			format: FormatTerraform,
+			file:   "main.tf",
+			contains: []string{
+				`region = "eu-west-1"`,
+				`bucket = "demo-bucket"`,
+				`"ecos:managed" = "true"`,
+				`"ecos:project" = "demo"`,
+				`resource "aws_athena_workgroup" "dbt"`,
+				`resource "aws_athena_workgroup" "adhoc"`,
+				`resource "aws_s3_object" "folder_dbt"`,
+				"s3://demo-bucket/demo-adhoc/",
+				"days = 30",
+				"days_after_initiation = 7",
+			},
+		},
+		{
+			name:     "terraform outputs",
+			format:   FormatTerraform,
+			file:     "outputs.tf",
+			contains: []string{`output "results_bucket"`, `output "dbt_workgroup"`},
+		},
+		{
+			name:   "cloudformation",
+			format: FormatCloudFormation,
+			file:   "ecos-resources.yaml",
+			contains: []string{
+				"AWS::S3::Bucket",
+				`BucketName: "demo-bucket"`,
+				"DbtWorkGroup:",
+				"AdhocWorkGroup:",
+				"ExpirationInDays: 30",
+				"DaysAfterInitiation: 7",
+				`Value: "demo"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Render(tt.format, testResources())
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			content, ok := files[tt.file]
+			if !ok {
+				t.Fatalf("Render() did not produce %s", tt.file)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(content, want) {
+					t.Errorf("%s missing %q", tt.file, want)
+				}
+			}
+		})
+	}
+}
+
+// cfnTag is a tag of a rendered CloudFormation resource
+type cfnTag struct {
+	Key   string `yaml:"Key"`
+	Value string `yaml:"Value"`
+}
+
+func TestRenderEscapesValues(t *testing.T) {
+	project := "acme \"finops\": ${var.x} %{if}\nteam"
+	res := NewAWSResources(project, "eu-west-1", "demo-bucket", "demo-dbt", "demo-adhoc")
+
+	files, err := Render(FormatCloudFormation, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stack struct {
+		Description string `yaml:"Description"`
+		Resources   map[string]struct {
+			Properties struct {
+				Description string   `yaml:"Description"`
+				Tags        []cfnTag `yaml:"Tags"`
+			} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	if err := yaml.Unmarshal([]byte(files["ecos-resources.yaml"]), &stack); err != nil {
+		t.Fatalf("rendered CloudFormation is not valid YAML: %v", err)
+	}
+	if !strings.Contains(stack.Description, project) {
+		t.Errorf("Description = %q", stack.Description)
+	}
+	wg := stack.Resources["DbtWorkGroup"].Properties
+	if !strings.HasSuffix(wg.Description, project) || !slices.Contains(wg.Tags, cfnTag{Key: "ecos:project", Value: project}) {
+		t.Errorf("workgroup properties = %+v", wg)
+	}
+
+	files, err = Render(FormatTerraform, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"ecos:project" = "acme \"finops\": $${var.x} %%{if}\nteam"`
+	if !strings.Contains(files["main.tf"], want) {
+		t.Errorf("main.tf missing %s", want)
+	}
+	if strings.Contains(files["main.tf"], "\nteam") {
+		t.Error("main.tf header comment broken by a newline in the project name")
+	}
+}
+
+func TestRenderValidation(t *testing.T) {
+	res := testResources()
+	res.Bucket = ""
+
+	if _, err := Render(FormatTerraform, res); err == nil {
+		t.Error("Render() expected error for missing bucket, got nil")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "infra", "terraform")
+
+	paths, err := Write(FormatTerraform, testResources(), dir)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "main.tf"), filepath.Join(dir, "outputs.tf")}
+	if len(paths) != len(want) {
+		t.Fatalf("Write() returned %v, want %v", paths, want)
+	}
+	for i, path := range want {
+		if paths[i] != path {
+			t.Errorf("paths[%d] = %q, want %q", i, paths[i], path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package iac
+
+import "fmt"
+
+const (
+	// AdhocQueryRetentionDays is the number of days to retain adhoc query results before deletion
+	AdhocQueryRetentionDays = 30
+	// IncompleteUploadCleanupDays is the number of days after which incomplete multipart uploads are aborted
+	IncompleteUploadCleanupDays = 7
+)
+
+// DefaultFolders lists the S3 prefixes ecos creates inside the results bucket
+var DefaultFolders = []string{"dbt/", "adhoc/", "temp/"}
+
+// Tag represents a key/value tag applied to ecos-managed resources
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// LifecycleRule describes an S3 lifecycle rule on the results bucket.
+// ExpirationDays and AbortIncompleteUploadDays are optional (zero means unset).
+type LifecycleRule struct {
+	ID                        string
+	Prefix                    string
+	ExpirationDays            int32
+	AbortIncompleteUploadDays int32
+}
+
+// Workgroup describes an Athena workgroup provisioned for the project
+type Workgroup struct {
+	Role           string // "dbt" or "adhoc"
+	Name           string
+	OutputLocation string
+	Description    string
+}
+
+// AWSResources is the provider-neutral definition of everything `ecos init`
+// provisions for an AWS project. The Go provisioning code and the IaC renderers
+// both read from it so names, settings and tags cannot drift apart.
+type AWSResources struct {
+	ProjectName    string
+	Region         string
+	Bucket         string
+	Folders        []string
+	LifecycleRules []LifecycleRule
+	Workgroups     []Workgroup
+	Tags           []Tag
+}
+
+// NewAWSResources builds the resource definitions for a project. Empty workgroup
+// names are skipped so projects without an adhoc workgroup render cleanly.
+func NewAWSResources(projectName, region, bucket, dbtWorkgroup, adhocWorkgroup string) *AWSResources {
+	res := &AWSResources{
+		ProjectName:    projectName,
+		Region:         region,
+		Bucket:         bucket,
+		Folders:        append([]string(nil), DefaultFolders...),
+		LifecycleRules: DefaultLifecycleRules(),
+		Tags:           ManagedTags(projectName),
+	}
+
+	for _, wg := range []struct{ role, name string }{
+		{"dbt", dbtWorkgroup},
+		{"adhoc", adhocWorkgroup},
+	} {
+		if wg.name == "" {
+			continue
+		}
+		res.Workgroups = append(res.Workgroups, Workgroup{
+			Role:           wg.role,
+			Name:           wg.name,
+			OutputLocation: WorkgroupOutputLocation(bucket, wg.name),
+			Description:    fmt.Sprintf("Workgroup created by ecos cli for project %s", projectName),
+		})
+	}
+
+	return res
+}
+
+// DefaultLifecycleRules returns the lifecycle rules for the query results bucket
+// - DeleteAdhocQueryResultsAfter30Days: Deletes objects in adhoc/ folder after AdhocQueryRetentionDays
+// - DeleteIncompleteMultipartUploads: Aborts incomplete multipart uploads after IncompleteUploadCleanupDays (bucket-wide)
+func DefaultLifecycleRules() []LifecycleRule {
+	return []LifecycleRule{
+		{
+			ID:             "DeleteAdhocQueryResultsAfter30Days",
+			Prefix:         "adhoc/",
+			ExpirationDays: AdhocQueryRetentionDays,
+		},
+		{
+			ID:                        "DeleteIncompleteMultipartUploads",
+			Prefix:                    "",
+			AbortIncompleteUploadDays: IncompleteUploadCleanupDays,
+		},
+	}
+}
+
+// ManagedTags returns the tags that mark a resource as ecos-managed
+func ManagedTags(projectName string) []Tag {
+	return []Tag{
+		{Key: "ecos:managed", Value: "true"},
+		{Key: "ecos:project", Value: projectName},
+	}
+}
+
+// WorkgroupOutputLocation returns the S3 location where a workgroup writes query results
+func WorkgroupOutputLocation(bucket, workgroup string) string {
+	return fmt.Sprintf("s3://%s/%s/", bucket, workgroup)
+}
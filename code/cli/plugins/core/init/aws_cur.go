@@ -10,13 +10,17 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/athena"
 	athenaTypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	glueTypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/iac"
 	initUtils "github.com/ecos-labs/ecos/code/cli/plugins/core/init/utils"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/permissions"
 	"github.com/ecos-labs/ecos/code/cli/plugins/registry"
 	initTypes "github.com/ecos-labs/ecos/code/cli/plugins/types"
 	"github.com/ecos-labs/ecos/code/cli/utils"
@@ -24,9 +28,9 @@ import (
 
 const (
 	// AdhocQueryRetentionDays is the number of days to retain adhoc query results before deletion
-	AdhocQueryRetentionDays = 30
+	AdhocQueryRetentionDays = iac.AdhocQueryRetentionDays
 	// IncompleteUploadCleanupDays is the number of days after which incomplete multipart uploads are aborted
-	IncompleteUploadCleanupDays = 7
+	IncompleteUploadCleanupDays = iac.IncompleteUploadCleanupDays
 )
 
 // normalizeDatabaseName converts a project name to a valid database name by replacing
@@ -36,6 +40,17 @@ func normalizeDatabaseName(projectName string) string {
 	return strings.ReplaceAll(strings.ReplaceAll(projectName, " ", "_"), "-", "_")
 }
 
+// nonEmpty returns the given values without empty strings
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // ------------------- Plugin Metadata -----------------------
 
 type AWSCURInitPlugin struct {
@@ -43,18 +58,51 @@ type AWSCURInitPlugin struct {
 	Force      bool
 	OutputPath string
 	SkipPrereq bool
+	// IaCFormat, when set, renders the cloud resources as infrastructure-as-code
+	// under the project instead of creating them through the AWS APIs.
+	IaCFormat iac.Format
+
+	// baseAWSConfig caches the loaded AWS config so MFA codes are only requested once
+	baseAWSConfig *aws.Config
+
+	// dataSource is the data_source written to .ecos.yaml and the models downloaded
+	// (aws_cur when empty), exportType the data export offered at the table prompt
+	// (CUR 2.0 when empty). The FOCUS plugin reuses this plugin with its own values.
+	dataSource string
+	exportType string
+}
+
+// source returns the data source the project is set up for
+func (p *AWSCURInitPlugin) source() string {
+	if p.dataSource == "" {
+		return "aws_cur"
+	}
+	return p.dataSource
+}
+
+// newExportType returns the type of the data export init offers to create
+func (p *AWSCURInitPlugin) newExportType() string {
+	if p.exportType == "" {
+		return dataexport.TypeCUR2
+	}
+	return p.exportType
 }
 
 // AWSCURInput represents the user input for AWS CUR initialization
 type AWSCURInput struct {
-	ProjectName      string `mapstructure:"project_name"`
-	TransformTool    string `mapstructure:"transform_tool"`
-	SQLEngine        string `mapstructure:"sql_engine"`
-	CURDatabase      string `mapstructure:"cur_database"`
-	CURSchema        string `mapstructure:"cur_schema"`
-	CURTable         string `mapstructure:"cur_table"`
-	AWSRegion        string `mapstructure:"aws_region"`
-	AWSProfile       string `mapstructure:"aws_profile"`
+	ProjectName   string `mapstructure:"project_name"`
+	TransformTool string `mapstructure:"transform_tool"`
+	SQLEngine     string `mapstructure:"sql_engine"`
+	CURDatabase   string `mapstructure:"cur_database"`
+	CURSchema     string `mapstructure:"cur_schema"`
+	CURTable      string `mapstructure:"cur_table"`
+	AWSRegion     string `mapstructure:"aws_region"`
+	AWSProfile    string `mapstructure:"aws_profile"`
+	AWSAccess     config.AWSAccessConfig
+	// CURInfo holds the detected CUR version and optional columns, when the table was inspected
+	CURInfo *initUtils.CURTable
+	// DataExport is set when ecos creates a BCM Data Export instead of using an existing CUR table
+	DataExport       *config.DataExportConfig
 	CreateResources  bool   `mapstructure:"create_resources"`
 	SkipProvisioning bool   `mapstructure:"skip_provisioning"`
 	DBTWorkgroup     string `mapstructure:"dbt_workgroup"`
@@ -64,6 +112,9 @@ type AWSCURInput struct {
 	AccountID        string `mapstructure:"account_id"`
 	DetectedRegion   string `mapstructure:"detected_region"`
 	ModelVersion     string `mapstructure:"model_version"`
+	ModelSource      string `mapstructure:"model_source"`
+	Offline          bool   `mapstructure:"offline"`
+	AllowUnsigned    bool   `mapstructure:"allow_unsigned"`
 	DryRun           bool   `mapstructure:"dry_run"`
 }
 
@@ -75,13 +126,10 @@ type MaterializationConfig struct {
 	Gold   string // Gold layer materialization
 }
 
+// DefaultMaterializationConfig builds every model as a view. No layer is overridden, so
+// switching the mode to smart applies the models' own materializations.
 func DefaultMaterializationConfig() MaterializationConfig {
-	return MaterializationConfig{
-		Mode:   "view",
-		Bronze: "view",
-		Silver: "view",
-		Gold:   "view",
-	}
+	return MaterializationConfig{Mode: "view"}
 }
 
 func (p *AWSCURInitPlugin) Name() string               { return "aws-cur-init" }
@@ -139,7 +187,95 @@ func (p *AWSCURInitPlugin) ValidatePrerequisites() error {
 		DBTAdapter: "athena", // dbt-athena adapter (shows as "athena" in dbt --version)
 	}
 
-	return initUtils.RunPrerequisiteChecks(context.Background(), config)
+	if err := initUtils.RunPrerequisiteChecks(context.Background(), config); err != nil {
+		return err
+	}
+
+	return p.checkPermissions()
+}
+
+// checkPermissions simulates the IAM actions init needs for the current identity so
+// missing permissions are reported before anything is created. The check is skipped
+// when no resources will be created, and only warns when simulation itself is not possible.
+func (p *AWSCURInitPlugin) checkPermissions() error {
+	if p.Config == nil || !p.Config.CreateResources || p.Config.DryRun || p.IaCFormat != "" {
+		return nil
+	}
+
+	scope := permissions.Scope{
+		AccountID:   p.Config.AccountID,
+		Region:      p.Config.AWSRegion,
+		Bucket:      p.Config.ResultsBucket,
+		Workgroups:  nonEmpty(p.Config.DBTWorkgroup, p.Config.AdhocWorkgroup),
+		CURDatabase: p.Config.CURSchema,
+		CURTable:    p.Config.CURTable,
+	}
+	if p.Config.DataExport != nil {
+		scope.DataExport = p.Config.DataExport.Name
+		scope.DataExportDatabase = p.Config.DataExport.GlueDatabase
+	}
+	return p.preflight(scope)
+}
+
+// checkExportPermissions runs the permission check for the resources a new data export
+// needs as soon as the export is chosen, so missing provisioning rights are reported
+// before the rest of the setup rather than at the end of init
+func (p *AWSCURInitPlugin) checkExportPermissions(ctx context.Context, export config.DataExportConfig) error {
+	if p.Config.DryRun {
+		return nil
+	}
+
+	accountID, _, err := p.awsAccountAndRegion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get AWS account for the data export: %w", err)
+	}
+	projectName := strings.ReplaceAll(p.Config.ProjectName, " ", "-")
+
+	return p.preflight(permissions.Scope{
+		AccountID:          accountID,
+		Region:             p.Config.AWSRegion,
+		Bucket:             fmt.Sprintf("%s-bucket-%s-%s", projectName, accountID, p.Config.AWSRegion),
+		Workgroups:         []string{projectName + "-dbt", projectName + "-adhoc"},
+		CURDatabase:        export.GlueDatabase,
+		CURTable:           export.GlueTable,
+		DataExport:         export.Name,
+		DataExportDatabase: export.GlueDatabase,
+	})
+}
+
+// preflight simulates the init IAM actions on the resources in scope and fails when
+// any is denied
+func (p *AWSCURInitPlugin) preflight(scope permissions.Scope) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	awsCfg, err := p.awsConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	sp := utils.NewSpinner("Checking IAM permissions")
+	sp.Start()
+
+	denials, err := permissions.Preflight(ctx, awsCfg, p.Config.AWSAccess.Endpoints, permissions.PurposeInit, scope)
+	if err != nil {
+		sp.Stop()
+		utils.PrintWarning(fmt.Sprintf("IAM permission check skipped: %v", err))
+		return nil
+	}
+
+	if len(denials) == 0 {
+		sp.Success("IAM permissions verified")
+		return nil
+	}
+
+	sp.Error("Missing IAM permissions")
+	for _, line := range permissions.FormatDenials(denials) {
+		fmt.Printf("  • %s\n", line)
+	}
+	utils.PrintInfo("Run 'ecos iam policy --for init' to generate the required policy")
+
+	return fmt.Errorf("%d required IAM actions are denied for the current identity", len(denials))
 }
 
 func (p *AWSCURInitPlugin) RunInteractiveSetup() error {
@@ -194,61 +330,42 @@ func (p *AWSCURInitPlugin) RunInteractiveSetup() error {
 	}
 	p.Config.SQLEngine = engineOpts[engineIdx].Code
 
-	// 4. CUR Datasource Details
-	utils.PrintSubHeader("🗄️ CUR Datasource Details")
-
-	// 4a. Database
-	curDatabase, err := utils.Input("Database", "awsdatacatalog", true, true, nil)
-	if err != nil {
-		return err
-	}
-	p.Config.CURDatabase = curDatabase
-
-	// 4b. Schema
-	curSchema, err := utils.Input("Schema", "cur", true, true, nil)
-	if err != nil {
-		return err
-	}
-	p.Config.CURSchema = curSchema
-
-	// 4c. Table
-	curTable, err := utils.Input("Table", "cur-data", true, true, nil)
-	if err != nil {
-		return err
-	}
-	p.Config.CURTable = curTable
-
-	fmt.Println()
-
-	// 5. Project Name
+	// 4. Project Name
 	uiProjectName, err := utils.Input("Project Name", "my-cost-analysis", true, false, nil)
 	if err != nil {
 		return err
 	}
 	p.Config.ProjectName = uiProjectName
 
-	// 6. AWS Configuration
+	// 5. AWS Configuration
 	utils.PrintSubHeader("☁️ AWS Configuration")
 
-	// 6a. AWS Region
+	// 5a. AWS Region
 	awsRegion, err := utils.Input("Region", "eu-west-1", true, true, p.ValidateRegion)
 	if err != nil {
 		return err
 	}
 	p.Config.AWSRegion = awsRegion
 
-	// 6b. AWS Profile
+	// 5b. AWS Profile
 	awsProfile, err := utils.Input("Profile", "default", true, true, nil)
 	if err != nil {
 		return err
 	}
 	p.Config.AWSProfile = awsProfile
 
+	// 6. CUR Datasource Details (discovered with the selected profile and region)
+	utils.PrintSubHeader("🗄️ CUR Datasource Details")
+	if err := p.selectCURTable(ctx); err != nil {
+		return err
+	}
+	fmt.Println()
+
 	// 7. Resource Preview
 	projectName := strings.ReplaceAll(uiProjectName, " ", "-")
 
 	// Get account ID for resource naming using the specified profile
-	accountID, detectedRegion, err := initUtils.GetAWSAccountAndRegionWithProfile(ctx, 0, awsProfile)
+	accountID, detectedRegion, err := p.awsAccountAndRegion(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get AWS account and region for resource preview: %w", err)
 	}
@@ -267,18 +384,37 @@ func (p *AWSCURInitPlugin) RunInteractiveSetup() error {
 		{"Workgroup", fmt.Sprintf("%s-dbt", projectName)},
 		{"Workgroup", fmt.Sprintf("%s-adhoc", projectName)},
 	}
+	if export := p.Config.DataExport; export != nil {
+		rows = append(rows,
+			[]string{"Data Export", export.Name},
+			[]string{"Glue Table", export.GlueDatabase + "." + export.GlueTable},
+		)
+	}
 	utils.PrintTable(headers, rows)
 	fmt.Println()
 
 	// 8. Resource Provisioning
+	if p.IaCFormat != "" {
+		p.Config.DBTWorkgroup = fmt.Sprintf("%s-dbt", projectName)
+		p.Config.AdhocWorkgroup = fmt.Sprintf("%s-adhoc", projectName)
+		p.Config.ResultsBucket = fmt.Sprintf("%s-bucket-%s-%s", projectName, accountID, awsRegion)
+		p.Config.AccountID = accountID
+		utils.PrintInfo(fmt.Sprintf("These resources will be rendered as %s under %s instead of being created", p.IaCFormat, p.iacOutputDir()))
+		return nil
+	}
+
 	provisionItems := []string{
 		"Have ecos provision these resources (recommended)",
 		"Use existing AWS resources",
 		"Skip provisioning",
 	}
-	provisionIdx, _, err := utils.Select("Resource Provisioning", provisionItems, 0, false, false)
-	if err != nil {
-		return err
+	// A new data export is delivered into the ecos bucket, so only provisioning applies
+	provisionIdx := 0
+	if p.Config.DataExport == nil {
+		provisionIdx, _, err = utils.Select("Resource Provisioning", provisionItems, 0, false, false)
+		if err != nil {
+			return err
+		}
 	}
 
 	switch provisionIdx {
@@ -332,6 +468,10 @@ func (p *AWSCURInitPlugin) RunInteractiveSetup() error {
 		fmt.Println("Skipping automatic provisioning")
 	}
 
+	if p.Config.DataExport != nil && !p.Config.CreateResources {
+		return errors.New("a new data export is delivered into the ecos bucket, so ecos must provision the resources")
+	}
+
 	return nil
 }
 
@@ -357,19 +497,18 @@ func (p *AWSCURInitPlugin) GenerateConfig() error {
 	// Generate ecos configuration using template
 	utils.PrintDebug("Generating .ecos.yaml configuration file")
 	ecosConfigData := config.EcosConfigTemplate{
-		ProjectName:  userInput.ProjectName,
-		ModelVersion: userInput.ModelVersion,
-		DataSource:   "aws_cur",
-		ProjectDir:   projectDir,
-		ProfileDir:   projectDir,
-		Profile:      "ecos-athena",
-		Target:       "prod",
-		AWSProfile:   userInput.AWSProfile,
-		DatasourceVars: []config.DatasourceVar{
-			{Key: "cur_database", Value: userInput.CURDatabase},
-			{Key: "cur_schema", Value: userInput.CURSchema},
-			{Key: "cur_table", Value: userInput.CURTable},
-		},
+		ProjectName:           userInput.ProjectName,
+		ModelVersion:          userInput.ModelVersion,
+		ModelSource:           userInput.ModelSource,
+		DataSource:            p.source(),
+		ProjectDir:            projectDir,
+		ProfileDir:            projectDir,
+		Profile:               "ecos-athena",
+		Target:                "prod",
+		AWSProfile:            userInput.AWSProfile,
+		AWSAccess:             userInput.AWSAccess,
+		DataExport:            userInput.DataExport,
+		DatasourceVars:        userInput.datasourceVars(),
 		AWSRegion:             userInput.AWSRegion,
 		Database:              database,
 		DBTWorkgroup:          dbtWorkgroup,
@@ -397,6 +536,11 @@ func (p *AWSCURInitPlugin) GenerateConfig() error {
 func (p *AWSCURInitPlugin) CreateResources() error {
 	userInput := p.Config
 
+	// Render IaC instead of calling AWS when --emit was requested
+	if p.IaCFormat != "" {
+		return p.emitIaC()
+	}
+
 	// If user chose not to create resources, just return success
 	if !userInput.CreateResources {
 		utils.PrintInfo("Cloud resources skipped")
@@ -415,7 +559,7 @@ func (p *AWSCURInitPlugin) CreateResources() error {
 	}
 
 	// Validate credentials and get account ID using the specified profile
-	accountID, detectedRegion, err := initUtils.GetAWSAccountAndRegionWithProfile(ctx, 0, userInput.AWSProfile)
+	accountID, detectedRegion, err := p.awsAccountAndRegion(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to validate AWS credentials: %w", err)
 	}
@@ -424,12 +568,7 @@ func (p *AWSCURInitPlugin) CreateResources() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
 	defer cancel()
 
-	var awsCfg aws.Config
-	if userInput.AWSProfile != "" && userInput.AWSProfile != "default" {
-		awsCfg, err = awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(userInput.AWSRegion), awsconfig.WithSharedConfigProfile(userInput.AWSProfile))
-	} else {
-		awsCfg, err = awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(userInput.AWSRegion))
-	}
+	awsCfg, err := p.awsConfig(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to load AWS config: %w", err)
 	}
@@ -438,17 +577,12 @@ func (p *AWSCURInitPlugin) CreateResources() error {
 	userInput.AccountID = accountID
 	userInput.DetectedRegion = detectedRegion
 
-	athenaClient := athena.NewFromConfig(awsCfg)
-	s3Client := s3.NewFromConfig(awsCfg)
+	athenaClient := awssession.NewAthenaClient(awsCfg, p.Config.AWSAccess.Endpoints)
+	s3Client := awssession.NewS3Client(awsCfg, p.Config.AWSAccess.Endpoints)
 
 	// Extract config
-	bucketName := userInput.ResultsBucket
-	workgroups := []string{
-		userInput.DBTWorkgroup,
-		userInput.AdhocWorkgroup,
-	}
-	folders := []string{"dbt/", "adhoc/", "temp/"}
-	projectName := userInput.ProjectName
+	resources := p.resourceDefinitions()
+	bucketName := resources.Bucket
 
 	spinner := utils.NewSpinner("Creating AWS resources...")
 	spinner.Start()
@@ -469,7 +603,7 @@ func (p *AWSCURInitPlugin) CreateResources() error {
 	}
 
 	// Create S3 folders
-	for _, folder := range folders {
+	for _, folder := range resources.Folders {
 		folderResult := p.createS3Folder(s3Client, bucketName, folder, bucketExists)
 		results = append(results, folderResult)
 		//nolint:staticcheck // QF1003: Using if/else instead of switch - we only need to check specific error/partial states, not exhaustive matching
@@ -481,8 +615,8 @@ func (p *AWSCURInitPlugin) CreateResources() error {
 	}
 
 	// Create Athena workgroups
-	for _, wg := range workgroups {
-		wgResult := p.createAthenaWorkgroup(athenaClient, wg, bucketName, projectName, bucketExists)
+	for _, wg := range resources.Workgroups {
+		wgResult := p.createAthenaWorkgroup(athenaClient, wg, resources.Tags, bucketExists)
 		results = append(results, wgResult)
 		//nolint:staticcheck // QF1003: Using if/else instead of switch - we only need to check specific error/partial states, not exhaustive matching
 		if wgResult.Status == initTypes.InitStatusFailed {
@@ -492,6 +626,26 @@ func (p *AWSCURInitPlugin) CreateResources() error {
 		}
 	}
 
+	// Create the data export once the bucket it is delivered to exists
+	if userInput.DataExport != nil && bucketExists {
+		exportCtx, exportCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		exportResults := dataexport.Provision(exportCtx, dataexport.NewClients(awsCfg, p.Config.AWSAccess.Endpoints), dataexport.ProvisionInput{
+			Export:    *userInput.DataExport,
+			Bucket:    bucketName,
+			Region:    userInput.AWSRegion,
+			AccountID: accountID,
+			Partition: permissions.Partition(userInput.AWSRegion),
+			Tags:      resources.Tags,
+		})
+		exportCancel()
+		for _, res := range exportResults {
+			if res.Status == initTypes.InitStatusFailed {
+				hasError = true
+			}
+		}
+		results = append(results, exportResults...)
+	}
+
 	// Show results
 	//nolint:gocritic // ifElseChain - if-else chain is clearer than switch for boolean conditions
 	if hasError {
@@ -513,6 +667,10 @@ func (p *AWSCURInitPlugin) CreateResources() error {
 		return errors.New("one or more resources failed to create")
 	}
 
+	if userInput.DataExport != nil {
+		utils.PrintInfo("AWS delivers the first data export within 24 hours; 'ecos transform' finds no cost data until then")
+	}
+
 	return nil
 }
 
@@ -542,22 +700,28 @@ func (p *AWSCURInitPlugin) DownloadTransformModels() (string, error) {
 	spinner.Start()
 	defer spinner.Stop()
 
-	ghClient, err := initUtils.NewGitHubClient()
+	downloader, err := initUtils.NewModelDownloader(userInput.ModelSource, awssession.Options{
+		Region:  userInput.AWSRegion,
+		Profile: userInput.AWSProfile,
+		Access:  userInput.AWSAccess,
+	})
 	if err != nil {
-		spinner.Error("Failed to create GitHub client")
-		return "", fmt.Errorf("failed to create GitHub client: %w", err)
+		spinner.Error("Invalid model source")
+		return "", err
 	}
+	downloader.Offline = userInput.Offline
+	downloader.AllowUnsigned = userInput.AllowUnsigned
 
 	ctx := context.Background()
 	destPath := filepath.Join(p.OutputPath, "transform", "dbt")
 
-	version, err := ghClient.DownloadTransformModels(ctx, "aws_cur", userInput.ModelVersion, destPath)
+	version, err := downloader.DownloadTransformModels(ctx, p.source(), userInput.ModelVersion, destPath)
 	if err != nil {
 		spinner.Error("Failed to download transform models")
 		return "", fmt.Errorf("transform models download failed: %w", err)
 	}
 
-	spinner.Success(fmt.Sprintf("Transform models for aws_cur downloaded successfully (version: %s)", version))
+	spinner.Success(fmt.Sprintf("Transform models for %s downloaded successfully (version: %s)", p.source(), version))
 	return version, nil
 }
 
@@ -571,6 +735,24 @@ func (p *AWSCURInitPlugin) SetModelVersion(version string) error {
 	return nil
 }
 
+func (p *AWSCURInitPlugin) SetModelSource(source string) error {
+	if _, err := initUtils.ParseModelSource(source, awssession.Options{}); err != nil {
+		return err
+	}
+	p.Config.ModelSource = source
+	return nil
+}
+
+func (p *AWSCURInitPlugin) SetOffline(offline bool) error {
+	p.Config.Offline = offline
+	return nil
+}
+
+func (p *AWSCURInitPlugin) SetAllowUnsigned(allow bool) error {
+	p.Config.AllowUnsigned = allow
+	return nil
+}
+
 func (p *AWSCURInitPlugin) createS3Bucket(s3Client *s3.Client, bucketName, region string) initTypes.InitResourceResult {
 	// Check if bucket exists
 	_, err := s3Client.HeadBucket(context.Background(), &s3.HeadBucketInput{
@@ -631,14 +813,13 @@ func (p *AWSCURInitPlugin) createS3Bucket(s3Client *s3.Client, bucketName, regio
 	}
 
 	// Tag bucket as ecos-managed
+	var tagSet []s3Types.Tag
+	for _, tag := range iac.ManagedTags(p.Config.ProjectName) {
+		tagSet = append(tagSet, s3Types.Tag{Key: aws.String(tag.Key), Value: aws.String(tag.Value)})
+	}
 	_, err = s3Client.PutBucketTagging(context.Background(), &s3.PutBucketTaggingInput{
-		Bucket: aws.String(bucketName),
-		Tagging: &s3Types.Tagging{
-			TagSet: []s3Types.Tag{
-				{Key: aws.String("ecos:managed"), Value: aws.String("true")},
-				{Key: aws.String("ecos:project"), Value: aws.String(p.Config.ProjectName)},
-			},
-		},
+		Bucket:  aws.String(bucketName),
+		Tagging: &s3Types.Tagging{TagSet: tagSet},
 	})
 	if err != nil {
 		warnings = append(warnings, fmt.Sprintf("Failed to tag bucket %q with ecos:managed and ecos:project tags: %v", bucketName, err))
@@ -712,9 +893,11 @@ func (p *AWSCURInitPlugin) createS3Folder(s3Client *s3.Client, bucketName, folde
 
 func (p *AWSCURInitPlugin) createAthenaWorkgroup(
 	athenaClient *athena.Client,
-	workgroupName, bucketName, projectName string,
+	workgroup iac.Workgroup,
+	tags []iac.Tag,
 	bucketExists bool,
 ) initTypes.InitResourceResult {
+	workgroupName := workgroup.Name
 	if !bucketExists {
 		return initTypes.InitResourceResult{
 			Kind:   "Athena Workgroup",
@@ -742,13 +925,13 @@ func (p *AWSCURInitPlugin) createAthenaWorkgroup(
 		Name: aws.String(workgroupName),
 		Configuration: &athenaTypes.WorkGroupConfiguration{
 			ResultConfiguration: &athenaTypes.ResultConfiguration{
-				OutputLocation: aws.String(fmt.Sprintf("s3://%s/%s/", bucketName, workgroupName)),
+				OutputLocation: aws.String(workgroup.OutputLocation),
 			},
 			EnforceWorkGroupConfiguration:   aws.Bool(true),
 			PublishCloudWatchMetricsEnabled: aws.Bool(true),
 			RequesterPaysEnabled:            aws.Bool(false),
 		},
-		Description: aws.String(fmt.Sprintf("Workgroup created by ecos cli for project %s", projectName)),
+		Description: aws.String(workgroup.Description),
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
@@ -779,12 +962,13 @@ func (p *AWSCURInitPlugin) createAthenaWorkgroup(
 	)
 
 	// Tag workgroup as ecos-managed
+	var athenaTags []athenaTypes.Tag
+	for _, tag := range tags {
+		athenaTags = append(athenaTags, athenaTypes.Tag{Key: aws.String(tag.Key), Value: aws.String(tag.Value)})
+	}
 	_, err = athenaClient.TagResource(context.Background(), &athena.TagResourceInput{
 		ResourceARN: aws.String(wgARN),
-		Tags: []athenaTypes.Tag{
-			{Key: aws.String("ecos:managed"), Value: aws.String("true")},
-			{Key: aws.String("ecos:project"), Value: aws.String(projectName)},
-		},
+		Tags:        athenaTags,
 	})
 	if err != nil {
 		warnings = append(warnings, fmt.Sprintf("Failed to tag workgroup %q (ARN: %s) with ecos:managed and ecos:project tags: %v", workgroupName, wgARN, err))
@@ -843,33 +1027,27 @@ func (p *AWSCURInitPlugin) handleBucketError(bucketName string, err error) initT
 	}
 }
 
-// getLifecycleRules returns the lifecycle rules for S3 bucket query results
-// - DeleteAdhocQueryResultsAfter30Days: Deletes objects in adhoc/ folder after AdhocQueryRetentionDays
-// - DeleteIncompleteMultipartUploads: Aborts incomplete multipart uploads after IncompleteUploadCleanupDays (bucket-wide)
+// getLifecycleRules converts the shared lifecycle rule definitions (see iac.DefaultLifecycleRules)
+// into S3 API types for the query results bucket
 func getLifecycleRules() []s3Types.LifecycleRule {
-	// Filter for adhoc folder only
-	adhocPrefixFilter := &s3Types.LifecycleRuleFilterMemberPrefix{Value: "adhoc/"}
-	// Empty prefix filter for bucket-wide rules (incomplete multipart uploads)
-	emptyPrefixFilter := &s3Types.LifecycleRuleFilterMemberPrefix{Value: ""}
-
-	return []s3Types.LifecycleRule{
-		{
-			ID:     aws.String("DeleteAdhocQueryResultsAfter30Days"),
-			Status: s3Types.ExpirationStatusEnabled,
-			Filter: adhocPrefixFilter,
-			Expiration: &s3Types.LifecycleExpiration{
-				Days: aws.Int32(AdhocQueryRetentionDays),
-			},
-		},
-		{
-			ID:     aws.String("DeleteIncompleteMultipartUploads"),
+	var rules []s3Types.LifecycleRule
+	for _, def := range iac.DefaultLifecycleRules() {
+		rule := s3Types.LifecycleRule{
+			ID:     aws.String(def.ID),
 			Status: s3Types.ExpirationStatusEnabled,
-			Filter: emptyPrefixFilter,
-			AbortIncompleteMultipartUpload: &s3Types.AbortIncompleteMultipartUpload{
-				DaysAfterInitiation: aws.Int32(IncompleteUploadCleanupDays),
-			},
-		},
+			Filter: &s3Types.LifecycleRuleFilterMemberPrefix{Value: def.Prefix},
+		}
+		if def.ExpirationDays > 0 {
+			rule.Expiration = &s3Types.LifecycleExpiration{Days: aws.Int32(def.ExpirationDays)}
+		}
+		if def.AbortIncompleteUploadDays > 0 {
+			rule.AbortIncompleteMultipartUpload = &s3Types.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: aws.Int32(def.AbortIncompleteUploadDays),
+			}
+		}
+		rules = append(rules, rule)
 	}
+	return rules
 }
 
 func (p *AWSCURInitPlugin) showResourceSummary(results []initTypes.InitResourceResult) {
@@ -904,16 +1082,300 @@ func (p *AWSCURInitPlugin) showResourceSummary(results []initTypes.InitResourceR
 }
 
 func (p *AWSCURInitPlugin) showDryRunPreview(userInput *AWSCURInput) {
-	workgroups := []string{
-		userInput.DBTWorkgroup,
-		userInput.AdhocWorkgroup,
+	resources := p.resourceDefinitions()
+	var workgroups []string
+	for _, wg := range resources.Workgroups {
+		workgroups = append(workgroups, wg.Name)
 	}
-	folders := []string{"dbt/", "adhoc/", "temp/"}
 
 	utils.PrintDryRun("Would create the following resources:")
 	fmt.Printf("  • S3 Bucket: s3://%s\n", userInput.ResultsBucket)
 	fmt.Printf("  • Athena Workgroups: %s\n", strings.Join(workgroups, ", "))
-	fmt.Printf("  • S3 Folders: %s\n", strings.Join(folders, ", "))
+	fmt.Printf("  • S3 Folders: %s\n", strings.Join(resources.Folders, ", "))
+	if export := userInput.DataExport; export != nil {
+		fmt.Printf("  • Data Export: %s (%s, s3://%s/%s)\n", export.Name, export.Type, userInput.ResultsBucket, export.S3Prefix)
+		fmt.Printf("  • Glue Table: %s.%s\n", export.GlueDatabase, export.GlueTable)
+	}
+}
+
+// loadBaseAWSConfig loads the AWS config for the selected profile and access settings once
+func (p *AWSCURInitPlugin) loadBaseAWSConfig(ctx context.Context) (aws.Config, error) {
+	if p.baseAWSConfig == nil {
+		cfg, err := awssession.Load(ctx, awssession.Options{
+			Profile: p.Config.AWSProfile,
+			Access:  p.Config.AWSAccess,
+		})
+		if err != nil {
+			return aws.Config{}, err
+		}
+		p.baseAWSConfig = &cfg
+	}
+	return *p.baseAWSConfig, nil
+}
+
+// awsConfig returns the AWS config pinned to the selected region
+func (p *AWSCURInitPlugin) awsConfig(ctx context.Context) (aws.Config, error) {
+	base, err := p.loadBaseAWSConfig(ctx)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	cfg := base.Copy()
+	if p.Config.AWSRegion != "" {
+		cfg.Region = p.Config.AWSRegion
+	}
+	return cfg, nil
+}
+
+// awsAccountAndRegion returns the account ID of the current identity and the region
+// configured in the AWS profile (which may differ from the selected region)
+func (p *AWSCURInitPlugin) awsAccountAndRegion(ctx context.Context) (accountID, detectedRegion string, err error) {
+	base, err := p.loadBaseAWSConfig(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	cfg, err := p.awsConfig(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	accountID, _, err = initUtils.GetAWSAccountAndRegionFromConfig(ctx, cfg, p.Config.AWSAccess.Endpoints)
+	if err != nil {
+		return "", "", err
+	}
+	return accountID, base.Region, nil
+}
+
+// selectCURTable asks for the CUR catalog, offers the CUR tables found in the Glue
+// Data Catalog in a picker and inspects the chosen table's columns. A missing or
+// non-CUR table fails here instead of on the first dbt run.
+func (p *AWSCURInitPlugin) selectCURTable(ctx context.Context) error {
+	catalog, err := utils.Input("Database", "awsdatacatalog", true, true, nil)
+	if err != nil {
+		return err
+	}
+	p.Config.CURDatabase = catalog
+
+	exportType := p.newExportType()
+	focus := exportType == dataexport.TypeFOCUS
+
+	// Discovery only works for the account's own Glue catalog, not federated catalogs
+	var glueClient initUtils.GlueCatalogAPI
+	var candidates []initUtils.CURTable
+	if catalog == "awsdatacatalog" {
+		awsCfg, err := p.awsConfig(ctx)
+		if err != nil {
+			return err
+		}
+		glueClient = awssession.NewGlueClient(awsCfg, p.Config.AWSAccess.Endpoints)
+		// Only CUR tables are detected; a FOCUS project starts from a new export or a named table
+		if !focus {
+			candidates = p.discoverCURTables(ctx, glueClient)
+		}
+	}
+
+	if glueClient != nil {
+		items := make([]string, 0, len(candidates)+2)
+		for _, c := range candidates {
+			items = append(items, c.Label())
+		}
+		createIdx := len(items)
+		items = append(items, fmt.Sprintf("Create a new %s data export in the ecos bucket", dataexport.Label(exportType)), "Enter schema and table manually")
+
+		// Accounts without a CUR table most likely want the export created for them
+		defaultIdx := 0
+		if len(candidates) == 0 {
+			defaultIdx = createIdx
+		}
+
+		label := "CUR Table"
+		if focus {
+			label = "FOCUS Table"
+		}
+		idx, _, err := utils.Select(label, items, defaultIdx, true, true)
+		if err != nil {
+			return err
+		}
+		switch {
+		case idx < len(candidates):
+			selected := candidates[idx]
+			p.Config.CURSchema = selected.Database
+			p.Config.CURTable = selected.Table
+			p.Config.CURInfo = &selected
+			utils.PrintSuccess(fmt.Sprintf("Using CUR %s table (%s)", selected.Version, selected.Features()))
+			return nil
+		case idx == createIdx:
+			return p.useNewDataExport(ctx, exportType)
+		}
+	}
+
+	curSchema, err := utils.Input("Schema", "cur", true, true, nil)
+	if err != nil {
+		return err
+	}
+	p.Config.CURSchema = curSchema
+
+	curTable, err := utils.Input("Table", "cur-data", true, true, nil)
+	if err != nil {
+		return err
+	}
+	p.Config.CURTable = curTable
+
+	if focus {
+		return nil
+	}
+	if glueClient == nil {
+		utils.PrintWarning(fmt.Sprintf("CUR table validation skipped for catalog '%s'", catalog))
+		return nil
+	}
+
+	info, err := initUtils.InspectCURTable(ctx, glueClient, curSchema, curTable)
+	if err != nil {
+		var notFound *glueTypes.EntityNotFoundException
+		if errors.As(err, &notFound) || errors.Is(err, initUtils.ErrNotCURTable) {
+			return err
+		}
+		utils.PrintWarning(fmt.Sprintf("CUR table validation skipped: %v", err))
+		return nil
+	}
+	p.Config.CURInfo = info
+	utils.PrintSuccess(fmt.Sprintf("Detected CUR %s table (%s)", info.Version, info.Features()))
+
+	return nil
+}
+
+// useNewDataExport points the datasource at the Glue table of a data export ecos
+// creates in CreateResources, once the current identity may provision it
+func (p *AWSCURInitPlugin) useNewDataExport(ctx context.Context, exportType string) error {
+	if p.IaCFormat != "" {
+		return errors.New("creating a data export is not supported with --emit, set up the table first or run 'ecos init' without --emit")
+	}
+
+	export, err := dataexport.NewConfig(p.Config.ProjectName, exportType)
+	if err != nil {
+		return err
+	}
+
+	// CUR features are detected from the export's columns; the table location does not matter
+	var info *initUtils.CURTable
+	if exportType == dataexport.TypeCUR2 {
+		table, err := dataexport.TableInput(export, "")
+		if err != nil {
+			return err
+		}
+		info, err = initUtils.DetectCURSchema(export.GlueDatabase, &glueTypes.Table{
+			Name:              table.Name,
+			StorageDescriptor: table.StorageDescriptor,
+			PartitionKeys:     table.PartitionKeys,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := p.checkExportPermissions(ctx, export); err != nil {
+		return err
+	}
+
+	p.Config.DataExport = &export
+	p.Config.CURSchema = export.GlueDatabase
+	p.Config.CURTable = export.GlueTable
+	p.Config.CURInfo = info
+	utils.PrintInfo(fmt.Sprintf("Data export '%s' will be created with the other AWS resources", export.Name))
+
+	return nil
+}
+
+// discoverCURTables lists the CUR tables in the Glue Data Catalog. Discovery needs
+// glue:GetDatabases and glue:GetTables; without them the user enters the table manually.
+func (p *AWSCURInitPlugin) discoverCURTables(ctx context.Context, client initUtils.GlueCatalogAPI) []initUtils.CURTable {
+	sp := utils.NewSpinner("Discovering CUR tables in the Glue Data Catalog")
+	sp.Start()
+
+	databases, err := initUtils.ListGlueDatabases(ctx, client)
+	if err == nil {
+		var tables []initUtils.CURTable
+		tables, err = initUtils.FindCURTables(ctx, client, databases)
+		if err == nil {
+			if len(tables) == 0 {
+				sp.Stop()
+				utils.PrintInfo("No CUR tables found in the Glue Data Catalog")
+				return nil
+			}
+			sp.Success(fmt.Sprintf("Found %d CUR table(s)", len(tables)))
+			return tables
+		}
+	}
+
+	sp.Stop()
+	utils.PrintWarning(fmt.Sprintf("CUR table discovery skipped: %v", err))
+	return nil
+}
+
+// datasourceVars returns the dbt vars pointing the models at the CUR table. The models
+// detect the table's CUR version and optional columns themselves when they run.
+func (in *AWSCURInput) datasourceVars() []config.DatasourceVar {
+	return []config.DatasourceVar{
+		{Key: "cur_database", Value: in.CURDatabase},
+		{Key: "cur_schema", Value: in.CURSchema},
+		{Key: "cur_table", Value: in.CURTable},
+	}
+}
+
+// SetAWSAccess applies assume-role, credential_process and endpoint settings on top of the AWS profile
+func (p *AWSCURInitPlugin) SetAWSAccess(access config.AWSAccessConfig) error {
+	if err := awssession.ValidateAccess(access); err != nil {
+		return err
+	}
+	if err := awssession.ValidateEndpoints(access.Endpoints); err != nil {
+		return err
+	}
+	if p.Config == nil {
+		p.Config = &AWSCURInput{}
+	}
+	p.Config.AWSAccess = access
+	p.baseAWSConfig = nil
+	return nil
+}
+
+// resourceDefinitions returns the AWS resources this project provisions, shared with the IaC renderers
+func (p *AWSCURInitPlugin) resourceDefinitions() *iac.AWSResources {
+	return iac.NewAWSResources(
+		p.Config.ProjectName,
+		p.Config.AWSRegion,
+		p.Config.ResultsBucket,
+		p.Config.DBTWorkgroup,
+		p.Config.AdhocWorkgroup,
+	)
+}
+
+// SetIaCFormat switches resource provisioning to IaC rendering (terraform, cloudformation)
+func (p *AWSCURInitPlugin) SetIaCFormat(format string) error {
+	parsed, err := iac.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	p.IaCFormat = parsed
+	return nil
+}
+
+// iacOutputDir returns the directory the IaC files are written to (e.g. infra/terraform)
+func (p *AWSCURInitPlugin) iacOutputDir() string {
+	return filepath.Join(p.OutputPath, "infra", string(p.IaCFormat))
+}
+
+// emitIaC writes the resource definitions as IaC files instead of creating them
+func (p *AWSCURInitPlugin) emitIaC() error {
+	paths, err := iac.Write(p.IaCFormat, p.resourceDefinitions(), p.iacOutputDir())
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", p.IaCFormat, err)
+	}
+
+	utils.PrintSubHeader(fmt.Sprintf("📦 AWS Resources (%s)", p.IaCFormat))
+	for _, path := range paths {
+		fmt.Printf("  • %s\n", path)
+	}
+	utils.PrintInfo("Apply these files through your pipeline before running 'ecos transform'")
+
+	return nil
 }
 
 func (p *AWSCURInitPlugin) generateDBTFiles(destPath string, userInput *AWSCURInput, matConfig MaterializationConfig) error {
@@ -932,16 +1394,15 @@ func (p *AWSCURInitPlugin) generateDBTFiles(destPath string, userInput *AWSCURIn
 		ResultsBucket: resultsBucket,
 		Database:      database,
 		Workgroup:     dbtWorkgroup,
+
+		InjectedCredentials: userInput.AWSAccess.UsesInjectedCredentials(),
+		AthenaEndpointURL:   userInput.AWSAccess.Endpoints.Athena,
 	}
 
 	// Prepare project template data - use materialization settings from ecos config
 	projectData := config.DBTProjectTemplate{
-		Profile: "ecos-athena",
-		DatasourceVars: []config.DatasourceVar{
-			{Key: "cur_database", Value: userInput.CURDatabase},
-			{Key: "cur_schema", Value: userInput.CURSchema},
-			{Key: "cur_table", Value: userInput.CURTable},
-		},
+		Profile:               "ecos-athena",
+		DatasourceVars:        userInput.datasourceVars(),
 		IcebergEnabled:        false,
 		BillingPeriodStart:    nil,
 		BillingPeriodEnd:      nil,
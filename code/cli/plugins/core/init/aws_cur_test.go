@@ -1,11 +1,13 @@
 package init
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 
 	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
 	"github.com/ecos-labs/ecos/code/cli/plugins/types"
 )
 
@@ -33,6 +35,33 @@ func TestAWSCURInitPlugin_Metadata(t *testing.T) {
 	}
 }
 
+func TestAWSFocusInitPlugin_DataExport(t *testing.T) {
+	plugin, err := NewAWSFocus(false, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	focus := plugin.(*AWSFocusInitPlugin)
+	if focus.Name() != "aws-focus-init" || focus.source() != "aws_focus" {
+		t.Errorf("Name() = %s, source() = %s", focus.Name(), focus.source())
+	}
+	if got := focus.newExportType(); got != dataexport.TypeFOCUS {
+		t.Errorf("newExportType() = %s, want %s", got, dataexport.TypeFOCUS)
+	}
+	if cur := (&AWSCURInitPlugin{}); cur.source() != "aws_cur" || cur.newExportType() != dataexport.TypeCUR2 {
+		t.Errorf("CUR plugin source() = %s, newExportType() = %s", cur.source(), cur.newExportType())
+	}
+
+	// A data export cannot be rendered as IaC, which is reported when the export is chosen
+	focus.IaCFormat = "terraform"
+	focus.Config.ProjectName = "demo"
+	if err := focus.useNewDataExport(context.Background(), dataexport.TypeFOCUS); err == nil {
+		t.Error("useNewDataExport() expected error with --emit")
+	}
+	if focus.Config.DataExport != nil {
+		t.Error("data export set although it was rejected")
+	}
+}
+
 func TestAWSCURInitPlugin_Type(t *testing.T) {
 	plugin := &AWSCURInitPlugin{}
 	if plugin.Type() != types.PluginTypeInit {
@@ -109,8 +138,11 @@ func TestAWSCURInitPlugin_ValidateRegion(t *testing.T) {
 
 func TestDefaultMaterializationConfig(t *testing.T) {
 	cfg := DefaultMaterializationConfig()
-	if cfg.Mode == "" || cfg.Bronze == "" || cfg.Silver == "" || cfg.Gold == "" {
-		t.Error("MaterializationConfig defaults should not be empty")
+	if cfg.Mode == "" {
+		t.Error("MaterializationConfig default mode should not be empty")
+	}
+	if cfg.Bronze != "" || cfg.Silver != "" || cfg.Gold != "" {
+		t.Errorf("MaterializationConfig defaults override layers: %+v", cfg)
 	}
 }
 
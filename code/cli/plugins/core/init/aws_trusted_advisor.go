@@ -1,4 +1,3 @@
-//nolint:dupl // Intentional similarity with aws_focus.go
 package init
 
 import (
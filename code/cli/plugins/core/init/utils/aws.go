@@ -6,10 +6,10 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/glue"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	v1endpoints "github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
 )
 
 // ================= AWS utility functions =================
@@ -22,12 +22,12 @@ func ValidateAWSCredentials(ctx context.Context, timeout time.Duration) error {
 		defer cancel()
 	}
 
-	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	cfg, err := awssession.Load(ctx, awssession.Options{})
 	if err != nil {
-		return fmt.Errorf("failed to load AWS config: %w", err)
+		return err
 	}
 
-	stsClient := sts.NewFromConfig(cfg)
+	stsClient := awssession.NewSTSClient(cfg, config.AWSEndpointsConfig{})
 	_, err = stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
 		return fmt.Errorf("failed to validate AWS credentials: %w", err)
@@ -36,28 +36,6 @@ func ValidateAWSCredentials(ctx context.Context, timeout time.Duration) error {
 	return nil
 }
 
-// ValidateGlueTable validates that a Glue table exists
-func ValidateGlueTable(ctx context.Context, catalog, database, table, profile string) error {
-	cfg, err := awsconfig.LoadDefaultConfig(ctx,
-		awsconfig.WithSharedConfigProfile(profile),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to load AWS config with profile %s: %w", profile, err)
-	}
-
-	glueClient := glue.NewFromConfig(cfg)
-	_, err = glueClient.GetTable(ctx, &glue.GetTableInput{
-		CatalogId:    aws.String(catalog),
-		DatabaseName: aws.String(database),
-		Name:         aws.String(table),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get Glue table %s.%s.%s: %w", catalog, database, table, err)
-	}
-
-	return nil
-}
-
 // GetAWSAccountAndRegion retrieves the current AWS account ID and region
 func GetAWSAccountAndRegion(ctx context.Context, timeout time.Duration) (accountID, region string, err error) {
 	return GetAWSAccountAndRegionWithProfile(ctx, timeout, "")
@@ -65,23 +43,29 @@ func GetAWSAccountAndRegion(ctx context.Context, timeout time.Duration) (account
 
 // GetAWSAccountAndRegionWithProfile retrieves the current AWS account ID and region using a specific profile
 func GetAWSAccountAndRegionWithProfile(ctx context.Context, timeout time.Duration, profile string) (accountID, region string, err error) {
+	return GetAWSAccountAndRegionWithOptions(ctx, timeout, awssession.Options{Profile: profile})
+}
+
+// GetAWSAccountAndRegionWithOptions retrieves the AWS account ID and region for the given
+// session options, including any assumed role or credential_process
+func GetAWSAccountAndRegionWithOptions(ctx context.Context, timeout time.Duration, opts awssession.Options) (accountID, region string, err error) {
 	if timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
-	var cfg aws.Config
-	if profile != "" && profile != "default" {
-		cfg, err = awsconfig.LoadDefaultConfig(ctx, awsconfig.WithSharedConfigProfile(profile))
-	} else {
-		cfg, err = awsconfig.LoadDefaultConfig(ctx)
-	}
+	cfg, err := awssession.Load(ctx, opts)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to load AWS config: %w", err)
+		return "", "", err
 	}
 
-	stsClient := sts.NewFromConfig(cfg)
+	return GetAWSAccountAndRegionFromConfig(ctx, cfg, opts.Access.Endpoints)
+}
+
+// GetAWSAccountAndRegionFromConfig retrieves the AWS account ID and region for an already loaded config
+func GetAWSAccountAndRegionFromConfig(ctx context.Context, cfg aws.Config, endpoints config.AWSEndpointsConfig) (accountID, region string, err error) {
+	stsClient := awssession.NewSTSClient(cfg, endpoints)
 	result, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get caller identity: %w", err)
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+)
+
+// CacheDirEnv overrides the directory ecos caches downloads in, e.g. to share it
+// between CI jobs
+const CacheDirEnv = "ECOS_CACHE_DIR"
+
+// DefaultReleasesTTL is how long a cached release list answers "latest" lookups
+const DefaultReleasesTTL = time.Hour
+
+// releasesFile is the cached release list of a datasource
+const releasesFile = "releases.json"
+
+// ErrNotCached reports a release list or package missing from the cache in offline mode
+var ErrNotCached = errors.New("not in the model cache")
+
+// ModelCache keeps the release lists and packages of the datasources between runs:
+//
+//	<dir>/<datasource>/releases.json         release list, with its source and fetch time
+//	<dir>/<datasource>/<version>/<archive>   package archive, checksum and signature
+//
+// Cached packages are verified like downloaded ones each time they are used.
+type ModelCache struct {
+	Dir string
+	// TTL is how long a cached release list answers "latest" lookups
+	TTL time.Duration
+}
+
+// CacheEntry is a cached package, or the release list of a datasource when Version is
+// empty
+type CacheEntry struct {
+	Datasource string
+	Version    string
+	Path       string
+	Size       int64
+	LastUsed   time.Time
+}
+
+type cachedReleases struct {
+	Source    string          `json:"source"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Releases  []GitHubRelease `json:"releases"`
+}
+
+// NewModelCache returns the cache under ECOS_CACHE_DIR, or the ecos directory of the user
+// cache directory
+func NewModelCache() (*ModelCache, error) {
+	root := os.Getenv(CacheDirEnv)
+	if root == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate the user cache directory: %w", err)
+		}
+		root = filepath.Join(dir, "ecos")
+	}
+	return &ModelCache{Dir: filepath.Join(root, "models"), TTL: DefaultReleasesTTL}, nil
+}
+
+// Releases returns the cached release list of a datasource from source, and when it was
+// fetched
+func (c *ModelCache) Releases(source, datasource string) ([]GitHubRelease, time.Time, bool) {
+	if !validCacheKey(datasource) {
+		return nil, time.Time{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(c.Dir, datasource, releasesFile)) // #nosec G304
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	var cached cachedReleases
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Source != source {
+		return nil, time.Time{}, false
+	}
+	return cached.Releases, cached.FetchedAt, true
+}
+
+// SaveReleases caches the release list of a datasource fetched from source
+func (c *ModelCache) SaveReleases(source, datasource string, releases []GitHubRelease) error {
+	if !validCacheKey(datasource) {
+		return fmt.Errorf("invalid datasource '%s'", datasource)
+	}
+	data, err := json.Marshal(cachedReleases{Source: source, FetchedAt: time.Now().UTC(), Releases: releases})
+	if err != nil {
+		return err
+	}
+	return writeCacheFile(filepath.Join(c.Dir, datasource, releasesFile), data)
+}
+
+// Package returns the cached archive, checksum and signature of a package version
+func (c *ModelCache) Package(datasource, version string) (archive, checksum, signature []byte, ok bool) {
+	if !validCacheKey(datasource) || !validCacheKey(version) {
+		return nil, nil, nil, false
+	}
+	path := filepath.Join(c.Dir, datasource, version, PackageFilename(datasource, version))
+	files := make([][]byte, 3)
+	for i, suffix := range []string{"", modelpkg.ChecksumSuffix, modelpkg.SignatureSuffix} {
+		data, err := os.ReadFile(path + suffix) // #nosec G304
+		if err != nil {
+			return nil, nil, nil, false
+		}
+		files[i] = data
+	}
+	// The archive's modification time records when the package was last used
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return files[0], files[1], files[2], true
+}
+
+// SavePackage caches the archive, checksum and signature of a package version. The
+// archive is written last, so a partly written package is never found.
+func (c *ModelCache) SavePackage(datasource, version string, archive, checksum, signature []byte) error {
+	if !validCacheKey(datasource) || !validCacheKey(version) {
+		return fmt.Errorf("invalid package %s %s", datasource, version)
+	}
+	path := filepath.Join(c.Dir, datasource, version, PackageFilename(datasource, version))
+	if err := writeCacheFile(path+modelpkg.ChecksumSuffix, checksum); err != nil {
+		return err
+	}
+	if err := writeCacheFile(path+modelpkg.SignatureSuffix, signature); err != nil {
+		return err
+	}
+	return writeCacheFile(path, archive)
+}
+
+// Versions returns the cached package versions of a datasource, newest first
+func (c *ModelCache) Versions(datasource string) []string {
+	entries, err := c.List()
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.Datasource == datasource && e.Version != "" {
+			versions = append(versions, e.Version)
+		}
+	}
+	slices.SortFunc(versions, func(a, b string) int { return CompareVersions(b, a) })
+	return versions
+}
+
+// List returns the cached release lists and packages, by datasource and newest version
+// first
+func (c *ModelCache) List() ([]CacheEntry, error) {
+	datasources, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the model cache: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, ds := range datasources {
+		if !ds.IsDir() {
+			continue
+		}
+		dsDir := filepath.Join(c.Dir, ds.Name())
+		releasesPath := filepath.Join(dsDir, releasesFile)
+		if info, err := os.Stat(releasesPath); err == nil {
+			entries = append(entries, CacheEntry{Datasource: ds.Name(), Path: releasesPath, Size: info.Size(), LastUsed: info.ModTime()})
+		}
+		versions, err := os.ReadDir(dsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the model cache: %w", err)
+		}
+		for _, v := range versions {
+			if !v.IsDir() {
+				continue
+			}
+			entry := CacheEntry{Datasource: ds.Name(), Version: v.Name(), Path: filepath.Join(dsDir, v.Name())}
+			files, err := os.ReadDir(entry.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the model cache: %w", err)
+			}
+			for _, f := range files {
+				info, err := f.Info()
+				if err != nil {
+					continue
+				}
+				entry.Size += info.Size()
+				if f.Name() == PackageFilename(ds.Name(), v.Name()) {
+					entry.LastUsed = info.ModTime()
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b CacheEntry) int {
+		if a.Datasource != b.Datasource {
+			return strings.Compare(a.Datasource, b.Datasource)
+		}
+		if a.Version == "" || b.Version == "" {
+			// The release list comes first
+			return strings.Compare(a.Version, b.Version)
+		}
+		return CompareVersions(b.Version, a.Version)
+	})
+	return entries, nil
+}
+
+// Remove deletes a cache entry
+func (c *ModelCache) Remove(entry CacheEntry) error {
+	rel, err := filepath.Rel(c.Dir, entry.Path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%s is not in the model cache", entry.Path)
+	}
+	if err := os.RemoveAll(entry.Path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// validCacheKey reports whether a datasource or version can name a cache directory
+func validCacheKey(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// writeCacheFile writes a cache file through a temporary file, so readers never see it
+// partly written
+func writeCacheFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
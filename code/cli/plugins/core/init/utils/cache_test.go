@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+)
+
+func TestModelCache(t *testing.T) {
+	cache := &ModelCache{Dir: t.TempDir(), TTL: time.Hour}
+
+	if _, _, ok := cache.Releases("github://ecos-labs/ecos", "aws_cur"); ok {
+		t.Error("Releases() found a list in an empty cache")
+	}
+	releases := []GitHubRelease{{TagName: "ds/aws_cur/v1.1.0"}}
+	if err := cache.SaveReleases("github://ecos-labs/ecos", "aws_cur", releases); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, ok := cache.Releases("github://ecos-labs/ecos", "aws_cur"); !ok || len(got) != 1 || got[0].TagName != "ds/aws_cur/v1.1.0" {
+		t.Errorf("Releases() = %v, %v", got, ok)
+	}
+	if _, _, ok := cache.Releases("s3://acme-mirror/ecos", "aws_cur"); ok {
+		t.Error("Releases() returned the list of another source")
+	}
+
+	for _, version := range []string{"v1.0.0", "v1.10.0", "v1.2.0"} {
+		if err := cache.SavePackage("aws_cur", version, []byte("archive"), []byte("sum"), []byte("sig")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if archive, checksum, signature, ok := cache.Package("aws_cur", "v1.2.0"); !ok || string(archive) != "archive" || string(checksum) != "sum" || string(signature) != "sig" {
+		t.Errorf("Package() = %q, %q, %q, %v", archive, checksum, signature, ok)
+	}
+	if _, _, _, ok := cache.Package("aws_cur", "v2.0.0"); ok {
+		t.Error("Package() found a version that was never cached")
+	}
+	if _, _, _, ok := cache.Package("aws_cur", "../aws_cur"); ok {
+		t.Error("Package() accepted a version outside the cache")
+	}
+	if got := cache.Versions("aws_cur"); len(got) != 3 || got[0] != "v1.10.0" || got[2] != "v1.0.0" {
+		t.Errorf("Versions() = %v, want newest first", got)
+	}
+
+	entries, err := cache.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[0].Version != "" || entries[1].Version != "v1.10.0" || entries[1].Size != int64(len("archivesumsig")) {
+		t.Errorf("List() = %+v", entries)
+	}
+	if err := cache.Remove(entries[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Remove(CacheEntry{Path: filepath.Dir(cache.Dir)}); err == nil {
+		t.Error("Remove() accepted a path outside the cache")
+	}
+	if got := cache.Versions("aws_cur"); len(got) != 2 || got[0] != "v1.2.0" {
+		t.Errorf("Versions() after Remove() = %v", got)
+	}
+}
+
+func TestModelDownloader_Cache(t *testing.T) {
+	mirror := t.TempDir()
+	t.Setenv(modelpkg.PublicKeyEnv, writeMirror(t, mirror))
+	t.Setenv(CacheDirEnv, t.TempDir())
+
+	var requests atomic.Int32
+	files := http.FileServer(http.Dir(mirror))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	downloader, err := NewModelDownloader(server.URL, awssession.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := &ModelDownloader{Source: downloader.Source, Cache: downloader.Cache, Offline: true}
+	if _, err := offline.DownloadTransformModels(context.Background(), "aws_cur", "", t.TempDir()); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("offline DownloadTransformModels() with an empty cache error = %v, want ErrNotCached", err)
+	}
+
+	// The first download fills the cache: release list, archive, checksum and signature
+	if _, err := downloader.DownloadTransformModels(context.Background(), "aws_cur", "", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("requests = %d, want 4", got)
+	}
+	if _, err := downloader.DownloadTransformModels(context.Background(), "aws_cur", "", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("requests after a cached download = %d, want 4", got)
+	}
+
+	dest := t.TempDir()
+	version, err := offline.DownloadTransformModels(context.Background(), "aws_cur", "", dest)
+	if err != nil || version != "v1.0.0" {
+		t.Fatalf("offline DownloadTransformModels() = %s, %v", version, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "models", "a.sql")); err != nil {
+		t.Errorf("package not extracted: %v", err)
+	}
+	if _, err := offline.DownloadTransformModels(context.Background(), "aws_cur", "v1.1.0", t.TempDir()); !errors.Is(err, ErrNotCached) {
+		t.Errorf("offline DownloadTransformModels() of an uncached version error = %v, want ErrNotCached", err)
+	}
+
+	// A tampered cached package fails verification
+	cached := filepath.Join(downloader.Cache.Dir, "aws_cur", "v1.0.0", "aws-cur-1.0.0.tar.gz")
+	if err := os.WriteFile(cached, []byte("tampered"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := offline.DownloadTransformModels(context.Background(), "aws_cur", "v1.0.0", t.TempDir()); !errors.Is(err, modelpkg.ErrVerification) {
+		t.Errorf("DownloadTransformModels() of a tampered package error = %v, want ErrVerification", err)
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	glueTypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+)
+
+// CUR export formats that can be detected from a table's columns
+const (
+	CURVersionLegacy = "legacy"
+	CURVersion2      = "2.0"
+)
+
+// ErrNotCURTable is returned when a table does not have the columns of a CUR export
+var ErrNotCURTable = errors.New("table does not look like a CUR export")
+
+// GlueCatalogAPI is the subset of the Glue client used for CUR table discovery
+type GlueCatalogAPI interface {
+	glue.GetDatabasesAPIClient
+	glue.GetTablesAPIClient
+	GetTable(ctx context.Context, params *glue.GetTableInput, optFns ...func(*glue.Options)) (*glue.GetTableOutput, error)
+}
+
+// CURTable describes a Glue table holding CUR data and the optional columns it provides
+type CURTable struct {
+	Database string
+	Table    string
+	Version  string
+
+	HasResourceIDs         bool
+	HasSplitCostAllocation bool
+	HasTags                bool
+}
+
+// Label returns a short description for pickers, e.g. "cur.cur_data (CUR 2.0)"
+func (t CURTable) Label() string {
+	return fmt.Sprintf("%s.%s (CUR %s)", t.Database, t.Table, t.Version)
+}
+
+// Features returns a human readable summary of the detected optional columns
+func (t CURTable) Features() string {
+	yesNo := func(v bool) string {
+		if v {
+			return "yes"
+		}
+		return "no"
+	}
+	return fmt.Sprintf("resource IDs: %s, split cost allocation: %s, tags: %s",
+		yesNo(t.HasResourceIDs), yesNo(t.HasSplitCostAllocation), yesNo(t.HasTags))
+}
+
+// DetectCURSchema inspects a table's columns (including partition keys) and reports
+// the CUR version and optional columns. ErrNotCURTable is returned for non-CUR tables.
+func DetectCURSchema(database string, table *glueTypes.Table) (*CURTable, error) {
+	columns := map[string]string{}
+	if table.StorageDescriptor != nil {
+		for _, c := range table.StorageDescriptor.Columns {
+			columns[strings.ToLower(aws.ToString(c.Name))] = strings.ToLower(aws.ToString(c.Type))
+		}
+	}
+	for _, c := range table.PartitionKeys {
+		columns[strings.ToLower(aws.ToString(c.Name))] = strings.ToLower(aws.ToString(c.Type))
+	}
+
+	// Present in both legacy CUR and CUR 2.0
+	for _, required := range []string{"line_item_usage_start_date", "line_item_unblended_cost"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: %s.%s has no %s column", ErrNotCURTable, database, aws.ToString(table.Name), required)
+		}
+	}
+
+	cur := &CURTable{
+		Database: database,
+		Table:    aws.ToString(table.Name),
+		Version:  CURVersionLegacy,
+	}
+
+	// CUR 2.0 nests product attributes and tags in map columns and adds account names
+	_, hasPayerName := columns["bill_payer_account_name"]
+	if strings.HasPrefix(columns["product"], "map<") || hasPayerName {
+		cur.Version = CURVersion2
+	}
+
+	_, cur.HasResourceIDs = columns["line_item_resource_id"]
+	for name, colType := range columns {
+		switch {
+		case strings.HasPrefix(name, "split_line_item_"):
+			cur.HasSplitCostAllocation = true
+		case strings.HasPrefix(name, "resource_tags_"):
+			cur.HasTags = true
+		case name == "resource_tags" && strings.HasPrefix(colType, "map<"):
+			cur.HasTags = true
+		}
+	}
+
+	return cur, nil
+}
+
+// ListGlueDatabases returns the names of all Glue databases in the default catalog
+func ListGlueDatabases(ctx context.Context, client GlueCatalogAPI) ([]string, error) {
+	var names []string
+	paginator := glue.NewGetDatabasesPaginator(client, &glue.GetDatabasesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Glue databases: %w", err)
+		}
+		for _, db := range page.DatabaseList {
+			names = append(names, aws.ToString(db.Name))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FindCURTables lists the tables of the given Glue databases and returns those
+// that look like CUR exports, sorted by database and table name
+func FindCURTables(ctx context.Context, client GlueCatalogAPI, databases []string) ([]CURTable, error) {
+	var found []CURTable
+	for _, database := range databases {
+		paginator := glue.NewGetTablesPaginator(client, &glue.GetTablesInput{DatabaseName: aws.String(database)})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tables in Glue database %s: %w", database, err)
+			}
+			for i := range page.TableList {
+				if cur, err := DetectCURSchema(database, &page.TableList[i]); err == nil {
+					found = append(found, *cur)
+				}
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Database != found[j].Database {
+			return found[i].Database < found[j].Database
+		}
+		return found[i].Table < found[j].Table
+	})
+	return found, nil
+}
+
+// InspectCURTable fetches a Glue table and detects its CUR schema
+func InspectCURTable(ctx context.Context, client GlueCatalogAPI, database, table string) (*CURTable, error) {
+	out, err := client.GetTable(ctx, &glue.GetTableInput{
+		DatabaseName: aws.String(database),
+		Name:         aws.String(table),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Glue table %s.%s: %w", database, table, err)
+	}
+	return DetectCURSchema(database, out.Table)
+}
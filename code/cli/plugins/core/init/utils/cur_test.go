@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	glueTypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+)
+
+func curTable(name string, columns ...string) glueTypes.Table {
+	var cols []glueTypes.Column
+	for _, c := range columns {
+		colName, colType, _ := strings.Cut(c, ":")
+		if colType == "" {
+			colType = "string"
+		}
+		cols = append(cols, glueTypes.Column{Name: aws.String(colName), Type: aws.String(colType)})
+	}
+	return glueTypes.Table{
+		Name:              aws.String(name),
+		StorageDescriptor: &glueTypes.StorageDescriptor{Columns: cols},
+	}
+}
+
+var (
+	legacyColumns = []string{
+		"identity_line_item_id", "line_item_usage_start_date:timestamp", "line_item_unblended_cost:double",
+		"line_item_resource_id", "product_product_name", "resource_tags_user_team",
+	}
+	cur2Columns = []string{
+		"bill_payer_account_name", "line_item_usage_start_date:timestamp", "line_item_unblended_cost:double",
+		"product:map<string,string>", "resource_tags:map<string,string>", "split_line_item_split_cost:double",
+	}
+)
+
+func TestDetectCURSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   glueTypes.Table
+		want    CURTable
+		wantErr error
+	}{
+		{
+			name:  "legacy with resource ids and tags",
+			table: curTable("cur_legacy", legacyColumns...),
+			want: CURTable{
+				Database: "cur", Table: "cur_legacy", Version: CURVersionLegacy,
+				HasResourceIDs: true, HasTags: true,
+			},
+		},
+		{
+			name:  "cur 2.0 with split cost allocation",
+			table: curTable("cur2", cur2Columns...),
+			want: CURTable{
+				Database: "cur", Table: "cur2", Version: CURVersion2,
+				HasSplitCostAllocation: true, HasTags: true,
+			},
+		},
+		{
+			name:    "not a cur table",
+			table:   curTable("orders", "order_id", "amount:double"),
+			wantErr: ErrNotCURTable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := tt.table
+			got, err := DetectCURSchema("cur", &table)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DetectCURSchema() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if *got != tt.want {
+				t.Errorf("DetectCURSchema() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeGlue serves databases and tables from memory
+type fakeGlue struct {
+	tables map[string][]glueTypes.Table
+}
+
+func (f *fakeGlue) GetDatabases(_ context.Context, _ *glue.GetDatabasesInput, _ ...func(*glue.Options)) (*glue.GetDatabasesOutput, error) {
+	out := &glue.GetDatabasesOutput{}
+	for name := range f.tables {
+		out.DatabaseList = append(out.DatabaseList, glueTypes.Database{Name: aws.String(name)})
+	}
+	return out, nil
+}
+
+func (f *fakeGlue) GetTables(_ context.Context, in *glue.GetTablesInput, _ ...func(*glue.Options)) (*glue.GetTablesOutput, error) {
+	return &glue.GetTablesOutput{TableList: f.tables[aws.ToString(in.DatabaseName)]}, nil
+}
+
+func (f *fakeGlue) GetTable(_ context.Context, in *glue.GetTableInput, _ ...func(*glue.Options)) (*glue.GetTableOutput, error) {
+	for _, table := range f.tables[aws.ToString(in.DatabaseName)] {
+		if aws.ToString(table.Name) == aws.ToString(in.Name) {
+			return &glue.GetTableOutput{Table: &table}, nil
+		}
+	}
+	return nil, &glueTypes.EntityNotFoundException{Message: aws.String("table not found")}
+}
+
+func TestFindCURTables(t *testing.T) {
+	client := &fakeGlue{tables: map[string][]glueTypes.Table{
+		"sales": {curTable("orders", "order_id")},
+		"cur":   {curTable("cur2", cur2Columns...), curTable("cur_legacy", legacyColumns...)},
+	}}
+
+	databases, err := ListGlueDatabases(context.Background(), client)
+	if err != nil {
+		t.Fatalf("ListGlueDatabases() error = %v", err)
+	}
+	if strings.Join(databases, ",") != "cur,sales" {
+		t.Errorf("ListGlueDatabases() = %v, want sorted [cur sales]", databases)
+	}
+
+	found, err := FindCURTables(context.Background(), client, databases)
+	if err != nil {
+		t.Fatalf("FindCURTables() error = %v", err)
+	}
+	if len(found) != 2 || found[0].Label() != "cur.cur2 (CUR 2.0)" || found[1].Label() != "cur.cur_legacy (CUR legacy)" {
+		t.Errorf("FindCURTables() = %+v", found)
+	}
+}
+
+func TestInspectCURTable(t *testing.T) {
+	client := &fakeGlue{tables: map[string][]glueTypes.Table{
+		"cur": {curTable("cur2", cur2Columns...)},
+	}}
+
+	info, err := InspectCURTable(context.Background(), client, "cur", "cur2")
+	if err != nil {
+		t.Fatalf("InspectCURTable() error = %v", err)
+	}
+	if info.Version != CURVersion2 {
+		t.Errorf("Version = %q, want %q", info.Version, CURVersion2)
+	}
+
+	_, err = InspectCURTable(context.Background(), client, "cur", "missing")
+	var notFound *glueTypes.EntityNotFoundException
+	if !errors.As(err, &notFound) {
+		t.Errorf("InspectCURTable() error = %v, want EntityNotFoundException", err)
+	}
+}
@@ -28,6 +28,8 @@ func SetupBaseFiles(outputPath, dataSourceName string) error {
 logs/
 output/
 .ecos/temp/
+.ecos-backfill.json
+.ecos-watch.json
 # OS & IDE
 .DS_Store
 .vscode/
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"archive/tar"
-	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
@@ -12,10 +11,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
-
-	"github.com/ecos-labs/ecos/code/cli/utils"
 )
 
 // Constants for GitHub repository owner and name.
@@ -25,15 +24,20 @@ const (
 	DefaultRepoName  = "ecos"
 )
 
+// defaultAPIURL is the GitHub REST API the client talks to
+const defaultAPIURL = "https://api.github.com"
+
 // GitHubClient provides GitHub API operations
 type GitHubClient struct {
 	client *http.Client
 	token  string
+	apiURL string
 }
 
 // GitHubRelease represents a GitHub release
 type GitHubRelease struct {
 	TagName     string    `json:"tag_name"`
+	Body        string    `json:"body"`
 	PublishedAt time.Time `json:"published_at"`
 	Assets      []struct {
 		Name string `json:"name"`
@@ -54,43 +58,22 @@ func NewGitHubClient() (*GitHubClient, error) {
 				return nil // Allow all redirects
 			},
 		},
-		token: token,
+		token:  token,
+		apiURL: defaultAPIURL,
 	}, nil
 }
 
-// GetLatestReleaseVersion gets the latest release version for a specific datasource
-func (gc *GitHubClient) GetLatestReleaseVersion(ctx context.Context, repoOwner, repoName, datasource string) (string, error) {
-	releases, err := gc.getAllReleases(ctx, repoOwner, repoName)
-	if err != nil {
-		return "", err
-	}
-
-	dsPrefix := fmt.Sprintf("ds/%s/", datasource)
-	var matchingReleases []string
-
-	for _, release := range releases {
-		if strings.HasPrefix(release.TagName, dsPrefix) {
-			version := strings.TrimPrefix(release.TagName, dsPrefix)
-			return version, nil
-		}
-		matchingReleases = append(matchingReleases, release.TagName)
-	}
-
-	if len(releases) == 0 {
-		return "", errors.New("no releases found in repository")
-	}
-
-	return "", fmt.Errorf("no releases found for datasource '%s' with prefix '%s'. Available releases: %v. Please ensure releases are tagged with format 'ds/%s/v1.0.0'",
-		datasource, dsPrefix, matchingReleases, datasource)
-}
-
 // DownloadReleaseAsset downloads a specific asset from a GitHub release
 func (gc *GitHubClient) DownloadReleaseAsset(ctx context.Context, repoOwner, repoName, releaseTag, assetName string) ([]byte, error) {
 	release, err := gc.getRelease(ctx, repoOwner, repoName, releaseTag)
 	if err != nil {
 		return nil, err
 	}
+	return gc.downloadReleaseAsset(ctx, release, assetName)
+}
 
+// downloadReleaseAsset downloads the asset of that name from a fetched release
+func (gc *GitHubClient) downloadReleaseAsset(ctx context.Context, release *GitHubRelease, assetName string) ([]byte, error) {
 	var assetURL string
 	for _, asset := range release.Assets {
 		if asset.Name == assetName {
@@ -100,30 +83,92 @@ func (gc *GitHubClient) DownloadReleaseAsset(ctx context.Context, repoOwner, rep
 	}
 
 	if assetURL == "" {
-		return nil, fmt.Errorf("asset '%s' not found in release '%s'", assetName, releaseTag)
+		return nil, fmt.Errorf("asset '%s' %w in release '%s'", assetName, ErrAssetNotFound, release.TagName)
 	}
 
 	return gc.downloadAsset(ctx, assetURL)
 }
 
-// DownloadDatasourcePackage downloads and extracts a datasource package from GitHub
-func (gc *GitHubClient) DownloadDatasourcePackage(ctx context.Context, repoOwner, repoName, datasource, version, destPath string) error {
-	packageName := strings.ReplaceAll(datasource, "_", "-")
-	versionWithoutV := strings.TrimPrefix(version, "v")
-	releaseTag := fmt.Sprintf("ds/%s/%s", datasource, version)
-	filename := fmt.Sprintf("%s-%s.tar.gz", packageName, versionWithoutV)
-
-	assetData, err := gc.DownloadReleaseAsset(ctx, repoOwner, repoName, releaseTag, filename)
+// DatasourceReleases returns the releases of a datasource, newest version first
+func (gc *GitHubClient) DatasourceReleases(ctx context.Context, repoOwner, repoName, datasource string) ([]GitHubRelease, error) {
+	releases, err := gc.getAllReleases(ctx, repoOwner, repoName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return filterDatasourceReleases(releases, datasource), nil
+}
+
+// filterDatasourceReleases returns the releases of a datasource, newest version first
+func filterDatasourceReleases(releases []GitHubRelease, datasource string) []GitHubRelease {
+	dsPrefix := fmt.Sprintf("ds/%s/", datasource)
+	var matching []GitHubRelease
+	for _, release := range releases {
+		if strings.HasPrefix(release.TagName, dsPrefix) {
+			matching = append(matching, release)
+		}
 	}
+	slices.SortStableFunc(matching, func(a, b GitHubRelease) int {
+		return CompareVersions(strings.TrimPrefix(b.TagName, dsPrefix), strings.TrimPrefix(a.TagName, dsPrefix))
+	})
+	return matching
+}
 
-	return ExtractTarGz(bytes.NewReader(assetData), destPath)
+// ReleasesBetween returns the releases of a datasource after version from up to and
+// including version to, oldest first
+func ReleasesBetween(releases []GitHubRelease, datasource, from, to string) []GitHubRelease {
+	dsPrefix := fmt.Sprintf("ds/%s/", datasource)
+	var between []GitHubRelease
+	for _, release := range releases {
+		version := strings.TrimPrefix(release.TagName, dsPrefix)
+		if version == release.TagName {
+			continue
+		}
+		if CompareVersions(version, from) > 0 && CompareVersions(version, to) <= 0 {
+			between = append(between, release)
+		}
+	}
+	slices.SortStableFunc(between, func(a, b GitHubRelease) int {
+		return CompareVersions(strings.TrimPrefix(a.TagName, dsPrefix), strings.TrimPrefix(b.TagName, dsPrefix))
+	})
+	return between
+}
+
+// CompareVersions compares two vX.Y.Z versions numerically, returning -1, 0 or 1. A
+// pre-release (v1.0.0-rc1) sorts before its release, and missing parts count as 0.
+func CompareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := range max(len(aParts), len(bParts)) {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	default:
+		return strings.Compare(aPre, bPre)
+	}
 }
 
 // getAllReleases fetches all releases from a repository
 func (gc *GitHubClient) getAllReleases(ctx context.Context, repoOwner, repoName string) ([]GitHubRelease, error) {
-	releasesAPIURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", repoOwner, repoName)
+	releasesAPIURL := fmt.Sprintf("%s/repos/%s/%s/releases", gc.apiURL, repoOwner, repoName)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", releasesAPIURL, nil)
 	if err != nil {
@@ -165,7 +210,7 @@ func (gc *GitHubClient) getAllReleases(ctx context.Context, repoOwner, repoName
 
 // getRelease fetches a specific release by tag
 func (gc *GitHubClient) getRelease(ctx context.Context, repoOwner, repoName, releaseTag string) (*GitHubRelease, error) {
-	releaseAPIURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", repoOwner, repoName, releaseTag)
+	releaseAPIURL := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", gc.apiURL, repoOwner, repoName, releaseTag)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", releaseAPIURL, nil)
 	if err != nil {
@@ -309,52 +354,6 @@ func ExtractTarGz(reader io.Reader, destPath string) error {
 	return nil
 }
 
-// DownloadTransformModels handles the complete workflow of downloading transform models:
-// - Resolves version (uses provided version or gets latest from GitHub)
-// - Creates destination directory
-// - Downloads and extracts the datasource package
-func (gc *GitHubClient) DownloadTransformModels(ctx context.Context, datasource, modelVersion, destPath string) (string, error) {
-	// Determine version: use provided version or get latest from GitHub
-	var version string
-	if modelVersion != "" {
-		version = normalizeVersion(modelVersion)
-		if version == "" {
-			// Invalid version provided, fall back to latest
-			utils.PrintDebug(fmt.Sprintf("Invalid version '%s' provided, fetching latest release from GitHub...", modelVersion))
-			latestVersion, err := gc.GetLatestReleaseVersion(ctx, DefaultRepoOwner, DefaultRepoName, datasource)
-			if err != nil {
-				return "", fmt.Errorf("failed to get latest version for datasource '%s': %w", datasource, err)
-			}
-			version = latestVersion
-			utils.PrintDebug(fmt.Sprintf("Latest version found: %s", version))
-		} else {
-			utils.PrintDebug(fmt.Sprintf("Using specified version: %s (normalized from: %s)", version, modelVersion))
-		}
-	} else {
-		utils.PrintDebug(fmt.Sprintf("No version specified, fetching latest release from GitHub for %s", datasource))
-		latestVersion, err := gc.GetLatestReleaseVersion(ctx, DefaultRepoOwner, DefaultRepoName, datasource)
-		if err != nil {
-			return "", fmt.Errorf("failed to get latest version for datasource '%s': %w", datasource, err)
-		}
-		version = latestVersion
-		utils.PrintDebug(fmt.Sprintf("Latest version found: %s", version))
-	}
-
-	// Ensure destination directory exists
-	if err := os.MkdirAll(filepath.Clean(destPath), 0o750); err != nil {
-		return "", fmt.Errorf("failed to create destination directory: %w", err)
-	}
-
-	utils.PrintDebug(fmt.Sprintf("Downloading and extracting %s version %s...", datasource, version))
-
-	// Download and extract the datasource package
-	if err := gc.DownloadDatasourcePackage(ctx, DefaultRepoOwner, DefaultRepoName, datasource, version, destPath); err != nil {
-		return "", fmt.Errorf("failed to download version %s: %w", version, err)
-	}
-
-	return version, nil
-}
-
 // normalizeVersion ensures version has 'v' prefix and validates format
 func normalizeVersion(version string) string {
 	// Reject invalid version strings
@@ -1,8 +1,23 @@
 package utils
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
 )
 
 func TestNormalizeVersion(t *testing.T) {
@@ -220,3 +235,150 @@ func TestIsRateLimitError(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"v1.0.0", "1.0.0", 0},
+		{"v1.2.0", "v1.10.0", -1},
+		{"v2.0.0", "v1.9.9", 1},
+		{"v1.0", "v1.0.0", 0},
+		{"v1.0.0-rc1", "v1.0.0", -1},
+		{"v1.0.0-rc2", "v1.0.0-rc1", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestReleasesBetween(t *testing.T) {
+	releases := []GitHubRelease{
+		{TagName: "ds/aws_cur/v1.3.0"},
+		{TagName: "ds/aws_cur/v1.10.0"},
+		{TagName: "ds/aws_cur/v1.2.0"},
+		{TagName: "ds/aws_focus/v1.3.0"},
+		{TagName: "ds/aws_cur/v1.1.0"},
+	}
+	got := ReleasesBetween(releases, "aws_cur", "v1.1.0", "v1.3.0")
+	if len(got) != 2 || got[0].TagName != "ds/aws_cur/v1.2.0" || got[1].TagName != "ds/aws_cur/v1.3.0" {
+		t.Errorf("ReleasesBetween() = %+v", got)
+	}
+}
+
+// testPackage builds a tar.gz package of one file
+func testPackage(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("select 1\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "models/a.sql", Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// minisign returns a minisign public key and a legacy signature of data with it
+func minisign(t *testing.T, data []byte) (string, []byte) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	sig := ed25519.Sign(private, data)
+	globalSig := ed25519.Sign(private, append(append([]byte{}, sig...), "release"...))
+	key := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), public...))
+	signature := "untrusted comment: signature\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), sig...)) + "\n" +
+		"trusted comment: release\n" + base64.StdEncoding.EncodeToString(globalSig) + "\n"
+	return key, []byte(signature)
+}
+
+func TestDownloadDatasourcePackage_Verification(t *testing.T) {
+	archive := testPackage(t)
+	sum := sha256.Sum256(archive)
+	digest := hex.EncodeToString(sum[:])
+	key, signature := minisign(t, archive)
+	t.Setenv(modelpkg.PublicKeyEnv, key)
+
+	tests := []struct {
+		name     string
+		assets   map[string][]byte
+		wantErr  bool
+		wantFile bool
+	}{
+		{"verified", map[string][]byte{
+			"aws-cur-1.0.0.tar.gz":         archive,
+			"aws-cur-1.0.0.tar.gz.sha256":  []byte(digest + "  aws-cur-1.0.0.tar.gz\n"),
+			"aws-cur-1.0.0.tar.gz.minisig": signature,
+		}, false, true},
+		{"checksum mismatch", map[string][]byte{
+			"aws-cur-1.0.0.tar.gz":         archive,
+			"aws-cur-1.0.0.tar.gz.sha256":  []byte(hex.EncodeToString(make([]byte, 32)) + "  aws-cur-1.0.0.tar.gz\n"),
+			"aws-cur-1.0.0.tar.gz.minisig": signature,
+		}, true, false},
+		{"unsigned release", map[string][]byte{
+			"aws-cur-1.0.0.tar.gz":        archive,
+			"aws-cur-1.0.0.tar.gz.sha256": []byte(digest + "  aws-cur-1.0.0.tar.gz\n"),
+		}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			server := httptest.NewServer(mux)
+			defer server.Close()
+			mux.HandleFunc("/repos/ecos-labs/ecos/releases/tags/ds/aws_cur/v1.0.0", func(w http.ResponseWriter, _ *http.Request) {
+				release := GitHubRelease{TagName: "ds/aws_cur/v1.0.0"}
+				for name := range tt.assets {
+					release.Assets = append(release.Assets, struct {
+						Name string `json:"name"`
+						URL  string `json:"url"`
+					}{name, server.URL + "/assets/" + name})
+				}
+				_ = json.NewEncoder(w).Encode(release)
+			})
+			mux.HandleFunc("/assets/{name}", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(tt.assets[r.PathValue("name")])
+			})
+
+			source := &githubSource{
+				client:   &GitHubClient{client: server.Client(), apiURL: server.URL},
+				owner:    DefaultRepoOwner,
+				repo:     DefaultRepoName,
+				releases: map[string]*GitHubRelease{},
+			}
+			dest := t.TempDir()
+			err := (&ModelDownloader{Source: source}).DownloadDatasourcePackage(context.Background(), "aws_cur", "v1.0.0", dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DownloadDatasourcePackage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, modelpkg.ErrVerification) {
+				t.Errorf("DownloadDatasourcePackage() error = %v, want ErrVerification", err)
+			}
+			if _, statErr := os.Stat(filepath.Join(dest, "models", "a.sql")); (statErr == nil) != tt.wantFile {
+				t.Errorf("extracted = %v, want %v", statErr == nil, tt.wantFile)
+			}
+			if !tt.wantErr {
+				manifest, err := modelpkg.LoadManifest(dest)
+				if err != nil || manifest.Digest != digest || manifest.KeyID != "0807060504030201" {
+					t.Errorf("manifest = %+v, %v", manifest, err)
+				}
+			}
+		})
+	}
+}
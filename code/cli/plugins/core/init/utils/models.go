@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+)
+
+// ModelDownloader resolves the versions of the datasource model packages of a source,
+// and downloads, verifies and extracts them
+type ModelDownloader struct {
+	Source ModelSource
+	// Cache keeps release lists and packages between runs; nil disables caching
+	Cache *ModelCache
+	// Offline resolves versions and packages from Cache only, without calling Source
+	Offline bool
+	// AllowUnsigned installs legacy releases published without a signature, and signed
+	// releases when no trusted key is set, verifying their checksum when there is one
+	AllowUnsigned bool
+}
+
+// NewModelDownloader returns a caching downloader for a model_source setting, the GitHub
+// releases of the ecos repository when empty. aws authenticates s3:// sources.
+func NewModelDownloader(source string, aws awssession.Options) (*ModelDownloader, error) {
+	s, err := ParseModelSource(source, aws)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := NewModelCache()
+	if err != nil {
+		utils.PrintDebug(fmt.Sprintf("Model cache disabled: %v", err))
+	}
+	return &ModelDownloader{Source: s, Cache: cache}, nil
+}
+
+// DatasourceReleases returns the releases of a datasource, newest version first. A
+// cached list younger than the cache TTL is used as is; an older one when offline, or
+// when the source cannot be reached.
+func (d *ModelDownloader) DatasourceReleases(ctx context.Context, datasource string) ([]GitHubRelease, error) {
+	if err := d.checkOffline(); err != nil {
+		return nil, err
+	}
+	source := d.Source.String()
+	var cached []GitHubRelease
+	var fetchedAt time.Time
+	var ok bool
+	if d.Cache != nil {
+		cached, fetchedAt, ok = d.Cache.Releases(source, datasource)
+		if ok && (d.Offline || time.Since(fetchedAt) < d.Cache.TTL) {
+			utils.PrintDebug(fmt.Sprintf("Using the release list of %s cached at %s", datasource, fetchedAt.Format(time.RFC3339)))
+			return cached, nil
+		}
+	}
+	if d.Offline {
+		return nil, fmt.Errorf("%w: no release list of %s from %s, run once without --offline", ErrNotCached, datasource, source)
+	}
+
+	releases, err := d.Source.DatasourceReleases(ctx, datasource)
+	if err != nil {
+		if ok {
+			utils.PrintWarning(fmt.Sprintf("Failed to list the releases of %s (%v), using the list cached at %s", datasource, err, fetchedAt.Format(time.RFC3339)))
+			return cached, nil
+		}
+		return nil, err
+	}
+	if d.Cache != nil {
+		if err := d.Cache.SaveReleases(source, datasource, releases); err != nil {
+			utils.PrintDebug(fmt.Sprintf("Failed to cache the release list: %v", err))
+		}
+	}
+	return releases, nil
+}
+
+// GetLatestReleaseVersion gets the latest release version for a specific datasource.
+// Offline, it is the newest cached package.
+func (d *ModelDownloader) GetLatestReleaseVersion(ctx context.Context, datasource string) (string, error) {
+	if d.Offline {
+		if err := d.checkOffline(); err != nil {
+			return "", err
+		}
+		versions := d.Cache.Versions(datasource)
+		if len(versions) == 0 {
+			return "", fmt.Errorf("%w: no package of %s in %s, run once without --offline", ErrNotCached, datasource, d.Cache.Dir)
+		}
+		return versions[0], nil
+	}
+
+	releases, err := d.DatasourceReleases(ctx, datasource)
+	if err != nil {
+		return "", err
+	}
+	if len(releases) == 0 {
+		return "", fmt.Errorf("no releases found for datasource '%s' in %s. Please ensure releases are tagged with format 'ds/%s/v1.0.0'",
+			datasource, d.Source, datasource)
+	}
+	return strings.TrimPrefix(releases[0].TagName, fmt.Sprintf("ds/%s/", datasource)), nil
+}
+
+// DownloadDatasourcePackage downloads a datasource package, or takes it from the cache,
+// verifies its checksum and signature, extracts it and records its digest, files and
+// their checksums in the model manifest of destPath. Nothing is extracted unless the
+// package verifies.
+func (d *ModelDownloader) DownloadDatasourcePackage(ctx context.Context, datasource, version, destPath string) error {
+	filename := PackageFilename(datasource, version)
+
+	keys, keyErr := modelpkg.TrustedPublicKeys()
+	if keyErr != nil && !d.AllowUnsigned {
+		return keyErr
+	}
+	if err := d.checkOffline(); err != nil {
+		return err
+	}
+
+	var assetData, checksumFile, signature []byte
+	var err error
+	cached := false
+	if d.Cache != nil {
+		assetData, checksumFile, signature, cached = d.Cache.Package(datasource, version)
+	}
+	if cached {
+		utils.PrintDebug(fmt.Sprintf("Using %s from the model cache", filename))
+	} else {
+		if d.Offline {
+			return fmt.Errorf("%w: version %s of %s is not in %s, run once without --offline", ErrNotCached, version, datasource, d.Cache.Dir)
+		}
+		if assetData, checksumFile, signature, err = d.fetchPackage(ctx, datasource, version, filename); err != nil {
+			return err
+		}
+	}
+
+	var digest, keyID string
+	switch {
+	case signature == nil:
+		// Only returned by fetchPackage with AllowUnsigned
+		if digest, err = modelpkg.VerifyUnsigned(assetData, checksumFile, filename); err != nil {
+			return err
+		}
+		utils.PrintWarning(fmt.Sprintf("%s is not signed, installed without signature verification (--allow-unsigned)", filename))
+	case keyErr != nil:
+		// Only reached with AllowUnsigned: without a trusted key only the checksum verifies
+		if digest, err = modelpkg.VerifyUnsigned(assetData, checksumFile, filename); err != nil {
+			return err
+		}
+		utils.PrintWarning(fmt.Sprintf("%s installed without signature verification (--allow-unsigned): %v", filename, keyErr))
+	default:
+		var key *modelpkg.PublicKey
+		digest, key, err = modelpkg.VerifyPackage(assetData, checksumFile, signature, filename, keys)
+		if err != nil {
+			if cached {
+				return fmt.Errorf("cached package %s: %w (remove it with 'ecos cache prune')", filename, err)
+			}
+			return err
+		}
+		keyID = key.KeyID()
+		utils.PrintDebug(fmt.Sprintf("Verified %s (sha256 %s, key %s)", filename, digest, keyID))
+		// Only verified packages are cached
+		if !cached && d.Cache != nil {
+			if err := d.Cache.SavePackage(datasource, version, assetData, checksumFile, signature); err != nil {
+				utils.PrintDebug(fmt.Sprintf("Failed to cache %s: %v", filename, err))
+			}
+		}
+	}
+
+	files, err := modelpkg.PackageFiles(bytes.NewReader(assetData))
+	if err != nil {
+		return err
+	}
+	if err := ExtractTarGz(bytes.NewReader(assetData), destPath); err != nil {
+		return err
+	}
+
+	manifest := &modelpkg.Manifest{Datasource: datasource, Version: version, Digest: digest, KeyID: keyID, Files: modelpkg.TrackedFiles(files)}
+	if err := manifest.Save(destPath); err != nil {
+		return fmt.Errorf("failed to record the model manifest: %w", err)
+	}
+	return nil
+}
+
+// fetchPackage downloads the archive, checksum and signature of a package version. The
+// signature, and then the checksum, of a legacy unsigned release are nil with
+// AllowUnsigned.
+func (d *ModelDownloader) fetchPackage(ctx context.Context, datasource, version, filename string) (archive, checksum, signature []byte, err error) {
+	archive, err = d.Source.FetchAsset(ctx, datasource, version, filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	signature, err = d.Source.FetchAsset(ctx, datasource, version, filename+modelpkg.SignatureSuffix)
+	unsigned := errors.Is(err, ErrAssetNotFound)
+	switch {
+	case unsigned && !d.AllowUnsigned:
+		return nil, nil, nil, fmt.Errorf("%w: version %s of %s is not signed, like the releases published before package signing. Install a signed version, or pass --allow-unsigned to install it without signature verification",
+			modelpkg.ErrVerification, version, datasource)
+	case err != nil && !unsigned:
+		return nil, nil, nil, fmt.Errorf("%w: %w", modelpkg.ErrVerification, err)
+	}
+	checksum, err = d.Source.FetchAsset(ctx, datasource, version, filename+modelpkg.ChecksumSuffix)
+	if err != nil && !(unsigned && errors.Is(err, ErrAssetNotFound)) {
+		return nil, nil, nil, fmt.Errorf("%w: %w", modelpkg.ErrVerification, err)
+	}
+	return archive, checksum, signature, nil
+}
+
+// checkOffline reports offline mode without a cache to resolve from
+func (d *ModelDownloader) checkOffline() error {
+	if d.Offline && d.Cache == nil {
+		return fmt.Errorf("%w: offline mode needs the model cache, set %s to a writable directory", ErrNotCached, CacheDirEnv)
+	}
+	return nil
+}
+
+// DownloadTransformModels handles the complete workflow of downloading transform models:
+// - Resolves version (uses provided version or gets the latest release of the source)
+// - Creates destination directory
+// - Downloads, verifies and extracts the datasource package
+func (d *ModelDownloader) DownloadTransformModels(ctx context.Context, datasource, modelVersion, destPath string) (string, error) {
+	// Determine version: use provided version or get latest from the source
+	var version string
+	if modelVersion != "" {
+		version = normalizeVersion(modelVersion)
+	}
+	if version == "" {
+		utils.PrintDebug(fmt.Sprintf("No version specified, fetching the latest release of %s from %s", datasource, d.Source))
+		latestVersion, err := d.GetLatestReleaseVersion(ctx, datasource)
+		if err != nil {
+			return "", fmt.Errorf("failed to get latest version for datasource '%s': %w", datasource, err)
+		}
+		version = latestVersion
+		utils.PrintDebug(fmt.Sprintf("Latest version found: %s", version))
+	} else {
+		utils.PrintDebug(fmt.Sprintf("Using specified version: %s (normalized from: %s)", version, modelVersion))
+	}
+
+	// Ensure destination directory exists
+	if err := os.MkdirAll(filepath.Clean(destPath), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	utils.PrintDebug(fmt.Sprintf("Downloading and extracting %s version %s from %s...", datasource, version, d.Source))
+
+	// Download and extract the datasource package
+	if err := d.DownloadDatasourcePackage(ctx, datasource, version, destPath); err != nil {
+		return "", fmt.Errorf("failed to download version %s: %w", version, err)
+	}
+
+	return version, nil
+}
+
+// PackageFilename returns the archive name of a datasource package version, e.g.
+// aws-cur-1.2.0.tar.gz
+func PackageFilename(datasource, version string) string {
+	return fmt.Sprintf("%s-%s.tar.gz", strings.ReplaceAll(datasource, "_", "-"), strings.TrimPrefix(version, "v"))
+}
@@ -0,0 +1,339 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+)
+
+// DefaultModelSource is where the datasource model packages are released
+var DefaultModelSource = fmt.Sprintf("github://%s/%s", DefaultRepoOwner, DefaultRepoName)
+
+// ErrAssetNotFound reports a release file the source does not have
+var ErrAssetNotFound = errors.New("not found")
+
+// mirrorIndex lists the releases of a mirror, in the format of the GitHub releases API
+const mirrorIndex = "releases.json"
+
+// ModelSource serves the releases of the datasource model packages
+type ModelSource interface {
+	// DatasourceReleases returns the releases of a datasource, newest version first
+	DatasourceReleases(ctx context.Context, datasource string) ([]GitHubRelease, error)
+	// FetchAsset returns a file of a datasource release: the package archive, its
+	// checksum or its signature
+	FetchAsset(ctx context.Context, datasource, version, name string) ([]byte, error)
+	// String describes the source in messages
+	String() string
+}
+
+// ParseModelSource returns the source a model_source setting names, the GitHub releases
+// of the ecos repository when empty:
+//
+//	github://owner/repo                        GitHub releases
+//	github://host/owner/repo                   GitHub Enterprise releases
+//	https://host/api/v3/repos/owner/repo       GitHub Enterprise releases, by API URL
+//	https://host/path                          artifact mirror
+//	s3://bucket/prefix                         artifact mirror in S3, read with aws
+//	file:///path/dir                           artifact mirror in a directory
+//	file:///path/aws-cur-1.2.0.tar.gz          a single package
+//
+// A mirror holds releases.json, the release list in the GitHub releases API format, and
+// the release files under their tag, e.g. ds/aws_cur/v1.2.0/aws-cur-1.2.0.tar.gz.
+func ParseModelSource(source string, aws awssession.Options) (ModelSource, error) {
+	if source == "" {
+		source = DefaultModelSource
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" {
+		return nil, fmt.Errorf("invalid model source %q: expected github://, https://, s3:// or file://", source)
+	}
+
+	switch u.Scheme {
+	case "github":
+		parts := strings.Split(strings.Trim(u.Host+u.Path, "/"), "/")
+		switch len(parts) {
+		case 2:
+			return newGitHubSource(defaultAPIURL, parts[0], parts[1]), nil
+		case 3:
+			return newGitHubSource("https://"+parts[0]+"/api/v3", parts[1], parts[2]), nil
+		}
+		return nil, fmt.Errorf("invalid model source %q: expected github://owner/repo or github://host/owner/repo", source)
+	case "https", "http":
+		if base, repo, ok := strings.Cut(u.Path, "/api/v3/repos/"); ok {
+			owner, name, ok := strings.Cut(strings.Trim(repo, "/"), "/")
+			if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+				return nil, fmt.Errorf("invalid model source %q: expected https://host/api/v3/repos/owner/repo", source)
+			}
+			return newGitHubSource(u.Scheme+"://"+u.Host+base+"/api/v3", owner, name), nil
+		}
+		return &mirrorSource{store: &httpStore{base: strings.TrimSuffix(source, "/"), client: &http.Client{Timeout: 60 * time.Second}}}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid model source %q: expected s3://bucket/prefix", source)
+		}
+		return &mirrorSource{store: &s3Store{bucket: u.Host, prefix: strings.Trim(u.Path, "/"), aws: aws}}, nil
+	case "file":
+		// file:///absolute/path, or file://relative/path
+		p := filepath.FromSlash(strings.TrimPrefix(source, "file://"))
+		if strings.HasSuffix(p, ".tar.gz") {
+			return &packageFileSource{path: p}, nil
+		}
+		return &mirrorSource{store: &dirStore{dir: p}}, nil
+	}
+	return nil, fmt.Errorf("unsupported model source %q: expected github://, https://, s3:// or file://", source)
+}
+
+// githubSource serves the releases of a GitHub or GitHub Enterprise repository
+type githubSource struct {
+	client *GitHubClient
+	owner  string
+	repo   string
+
+	mu       sync.Mutex
+	releases map[string]*GitHubRelease
+}
+
+func newGitHubSource(apiURL, owner, repo string) *githubSource {
+	client, _ := NewGitHubClient()
+	client.apiURL = apiURL
+	return &githubSource{client: client, owner: owner, repo: repo, releases: map[string]*GitHubRelease{}}
+}
+
+func (s *githubSource) DatasourceReleases(ctx context.Context, datasource string) ([]GitHubRelease, error) {
+	return s.client.DatasourceReleases(ctx, s.owner, s.repo, datasource)
+}
+
+// FetchAsset downloads a release asset; the release is looked up once for its files
+func (s *githubSource) FetchAsset(ctx context.Context, datasource, version, name string) ([]byte, error) {
+	tag := fmt.Sprintf("ds/%s/%s", datasource, version)
+	s.mu.Lock()
+	release, ok := s.releases[tag]
+	s.mu.Unlock()
+	if !ok {
+		var err error
+		if release, err = s.client.getRelease(ctx, s.owner, s.repo, tag); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.releases[tag] = release
+		s.mu.Unlock()
+	}
+	return s.client.downloadReleaseAsset(ctx, release, name)
+}
+
+func (s *githubSource) String() string {
+	if s.client.apiURL == defaultAPIURL {
+		return fmt.Sprintf("github://%s/%s", s.owner, s.repo)
+	}
+	return fmt.Sprintf("%s/repos/%s/%s", s.client.apiURL, s.owner, s.repo)
+}
+
+// mirrorStore reads the files of a mirror by slash-separated name
+type mirrorStore interface {
+	read(ctx context.Context, name string) ([]byte, error)
+	String() string
+}
+
+// mirrorSource serves the releases copied to a mirror
+type mirrorSource struct {
+	store mirrorStore
+}
+
+func (s *mirrorSource) DatasourceReleases(ctx context.Context, datasource string) ([]GitHubRelease, error) {
+	data, err := s.store.read(ctx, mirrorIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the release list: %w", err)
+	}
+	var releases []GitHubRelease
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of %s: %w", mirrorIndex, s.store, err)
+	}
+	return filterDatasourceReleases(releases, datasource), nil
+}
+
+func (s *mirrorSource) FetchAsset(ctx context.Context, datasource, version, name string) ([]byte, error) {
+	return s.store.read(ctx, path.Join("ds", datasource, version, name))
+}
+
+func (s *mirrorSource) String() string {
+	return s.store.String()
+}
+
+// httpStore reads a mirror served over HTTP. Credentials in the URL are sent as basic
+// authentication.
+type httpStore struct {
+	base   string
+	client *http.Client
+}
+
+func (s *httpStore) read(ctx context.Context, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.base+"/"+name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s %w in %s", name, ErrAssetNotFound, s)
+	}
+	return nil, fmt.Errorf("failed to download %s (HTTP %d)", name, resp.StatusCode)
+}
+
+func (s *httpStore) String() string {
+	u, err := url.Parse(s.base)
+	if err != nil {
+		return s.base
+	}
+	return u.Redacted()
+}
+
+// s3Store reads a mirror in an S3 bucket, loading the AWS configuration on first use
+type s3Store struct {
+	bucket string
+	prefix string
+	aws    awssession.Options
+
+	mu     sync.Mutex
+	client *s3.Client
+}
+
+func (s *s3Store) read(ctx context.Context, name string) ([]byte, error) {
+	client, err := s.s3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := path.Join(s.prefix, name)
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		var noKey *s3types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, fmt.Errorf("%s %w in %s", name, ErrAssetNotFound, s)
+		}
+		return nil, fmt.Errorf("failed to download s3://%s/%s: %w", s.bucket, key, err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *s3Store) s3Client(ctx context.Context) (*s3.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		cfg, err := awssession.Load(ctx, s.aws)
+		if err != nil {
+			return nil, err
+		}
+		s.client = awssession.NewS3Client(cfg, s.aws.Access.Endpoints)
+	}
+	return s.client, nil
+}
+
+func (s *s3Store) String() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+// dirStore reads a mirror in a local directory
+type dirStore struct {
+	dir string
+}
+
+func (s *dirStore) read(_ context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(name))) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s %w in %s", name, ErrAssetNotFound, s)
+	}
+	return data, err
+}
+
+func (s *dirStore) String() string {
+	return "file://" + filepath.ToSlash(s.dir)
+}
+
+// packageFileSource serves a single package archive, with the checksum and signature
+// files next to it. Its version is read from the archive name.
+type packageFileSource struct {
+	path string
+}
+
+func (s *packageFileSource) DatasourceReleases(_ context.Context, datasource string) ([]GitHubRelease, error) {
+	version, ok := s.version(datasource)
+	if !ok {
+		return nil, nil
+	}
+	return []GitHubRelease{{TagName: fmt.Sprintf("ds/%s/%s", datasource, version)}}, nil
+}
+
+func (s *packageFileSource) FetchAsset(_ context.Context, datasource, version, name string) ([]byte, error) {
+	if filepath.Base(s.path) != PackageFilename(datasource, version) {
+		return nil, fmt.Errorf("%s is not version %s of %s", s.path, version, datasource)
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(s.path), name)) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s %w next to %s", name, ErrAssetNotFound, s.path)
+	}
+	return data, err
+}
+
+// version returns the version of the archive, when it is a package of the datasource
+func (s *packageFileSource) version(datasource string) (string, bool) {
+	name := strings.TrimSuffix(filepath.Base(s.path), ".tar.gz")
+	version, ok := strings.CutPrefix(name, strings.ReplaceAll(datasource, "_", "-")+"-")
+	if !ok || version == "" {
+		return "", false
+	}
+	return "v" + version, true
+}
+
+func (s *packageFileSource) String() string {
+	return "file://" + filepath.ToSlash(s.path)
+}
+
+// AddMirrorRelease adds a release to the release list of a mirror directory, replacing
+// the release with the same tag
+func AddMirrorRelease(dir string, release GitHubRelease) error {
+	path := filepath.Join(dir, mirrorIndex)
+	var releases []GitHubRelease
+	data, err := os.ReadFile(path) // #nosec G304
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &releases); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	releases = slices.DeleteFunc(releases, func(r GitHubRelease) bool { return r.TagName == release.TagName })
+	releases = append(releases, release)
+	data, err = json.MarshalIndent(releases, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
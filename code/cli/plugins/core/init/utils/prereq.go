@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -318,6 +319,58 @@ func CheckDBTWithAdapter(ctx context.Context, adapterName string) (bool, string)
 	return true, result
 }
 
+// DBTVersions returns the installed versions of dbt Core and of an adapter plugin, from
+// 'dbt --version'
+func DBTVersions(ctx context.Context, adapterName string) (coreVersion, adapterVersion string, err error) {
+	output, err := exec.CommandContext(ctx, "dbt", "--version").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to run 'dbt --version': %w", err)
+	}
+	coreVersion, adapterVersion = ParseDBTVersion(string(output), adapterName)
+	if coreVersion == "" {
+		return "", "", errors.New("failed to read the dbt Core version from 'dbt --version'")
+	}
+	return coreVersion, adapterVersion, nil
+}
+
+// ParseDBTVersion reads the dbt Core and adapter versions from the output of
+// 'dbt --version', either the current format ("Core:" / "- installed: 1.8.7" and
+// "Plugins:" / "- athena: 1.8.4") or the one of dbt before 1.5 ("installed version: 1.4.5").
+// Versions not found are empty.
+func ParseDBTVersion(output, adapterName string) (coreVersion, adapterVersion string) {
+	inPluginsSection := false
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "plugins:"):
+			inPluginsSection = true
+			continue
+		case strings.HasPrefix(lower, "installed:") || strings.HasPrefix(lower, "installed version:"):
+			if coreVersion == "" {
+				_, value, _ := strings.Cut(line, ":")
+				coreVersion = firstField(value)
+			}
+			continue
+		}
+		if inPluginsSection && adapterVersion == "" {
+			name, value, ok := strings.Cut(line, ":")
+			if ok && strings.EqualFold(strings.TrimSpace(name), adapterName) {
+				adapterVersion = firstField(value)
+			}
+		}
+	}
+	return coreVersion, adapterVersion
+}
+
+func firstField(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
 // CheckAWSCLI checks if AWS CLI is installed and accessible
 func CheckAWSCLI(ctx context.Context) (bool, string) {
 	err := exec.CommandContext(ctx, "aws", "--version").Run()
@@ -0,0 +1,52 @@
+package utils
+
+import "testing"
+
+func TestParseDBTVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantCore    string
+		wantAdapter string
+	}{
+		{
+			name: "current format",
+			output: `Core:
+  - installed: 1.8.7
+  - latest:    1.9.1 - Update available!
+
+  Your version of dbt-core is out of date!
+
+Plugins:
+  - athena: 1.8.4 - Update available!
+  - duckdb: 1.9.0 - Up to date!
+`,
+			wantCore:    "1.8.7",
+			wantAdapter: "1.8.4",
+		},
+		{
+			name: "before dbt 1.5",
+			output: `installed version: 1.4.5
+   latest version: 1.5.0
+
+Plugins:
+  - athena: 1.4.2
+`,
+			wantCore:    "1.4.5",
+			wantAdapter: "1.4.2",
+		},
+		{
+			name:     "adapter not installed",
+			output:   "Core:\n  - installed: 1.8.7\n\nPlugins:\n  - duckdb: 1.9.0\n",
+			wantCore: "1.8.7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, adapter := ParseDBTVersion(tt.output, "athena")
+			if core != tt.wantCore || adapter != tt.wantAdapter {
+				t.Errorf("ParseDBTVersion() = %q, %q, want %q, %q", core, adapter, tt.wantCore, tt.wantAdapter)
+			}
+		})
+	}
+}
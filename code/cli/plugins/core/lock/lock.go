@@ -0,0 +1,156 @@
+// Package lock pins what a project's transforms depend on in .ecos.lock: the installed
+// model package, the resolved dbt packages and the dbt Core and adapter versions, so
+// that a runtime differing from the one the project was set up with is noticed.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+	"gopkg.in/yaml.v3"
+)
+
+// Filename is the lock file in the ecos project directory
+const Filename = ".ecos.lock"
+
+// PackageLockFilename is the file dbt deps resolves the dbt packages to
+const PackageLockFilename = "package-lock.yml"
+
+const header = "# Generated by ecos init and ecos models upgrade. Do not edit, refresh it with 'ecos lock update'.\n"
+
+// File is the content of .ecos.lock
+type File struct {
+	GeneratedAt time.Time `yaml:"generated_at"`
+	Models      Models    `yaml:"models"`
+	DBT         DBT       `yaml:"dbt"`
+}
+
+// Models pins the installed model package
+type Models struct {
+	Datasource string `yaml:"datasource,omitempty"`
+	Version    string `yaml:"version,omitempty"`
+	// Digest is the SHA-256 of the package archive, and KeyID the key its signature was
+	// verified with
+	Digest string `yaml:"digest,omitempty"`
+	KeyID  string `yaml:"key_id,omitempty"`
+}
+
+// DBT pins dbt, its adapter and the dbt packages of the project
+type DBT struct {
+	Core           string `yaml:"core,omitempty"`
+	Adapter        string `yaml:"adapter,omitempty"`
+	AdapterVersion string `yaml:"adapter_version,omitempty"`
+	// Packages and PackagesHash are the packages and sha1_hash of package-lock.yml
+	Packages     []map[string]string `yaml:"packages,omitempty"`
+	PackagesHash string              `yaml:"packages_sha1_hash,omitempty"`
+}
+
+type packageLock struct {
+	Packages []map[string]string `yaml:"packages"`
+	SHA1Hash string              `yaml:"sha1_hash"`
+}
+
+// Path returns the lock file location in an ecos project directory
+func Path(projectDir string) string {
+	return filepath.Join(projectDir, Filename)
+}
+
+// Load reads the lock file of an ecos project. It returns nil without an error when there
+// is none.
+func Load(projectDir string) (*File, error) {
+	data, err := os.ReadFile(Path(projectDir)) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", Filename, err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", Path(projectDir), err)
+	}
+	return &f, nil
+}
+
+// Save writes the lock file to an ecos project directory
+func (f *File) Save(projectDir string) error {
+	f.GeneratedAt = time.Now().UTC()
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(Path(projectDir), append([]byte(header), data...), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", Filename, err)
+	}
+	return nil
+}
+
+// Capture records the current state of the dbt project in dbtDir: its model package
+// from the model manifest, or datasource and modelVersion for projects without one, and
+// its resolved dbt packages. dbt holds the dbt Core and adapter versions found, if any.
+func Capture(dbtDir, datasource, modelVersion string, dbt DBT) (*File, error) {
+	f := &File{Models: Models{Datasource: datasource, Version: modelVersion}, DBT: dbt}
+
+	manifest, err := modelpkg.LoadManifest(dbtDir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		f.Models = Models{Datasource: manifest.Datasource, Version: manifest.Version, Digest: manifest.Digest, KeyID: manifest.KeyID}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbtDir, PackageLockFilename)) // #nosec G304
+	switch {
+	case err == nil:
+		var pl packageLock
+		if err := yaml.Unmarshal(data, &pl); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", PackageLockFilename, err)
+		}
+		f.DBT.Packages, f.DBT.PackagesHash = pl.Packages, pl.SHA1Hash
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read %s: %w", PackageLockFilename, err)
+	}
+	return f, nil
+}
+
+// Diff lists how the current state differs from the lock. What the lock does not pin,
+// e.g. dbt packages not yet resolved when it was written, is not compared.
+func (f *File) Diff(current *File) []string {
+	var diffs []string
+	differs := func(what, locked, got string) {
+		if locked == "" || locked == got {
+			return
+		}
+		if got == "" {
+			got = "not found"
+		}
+		diffs = append(diffs, fmt.Sprintf("%s: %s, locked %s", what, got, locked))
+	}
+
+	differs("model version", f.Models.Version, current.Models.Version)
+	differs("model package digest", f.Models.Digest, current.Models.Digest)
+	differs("dbt Core", f.DBT.Core, current.DBT.Core)
+	if f.DBT.Adapter == current.DBT.Adapter {
+		differs(fmt.Sprintf("dbt-%s", f.DBT.Adapter), f.DBT.AdapterVersion, current.DBT.AdapterVersion)
+	} else {
+		differs("dbt adapter", f.DBT.Adapter, current.DBT.Adapter)
+	}
+
+	switch {
+	case f.DBT.PackagesHash != "" && current.DBT.PackagesHash != "":
+		if f.DBT.PackagesHash != current.DBT.PackagesHash {
+			diffs = append(diffs, "dbt packages: package-lock.yml differs from the lock")
+		}
+	case len(f.DBT.Packages) > 0:
+		if !slices.EqualFunc(f.DBT.Packages, current.DBT.Packages, maps.Equal) {
+			diffs = append(diffs, "dbt packages: package-lock.yml differs from the lock")
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,108 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/modelpkg"
+)
+
+func TestCapture(t *testing.T) {
+	dbtDir := t.TempDir()
+
+	f, err := Capture(dbtDir, "aws_cur", "v1.0.0", DBT{Core: "1.9.4", Adapter: "athena", AdapterVersion: "1.9.3"})
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+	if f.Models.Version != "v1.0.0" || f.Models.Digest != "" || len(f.DBT.Packages) != 0 {
+		t.Errorf("Capture() without manifest or package-lock.yml = %+v", f)
+	}
+
+	manifest := &modelpkg.Manifest{Datasource: "aws_cur", Version: "v1.1.0", Digest: "abc123", KeyID: "key", Files: map[string]string{}}
+	if err := manifest.Save(dbtDir); err != nil {
+		t.Fatal(err)
+	}
+	packageLock := `packages:
+  - package: dbt-labs/dbt_utils
+    version: 1.3.0
+sha1_hash: 0123456789abcdef
+`
+	if err := os.WriteFile(filepath.Join(dbtDir, PackageLockFilename), []byte(packageLock), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = Capture(dbtDir, "aws_cur", "v1.0.0", DBT{})
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+	wantModels := Models{Datasource: "aws_cur", Version: "v1.1.0", Digest: "abc123", KeyID: "key"}
+	if f.Models != wantModels {
+		t.Errorf("Capture() models = %+v, want %+v", f.Models, wantModels)
+	}
+	wantPackages := []map[string]string{{"package": "dbt-labs/dbt_utils", "version": "1.3.0"}}
+	if !reflect.DeepEqual(f.DBT.Packages, wantPackages) || f.DBT.PackagesHash != "0123456789abcdef" {
+		t.Errorf("Capture() packages = %v (%s)", f.DBT.Packages, f.DBT.PackagesHash)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	if f, err := Load(dir); err != nil || f != nil {
+		t.Fatalf("Load() without lock = %v, %v", f, err)
+	}
+
+	f := &File{
+		Models: Models{Datasource: "aws_cur", Version: "v1.1.0", Digest: "abc123"},
+		DBT:    DBT{Core: "1.9.4", Adapter: "athena", AdapterVersion: "1.9.3", Packages: []map[string]string{{"package": "dbt-labs/dbt_utils", "version": "1.3.0"}}},
+	}
+	if err := f.Save(dir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Models, f.Models) || !reflect.DeepEqual(got.DBT, f.DBT) || got.GeneratedAt.IsZero() {
+		t.Errorf("Load() = %+v, want %+v", got, f)
+	}
+	if len(got.Diff(f)) != 0 {
+		t.Errorf("Diff() of a loaded lock = %v", got.Diff(f))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	locked := &File{
+		Models: Models{Version: "v1.1.0", Digest: "abc123"},
+		DBT:    DBT{Core: "1.9.4", Adapter: "athena", AdapterVersion: "1.9.3", PackagesHash: "aaa"},
+	}
+
+	tests := []struct {
+		name    string
+		locked  *File
+		current File
+		want    int
+	}{
+		{"same", locked, *locked, 0},
+		{"model version and digest", locked, File{Models: Models{Version: "v1.2.0", Digest: "def456"}, DBT: locked.DBT}, 2},
+		{"dbt upgraded", locked, File{Models: locked.Models, DBT: DBT{Core: "1.10.0", Adapter: "athena", AdapterVersion: "1.10.1", PackagesHash: "aaa"}}, 2},
+		{"dbt not installed", locked, File{Models: locked.Models, DBT: DBT{Adapter: "athena", PackagesHash: "aaa"}}, 2},
+		{"other adapter", locked, File{Models: locked.Models, DBT: DBT{Core: "1.9.4", Adapter: "duckdb", AdapterVersion: "1.9.3", PackagesHash: "aaa"}}, 1},
+		{"packages resolved again", locked, File{Models: locked.Models, DBT: DBT{Core: "1.9.4", Adapter: "athena", AdapterVersion: "1.9.3", PackagesHash: "bbb"}}, 1},
+		{"nothing pinned", &File{}, File{Models: Models{Version: "v1.2.0"}, DBT: DBT{Core: "1.10.0", PackagesHash: "bbb"}}, 0},
+		{
+			"packages without hash",
+			&File{DBT: DBT{Packages: []map[string]string{{"package": "dbt-labs/dbt_utils", "version": "1.3.0"}}}},
+			File{DBT: DBT{Packages: []map[string]string{{"package": "dbt-labs/dbt_utils", "version": "1.3.1"}}}},
+			1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.locked.Diff(&tt.current); len(got) != tt.want {
+				t.Errorf("Diff() = %v, want %d differences", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// token is a word, quoted identifier, string literal or punctuation of a SQL statement
+type token struct {
+	text   string
+	quoted bool // a "quoted" identifier
+	str    bool // a 'string' literal
+}
+
+// writeKeywords are the statements and clauses that change data or reach outside the
+// database
+var writeKeywords = []string{
+	"insert", "update", "delete", "merge", "create", "drop", "alter", "truncate", "unload",
+	"grant", "revoke", "call", "execute", "prepare", "deallocate", "copy", "attach", "detach",
+	"install", "load", "pragma", "export", "import", "vacuum", "optimize", "msck", "set", "reset",
+	"checkpoint", "use",
+}
+
+// clauseKeywords end the relation list of a from clause
+var clauseKeywords = []string{
+	"where", "group", "order", "limit", "having", "join", "inner", "left", "right", "full",
+	"cross", "natural", "on", "using", "union", "except", "intersect", "window", "offset",
+	"fetch", "qualify", "tablesample", "lateral",
+}
+
+// fromFunctions use FROM inside their arguments, e.g. extract(year from usage_date)
+var fromFunctions = []string{"extract", "substring", "trim", "overlay", "position"}
+
+// CheckReadOnly accepts a single select statement whose relations are all tables of
+// the allowed list, optionally qualified with database, or its own common table
+// expressions. Table functions other than unnest are rejected, so a query cannot read
+// files or other catalogs.
+func CheckReadOnly(sql, database string, allowed []string) error {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return err
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return errors.New("empty query")
+	}
+	if first := keyword(tokens[0]); first != "select" && first != "with" {
+		return errors.New("only select queries are allowed")
+	}
+
+	ctes := map[string]bool{}
+	for i, t := range tokens {
+		if t.text == ";" {
+			return errors.New("only one statement is allowed")
+		}
+		if slices.Contains(writeKeywords, keyword(t)) {
+			return fmt.Errorf("'%s' is not allowed in a read-only query", t.text)
+		}
+		// name as ( ... ) defines a common table expression
+		if i+2 < len(tokens) && keyword(tokens[i+1]) == "as" && tokens[i+2].text == "(" && isIdentifier(t) {
+			ctes[strings.ToLower(t.text)] = true
+		}
+	}
+
+	var parens []paren
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.text == "(":
+			parens = append(parens, paren{function: functionName(tokens, i)})
+		case t.text == ")":
+			if len(parens) == 0 {
+				return errors.New("unbalanced parentheses")
+			}
+			closed := parens[len(parens)-1]
+			parens = parens[:len(parens)-1]
+			// More relations may follow a subquery or unnest in the from clause
+			if next := skipAlias(tokens, i+1); closed.relation && next < len(tokens) && tokens[next].text == "," {
+				if i, err = checkRelations(tokens, next+1, database, allowed, ctes, &parens); err != nil {
+					return err
+				}
+			}
+		case keyword(t) == "from" || keyword(t) == "join":
+			if len(parens) > 0 && slices.Contains(fromFunctions, parens[len(parens)-1].function) {
+				continue
+			}
+			// a is distinct from b
+			if keyword(t) == "from" && i > 0 && keyword(tokens[i-1]) == "distinct" {
+				continue
+			}
+			if i, err = checkRelations(tokens, i+1, database, allowed, ctes, &parens); err != nil {
+				return err
+			}
+		}
+	}
+	if len(parens) > 0 {
+		return errors.New("unbalanced parentheses")
+	}
+	return nil
+}
+
+// paren is an open parenthesis, the function it calls and whether it is a relation of a
+// from clause: a subquery or unnest
+type paren struct {
+	function string
+	relation bool
+}
+
+// checkRelations checks the comma-separated relations of a from clause starting at
+// tokens[i]. It stops at a subquery or unnest, whose parenthesis it opens, and returns
+// the index of the last token it read.
+func checkRelations(tokens []token, i int, database string, allowed []string, ctes map[string]bool, parens *[]paren) (int, error) {
+	for i < len(tokens) {
+		t := tokens[i]
+		if t.text == "(" {
+			*parens = append(*parens, paren{relation: true})
+			return i, nil
+		}
+		if !isIdentifier(t) {
+			return i, fmt.Errorf("unexpected '%s' after FROM", t.text)
+		}
+
+		parts := []token{t}
+		for i+2 < len(tokens) && tokens[i+1].text == "." && isIdentifier(tokens[i+2]) {
+			parts = append(parts, tokens[i+2])
+			i += 2
+		}
+		name := strings.ToLower(parts[len(parts)-1].text)
+		switch {
+		case i+1 < len(tokens) && tokens[i+1].text == "(":
+			if len(parts) > 1 || name != "unnest" {
+				return i, fmt.Errorf("table function '%s' is not allowed", name)
+			}
+			*parens = append(*parens, paren{function: name, relation: true})
+			return i + 1, nil
+		case len(parts) > 2, len(parts) == 2 && !strings.EqualFold(parts[0].text, database):
+			return i, fmt.Errorf("only tables of the %s database can be queried", database)
+		case len(parts) == 1 && ctes[name]:
+		case !slices.Contains(allowed, name):
+			return i, fmt.Errorf("table '%s' is not available, use list_models to see the models that can be queried", name)
+		}
+
+		i = skipAlias(tokens, i+1)
+		if i >= len(tokens) || tokens[i].text != "," {
+			return i - 1, nil
+		}
+		i++
+	}
+	return i, nil
+}
+
+// skipAlias returns the index after the alias starting at tokens[i], if any: [as] name
+// and an optional column list
+func skipAlias(tokens []token, i int) int {
+	if i < len(tokens) && keyword(tokens[i]) == "as" {
+		i++
+	}
+	if i >= len(tokens) || !isIdentifier(tokens[i]) || slices.Contains(clauseKeywords, keyword(tokens[i])) {
+		return i
+	}
+	i++
+	if i < len(tokens) && tokens[i].text == "(" {
+		for i < len(tokens) && tokens[i].text != ")" {
+			i++
+		}
+		i++
+	}
+	return i
+}
+
+// functionName returns the function called by the parenthesis at tokens[i], if any
+func functionName(tokens []token, i int) string {
+	if i > 0 && isIdentifier(tokens[i-1]) {
+		return strings.ToLower(tokens[i-1].text)
+	}
+	return ""
+}
+
+// tokenize splits sql into tokens, dropping comments
+func tokenize(sql string) ([]token, error) {
+	var tokens []token
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/') {
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, errors.New("unterminated comment")
+			}
+			i += 2
+		case r == '\'' || r == '"':
+			text, next, ok := quoted(runes, i)
+			if !ok {
+				return nil, fmt.Errorf("unterminated %c quote", r)
+			}
+			tokens = append(tokens, token{text: text, quoted: r == '"', str: r == '\''})
+			i = next
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '$' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{text: string(runes[start:i])})
+		default:
+			tokens = append(tokens, token{text: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// quoted reads the quoted text starting at runes[i], where a doubled quote escapes it
+func quoted(runes []rune, i int) (string, int, bool) {
+	q := runes[i]
+	var text strings.Builder
+	for i++; i < len(runes); i++ {
+		if runes[i] == q {
+			if i+1 < len(runes) && runes[i+1] == q {
+				text.WriteRune(q)
+				i++
+				continue
+			}
+			return text.String(), i + 1, true
+		}
+		text.WriteRune(runes[i])
+	}
+	return "", i, false
+}
+
+// keyword returns the lower-cased word of an unquoted token
+func keyword(t token) string {
+	if t.quoted || t.str {
+		return ""
+	}
+	return strings.ToLower(t.text)
+}
+
+func isIdentifier(t token) bool {
+	if t.str || t.text == "" {
+		return false
+	}
+	r := []rune(t.text)[0]
+	return t.quoted || r == '_' || unicode.IsLetter(r)
+}
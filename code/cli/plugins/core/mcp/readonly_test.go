@@ -0,0 +1,44 @@
+package mcp
+
+import "testing"
+
+func TestCheckReadOnly(t *testing.T) {
+	allowed := []string{"serve_core__cost_service_account_daily", "serve_meta__account_metadata"}
+	tests := []struct {
+		name    string
+		sql     string
+		wantErr bool
+	}{
+		{"select", "select * from serve_core__cost_service_account_daily where billing_period = '2025-05';", false},
+		{"qualified", `select count(*) from "ecos"."serve_meta__account_metadata" m`, false},
+		{"join", "select * from serve_core__cost_service_account_daily d join serve_meta__account_metadata m on d.account_id = m.account_id", false},
+		{"comma join", "select * from serve_core__cost_service_account_daily d, serve_meta__account_metadata as m where d.account_id = m.account_id", false},
+		{"cte", "with top as (select service_name from serve_core__cost_service_account_daily) select * from top", false},
+		{"subquery", "select * from (select account_id from serve_meta__account_metadata) a", false},
+		{"extract", "select extract(year from usage_date), trim(leading '0' from account_id) from serve_core__cost_service_account_daily", false},
+		{"distinct from", "select * from serve_meta__account_metadata where account_name is distinct from 'x'", false},
+		{"unnest", "select * from serve_meta__account_metadata cross join unnest(array[1, 2]) as t(n)", false},
+		{"keywords in literals and comments", "select 'drop table x; delete' as s -- insert\nfrom serve_meta__account_metadata /* update */", false},
+
+		{"other table", "select * from bronze_aws__cur_source", true},
+		{"other database", `select * from "other"."serve_meta__account_metadata"`, true},
+		{"catalog qualified", "select * from awsdatacatalog.ecos.serve_meta__account_metadata", true},
+		{"table after subquery", "select * from (select 1) a, bronze_aws__cur_source", true},
+		{"table after unnest", "select * from unnest(array[1]) as t(n), bronze_aws__cur_source", true},
+		{"nested table", "select * from serve_meta__account_metadata where account_id in (select account_id from information_schema.tables)", true},
+		{"table function", "select * from read_csv('/etc/passwd')", true},
+		{"insert", "insert into serve_meta__account_metadata select * from serve_meta__account_metadata", true},
+		{"cte insert", "with x as (select 1) insert into t select * from x", true},
+		{"two statements", "select 1 from serve_meta__account_metadata; drop table serve_meta__account_metadata", true},
+		{"unload", "unload (select * from serve_meta__account_metadata) to 's3://bucket/'", true},
+		{"unterminated", "select 'x from serve_meta__account_metadata", true},
+		{"empty", " ; ", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckReadOnly(tt.sql, "ecos", allowed); (err != nil) != tt.wantErr {
+				t.Errorf("CheckReadOnly() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Package mcp implements a Model Context Protocol server over stdio, exposing the ecos
+// models as tools to AI assistants.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// ProtocolVersions are the MCP versions the server speaks, latest first
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// maxMessageSize bounds one JSON-RPC message read from the client
+const maxMessageSize = 4 << 20
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a tool the server exposes. Call receives the arguments of a tool call and
+// returns the result, encoded as JSON text for the client; an error is returned to the
+// client as a failed tool call.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]any
+	Call        func(ctx context.Context, arguments json.RawMessage) (any, error)
+}
+
+// Server answers MCP requests read from a stream of newline-delimited JSON-RPC messages.
+// Requests are handled concurrently; a notifications/cancelled message cancels one.
+type Server struct {
+	Name         string
+	Version      string
+	Instructions string
+	Tools        []Tool
+	// Logf, when set, is called for every tool call and protocol error
+	Logf func(format string, args ...any)
+
+	mu      sync.Mutex
+	out     io.Writer
+	pending map[string]context.CancelFunc
+}
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads requests from r and writes the responses to w until r is closed or ctx is
+// cancelled, then waits for the running requests
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.out = w
+	s.pending = map[string]context.CancelFunc{}
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+		for scanner.Scan() {
+			line := slices.Clone(scanner.Bytes())
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			s.cancelAll()
+			return nil
+		case err := <-readErr:
+			if err != nil {
+				s.cancelAll()
+				return fmt.Errorf("failed to read MCP messages: %w", err)
+			}
+			return nil
+		case line := <-lines:
+			if len(line) == 0 {
+				continue
+			}
+			var msg message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				s.write(response{ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "invalid JSON: " + err.Error()}})
+				continue
+			}
+			if msg.ID == nil {
+				s.notify(msg)
+				continue
+			}
+			reqCtx, cancel := context.WithCancel(ctx)
+			s.track(string(msg.ID), cancel)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer s.untrack(string(msg.ID))
+				s.write(s.handle(reqCtx, msg))
+			}()
+		}
+	}
+}
+
+// handle answers one request
+func (s *Server) handle(ctx context.Context, msg message) response {
+	resp := response{ID: msg.ID}
+	if msg.JSONRPC != "2.0" {
+		resp.Error = &rpcError{codeInvalidRequest, "jsonrpc must be 2.0"}
+		return resp
+	}
+
+	var err *rpcError
+	switch msg.Method {
+	case "initialize":
+		resp.Result, err = s.initialize(msg.Params)
+	case "ping":
+		resp.Result = map[string]any{}
+	case "tools/list":
+		resp.Result = s.listTools()
+	case "tools/call":
+		resp.Result, err = s.callTool(ctx, msg.Params)
+	default:
+		err = &rpcError{codeMethodNotFound, "method not found: " + msg.Method}
+	}
+	resp.Error = err
+	return resp
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{codeInvalidParams, "invalid initialize params: " + err.Error()}
+	}
+	// Answer with the client's version when supported, else the latest one
+	version := ProtocolVersions[0]
+	if slices.Contains(ProtocolVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+		"serverInfo":      map[string]any{"name": s.Name, "version": s.Version},
+		"instructions":    s.Instructions,
+	}, nil
+}
+
+func (s *Server) listTools() any {
+	tools := make([]map[string]any, len(s.Tools))
+	for i, t := range s.Tools {
+		tools[i] = map[string]any{"name": t.Name, "description": t.Description, "inputSchema": t.InputSchema}
+	}
+	return map[string]any{"tools": tools}
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{codeInvalidParams, "invalid tools/call params: " + err.Error()}
+	}
+	i := slices.IndexFunc(s.Tools, func(t Tool) bool { return t.Name == p.Name })
+	if i < 0 {
+		return nil, &rpcError{codeInvalidParams, "unknown tool: " + p.Name}
+	}
+	if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+		p.Arguments = json.RawMessage("{}")
+	}
+
+	s.logf("tool %s %s", p.Name, p.Arguments)
+	result, err := s.Tools[i].Call(ctx, p.Arguments)
+	if err != nil {
+		s.logf("tool %s failed: %v", p.Name, err)
+		return toolResult(err.Error(), true), nil
+	}
+	text, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return toolResult("failed to encode the result: "+err.Error(), true), nil
+	}
+	return toolResult(string(text), false), nil
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// notify handles a notification; only cancellations need an action
+func (s *Server) notify(msg message) {
+	if msg.Method != "notifications/cancelled" {
+		return
+	}
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(msg.Params, &p); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.pending[string(p.RequestID)]; ok {
+		cancel()
+	}
+}
+
+func (s *Server) track(id string, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[id] = cancel
+}
+
+func (s *Server) untrack(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.pending[id]; ok {
+		cancel()
+		delete(s.pending, id)
+	}
+}
+
+func (s *Server) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cancel := range s.pending {
+		cancel()
+	}
+}
+
+// write sends one message; a response to a cancelled request is still sent, as the
+// client ignores it
+func (s *Server) write(resp response) {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{codeInvalidRequest, err.Error()}})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		s.logf("failed to write MCP response: %v", err)
+	}
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
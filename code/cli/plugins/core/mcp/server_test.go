@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+)
+
+// fakeEngine answers the savings query of one optimization model and returns the same
+// rows to every other query
+type fakeEngine struct{}
+
+func (f *fakeEngine) Query(_ context.Context, sql string) ([][]string, error) {
+	if strings.Contains(sql, "gold_storage__ebs_summary_monthly") {
+		return [][]string{{"120.5", "3"}}, nil
+	}
+	return nil, context.DeadlineExceeded
+}
+
+func (f *fakeEngine) Execute(_ context.Context, _ string, page func([]query.Column, [][]string) error) (*query.Execution, error) {
+	columns := []query.Column{{Name: "service_name", Type: "varchar"}, {Name: "cost", Type: "double"}}
+	if err := page(columns, [][]string{{"Amazon EC2", "700.5"}, {"Amazon S3", "250"}}); err != nil && err != query.ErrStop {
+		return nil, err
+	}
+	return &query.Execution{ID: "q-1", Columns: columns, DataScannedBytes: 2048}, nil
+}
+
+// call sends the requests to a server and returns its responses by id
+func call(t *testing.T, requests ...string) map[string]map[string]any {
+	t.Helper()
+	engine := &fakeEngine{}
+	backend := &Backend{
+		Engine:   engine,
+		Database: "ecos",
+		Catalog: &catalog.Catalog{Models: []catalog.Model{{
+			Name:        "serve_core__cost_service_account_daily",
+			Description: "Daily cost by service.\n\nDetails.",
+			Columns:     []catalog.Column{{Name: "service_name", DataType: "varchar", Description: "Service name."}},
+		}}},
+		MaxRows: 1,
+		Now:     func() time.Time { return time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC) },
+	}
+	server := &Server{Name: "ecos", Version: "1.2.3", Tools: backend.Tools()}
+
+	var out bytes.Buffer
+	if err := server.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := map[string]map[string]any{}
+	for line := range strings.Lines(out.String()) {
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response %s: %v", line, err)
+		}
+		id, _ := json.Marshal(resp["id"])
+		responses[string(id)] = resp
+	}
+	return responses
+}
+
+// toolText returns the text content of a tools/call response and whether it is an error
+func toolText(t *testing.T, resp map[string]any) (string, bool) {
+	t.Helper()
+	result, ok := resp["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("response without result: %v", resp)
+	}
+	content := result["content"].([]any)[0].(map[string]any)
+	return content["text"].(string), result["isError"].(bool)
+}
+
+func TestServer_Protocol(t *testing.T) {
+	responses := call(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":"p","method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"drop_tables"}}`,
+		`not json`,
+	)
+	if len(responses) != 6 {
+		t.Fatalf("got %d responses, want 6 (none for the notification): %v", len(responses), responses)
+	}
+
+	result := responses["1"]["result"].(map[string]any)
+	if result["protocolVersion"] != "2025-03-26" || result["serverInfo"].(map[string]any)["version"] != "1.2.3" {
+		t.Errorf("initialize result = %v", result)
+	}
+	tools := responses["2"]["result"].(map[string]any)["tools"].([]any)
+	if len(tools) != 4 || tools[0].(map[string]any)["name"] != "list_models" {
+		t.Errorf("tools/list result = %v", tools)
+	}
+	if responses[`"p"`]["result"] == nil {
+		t.Errorf("ping response = %v", responses[`"p"`])
+	}
+	for id, code := range map[string]float64{"3": codeMethodNotFound, "4": codeInvalidParams, "null": codeParseError} {
+		if rpcErr, _ := responses[id]["error"].(map[string]any); rpcErr["code"] != code {
+			t.Errorf("response %s = %v, want error %v", id, responses[id], code)
+		}
+	}
+}
+
+func TestBackend_Tools(t *testing.T) {
+	responses := call(t,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_models","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"describe_model","arguments":{"model":"serve_core__cost_service_account_daily"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"describe_model","arguments":{"model":"bronze_aws__cur_source"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"query_models","arguments":{"sql":"select service_name, sum(total_effective_cost) as cost from serve_core__cost_service_account_daily group by 1"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"query_models","arguments":{"sql":"select * from bronze_aws__cur_source"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"get_optimization_recommendations","arguments":{"account_id":"111111111111"}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"get_optimization_recommendations","arguments":{"period":"May"}}}`,
+	)
+
+	if text, isError := toolText(t, responses["1"]); isError || !strings.Contains(text, `"description": "Daily cost by service."`) {
+		t.Errorf("list_models = %s", text)
+	}
+	if text, isError := toolText(t, responses["2"]); isError || !strings.Contains(text, `"data_type": "varchar"`) || !strings.Contains(text, "Service name.") {
+		t.Errorf("describe_model = %s", text)
+	}
+	if text, isError := toolText(t, responses["3"]); !isError || !strings.Contains(text, "not available") {
+		t.Errorf("describe_model of an unexposed model = %s", text)
+	}
+
+	text, isError := toolText(t, responses["4"])
+	var result struct {
+		Rows             []map[string]any
+		Truncated        bool
+		DataScannedBytes int64 `json:"data_scanned_bytes"`
+	}
+	if err := json.Unmarshal([]byte(text), &result); err != nil || isError {
+		t.Fatalf("query_models = %s", text)
+	}
+	if len(result.Rows) != 1 || result.Rows[0]["cost"] != 700.5 || !result.Truncated || result.DataScannedBytes != 2048 {
+		t.Errorf("query_models result = %+v", result)
+	}
+	if text, isError := toolText(t, responses["5"]); !isError || !strings.Contains(text, "bronze_aws__cur_source") {
+		t.Errorf("query_models of an unexposed table = %s", text)
+	}
+
+	text, isError = toolText(t, responses["6"])
+	if isError || !strings.Contains(text, `"billing_period": "2025-05"`) || !strings.Contains(text, `"savings": 120.5`) || !strings.Contains(text, "gold_compute__lambda_summary_monthly") {
+		t.Errorf("get_optimization_recommendations = %s", text)
+	}
+	if text, isError := toolText(t, responses["7"]); !isError || !strings.Contains(text, "invalid billing period") {
+		t.Errorf("get_optimization_recommendations with an invalid period = %s", text)
+	}
+}
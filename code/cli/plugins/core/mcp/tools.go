@@ -0,0 +1,240 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/report"
+)
+
+// DefaultMaxRows bounds the rows of a query result unless MaxRows is set
+const DefaultMaxRows = 500
+
+// Engine runs the queries of the tools
+type Engine interface {
+	query.Querier
+	query.Executor
+}
+
+// Backend answers the tool calls from the models in Database
+type Backend struct {
+	Engine   Engine
+	Database string
+	// Catalog holds the models the tools expose: the documented serve models the
+	// configured credentials can read
+	Catalog *catalog.Catalog
+	MaxRows int
+	Now     func() time.Time
+}
+
+// ReadableTables returns the tables of database the engine's credentials can see. With
+// Athena, information_schema only lists the tables the caller has access to.
+func ReadableTables(ctx context.Context, q query.Querier, database string) ([]string, error) {
+	rows, err := q.Query(ctx, fmt.Sprintf(
+		"select table_name from information_schema.tables where table_schema = '%s'", strings.ReplaceAll(database, "'", "''")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tables of %s: %w", database, err)
+	}
+	tables := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row) > 0 {
+			tables = append(tables, strings.ToLower(row[0]))
+		}
+	}
+	return tables, nil
+}
+
+// Tools returns the ecos tools
+func (b *Backend) Tools() []Tool {
+	return []Tool{
+		{
+			Name:        "list_models",
+			Description: "List the ecos cost models that can be queried, with a summary of each.",
+			InputSchema: schema(nil),
+			Call:        b.listModels,
+		},
+		{
+			Name:        "describe_model",
+			Description: "Describe an ecos cost model: its description and its columns with their types and meaning.",
+			InputSchema: schema(map[string]any{
+				"model": property("string", "Model name, as returned by list_models"),
+			}, "model"),
+			Call: b.describeModel,
+		},
+		{
+			Name: "query_models",
+			Description: fmt.Sprintf("Run a read-only SQL select against the models returned by list_models, in the %s database. "+
+				"Filter on billing_period (YYYY-MM) to keep scans small. Results are limited to %d rows.", b.Database, b.maxRows()),
+			InputSchema: schema(map[string]any{
+				"sql":   property("string", "A single SELECT statement"),
+				"limit": property("integer", fmt.Sprintf("Maximum number of rows to return (default and maximum %d)", b.maxRows())),
+			}, "sql"),
+			Call: b.queryModels,
+		},
+		{
+			Name:        "get_optimization_recommendations",
+			Description: "Get the potential monthly savings found by the ecos optimization models, largest first, e.g. moving EBS gp2 volumes to gp3.",
+			InputSchema: schema(map[string]any{
+				"period":     property("string", "Billing period YYYY-MM (default: previous month)"),
+				"account_id": property("string", "Restrict the recommendations to one 12-digit account"),
+			}),
+			Call: b.optimizationRecommendations,
+		},
+	}
+}
+
+func (b *Backend) listModels(_ context.Context, _ json.RawMessage) (any, error) {
+	type model struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	models := []model{}
+	for _, m := range b.Catalog.Models {
+		models = append(models, model{Name: m.Name, Description: m.Summary()})
+	}
+	return map[string]any{"database": b.Database, "models": models}, nil
+}
+
+func (b *Backend) describeModel(_ context.Context, arguments json.RawMessage) (any, error) {
+	var args struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	m, ok := b.Catalog.Model(args.Model)
+	if !ok {
+		return nil, fmt.Errorf("model '%s' is not available, use list_models to see the models", args.Model)
+	}
+	return m, nil
+}
+
+func (b *Backend) queryModels(ctx context.Context, arguments json.RawMessage) (any, error) {
+	var args struct {
+		SQL   string `json:"sql"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	allowed := make([]string, len(b.Catalog.Models))
+	for i, m := range b.Catalog.Models {
+		allowed[i] = m.Name
+	}
+	if err := CheckReadOnly(args.SQL, b.Database, allowed); err != nil {
+		return nil, err
+	}
+	limit := b.maxRows()
+	if args.Limit > 0 {
+		limit = min(args.Limit, limit)
+	}
+
+	result := struct {
+		Columns          []query.Column   `json:"columns"`
+		Rows             []map[string]any `json:"rows"`
+		Truncated        bool             `json:"truncated"`
+		DataScannedBytes int64            `json:"data_scanned_bytes"`
+	}{Rows: []map[string]any{}}
+	exec, err := b.Engine.Execute(ctx, args.SQL, func(columns []query.Column, rows [][]string) error {
+		result.Columns = columns
+		for _, row := range rows {
+			if len(result.Rows) == limit {
+				result.Truncated = true
+				return query.ErrStop
+			}
+			values := make(map[string]any, len(columns))
+			for i, c := range columns {
+				if i >= len(row) {
+					break
+				}
+				v, err := c.Value(row[i])
+				if err != nil {
+					v = row[i]
+				}
+				values[c.Name] = v
+			}
+			result.Rows = append(result.Rows, values)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, query.ErrScanLimit) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	result.DataScannedBytes = exec.DataScannedBytes
+	if result.Columns == nil {
+		result.Columns = exec.Columns
+	}
+	return result, nil
+}
+
+func (b *Backend) optimizationRecommendations(ctx context.Context, arguments json.RawMessage) (any, error) {
+	var args struct {
+		Period    string `json:"period"`
+		AccountID string `json:"account_id"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Period == "" {
+		args.Period = b.now().UTC().AddDate(0, -1, 0).Format(config.BillingPeriodLayout)
+	}
+	if _, err := config.ParseBillingPeriod(args.Period); err != nil {
+		return nil, err
+	}
+	if args.AccountID != "" && (len(args.AccountID) != 12 || strings.Trim(args.AccountID, "0123456789") != "") {
+		return nil, fmt.Errorf("invalid account_id '%s', expected a 12-digit account ID", args.AccountID)
+	}
+
+	opportunities, unavailable, err := report.Opportunities(ctx, b.Engine, b.Database, args.Period, args.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if opportunities == nil {
+		opportunities = []report.Opportunity{}
+	}
+	return map[string]any{
+		"billing_period":  args.Period,
+		"recommendations": opportunities,
+		"unavailable":     unavailable,
+	}, nil
+}
+
+func (b *Backend) maxRows() int {
+	if b.MaxRows > 0 {
+		return b.MaxRows
+	}
+	return DefaultMaxRows
+}
+
+func (b *Backend) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+// schema returns the JSON schema of tool arguments
+func schema(properties map[string]any, required ...string) map[string]any {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func property(typ, description string) map[string]any {
+	return map[string]any{"type": typ, "description": description}
+}
@@ -0,0 +1,135 @@
+package modelexport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ManifestFilename lists what was exported in the model's export directory
+const ManifestFilename = "manifest.json"
+
+// Manifest lists the exported partitions of a model and their files. It is saved after
+// every partition, so it also records where an interrupted export resumes.
+type Manifest struct {
+	Model      string      `json:"model"`
+	Database   string      `json:"database"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	Partitions []Partition `json:"partitions"`
+
+	path string
+}
+
+// Partition is one exported billing period of the model in one format
+type Partition struct {
+	// BillingPeriod is empty for a model exported whole
+	BillingPeriod string    `json:"billing_period,omitempty"`
+	Format        Format    `json:"format"`
+	Files         []File    `json:"files"`
+	QueryID       string    `json:"query_id"`
+	Source        string    `json:"source"`
+	ExportedAt    time.Time `json:"exported_at"`
+}
+
+// File is an exported file, relative to the model's export directory
+type File struct {
+	Path   string `json:"path"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestPath returns the manifest location in the model's export directory
+func ManifestPath(dir string) string {
+	return filepath.Join(dir, ManifestFilename)
+}
+
+// NewManifest returns an empty manifest saved to path
+func NewManifest(path, model, database string) *Manifest {
+	return &Manifest{Model: model, Database: database, Partitions: []Partition{}, path: path}
+}
+
+// LoadManifest reads the manifest at path. It returns nil without an error when there
+// is none.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse export manifest %s: %w", path, err)
+	}
+	m.path = path
+	return &m, nil
+}
+
+// IsComplete reports whether the partition was exported in the format and its files
+// still have their exported size
+func (m *Manifest) IsComplete(period string, format Format) bool {
+	i := m.find(period, format)
+	if i < 0 {
+		return false
+	}
+	for _, f := range m.Partitions[i].Files {
+		info, err := os.Stat(filepath.Join(filepath.Dir(m.path), filepath.FromSlash(f.Path)))
+		if err != nil || info.Size() != f.Bytes {
+			return false
+		}
+	}
+	return true
+}
+
+// Record adds or replaces the partition, removes the files of the replaced export that
+// were not written again and saves the manifest
+func (m *Manifest) Record(p Partition) error {
+	if i := m.find(p.BillingPeriod, p.Format); i >= 0 {
+		for _, old := range m.Partitions[i].Files {
+			if !slices.ContainsFunc(p.Files, func(f File) bool { return f.Path == old.Path }) {
+				_ = os.Remove(filepath.Join(filepath.Dir(m.path), filepath.FromSlash(old.Path)))
+			}
+		}
+		m.Partitions = slices.Delete(m.Partitions, i, i+1)
+	}
+	m.Partitions = append(m.Partitions, p)
+	slices.SortFunc(m.Partitions, func(a, b Partition) int {
+		if c := strings.Compare(a.BillingPeriod, b.BillingPeriod); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Format), string(b.Format))
+	})
+	return m.save()
+}
+
+func (m *Manifest) find(period string, format Format) int {
+	return slices.IndexFunc(m.Partitions, func(p Partition) bool {
+		return p.BillingPeriod == period && p.Format == format
+	})
+}
+
+// save writes the manifest through a temporary file, so an interrupted write never
+// leaves a truncated manifest behind
+func (m *Manifest) save() error {
+	m.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write export manifest: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("failed to write export manifest: %w", err)
+	}
+	return nil
+}
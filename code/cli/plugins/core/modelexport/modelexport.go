@@ -0,0 +1,342 @@
+// Package modelexport exports the serve models of 'ecos export <model>' to local
+// Parquet, CSV or Excel files, one partition per billing period, through the Athena
+// results bucket.
+package modelexport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+)
+
+// DefaultPrefix is the prefix of the results bucket UNLOAD writes to
+const DefaultPrefix = "exports"
+
+// Format identifies an export file format
+type Format string
+
+const (
+	// FormatParquet downloads the Parquet files written by UNLOAD
+	FormatParquet Format = "parquet"
+	// FormatCSV downloads the CSV results file of the query
+	FormatCSV Format = "csv"
+	// FormatXLSX converts the CSV results file to an Excel workbook
+	FormatXLSX Format = "xlsx"
+)
+
+// SupportedFormats lists the formats accepted by ParseFormat
+var SupportedFormats = []Format{FormatParquet, FormatCSV, FormatXLSX}
+
+// ParseFormat validates a user-supplied format name
+func ParseFormat(value string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(value)))
+	if !slices.Contains(SupportedFormats, f) {
+		return "", fmt.Errorf("unsupported export format '%s', must be one of: %v", value, SupportedFormats)
+	}
+	return f, nil
+}
+
+// Model is an exportable model
+type Model struct {
+	Name string
+	// Partitioned models have a billing_period column and are exported one billing
+	// period at a time; the others are exported whole
+	Partitioned bool
+}
+
+// Models are the serve models analysts export
+var Models = []Model{
+	{Name: "serve_core__cost_service_account_daily", Partitioned: true},
+	{Name: "serve_meta__account_metadata"},
+}
+
+// FindModel returns the exportable model of that name
+func FindModel(name string) (Model, error) {
+	for _, m := range Models {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	names := make([]string, len(Models))
+	for i, m := range Models {
+		names[i] = m.Name
+	}
+	return Model{}, fmt.Errorf("model '%s' cannot be exported, must be one of: %s", name, strings.Join(names, ", "))
+}
+
+// ObjectAPI is the subset of the S3 client used to download the exported files
+type ObjectAPI interface {
+	s3.ListObjectsV2APIClient
+	GetObject(ctx context.Context, in *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// Exporter exports models from Athena to local files
+type Exporter struct {
+	Executor query.Executor
+	S3       ObjectAPI
+	Database string
+	// Bucket and Prefix locate the UNLOAD output, s3://Bucket/Prefix/<model>/...
+	Bucket string
+	Prefix string
+	// Progress, when set, is called before every step of an export
+	Progress func(msg string)
+}
+
+// Request selects the model, billing periods and format of an export
+type Request struct {
+	Model Model
+	// Periods are the billing periods (YYYY-MM) of a partitioned model
+	Periods []string
+	Format  Format
+	// OutDir is the directory the model's directory is created in
+	OutDir string
+	// Force exports partitions again that the manifest records as complete
+	Force bool
+}
+
+// Result lists the partitions exported and those skipped as already complete
+type Result struct {
+	Manifest *Manifest
+	Exported []string
+	Skipped  []string
+}
+
+// Export exports every partition of the request and records it in the manifest of the
+// model's directory. Partitions the manifest records as complete, with their files
+// unchanged, are skipped, so an interrupted export resumes where it stopped.
+func (e *Exporter) Export(ctx context.Context, req Request) (*Result, error) {
+	dir := filepath.Join(req.OutDir, req.Model.Name)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	manifest, err := LoadManifest(ManifestPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		manifest = NewManifest(ManifestPath(dir), req.Model.Name, e.Database)
+	} else if manifest.Model != req.Model.Name {
+		return nil, fmt.Errorf("%s belongs to an export of %s", manifest.path, manifest.Model)
+	}
+
+	periods := req.Periods
+	if !req.Model.Partitioned {
+		periods = []string{""}
+	}
+
+	result := &Result{Manifest: manifest}
+	for _, period := range periods {
+		name := partitionName(req.Model, period)
+		if !req.Force && manifest.IsComplete(period, req.Format) {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+
+		partition, err := e.exportPartition(ctx, req, dir, period)
+		if err != nil {
+			return result, fmt.Errorf("failed to export %s: %w", name, err)
+		}
+		if err := manifest.Record(*partition); err != nil {
+			return result, err
+		}
+		result.Exported = append(result.Exported, name)
+	}
+	return result, nil
+}
+
+// exportPartition exports one billing period, or the whole model when period is empty
+func (e *Exporter) exportPartition(ctx context.Context, req Request, dir, period string) (*Partition, error) {
+	sql := fmt.Sprintf("select * from %q.%q", e.Database, req.Model.Name)
+	if period != "" {
+		sql += fmt.Sprintf(" where billing_period = '%s'", period)
+	}
+
+	partDir := dir
+	if period != "" {
+		partDir = filepath.Join(dir, "billing_period="+period)
+	}
+	if err := os.MkdirAll(partDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", partDir, err)
+	}
+	base := req.Model.Name
+	if period != "" {
+		base += "_" + period
+	}
+
+	partition := &Partition{BillingPeriod: period, Format: req.Format}
+	switch req.Format {
+	case FormatParquet:
+		// UNLOAD needs an empty location; every run writes to its own
+		location := path.Join(strings.Trim(e.Prefix, "/"), req.Model.Name)
+		if period != "" {
+			location = path.Join(location, "billing_period="+period)
+		}
+		location = path.Join(location, time.Now().UTC().Format("20060102T150405Z")) + "/"
+		e.progress(fmt.Sprintf("Unloading %s to s3://%s/%s", partitionName(req.Model, period), e.Bucket, location))
+		exec, err := e.Executor.Execute(ctx, fmt.Sprintf(
+			"unload (%s) to 's3://%s/%s' with (format = 'PARQUET', compression = 'SNAPPY')", sql, e.Bucket, location),
+			func([]query.Column, [][]string) error { return query.ErrStop })
+		if err != nil {
+			return nil, err
+		}
+		partition.QueryID = exec.ID
+		partition.Source = fmt.Sprintf("s3://%s/%s", e.Bucket, location)
+
+		keys, err := e.list(ctx, e.Bucket, location)
+		if err != nil {
+			return nil, err
+		}
+		e.progress(fmt.Sprintf("Downloading %d files", len(keys)))
+		for i, key := range keys {
+			file, err := e.download(ctx, e.Bucket, key, filepath.Join(partDir, fmt.Sprintf("%s_%05d.parquet", base, i)), copyTo)
+			if err != nil {
+				return nil, err
+			}
+			partition.Files = append(partition.Files, file)
+		}
+
+	case FormatCSV, FormatXLSX:
+		e.progress(fmt.Sprintf("Querying %s", partitionName(req.Model, period)))
+		// The first results page is enough for the column types; the rows are
+		// downloaded from the results file
+		exec, err := e.Executor.Execute(ctx, sql, func([]query.Column, [][]string) error { return query.ErrStop })
+		if err != nil {
+			return nil, err
+		}
+		partition.QueryID = exec.ID
+		partition.Source = exec.OutputLocation
+		bucket, key, err := splitS3URL(exec.OutputLocation)
+		if err != nil {
+			return nil, err
+		}
+
+		write := copyTo
+		if req.Format == FormatXLSX {
+			// Excel limits sheet names to 31 characters
+			sheet := "data"
+			if period != "" {
+				sheet = period
+			}
+			write = func(w io.Writer, r io.Reader) error { return writeXLSX(w, r, sheet, exec.Columns) }
+		}
+		e.progress(fmt.Sprintf("Downloading %s", exec.OutputLocation))
+		file, err := e.download(ctx, bucket, key, filepath.Join(partDir, fmt.Sprintf("%s.%s", base, req.Format)), write)
+		if err != nil {
+			return nil, err
+		}
+		partition.Files = append(partition.Files, file)
+
+	default:
+		return nil, fmt.Errorf("unsupported export format '%s'", req.Format)
+	}
+
+	for i, f := range partition.Files {
+		rel, err := filepath.Rel(dir, f.Path)
+		if err != nil {
+			return nil, err
+		}
+		partition.Files[i].Path = filepath.ToSlash(rel)
+	}
+	partition.ExportedAt = time.Now().UTC()
+	return partition, nil
+}
+
+// list returns the keys of the non-empty objects under prefix, sorted
+func (e *Exporter) list(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(e.S3, &s3.ListObjectsV2Input{Bucket: aws.String(bucket), Prefix: aws.String(prefix)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list s3://%s/%s: %w", bucket, prefix, err)
+		}
+		for _, obj := range page.Contents {
+			if aws.ToInt64(obj.Size) > 0 {
+				keys = append(keys, aws.ToString(obj.Key))
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// download writes the object through write to path. The file is written under a
+// temporary name first, so an interrupted download never leaves a partial file behind.
+func (e *Exporter) download(ctx context.Context, bucket, key, path string, write func(io.Writer, io.Reader) error) (File, error) {
+	out, err := e.S3.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return File{}, fmt.Errorf("failed to download s3://%s/%s: %w", bucket, key, err)
+	}
+	defer out.Body.Close()
+
+	tmp := path + ".part"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+	hash := sha256.New()
+	counter := &countingWriter{}
+	err = write(io.MultiWriter(f, hash, counter), out.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return File{}, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return File{}, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return File{Path: path, Bytes: counter.n, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func (e *Exporter) progress(msg string) {
+	if e.Progress != nil {
+		e.Progress(msg)
+	}
+}
+
+func copyTo(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, r)
+	return err
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// partitionName names the partition in messages and the S3 layout
+func partitionName(m Model, period string) string {
+	if period == "" {
+		return m.Name
+	}
+	return "billing_period=" + period
+}
+
+// splitS3URL splits s3://bucket/key into the bucket and key
+func splitS3URL(url string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(url, "s3://")
+	bucket, key, found := strings.Cut(rest, "/")
+	if !ok || !found || bucket == "" || key == "" {
+		return "", "", errors.New("invalid S3 location '" + url + "'")
+	}
+	return bucket, key, nil
+}
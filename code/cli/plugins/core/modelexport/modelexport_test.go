@@ -0,0 +1,187 @@
+package modelexport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/xuri/excelize/v2"
+)
+
+// fakeExecutor records the queries. UNLOAD queries store objects in the fake bucket,
+// the others a CSV results file.
+type fakeExecutor struct {
+	store   *fakeS3
+	queries []string
+	files   int
+}
+
+func (f *fakeExecutor) Execute(_ context.Context, sql string, page func([]query.Column, [][]string) error) (*query.Execution, error) {
+	f.queries = append(f.queries, sql)
+	columns := []query.Column{{Name: "service_name", Type: "varchar"}, {Name: "total_effective_cost", Type: "double"}}
+	if location, ok := strings.CutPrefix(sql, "unload "); ok {
+		_, location, _ = strings.Cut(location, "to 's3://bucket/")
+		location, _, _ = strings.Cut(location, "'")
+		for i := range f.files {
+			f.store.objects[location+"part-"+string(rune('a'+i))] = "PAR1"
+		}
+		f.store.objects[location+"empty"] = ""
+		return &query.Execution{ID: "unload-1"}, nil
+	}
+	f.store.objects["queries/q-1.csv"] = "\"service_name\",\"total_effective_cost\"\n\"Amazon EC2\",\"700.5\"\n\"Amazon S3\",\"\"\n"
+	_ = page(columns, nil)
+	return &query.Execution{ID: "q-1", Columns: columns, OutputLocation: "s3://bucket/queries/q-1.csv"}, nil
+}
+
+// fakeS3 serves objects of one bucket
+type fakeS3 struct {
+	objects map[string]string
+}
+
+func (f *fakeS3) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	out := &s3.ListObjectsV2Output{}
+	for key, body := range f.objects {
+		if strings.HasPrefix(key, aws.ToString(in.Prefix)) {
+			out.Contents = append(out.Contents, s3Types.Object{Key: aws.String(key), Size: aws.Int64(int64(len(body)))})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeS3) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(f.objects[aws.ToString(in.Key)]))}, nil
+}
+
+func newTestExporter(files int) (*Exporter, *fakeExecutor) {
+	store := &fakeS3{objects: map[string]string{}}
+	executor := &fakeExecutor{store: store, files: files}
+	return &Exporter{Executor: executor, S3: store, Database: "ecos", Bucket: "bucket", Prefix: DefaultPrefix}, executor
+}
+
+func TestExport_Parquet(t *testing.T) {
+	out := t.TempDir()
+	model, _ := FindModel("serve_core__cost_service_account_daily")
+	req := Request{Model: model, Periods: []string{"2025-04", "2025-05"}, Format: FormatParquet, OutDir: out}
+
+	exporter, executor := newTestExporter(2)
+	result, err := exporter.Export(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(result.Exported) != 2 || len(result.Skipped) != 0 {
+		t.Errorf("Export() = %+v", result)
+	}
+	if !strings.Contains(executor.queries[0], `unload (select * from "ecos"."serve_core__cost_service_account_daily" where billing_period = '2025-04') to 's3://bucket/exports/serve_core__cost_service_account_daily/billing_period=2025-04/`) {
+		t.Errorf("query = %s", executor.queries[0])
+	}
+
+	dir := filepath.Join(out, model.Name)
+	for _, name := range []string{"serve_core__cost_service_account_daily_2025-05_00000.parquet", "serve_core__cost_service_account_daily_2025-05_00001.parquet"} {
+		if _, err := os.Stat(filepath.Join(dir, "billing_period=2025-05", name)); err != nil {
+			t.Errorf("missing exported file: %v", err)
+		}
+	}
+
+	manifest, err := LoadManifest(ManifestPath(dir))
+	if err != nil || manifest == nil {
+		t.Fatalf("LoadManifest() = %v, %v", manifest, err)
+	}
+	files := manifest.Partitions[1].Files
+	if len(manifest.Partitions) != 2 || len(files) != 2 || files[0].Path != "billing_period=2025-05/serve_core__cost_service_account_daily_2025-05_00000.parquet" || files[0].Bytes != 4 || files[0].SHA256 == "" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+
+	// A second run resumes: complete partitions are skipped
+	exporter, executor = newTestExporter(1)
+	result, err = exporter.Export(context.Background(), req)
+	if err != nil || len(result.Skipped) != 2 || len(executor.queries) != 0 {
+		t.Errorf("resumed Export() = %+v, %v after %d queries", result, err, len(executor.queries))
+	}
+
+	// Forcing exports again and removes the files no longer exported
+	req.Force = true
+	req.Periods = []string{"2025-05"}
+	if _, err := exporter.Export(context.Background(), req); err != nil {
+		t.Fatalf("forced Export() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "billing_period=2025-05", "serve_core__cost_service_account_daily_2025-05_00001.parquet")); !os.IsNotExist(err) {
+		t.Errorf("stale file not removed: %v", err)
+	}
+
+	// A file changed since the export makes its partition incomplete
+	if err := os.WriteFile(filepath.Join(dir, files[0].Path), []byte("truncated PAR1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ = LoadManifest(ManifestPath(dir))
+	if manifest.IsComplete("2025-05", FormatParquet) || !manifest.IsComplete("2025-04", FormatParquet) || manifest.IsComplete("2025-04", FormatCSV) {
+		t.Error("IsComplete() does not check the exported files and format")
+	}
+}
+
+func TestExport_CSVAndXLSX(t *testing.T) {
+	out := t.TempDir()
+	model, _ := FindModel("serve_meta__account_metadata")
+	exporter, executor := newTestExporter(0)
+
+	for _, format := range []Format{FormatCSV, FormatXLSX} {
+		if _, err := exporter.Export(context.Background(), Request{Model: model, Format: format, OutDir: out}); err != nil {
+			t.Fatalf("Export(%s) error = %v", format, err)
+		}
+	}
+	if executor.queries[0] != `select * from "ecos"."serve_meta__account_metadata"` {
+		t.Errorf("query = %s", executor.queries[0])
+	}
+
+	dir := filepath.Join(out, model.Name)
+	data, err := os.ReadFile(filepath.Join(dir, "serve_meta__account_metadata.csv"))
+	if err != nil || !strings.HasPrefix(string(data), `"service_name"`) {
+		t.Errorf("csv = %q, %v", data, err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "serve_meta__account_metadata.xlsx"))
+	if err != nil {
+		t.Fatalf("xlsx not written: %v", err)
+	}
+	book, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid xlsx: %v", err)
+	}
+	defer book.Close()
+	// Numbers are written without a cell type; text is an inline or shared string
+	name, _ := book.GetCellType("data", "A2")
+	cost, _ := book.GetCellType("data", "B2")
+	if value, _ := book.GetCellValue("data", "B2"); value != "700.5" || cost == name {
+		t.Errorf("cost cell = %s of type %v, want a number", value, cost)
+	}
+	if value, _ := book.GetCellValue("data", "A1"); value != "service_name" {
+		t.Errorf("header = %s", value)
+	}
+
+	manifest, _ := LoadManifest(ManifestPath(dir))
+	if len(manifest.Partitions) != 2 || manifest.Partitions[0].Source != "s3://bucket/queries/q-1.csv" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+}
+
+func TestFindModelAndFormat(t *testing.T) {
+	if _, err := FindModel("gold_core__service_monthly"); err == nil {
+		t.Error("FindModel() expected error for a gold model")
+	}
+	if f, err := ParseFormat("XLSX"); err != nil || f != FormatXLSX {
+		t.Errorf("ParseFormat(XLSX) = %v, %v", f, err)
+	}
+	if _, err := ParseFormat("json"); err == nil {
+		t.Error("ParseFormat() expected error for json")
+	}
+	if _, _, err := splitS3URL("s3://bucket"); err == nil {
+		t.Error("splitS3URL() expected error without a key")
+	}
+}
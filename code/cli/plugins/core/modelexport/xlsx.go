@@ -0,0 +1,67 @@
+package modelexport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/xuri/excelize/v2"
+)
+
+// maxXLSXRows is the row limit of an Excel worksheet, header included
+const maxXLSXRows = 1048576
+
+// writeXLSX converts the CSV results of a query to a workbook with one sheet. Numeric
+// and boolean columns are written as numbers and booleans, the rest as text.
+func writeXLSX(w io.Writer, r io.Reader, sheet string, columns []query.Column) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read query results: %w", err)
+		}
+		if row > maxXLSXRows {
+			return fmt.Errorf("the results exceed the %d rows of an Excel sheet, export them as csv or parquet", maxXLSXRows)
+		}
+
+		values := make([]any, len(record))
+		for i, s := range record {
+			values[i] = s
+			// The first row is the header
+			if row > 1 && i < len(columns) {
+				if v, err := columns[i].Value(s); err == nil {
+					values[i] = v
+				}
+			}
+		}
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, values); err != nil {
+			return err
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
@@ -0,0 +1,174 @@
+package modelpkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReleaseConfigFilename is the release configuration of the datasource packages, at the
+// root of the ecos repository
+const ReleaseConfigFilename = "datasources-release.yml"
+
+// ReleaseConfig is the datasource package release configuration: the assets every
+// package holds and the model paths of each datasource, relative to the dbt project
+type ReleaseConfig struct {
+	Metadata struct {
+		BasePath string `yaml:"base_path"`
+	} `yaml:"metadata"`
+	CommonAssets []string                     `yaml:"common_assets"`
+	Datasources  map[string]DatasourceRelease `yaml:"datasources"`
+	Release      struct {
+		ArchiveFormat         string `yaml:"archive_format"`
+		DistributionDirectory string `yaml:"distribution_directory"`
+	} `yaml:"release_config"`
+
+	// dir is the directory of the configuration file, which base_path is relative to
+	dir string
+}
+
+// DatasourceRelease is the package definition of a datasource
+type DatasourceRelease struct {
+	Name        string   `yaml:"name"`
+	PackageName string   `yaml:"package_name"`
+	Version     string   `yaml:"version"`
+	Description string   `yaml:"description"`
+	ModelPaths  []string `yaml:"model_paths"`
+}
+
+// LoadReleaseConfig reads a datasources-release.yml
+func LoadReleaseConfig(path string) (*ReleaseConfig, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release configuration: %w", err)
+	}
+	var c ReleaseConfig
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	c.dir = filepath.Dir(path)
+	return &c, nil
+}
+
+// BaseDir returns the dbt project directory the package paths are relative to
+func (c *ReleaseConfig) BaseDir() string {
+	return filepath.Join(c.dir, filepath.FromSlash(c.Metadata.BasePath))
+}
+
+// Datasource returns the package definition of a datasource
+func (c *ReleaseConfig) Datasource(name string) (DatasourceRelease, error) {
+	ds, ok := c.Datasources[name]
+	if !ok {
+		names := make([]string, 0, len(c.Datasources))
+		for n := range c.Datasources {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return DatasourceRelease{}, fmt.Errorf("datasource '%s' is not defined in the release configuration, must be one of: %s", name, strings.Join(names, ", "))
+	}
+	return ds, nil
+}
+
+// ArchiveName returns the archive name of a package version from archive_format, e.g.
+// aws-cur-1.2.0.tar.gz
+func (c *ReleaseConfig) ArchiveName(ds DatasourceRelease, version string) string {
+	format := c.Release.ArchiveFormat
+	if format == "" {
+		format = "{package_name}-{version}.tar.gz"
+	}
+	return strings.NewReplacer("{package_name}", ds.PackageName, "{version}", strings.TrimPrefix(version, "v")).Replace(format)
+}
+
+// BuildPackage writes the package archive of a datasource to w: its common assets and
+// model paths, stored relative to the dbt project the way ecos extracts them. Missing
+// paths are skipped, like the release workflow does. It returns the packaged files,
+// slash-separated, with their SHA-256.
+func (c *ReleaseConfig) BuildPackage(w io.Writer, ds DatasourceRelease) (map[string]string, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{}
+
+	for _, rel := range append(slices.Clone(c.CommonAssets), ds.ModelPaths...) {
+		root := filepath.Join(c.BaseDir(), filepath.FromSlash(rel))
+		if _, err := os.Stat(root); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && !d.Type().IsRegular() {
+				return nil // symlinks and special files are not extracted
+			}
+			return addTarEntry(tw, c.BaseDir(), path, files)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to package %s: %w", rel, err)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to package under %s", c.BaseDir())
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write package: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write package: %w", err)
+	}
+	return files, nil
+}
+
+// addTarEntry adds a directory or regular file to the archive, named relative to base
+func addTarEntry(tw *tar.Writer, base, path string, files map[string]string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(rel)
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	if info.IsDir() {
+		header.Name += "/"
+		return tw.WriteHeader(header)
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	files[header.Name] = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// ChecksumFile returns the checksum file of an archive in sha256sum format, the way
+// releases publish it next to the archive
+func ChecksumFile(archive []byte, filename string) []byte {
+	sum := sha256.Sum256(archive)
+	return []byte(hex.EncodeToString(sum[:]) + "  " + filename + "\n")
+}
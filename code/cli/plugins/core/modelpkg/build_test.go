@@ -0,0 +1,102 @@
+package modelpkg
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeReleaseTree writes a release configuration and the dbt project it packages
+func writeReleaseTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		ReleaseConfigFilename: `metadata:
+  base_path: "code/dbt"
+common_assets:
+  - "macros"
+  - "snapshots"
+  - "dbt_project.yml"
+datasources:
+  aws_cur:
+    package_name: "aws-cur"
+    version: "0.1.0"
+    model_paths:
+      - "models/1_bronze/aws/cur"
+release_config:
+  archive_format: "{package_name}-{version}.tar.gz"
+`,
+		"code/dbt/dbt_project.yml":                      "name: ecos\n",
+		"code/dbt/macros/cost.sql":                      "{% macro cost() %}1{% endmacro %}\n",
+		"code/dbt/models/1_bronze/aws/cur/bronze.sql":   "select 1\n",
+		"code/dbt/models/1_bronze/aws/focus/focus.sql":  "select 2\n",
+		"code/dbt/models/1_bronze/aws/cur/_bronze.yml":  "version: 2\n",
+		"code/dbt/target/manifest.json":                 "{}\n",
+		"code/dbt/models/1_bronze/aws/cur/nested/a.sql": "select 3\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(root, ReleaseConfigFilename)
+}
+
+func TestBuildPackage(t *testing.T) {
+	rc, err := LoadReleaseConfig(writeReleaseTree(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := rc.Datasource("aws_cur")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Datasource("gcp_billing"); err == nil {
+		t.Error("Datasource() expected error for an undefined datasource")
+	}
+	if got := rc.ArchiveName(ds, "v1.2.0-acme"); got != "aws-cur-1.2.0-acme.tar.gz" {
+		t.Errorf("ArchiveName() = %s", got)
+	}
+
+	var buf bytes.Buffer
+	files, err := rc.BuildPackage(&buf, ds)
+	if err != nil {
+		t.Fatalf("BuildPackage() error = %v", err)
+	}
+	packaged, err := PackageFiles(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, packaged) {
+		t.Errorf("BuildPackage() files = %v, archive holds %v", files, packaged)
+	}
+	want := []string{"dbt_project.yml", "macros/cost.sql", "models/1_bronze/aws/cur/_bronze.yml", "models/1_bronze/aws/cur/bronze.sql", "models/1_bronze/aws/cur/nested/a.sql"}
+	if len(packaged) != len(want) {
+		t.Errorf("packaged files = %v, want %v", packaged, want)
+	}
+	for _, name := range want {
+		if _, ok := packaged[name]; !ok {
+			t.Errorf("%s not packaged", name)
+		}
+	}
+
+	checksum := ChecksumFile(buf.Bytes(), "aws-cur-1.2.0-acme.tar.gz")
+	if _, err := VerifyChecksum(buf.Bytes(), checksum, "aws-cur-1.2.0-acme.tar.gz"); err != nil {
+		t.Errorf("VerifyChecksum() of ChecksumFile() error = %v", err)
+	}
+
+	empty := DatasourceRelease{ModelPaths: []string{"models/missing"}}
+	rc.CommonAssets = nil
+	if _, err := rc.BuildPackage(&bytes.Buffer{}, empty); err == nil {
+		t.Error("BuildPackage() expected error without files to package")
+	}
+	if _, err := LoadReleaseConfig(filepath.Join(t.TempDir(), ReleaseConfigFilename)); err == nil {
+		t.Error("LoadReleaseConfig() expected error for a missing file")
+	}
+}
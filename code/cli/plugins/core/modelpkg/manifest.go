@@ -0,0 +1,159 @@
+// Package modelpkg tracks the datasource model package installed in a dbt project and
+// upgrades it while preserving local modifications.
+package modelpkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ManifestFilename records the installed package in the dbt project directory
+const ManifestFilename = ".ecos-models.json"
+
+// Manifest lists the files of the installed model package with the checksums they had
+// when they were downloaded, so that local modifications can be told apart from the
+// packaged content
+type Manifest struct {
+	Datasource  string    `json:"datasource"`
+	Version     string    `json:"version"`
+	InstalledAt time.Time `json:"installed_at"`
+	// Digest is the verified SHA-256 of the package archive, and KeyID the minisign key
+	// its signature was verified with
+	Digest string `json:"digest,omitempty"`
+	KeyID  string `json:"key_id,omitempty"`
+	// Files maps the slash-separated path of every packaged file, relative to the dbt
+	// project directory, to its SHA-256
+	Files map[string]string `json:"files"`
+	// Conflicts lists the files an upgrade left with conflict markers
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// GeneratedFiles are the dbt files ecos generates from .ecos.yaml. Packages ship them as
+// defaults, but they are neither recorded in the manifest nor upgraded: they are
+// regenerated from the configuration instead.
+var GeneratedFiles = []string{"dbt_project.yml", "profiles.yml"}
+
+// TrackedFiles returns the packaged files without the generated ones
+func TrackedFiles(files map[string]string) map[string]string {
+	tracked := maps.Clone(files)
+	maps.DeleteFunc(tracked, func(name, _ string) bool { return slices.Contains(GeneratedFiles, name) })
+	return tracked
+}
+
+// ManifestPath returns the manifest location in a dbt project directory
+func ManifestPath(dir string) string {
+	return filepath.Join(dir, ManifestFilename)
+}
+
+// LoadManifest reads the manifest of the dbt project in dir. It returns nil without an
+// error when there is none, e.g. for projects initialized before manifests existed.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(ManifestPath(dir)) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse model manifest %s: %w", ManifestPath(dir), err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	return &m, nil
+}
+
+// Save writes the manifest to the dbt project in dir through a temporary file, so an
+// interrupted write never leaves a truncated manifest behind
+func (m *Manifest) Save(dir string) error {
+	m.InstalledAt = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ManifestPath(dir), append(data, '\n'))
+}
+
+// PackageFiles returns the SHA-256 of every regular file of a tar.gz package, keyed by
+// its cleaned slash-separated path
+func PackageFiles(r io.Reader) (map[string]string, error) {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid gzip archive: %w", err)
+	}
+	defer gzReader.Close()
+
+	files := map[string]string{}
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, tarReader); err != nil { // #nosec G110 -- package size is bounded by the download
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files[path.Clean(header.Name)] = hex.EncodeToString(hash.Sum(nil))
+	}
+	return files, nil
+}
+
+// FileChecksum returns the SHA-256 of a file, or "" when it does not exist
+func FileChecksum(name string) (string, error) {
+	data, err := os.ReadFile(name) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return checksum(data), nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic replaces name with data through a temporary file in the same
+// directory, keeping the mode of the file it replaces
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(name); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package modelpkg
+
+import (
+	"slices"
+	"strings"
+)
+
+// Labels name the three sides of a merge in conflict markers
+type Labels struct {
+	Local string
+	Base  string
+	Next  string
+}
+
+// Merge3 merges the changes from base to local and from base to next, line by line.
+// Regions changed differently on both sides are kept with diff3-style conflict markers;
+// conflicts reports how many there are.
+func Merge3(base, local, next string, labels Labels) (merged string, conflicts int) {
+	b, l, n := splitLines(base), splitLines(local), splitLines(next)
+	toLocal, toNext := matchLines(b, l), matchLines(b, n)
+
+	var out strings.Builder
+	bi, li, ni := 0, 0, 0
+	for {
+		// The next stable line is unchanged on both sides
+		j := bi
+		for j < len(b) && (toLocal[j] < 0 || toNext[j] < 0) {
+			j++
+		}
+		if j == bi && j < len(b) && toLocal[j] == li && toNext[j] == ni {
+			out.WriteString(b[bi])
+			bi, li, ni = bi+1, li+1, ni+1
+			continue
+		}
+
+		le, ne := len(l), len(n)
+		if j < len(b) {
+			le, ne = toLocal[j], toNext[j]
+		}
+		baseChunk, localChunk, nextChunk := b[bi:j], l[li:le], n[ni:ne]
+		switch {
+		case slices.Equal(localChunk, baseChunk), slices.Equal(localChunk, nextChunk):
+			writeLines(&out, nextChunk)
+		case slices.Equal(nextChunk, baseChunk):
+			writeLines(&out, localChunk)
+		default:
+			conflicts++
+			writeConflict(&out, localChunk, baseChunk, nextChunk, labels)
+		}
+		if j == len(b) {
+			return out.String(), conflicts
+		}
+		bi, li, ni = j, le, ne
+	}
+}
+
+// HasConflictMarkers reports whether content still holds unresolved merge conflicts
+func HasConflictMarkers(content string) bool {
+	for line := range strings.Lines(content) {
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+			return true
+		}
+	}
+	return false
+}
+
+func writeConflict(out *strings.Builder, local, base, next []string, labels Labels) {
+	out.WriteString("<<<<<<< " + labels.Local + "\n")
+	writeLines(out, local)
+	terminate(out)
+	out.WriteString("||||||| " + labels.Base + "\n")
+	writeLines(out, base)
+	terminate(out)
+	out.WriteString("=======\n")
+	writeLines(out, next)
+	terminate(out)
+	out.WriteString(">>>>>>> " + labels.Next + "\n")
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// terminate ends the last line of a conflict side, which may lack a newline at the end
+// of a file
+func terminate(out *strings.Builder) {
+	if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		out.WriteString("\n")
+	}
+}
+
+// splitLines splits s into lines, keeping their line endings
+func splitLines(s string) []string {
+	var lines []string
+	for line := range strings.Lines(s) {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// matchLines returns, for every line of a, the index of the line of b it is matched with
+// in a longest common subsequence, or -1
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// The common prefix and suffix match directly, which leaves a small table for the
+	// usual localized edits
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		match[prefix] = prefix
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		match[len(a)-1-suffix] = len(b) - 1 - suffix
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:]
+	lcs := make([][]int32, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(ma) && j < len(mb); {
+		switch {
+		case ma[i] == mb[j]:
+			match[prefix+i] = prefix + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
@@ -0,0 +1,81 @@
+package modelpkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	labels := Labels{Local: "local", Base: "v1.0.0", Next: "v1.1.0"}
+	base := "select\n  a,\n  b,\n  c\nfrom t\n"
+
+	tests := []struct {
+		name          string
+		local, next   string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:  "unchanged locally",
+			local: base,
+			next:  "select\n  a,\n  b,\n  c,\n  d\nfrom t\n",
+			want:  "select\n  a,\n  b,\n  c,\n  d\nfrom t\n",
+		},
+		{
+			name:  "unchanged upstream",
+			local: "-- ours\n" + base,
+			next:  base,
+			want:  "-- ours\n" + base,
+		},
+		{
+			name:  "changes in different regions",
+			local: "-- ours\nselect\n  a,\n  b,\n  c\nfrom t\n",
+			next:  "select\n  a,\n  b,\n  c\nfrom t\nwhere a > 0\n",
+			want:  "-- ours\nselect\n  a,\n  b,\n  c\nfrom t\nwhere a > 0\n",
+		},
+		{
+			name:  "same change on both sides",
+			local: "select\n  a,\n  x,\n  c\nfrom t\n",
+			next:  "select\n  a,\n  x,\n  c\nfrom t\n",
+			want:  "select\n  a,\n  x,\n  c\nfrom t\n",
+		},
+		{
+			name:          "conflicting changes",
+			local:         "select\n  a,\n  local_b,\n  c\nfrom t\n",
+			next:          "select\n  a,\n  next_b,\n  c\nfrom t\n",
+			want:          "select\n  a,\n<<<<<<< local\n  local_b,\n||||||| v1.0.0\n  b,\n=======\n  next_b,\n>>>>>>> v1.1.0\n  c\nfrom t\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflict on a last line without newline",
+			local:         "select\n  a,\n  b,\n  c\nfrom local_t",
+			next:          "select\n  a,\n  b,\n  c\nfrom next_t",
+			want:          "select\n  a,\n  b,\n  c\n<<<<<<< local\nfrom local_t\n||||||| v1.0.0\nfrom t\n=======\nfrom next_t\n>>>>>>> v1.1.0\n",
+			wantConflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge3(base, tt.local, tt.next, labels)
+			if got != tt.want || conflicts != tt.wantConflicts {
+				t.Errorf("Merge3() = %q, %d conflicts, want %q, %d", got, conflicts, tt.want, tt.wantConflicts)
+			}
+			if HasConflictMarkers(got) != (conflicts > 0) {
+				t.Errorf("HasConflictMarkers() = %v", HasConflictMarkers(got))
+			}
+		})
+	}
+}
+
+func TestMatchLines(t *testing.T) {
+	a := strings.Split("a b c d e", " ")
+	b := strings.Split("a x c e f", " ")
+	got := matchLines(a, b)
+	want := []int{0, -1, 2, -1, 3}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("matchLines() = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build release
+
+package modelpkg
+
+import "testing"
+
+// TestReleasePublicKey fails a release build without the release key, which would
+// refuse every signed package. The release workflow runs it with the release ldflags.
+func TestReleasePublicKey(t *testing.T) {
+	if ReleasePublicKey == "" {
+		t.Fatal("ReleasePublicKey is empty, set it with -ldflags \"-X ...modelpkg.ReleasePublicKey=<key>\"")
+	}
+	if _, err := ParsePublicKey(ReleasePublicKey); err != nil {
+		t.Fatalf("ReleasePublicKey: %v", err)
+	}
+}
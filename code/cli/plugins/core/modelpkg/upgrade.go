@@ -0,0 +1,227 @@
+package modelpkg
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Package is an extracted model package and its manifest
+type Package struct {
+	Dir string
+	*Manifest
+}
+
+// LoadPackage reads the package extracted in dir
+func LoadPackage(dir string) (*Package, error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("no model manifest in %s", dir)
+	}
+	return &Package{Dir: dir, Manifest: m}, nil
+}
+
+func (p *Package) read(name string) (string, error) {
+	if _, ok := p.Files[name]; !ok {
+		return "", nil
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir, filepath.FromSlash(name))) // #nosec G304
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s of version %s: %w", name, p.Version, err)
+	}
+	return string(data), nil
+}
+
+// Action is what an upgrade does to a file of the project
+type Action string
+
+const (
+	ActionAdd      Action = "added"    // new in the package
+	ActionUpdate   Action = "updated"  // unmodified locally, replaced with the new version
+	ActionMerge    Action = "merged"   // modified locally and upstream, merged cleanly
+	ActionConflict Action = "conflict" // modified locally and upstream, left with conflict markers
+	ActionKeep     Action = "kept"     // modified locally, unchanged upstream or removed from the package
+	ActionRemove   Action = "removed"  // unmodified locally, removed from the package
+	ActionSkip     Action = "skipped"  // deleted locally, changed upstream
+)
+
+// Change is the action an upgrade takes on a file
+type Change struct {
+	Path      string
+	Action    Action
+	Conflicts int
+}
+
+// Plan is an upgrade of the dbt project in a directory from one package version to
+// another. Nothing is written until it is applied.
+type Plan struct {
+	Changes  []Change
+	Manifest *Manifest
+
+	dir      string
+	contents map[string]string
+}
+
+// PlanUpgrade compares every packaged file of the project with the installed and the
+// next version. Files are modified locally when their checksum differs from the one
+// recorded in installed, or from base when the project has no manifest; base is the
+// installed version of the package, the common ancestor of the merges. Generated files
+// are left out, also when recorded by older manifests.
+func PlanUpgrade(dir string, installed *Manifest, base, next *Package) (*Plan, error) {
+	recorded := base.Files
+	if installed != nil {
+		if installed.Version != base.Version {
+			return nil, fmt.Errorf("the project has version %s installed, not %s", installed.Version, base.Version)
+		}
+		recorded = installed.Files
+		var unresolved []string
+		for _, name := range installed.Conflicts {
+			if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err == nil && HasConflictMarkers(string(data)) { // #nosec G304
+				unresolved = append(unresolved, name)
+			}
+		}
+		if len(unresolved) > 0 {
+			return nil, fmt.Errorf("resolve the conflicts left by the previous upgrade first: %s", strings.Join(unresolved, ", "))
+		}
+	}
+	recorded = TrackedFiles(recorded)
+	nextFiles := TrackedFiles(next.Files)
+
+	plan := &Plan{
+		Manifest: &Manifest{
+			Datasource: next.Datasource,
+			Version:    next.Version,
+			Digest:     next.Digest,
+			KeyID:      next.KeyID,
+			Files:      nextFiles,
+		},
+		dir:      dir,
+		contents: map[string]string{},
+	}
+	labels := Labels{Local: "local", Base: base.Version, Next: next.Version}
+	names := slices.Sorted(maps.Keys(recorded))
+	for name := range nextFiles {
+		if _, ok := recorded[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if err := plan.add(name, recorded[name], base, next, labels); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// add plans the upgrade of one file whose checksum at installation was recordedSum
+func (p *Plan) add(name, recordedSum string, base, next *Package, labels Labels) error {
+	localSum, err := FileChecksum(filepath.Join(p.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	nextSum := next.Files[name]
+	modified := localSum != recordedSum
+
+	switch {
+	case localSum == nextSum:
+		// Already the new content, or gone on both sides
+	case nextSum == "":
+		if modified {
+			p.Changes = append(p.Changes, Change{Path: name, Action: ActionKeep})
+		} else {
+			p.Changes = append(p.Changes, Change{Path: name, Action: ActionRemove})
+		}
+	case localSum == "":
+		if recordedSum == "" {
+			return p.write(name, ActionAdd, next)
+		}
+		if nextSum != recordedSum {
+			p.Changes = append(p.Changes, Change{Path: name, Action: ActionSkip})
+		}
+	case !modified:
+		return p.write(name, ActionUpdate, next)
+	case nextSum == base.Files[name]:
+		p.Changes = append(p.Changes, Change{Path: name, Action: ActionKeep})
+	default:
+		return p.merge(name, base, next, labels)
+	}
+	return nil
+}
+
+func (p *Plan) write(name string, action Action, next *Package) error {
+	content, err := next.read(name)
+	if err != nil {
+		return err
+	}
+	p.contents[name] = content
+	p.Changes = append(p.Changes, Change{Path: name, Action: action})
+	return nil
+}
+
+func (p *Plan) merge(name string, base, next *Package, labels Labels) error {
+	local, err := os.ReadFile(filepath.Join(p.dir, filepath.FromSlash(name))) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	baseContent, err := base.read(name)
+	if err != nil {
+		return err
+	}
+	nextContent, err := next.read(name)
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts := Merge3(baseContent, string(local), nextContent, labels)
+	p.contents[name] = merged
+	change := Change{Path: name, Action: ActionMerge, Conflicts: conflicts}
+	if conflicts > 0 {
+		change.Action = ActionConflict
+		p.Manifest.Conflicts = append(p.Manifest.Conflicts, name)
+	}
+	p.Changes = append(p.Changes, change)
+	return nil
+}
+
+// Count returns the number of changes with the action
+func (p *Plan) Count(action Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply writes the planned files, removes the files dropped from the package and saves
+// the manifest of the new version. Every file is replaced atomically.
+func (p *Plan) Apply() error {
+	var errs []error
+	for _, c := range p.Changes {
+		target := filepath.Join(p.dir, filepath.FromSlash(c.Path))
+		switch c.Action {
+		case ActionAdd, ActionUpdate, ActionMerge, ActionConflict:
+			if err := writeFileAtomic(target, []byte(p.contents[c.Path])); err != nil {
+				errs = append(errs, err)
+			}
+		case ActionRemove:
+			if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %w", c.Path, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return p.Manifest.Save(p.dir)
+}
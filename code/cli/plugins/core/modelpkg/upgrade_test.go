@@ -0,0 +1,165 @@
+package modelpkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePackage writes files to dir with their manifest
+func writePackage(t *testing.T, dir, version string, files map[string]string) *Package {
+	t.Helper()
+	m := &Manifest{Datasource: "aws_cur", Version: version, Files: map[string]string{}}
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+		m.Files[name] = checksum([]byte(content))
+	}
+	if err := m.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	return &Package{Dir: dir, Manifest: m}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestPlanUpgrade(t *testing.T) {
+	baseFiles := map[string]string{
+		"dbt_project.yml":             "name: ecos\n",
+		"models/unchanged.sql":        "select 1\n",
+		"models/updated.sql":          "select 1\n",
+		"models/kept.sql":             "select 1\n",
+		"models/merged.sql":           "select\n  a,\n  b\nfrom t\n",
+		"models/conflict.sql":         "select a from t\n",
+		"models/dropped.sql":          "select 1\n",
+		"models/dropped_modified.sql": "select 1\n",
+		"models/deleted.sql":          "select 1\n",
+	}
+	nextFiles := map[string]string{
+		"dbt_project.yml":      "name: ecos\nversion: 1.1.0\n",
+		"models/unchanged.sql": "select 1\n",
+		"models/updated.sql":   "select 2\n",
+		"models/kept.sql":      "select 1\n",
+		"models/merged.sql":    "select\n  a,\n  b\nfrom t\nwhere a > 0\n",
+		"models/conflict.sql":  "select b from t\n",
+		"models/deleted.sql":   "select 2\n",
+		"models/added.sql":     "select 3\n",
+	}
+	base := writePackage(t, t.TempDir(), "v1.0.0", baseFiles)
+	next := writePackage(t, t.TempDir(), "v1.1.0", nextFiles)
+
+	dir := t.TempDir()
+	installed := writePackage(t, dir, "v1.0.0", baseFiles).Manifest
+	// Generated by ecos init from .ecos.yaml, recorded by older manifests
+	writeFile(t, dir, "dbt_project.yml", "name: demo\n")
+	writeFile(t, dir, "models/kept.sql", "select 1 -- ours\n")
+	writeFile(t, dir, "models/merged.sql", "-- ours\nselect\n  a,\n  b\nfrom t\n")
+	writeFile(t, dir, "models/conflict.sql", "select c from t\n")
+	writeFile(t, dir, "models/dropped_modified.sql", "select 2\n")
+	if err := os.Remove(filepath.Join(dir, "models", "deleted.sql")); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanUpgrade(dir, installed, base, next)
+	if err != nil {
+		t.Fatalf("PlanUpgrade() error = %v", err)
+	}
+	want := map[string]Action{
+		"models/added.sql":            ActionAdd,
+		"models/updated.sql":          ActionUpdate,
+		"models/kept.sql":             ActionKeep,
+		"models/merged.sql":           ActionMerge,
+		"models/conflict.sql":         ActionConflict,
+		"models/dropped.sql":          ActionRemove,
+		"models/dropped_modified.sql": ActionKeep,
+		"models/deleted.sql":          ActionSkip,
+	}
+	if len(plan.Changes) != len(want) {
+		t.Errorf("PlanUpgrade() changes = %+v", plan.Changes)
+	}
+	for _, c := range plan.Changes {
+		if want[c.Path] != c.Action {
+			t.Errorf("%s: action %s, want %s", c.Path, c.Action, want[c.Path])
+		}
+	}
+	if readFile(t, dir, "models/updated.sql") != "select 1\n" {
+		t.Error("PlanUpgrade() wrote files before Apply()")
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	for name, content := range map[string]string{
+		"models/added.sql":            "select 3\n",
+		"models/updated.sql":          "select 2\n",
+		"models/kept.sql":             "select 1 -- ours\n",
+		"models/merged.sql":           "-- ours\nselect\n  a,\n  b\nfrom t\nwhere a > 0\n",
+		"models/dropped.sql":          "",
+		"models/dropped_modified.sql": "select 2\n",
+		"models/deleted.sql":          "",
+	} {
+		if got := readFile(t, dir, name); got != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+	if got := readFile(t, dir, "models/conflict.sql"); !strings.Contains(got, "<<<<<<< local\nselect c from t\n||||||| v1.0.0") {
+		t.Errorf("conflict.sql = %q", got)
+	}
+
+	manifest, err := LoadManifest(dir)
+	if err != nil || manifest.Version != "v1.1.0" || len(manifest.Files) != len(nextFiles)-1 {
+		t.Fatalf("LoadManifest() = %+v, %v", manifest, err)
+	}
+	if _, ok := manifest.Files["dbt_project.yml"]; ok || readFile(t, dir, "dbt_project.yml") != "name: demo\n" {
+		t.Error("upgrade tracked or merged the generated dbt_project.yml")
+	}
+	if len(manifest.Conflicts) != 1 || manifest.Conflicts[0] != "models/conflict.sql" {
+		t.Errorf("manifest conflicts = %v", manifest.Conflicts)
+	}
+
+	// The next upgrade waits for the conflict to be resolved
+	if _, err := PlanUpgrade(dir, manifest, next, next); err == nil || !strings.Contains(err.Error(), "models/conflict.sql") {
+		t.Errorf("PlanUpgrade() with unresolved conflicts error = %v", err)
+	}
+	writeFile(t, dir, "models/conflict.sql", "select c from t\n")
+	if _, err := PlanUpgrade(dir, manifest, next, next); err != nil {
+		t.Errorf("PlanUpgrade() after resolving error = %v", err)
+	}
+}
+
+func TestPlanUpgrade_WithoutManifest(t *testing.T) {
+	base := writePackage(t, t.TempDir(), "v1.0.0", map[string]string{"models/a.sql": "select 1\n"})
+	next := writePackage(t, t.TempDir(), "v1.1.0", map[string]string{"models/a.sql": "select 2\n"})
+	dir := t.TempDir()
+	writeFile(t, dir, "models/a.sql", "select 1\n")
+
+	plan, err := PlanUpgrade(dir, nil, base, next)
+	if err != nil {
+		t.Fatalf("PlanUpgrade() error = %v", err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != ActionUpdate {
+		t.Errorf("PlanUpgrade() changes = %+v", plan.Changes)
+	}
+
+	if _, err := PlanUpgrade(dir, next.Manifest, base, next); err == nil {
+		t.Error("PlanUpgrade() expected error when the base is not the installed version")
+	}
+}
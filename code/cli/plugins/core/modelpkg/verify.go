@@ -0,0 +1,214 @@
+package modelpkg
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ChecksumSuffix and SignatureSuffix name the release assets published next to a
+// package archive: its SHA-256 in sha256sum format and its minisign signature
+const (
+	ChecksumSuffix  = ".sha256"
+	SignatureSuffix = ".minisig"
+)
+
+// PublicKeyEnv lists public keys trusted in addition to the release key, e.g. the keys
+// forks sign their packages with, separated by commas or newlines
+const PublicKeyEnv = "ECOS_MODELS_PUBLIC_KEY"
+
+// ReleasePublicKey is the minisign public key the datasource releases are signed with.
+// Release builds set it with -ldflags "-X ...modelpkg.ReleasePublicKey=<key>"; the release
+// workflow runs TestReleasePublicKey (build tag release) to refuse a build without it.
+var ReleasePublicKey = ""
+
+// ErrVerification reports a package whose checksum or signature does not verify
+var ErrVerification = errors.New("model package verification failed")
+
+var errUntrustedKey = fmt.Errorf("%w: signed with an untrusted key", ErrVerification)
+
+// PublicKey is a minisign Ed25519 public key
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// TrustedPublicKeys returns the keys package signatures are verified with: the key built
+// into ecos and the keys in the ECOS_MODELS_PUBLIC_KEY environment variable
+func TrustedPublicKeys() ([]*PublicKey, error) {
+	var keys []*PublicKey
+	if strings.TrimSpace(ReleasePublicKey) != "" {
+		key, err := ParsePublicKey(ReleasePublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("release key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	extra, err := ParsePublicKeys(os.Getenv(PublicKeyEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", PublicKeyEnv, err)
+	}
+	keys = append(keys, extra...)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: this build of ecos has no trusted release key, set %s to the minisign public key the packages are signed with", ErrVerification, PublicKeyEnv)
+	}
+	return keys, nil
+}
+
+// ParsePublicKey parses a minisign public key, either the base64 key line or the
+// content of a .pub file
+func ParsePublicKey(s string) (*PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(lastLine(s))
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != "Ed" {
+		return nil, errors.New("invalid minisign public key")
+	}
+	k := &PublicKey{Key: ed25519.PublicKey(data[10:])}
+	copy(k.ID[:], data[2:10])
+	return k, nil
+}
+
+// ParsePublicKeys parses minisign public keys separated by commas or newlines. The
+// comment lines of .pub files are ignored.
+func ParsePublicKeys(s string) ([]*PublicKey, error) {
+	var keys []*PublicKey
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, "untrusted comment:") {
+			continue
+		}
+		key, err := ParsePublicKey(field)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// KeyID returns the key id minisign prints, in hexadecimal
+func (k *PublicKey) KeyID() string {
+	id := k.ID
+	// minisign stores the id little-endian and prints it big-endian
+	for i, j := 0, len(id)-1; i < j; i, j = i+1, j-1 {
+		id[i], id[j] = id[j], id[i]
+	}
+	return strings.ToUpper(hex.EncodeToString(id[:]))
+}
+
+// Verify checks a minisign signature of data, both the signature of the content and the
+// global signature covering the trusted comment
+func (k *PublicKey) Verify(data, signature []byte) error {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(signature), "\r\n", "\n")), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("%w: malformed minisign signature", ErrVerification)
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed minisign signature", ErrVerification)
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed minisign signature", ErrVerification)
+	}
+	if !bytes.Equal(sig[2:10], k.ID[:]) {
+		return errUntrustedKey
+	}
+
+	message := data
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		// Prehashed signatures, the minisign default
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	default:
+		return fmt.Errorf("%w: unsupported signature algorithm", ErrVerification)
+	}
+	if !ed25519.Verify(k.Key, message, sig[10:]) {
+		return fmt.Errorf("%w: invalid signature", ErrVerification)
+	}
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	signed := append(bytes.Clone(sig[10:]), trustedComment...)
+	if !ed25519.Verify(k.Key, signed, globalSig) {
+		return fmt.Errorf("%w: invalid signature of the trusted comment", ErrVerification)
+	}
+	return nil
+}
+
+// VerifyChecksum checks data against a checksum file in sha256sum format listing the
+// archive filename, and returns the verified SHA-256 digest
+func VerifyChecksum(data, checksumFile []byte, filename string) (string, error) {
+	var expected string
+	for line := range strings.Lines(string(checksumFile)) {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == filename {
+			expected = strings.ToLower(fields[0])
+			break
+		}
+	}
+	if expected == "" {
+		return "", fmt.Errorf("%w: no checksum for %s", ErrVerification, filename)
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	if digest != expected {
+		return "", fmt.Errorf("%w: checksum mismatch for %s: expected %s, got %s", ErrVerification, filename, expected, digest)
+	}
+	return digest, nil
+}
+
+// VerifyPackage checks the checksum and the signature of a package archive before it is
+// extracted, and returns its SHA-256 digest and the trusted key it is signed with
+func VerifyPackage(data, checksumFile, signature []byte, filename string, keys []*PublicKey) (string, *PublicKey, error) {
+	digest, err := VerifyChecksum(data, checksumFile, filename)
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := VerifySignature(data, signature, keys)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return digest, key, nil
+}
+
+// VerifySignature checks a minisign signature of data against the trusted keys, and
+// returns the key it is signed with
+func VerifySignature(data, signature []byte, keys []*PublicKey) (*PublicKey, error) {
+	verr := errUntrustedKey
+	for _, key := range keys {
+		err := key.Verify(data, signature)
+		if err == nil {
+			return key, nil
+		}
+		// Report why a key with the signature's id did not verify, rather than that
+		// the other keys are not the signing key
+		if !errors.Is(err, errUntrustedKey) {
+			verr = err
+		}
+	}
+	return nil, verr
+}
+
+// VerifyUnsigned checks the checksum of a legacy unsigned package archive when one is
+// published, and returns its SHA-256 digest
+func VerifyUnsigned(data, checksumFile []byte, filename string) (string, error) {
+	if checksumFile != nil {
+		return VerifyChecksum(data, checksumFile, filename)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
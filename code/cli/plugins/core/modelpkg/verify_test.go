@@ -0,0 +1,163 @@
+package modelpkg
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testKey is a minisign key pair for signing test packages
+type testKey struct {
+	id      [8]byte
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKey{id: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, public: public, private: private}
+}
+
+// publicKey returns the key in the minisign .pub format
+func (k *testKey) publicKey() string {
+	data := append([]byte("Ed"), k.id[:]...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(append(data, k.public...)) + "\n"
+}
+
+// sign returns a minisign signature of data, prehashed like minisign signs by default
+func (k *testKey) sign(data []byte, prehashed bool, trustedComment string) []byte {
+	algorithm, message := "Ed", data
+	if prehashed {
+		hash := blake2b.Sum512(data)
+		algorithm, message = "ED", hash[:]
+	}
+	sig := ed25519.Sign(k.private, message)
+	globalSig := ed25519.Sign(k.private, append(append([]byte{}, sig...), trustedComment...))
+	line := append(append([]byte(algorithm), k.id[:]...), sig...)
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(line) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n")
+}
+
+func TestPublicKey_Verify(t *testing.T) {
+	signer := newTestKey(t)
+	key, err := ParsePublicKey(signer.publicKey())
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+	if key.KeyID() != "0807060504030201" {
+		t.Errorf("KeyID() = %s", key.KeyID())
+	}
+	data := []byte("package content")
+
+	for _, prehashed := range []bool{true, false} {
+		if err := key.Verify(data, signer.sign(data, prehashed, "file:aws-cur-1.0.0.tar.gz")); err != nil {
+			t.Errorf("Verify(prehashed=%v) error = %v", prehashed, err)
+		}
+	}
+
+	other := newTestKey(t)
+	tampered := strings.Replace(string(signer.sign(data, true, "release")), "trusted comment: release", "trusted comment: forged", 1)
+	tests := []struct {
+		name      string
+		data      []byte
+		signature []byte
+	}{
+		{"tampered content", []byte("package content!"), signer.sign(data, true, "release")},
+		{"other key with the same id", data, other.sign(data, true, "release")},
+		{"tampered trusted comment", data, []byte(tampered)},
+		{"malformed", data, []byte("not a signature")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := key.Verify(tt.data, tt.signature); !errors.Is(err, ErrVerification) {
+				t.Errorf("Verify() error = %v, want ErrVerification", err)
+			}
+		})
+	}
+
+	other.id = [8]byte{9}
+	if err := key.Verify(data, other.sign(data, true, "release")); err == nil || !strings.Contains(err.Error(), "untrusted key") {
+		t.Errorf("Verify() with another key id error = %v", err)
+	}
+	if _, err := ParsePublicKey("RWQ"); err == nil {
+		t.Error("ParsePublicKey() expected error for an invalid key")
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("package content")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	got, err := VerifyChecksum(data, []byte(digest+"  aws-cur-1.0.0.tar.gz\n"), "aws-cur-1.0.0.tar.gz")
+	if err != nil || got != digest {
+		t.Errorf("VerifyChecksum() = %q, %v", got, err)
+	}
+	// Binary mode entries and upper-case digests are accepted
+	if _, err := VerifyChecksum(data, []byte(strings.ToUpper(digest)+" *aws-cur-1.0.0.tar.gz\n"), "aws-cur-1.0.0.tar.gz"); err != nil {
+		t.Errorf("VerifyChecksum() binary mode error = %v", err)
+	}
+	if _, err := VerifyChecksum([]byte("tampered"), []byte(digest+"  aws-cur-1.0.0.tar.gz\n"), "aws-cur-1.0.0.tar.gz"); !errors.Is(err, ErrVerification) {
+		t.Errorf("VerifyChecksum() mismatch error = %v", err)
+	}
+	if _, err := VerifyChecksum(data, []byte(digest+"  other.tar.gz\n"), "aws-cur-1.0.0.tar.gz"); !errors.Is(err, ErrVerification) {
+		t.Errorf("VerifyChecksum() missing entry error = %v", err)
+	}
+}
+
+func TestTrustedPublicKeys(t *testing.T) {
+	releaseKey := ReleasePublicKey
+	t.Cleanup(func() { ReleasePublicKey = releaseKey })
+
+	ReleasePublicKey = ""
+	t.Setenv(PublicKeyEnv, "")
+	if _, err := TrustedPublicKeys(); !errors.Is(err, ErrVerification) {
+		t.Errorf("TrustedPublicKeys() without a key error = %v", err)
+	}
+
+	release, fork, other := newTestKey(t), newTestKey(t), newTestKey(t)
+	fork.id = [8]byte{9, 9}
+	other.id = [8]byte{7, 7}
+	ReleasePublicKey = release.publicKey()
+	t.Setenv(PublicKeyEnv, fork.publicKey()+","+lastLine(other.publicKey()))
+	keys, err := TrustedPublicKeys()
+	if err != nil || len(keys) != 3 || !keys[0].Key.Equal(release.public) || !keys[1].Key.Equal(fork.public) {
+		t.Fatalf("TrustedPublicKeys() = %v, %v", keys, err)
+	}
+
+	// Official and fork packages verify side by side
+	data := []byte("package content")
+	sum := sha256.Sum256(data)
+	checksum := []byte(hex.EncodeToString(sum[:]) + "  aws-cur-1.0.0.tar.gz\n")
+	for _, signer := range []*testKey{release, fork} {
+		_, key, err := VerifyPackage(data, checksum, signer.sign(data, true, "release"), "aws-cur-1.0.0.tar.gz", keys)
+		if err != nil || !key.Key.Equal(signer.public) {
+			t.Errorf("VerifyPackage() key = %v, error = %v", key, err)
+		}
+	}
+	unknown := newTestKey(t)
+	unknown.id = [8]byte{3}
+	if _, _, err := VerifyPackage(data, checksum, unknown.sign(data, true, "release"), "aws-cur-1.0.0.tar.gz", keys); err == nil || !strings.Contains(err.Error(), "untrusted key") {
+		t.Errorf("VerifyPackage() with an untrusted key error = %v", err)
+	}
+	if _, _, err := VerifyPackage([]byte("tampered"), checksum, release.sign(data, true, "release"), "aws-cur-1.0.0.tar.gz", keys); !errors.Is(err, ErrVerification) {
+		t.Errorf("VerifyPackage() tampered error = %v", err)
+	}
+
+	t.Setenv(PublicKeyEnv, "not a key")
+	if _, err := TrustedPublicKeys(); err == nil {
+		t.Error("TrustedPublicKeys() expected error for an invalid key")
+	}
+}
@@ -0,0 +1,349 @@
+// Package overlay merges a user-owned directory of dbt models, macros and seeds into the
+// packaged dbt project before dbt runs. The packaged files are never modified: overlay
+// files are copied to generated ecos_custom directories, and packaged files they shadow
+// are hidden from dbt through .dbtignore.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DirName is the generated directory, in the models, macros and seeds directories of the
+// dbt project, holding the overlay files
+const DirName = "ecos_custom"
+
+// packagedDir holds, under macros/ecos_custom, the packaged macro files rewritten without
+// their shadowed macros
+const packagedDir = "_packaged"
+
+// Markers of the generated section of .dbtignore
+const (
+	ignoreBegin = "# BEGIN ecos custom overlay (generated by ecos transform, do not edit)"
+	ignoreEnd   = "# END ecos custom overlay"
+)
+
+// Kind is the kind of dbt resource an override replaces
+type Kind string
+
+const (
+	KindModel Kind = "model"
+	KindMacro Kind = "macro"
+	KindSeed  Kind = "seed"
+)
+
+// Dirs are the directories of an overlay, named like the dbt project's
+var Dirs = []string{"models", "macros", "seeds"}
+
+var (
+	macroStart = regexp.MustCompile(`\{%-?\s*macro\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	macroEnd   = regexp.MustCompile(`\{%-?\s*endmacro\s*-?%\}`)
+)
+
+// Override is a packaged resource shadowed by an overlay resource of the same name
+type Override struct {
+	Kind Kind
+	Name string
+	// Custom is the overlay file, relative to the overlay directory
+	Custom string
+	// Packaged is the shadowed file, relative to the dbt project directory
+	Packaged string
+}
+
+// Overlay is the merge of an overlay directory into a dbt project
+type Overlay struct {
+	DBTDir    string
+	CustomDir string
+	// Files are the overlay files, relative to CustomDir
+	Files     []string
+	Overrides []Override
+
+	// ignored are the packaged files hidden from dbt, relative to DBTDir
+	ignored []string
+	// rewritten maps the packaged macro files that define shadowed macros, relative to the
+	// macros directory, to their content without them
+	rewritten map[string]string
+}
+
+// Load reads the overlay in customDir and finds the packaged models, seeds and macros of
+// the dbt project in dbtDir it shadows. Nothing is written.
+func Load(dbtDir, customDir string) (*Overlay, error) {
+	info, err := os.Stat(customDir)
+	if err != nil {
+		return nil, fmt.Errorf("custom overlay directory not found: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("custom overlay %s is not a directory", customDir)
+	}
+
+	o := &Overlay{DBTDir: dbtDir, CustomDir: customDir, rewritten: map[string]string{}}
+	for _, dir := range Dirs {
+		files, err := listFiles(filepath.Join(customDir, dir), "")
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			o.Files = append(o.Files, path.Join(dir, f))
+		}
+	}
+
+	if err := o.shadowFiles("models", KindModel, ".sql", ".py"); err != nil {
+		return nil, err
+	}
+	if err := o.shadowFiles("seeds", KindSeed, ".csv"); err != nil {
+		return nil, err
+	}
+	if err := o.shadowMacros(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(o.Overrides, func(a, b Override) int {
+		if c := strings.Compare(string(a.Kind), string(b.Kind)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	slices.Sort(o.ignored)
+	return o, nil
+}
+
+// shadowFiles hides the packaged files of dir named like an overlay file with one of
+// the extensions, since dbt names models and seeds after their file
+func (o *Overlay) shadowFiles(dir string, kind Kind, extensions ...string) error {
+	custom := map[string]string{}
+	for _, f := range o.Files {
+		if rel, ok := strings.CutPrefix(f, dir+"/"); ok && slices.Contains(extensions, path.Ext(rel)) {
+			custom[resourceName(rel)] = f
+		}
+	}
+	if len(custom) == 0 {
+		return nil
+	}
+
+	packaged, err := listFiles(filepath.Join(o.DBTDir, dir), DirName)
+	if err != nil {
+		return err
+	}
+	for _, f := range packaged {
+		if !slices.Contains(extensions, path.Ext(f)) {
+			continue
+		}
+		name := resourceName(f)
+		if customFile, ok := custom[name]; ok {
+			packagedFile := path.Join(dir, f)
+			o.Overrides = append(o.Overrides, Override{Kind: kind, Name: name, Custom: customFile, Packaged: packagedFile})
+			o.ignored = append(o.ignored, packagedFile)
+		}
+	}
+	return nil
+}
+
+// shadowMacros hides the packaged macro files defining a macro of the overlay, and
+// rewrites them without it so their other macros stay available
+func (o *Overlay) shadowMacros() error {
+	custom := map[string]string{}
+	for _, f := range o.Files {
+		if !strings.HasPrefix(f, "macros/") || path.Ext(f) != ".sql" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(o.CustomDir, filepath.FromSlash(f))) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f, err)
+		}
+		for _, m := range macroStart.FindAllStringSubmatch(string(data), -1) {
+			custom[m[1]] = f
+		}
+	}
+	if len(custom) == 0 {
+		return nil
+	}
+
+	packaged, err := listFiles(filepath.Join(o.DBTDir, "macros"), DirName)
+	if err != nil {
+		return err
+	}
+	for _, f := range packaged {
+		if path.Ext(f) != ".sql" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(o.DBTDir, "macros", filepath.FromSlash(f))) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to read macros/%s: %w", f, err)
+		}
+		content, shadowed := removeMacros(string(data), func(name string) bool { _, ok := custom[name]; return ok })
+		if len(shadowed) == 0 {
+			continue
+		}
+		for _, name := range shadowed {
+			o.Overrides = append(o.Overrides, Override{Kind: KindMacro, Name: name, Custom: custom[name], Packaged: path.Join("macros", f)})
+		}
+		o.ignored = append(o.ignored, path.Join("macros", f))
+		o.rewritten[f] = content
+	}
+	return nil
+}
+
+// removeMacros removes the macro blocks whose name matches from content and returns
+// the removed names
+func removeMacros(content string, match func(name string) bool) (string, []string) {
+	var out strings.Builder
+	var removed []string
+	for {
+		loc := macroStart.FindStringSubmatchIndex(content)
+		if loc == nil {
+			out.WriteString(content)
+			return out.String(), removed
+		}
+		name := content[loc[2]:loc[3]]
+		end := macroEnd.FindStringIndex(content[loc[1]:])
+		if end == nil {
+			// An unterminated macro is left for dbt to report
+			out.WriteString(content)
+			return out.String(), removed
+		}
+		blockEnd := loc[1] + end[1]
+		if match(name) {
+			out.WriteString(content[:loc[0]])
+			removed = append(removed, name)
+		} else {
+			out.WriteString(content[:blockEnd])
+		}
+		content = content[blockEnd:]
+	}
+}
+
+// Apply writes the overlay into the dbt project: it replaces the ecos_custom directories
+// with the overlay files and the rewritten macro files, and hides the shadowed packaged
+// files in .dbtignore
+func (o *Overlay) Apply() error {
+	if err := Clean(o.DBTDir); err != nil {
+		return err
+	}
+	for _, f := range o.Files {
+		dir, rel, _ := strings.Cut(f, "/")
+		data, err := os.ReadFile(filepath.Join(o.CustomDir, filepath.FromSlash(f))) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f, err)
+		}
+		if err := writeFile(filepath.Join(o.DBTDir, dir, DirName, filepath.FromSlash(rel)), data); err != nil {
+			return err
+		}
+	}
+	for f, content := range o.rewritten {
+		if err := writeFile(filepath.Join(o.DBTDir, "macros", DirName, packagedDir, filepath.FromSlash(f)), []byte(content)); err != nil {
+			return err
+		}
+	}
+	return writeIgnore(o.DBTDir, o.ignored)
+}
+
+// Clean removes a previously applied overlay from the dbt project in dbtDir
+func Clean(dbtDir string) error {
+	for _, dir := range Dirs {
+		if err := os.RemoveAll(filepath.Join(dbtDir, dir, DirName)); err != nil {
+			return fmt.Errorf("failed to remove the previous overlay: %w", err)
+		}
+	}
+	return writeIgnore(dbtDir, nil)
+}
+
+// writeIgnore replaces the generated section of .dbtignore with the ignored files,
+// keeping the user's own patterns. The file is removed when nothing is left.
+func writeIgnore(dbtDir string, ignored []string) error {
+	name := filepath.Join(dbtDir, ".dbtignore")
+	data, err := os.ReadFile(name) // #nosec G304
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read .dbtignore: %w", err)
+	}
+
+	var kept []string
+	inSection := false
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == ignoreBegin:
+			inSection = true
+		case line == ignoreEnd:
+			inSection = false
+		case !inSection:
+			kept = append(kept, line)
+		}
+	}
+	for len(kept) > 0 && kept[len(kept)-1] == "" {
+		kept = kept[:len(kept)-1]
+	}
+	if len(ignored) > 0 {
+		if len(kept) > 0 {
+			kept = append(kept, "")
+		}
+		kept = append(kept, ignoreBegin)
+		for _, f := range ignored {
+			kept = append(kept, "/"+f)
+		}
+		kept = append(kept, ignoreEnd)
+	}
+
+	if len(kept) == 0 {
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove .dbtignore: %w", err)
+		}
+		return nil
+	}
+	content := strings.Join(kept, "\n") + "\n"
+	if string(data) == content {
+		return nil
+	}
+	return writeFile(name, []byte(content))
+}
+
+// listFiles returns the files under dir, slash-separated and relative to it, skipping
+// the subdirectory skip. A missing dir has no files.
+func listFiles(dir, skip string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if skip != "" && rel == skip {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// resourceName returns the dbt name of a model or seed file
+func resourceName(file string) string {
+	base := path.Base(file)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
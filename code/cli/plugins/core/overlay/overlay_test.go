@@ -0,0 +1,106 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestOverlay(t *testing.T) {
+	dbtDir, customDir := t.TempDir(), t.TempDir()
+	writeFiles(t, dbtDir, map[string]string{
+		"models/3_gold/gold_core__service_monthly.sql": "select 1",
+		"models/3_gold/gold_core__other.sql":           "select 2",
+		"macros/utils/helpers.sql":                     "{% macro keep_me() %}1{% endmacro %}\n\n{%- macro cost_label(\n  col\n) -%}\n{{ col }}\n{%- endmacro %}\n",
+		"macros/utils/untouched.sql":                   "{% macro untouched() %}2{% endmacro %}\n",
+		"seeds/regions.csv":                            "region\nus-east-1\n",
+		".dbtignore":                                   "scratch/\n",
+	})
+	writeFiles(t, customDir, map[string]string{
+		"models/gold_core__service_monthly.sql": "select 3",
+		"models/acme/gold_acme__chargeback.sql": "select 4",
+		"macros/cost_label.sql":                 "{% macro cost_label(col) %}round({{ col }}, 2){% endmacro %}\n",
+		"seeds/regions.csv":                     "region\neu-west-1\n",
+	})
+
+	o, err := Load(dbtDir, customDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []Override{
+		{Kind: KindMacro, Name: "cost_label", Custom: "macros/cost_label.sql", Packaged: "macros/utils/helpers.sql"},
+		{Kind: KindModel, Name: "gold_core__service_monthly", Custom: "models/gold_core__service_monthly.sql", Packaged: "models/3_gold/gold_core__service_monthly.sql"},
+		{Kind: KindSeed, Name: "regions", Custom: "seeds/regions.csv", Packaged: "seeds/regions.csv"},
+	}
+	if len(o.Overrides) != len(want) {
+		t.Fatalf("Overrides = %+v", o.Overrides)
+	}
+	for i := range want {
+		if o.Overrides[i] != want[i] {
+			t.Errorf("Overrides[%d] = %+v, want %+v", i, o.Overrides[i], want[i])
+		}
+	}
+
+	if err := o.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := readFile(t, dbtDir, "models/ecos_custom/acme/gold_acme__chargeback.sql"); got != "select 4" {
+		t.Errorf("overlay model = %q", got)
+	}
+	if got := readFile(t, dbtDir, "macros/ecos_custom/_packaged/utils/helpers.sql"); !strings.Contains(got, "keep_me") || strings.Contains(got, "cost_label") {
+		t.Errorf("rewritten macros = %q", got)
+	}
+	if got := readFile(t, dbtDir, "macros/utils/helpers.sql"); !strings.Contains(got, "cost_label") {
+		t.Errorf("packaged macro file was modified: %q", got)
+	}
+	wantIgnore := "scratch/\n\n" + ignoreBegin + "\n/macros/utils/helpers.sql\n/models/3_gold/gold_core__service_monthly.sql\n/seeds/regions.csv\n" + ignoreEnd + "\n"
+	if got := readFile(t, dbtDir, ".dbtignore"); got != wantIgnore {
+		t.Errorf(".dbtignore = %q, want %q", got, wantIgnore)
+	}
+
+	// Applying again is stable, and cleaning restores the project
+	if err := o.Apply(); err != nil {
+		t.Fatalf("Apply() again error = %v", err)
+	}
+	if got := readFile(t, dbtDir, ".dbtignore"); got != wantIgnore {
+		t.Errorf(".dbtignore after a second Apply() = %q", got)
+	}
+	if err := Clean(dbtDir); err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if got := readFile(t, dbtDir, ".dbtignore"); got != "scratch/\n" {
+		t.Errorf(".dbtignore after Clean() = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dbtDir, "models", DirName)); !os.IsNotExist(err) {
+		t.Errorf("overlay models not removed: %v", err)
+	}
+}
+
+func TestLoad_MissingDir(t *testing.T) {
+	if _, err := Load(t.TempDir(), filepath.Join(t.TempDir(), "custom")); err == nil {
+		t.Error("Load() expected error for a missing overlay directory")
+	}
+}
@@ -0,0 +1,435 @@
+package permissions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+)
+
+// Purpose identifies the ecos command a policy is generated for
+type Purpose string
+
+const (
+	// PurposeInit covers provisioning the results bucket and workgroups.
+	PurposeInit Purpose = "init"
+	// PurposeTransform covers running dbt against Athena and Glue.
+	PurposeTransform Purpose = "transform"
+	// PurposeDestroy covers removing ecos-managed resources.
+	PurposeDestroy Purpose = "destroy"
+)
+
+// SupportedPurposes lists the purposes accepted by ParsePurpose
+var SupportedPurposes = []Purpose{PurposeInit, PurposeTransform, PurposeDestroy}
+
+// curBucketPlaceholder is used when the bucket holding the raw CUR data is unknown
+const curBucketPlaceholder = "REPLACE_WITH_CUR_BUCKET"
+
+// ParsePurpose validates a user-supplied purpose name
+func ParsePurpose(value string) (Purpose, error) {
+	for _, p := range SupportedPurposes {
+		if strings.EqualFold(strings.TrimSpace(value), string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported policy purpose '%s', must be one of: %v", value, SupportedPurposes)
+}
+
+// Scope holds the project resources a policy is restricted to
+type Scope struct {
+	AccountID  string
+	Region     string
+	Bucket     string
+	Workgroups []string
+	// Database is the Glue database dbt writes to; layer schemas derived
+	// from it (e.g. <database>_audit) are included.
+	Database string
+	// CURDatabase and CURTable identify the Glue table holding the raw CUR data.
+	CURDatabase string
+	CURTable    string
+	// CURBucket is the S3 bucket holding the raw CUR data, read by Athena during transform.
+	CURBucket string
+	// DataExport and DataExportDatabase identify the BCM Data Export created by init and
+	// the Glue database over its delivered files; empty when no export is managed.
+	DataExport         string
+	DataExportDatabase string
+}
+
+// Statement is a single IAM policy statement
+type Statement struct {
+	Sid      string   `json:"Sid"`
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// Policy is an IAM identity policy document
+type Policy struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+// Partition returns the AWS partition for a region (aws, aws-cn, aws-us-gov)
+func Partition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	default:
+		return "aws"
+	}
+}
+
+// Validate checks that the scope contains everything needed to build resource ARNs
+func (s *Scope) Validate() error {
+	if s.Region == "" {
+		return errors.New("aws region is required to scope the policy")
+	}
+	if s.AccountID == "" {
+		return errors.New("aws account ID is required to scope the policy")
+	}
+	if s.Bucket == "" {
+		return errors.New("results bucket is required to scope the policy")
+	}
+	if len(s.Workgroups) == 0 {
+		return errors.New("at least one Athena workgroup is required to scope the policy")
+	}
+	return nil
+}
+
+// PolicyFor returns the minimal policy the given command needs for the scoped resources
+func PolicyFor(purpose Purpose, scope Scope) (*Policy, error) {
+	if err := scope.Validate(); err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	switch purpose {
+	case PurposeInit:
+		statements = initStatements(scope)
+	case PurposeTransform:
+		statements = transformStatements(scope)
+	case PurposeDestroy:
+		statements = destroyStatements(scope)
+	default:
+		return nil, fmt.Errorf("unsupported policy purpose '%s'", purpose)
+	}
+
+	return &Policy{Version: "2012-10-17", Statement: statements}, nil
+}
+
+// JSON renders the policy as indented JSON
+func (p *Policy) JSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// Actions returns the sorted, de-duplicated list of actions in the policy
+func (p *Policy) Actions() []string {
+	seen := map[string]bool{}
+	var actions []string
+	for _, st := range p.Statement {
+		for _, a := range st.Action {
+			if !seen[a] {
+				seen[a] = true
+				actions = append(actions, a)
+			}
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// initStatements covers the calls made by `ecos init` (see plugins/core/init/aws_cur.go)
+func initStatements(s Scope) []Statement {
+	statements := []Statement{
+		identityStatement(),
+		{
+			Sid:    "EcosResultsBucket",
+			Effect: "Allow",
+			Action: []string{
+				"s3:CreateBucket",
+				"s3:ListBucket",
+				"s3:PutBucketVersioning",
+				"s3:PutLifecycleConfiguration",
+				"s3:PutBucketTagging",
+			},
+			Resource: []string{bucketARN(s)},
+		},
+		{
+			Sid:      "EcosResultsFolders",
+			Effect:   "Allow",
+			Action:   []string{"s3:GetObject", "s3:PutObject"},
+			Resource: []string{objectsARN(s)},
+		},
+		{
+			Sid:      "EcosWorkgroups",
+			Effect:   "Allow",
+			Action:   []string{"athena:GetWorkGroup", "athena:CreateWorkGroup", "athena:TagResource"},
+			Resource: workgroupARNs(s),
+		},
+		{
+			Sid:      "EcosCURTableLookup",
+			Effect:   "Allow",
+			Action:   []string{"glue:GetDatabase", "glue:GetTable"},
+			Resource: curGlueARNs(s),
+		},
+	}
+	if s.DataExport == "" {
+		return statements
+	}
+
+	return append(statements,
+		listExportsStatement(),
+		Statement{
+			Sid:      "EcosDataExport",
+			Effect:   "Allow",
+			Action:   []string{"bcm-data-exports:CreateExport", "bcm-data-exports:TagResource"},
+			Resource: []string{dataExportARN(s)},
+		},
+		Statement{
+			Sid:      "EcosDataExportBucketPolicy",
+			Effect:   "Allow",
+			Action:   []string{"s3:GetBucketPolicy", "s3:PutBucketPolicy"},
+			Resource: []string{bucketARN(s)},
+		},
+		Statement{
+			Sid:      "EcosDataExportCatalog",
+			Effect:   "Allow",
+			Action:   []string{"glue:CreateDatabase", "glue:CreateTable"},
+			Resource: dataExportGlueARNs(s),
+		},
+	)
+}
+
+// transformStatements covers the calls dbt-athena makes while building the models
+func transformStatements(s Scope) []Statement {
+	curBucket := s.CURBucket
+	if curBucket == "" {
+		curBucket = curBucketPlaceholder
+	}
+	partition := Partition(s.Region)
+
+	return []Statement{
+		identityStatement(),
+		{
+			Sid:    "EcosQueryExecution",
+			Effect: "Allow",
+			Action: []string{
+				"athena:GetWorkGroup",
+				"athena:StartQueryExecution",
+				"athena:GetQueryExecution",
+				"athena:GetQueryResults",
+				"athena:StopQueryExecution",
+			},
+			Resource: workgroupARNs(s),
+		},
+		{
+			Sid:      "EcosDataCatalog",
+			Effect:   "Allow",
+			Action:   []string{"athena:GetDataCatalog"},
+			Resource: []string{fmt.Sprintf("arn:%s:athena:%s:%s:datacatalog/AwsDataCatalog", partition, s.Region, s.AccountID)},
+		},
+		{
+			Sid:    "EcosGlueModels",
+			Effect: "Allow",
+			Action: []string{
+				"glue:GetDatabase",
+				"glue:GetDatabases",
+				"glue:CreateDatabase",
+				"glue:GetTable",
+				"glue:GetTables",
+				"glue:CreateTable",
+				"glue:UpdateTable",
+				"glue:DeleteTable",
+				"glue:GetPartition",
+				"glue:GetPartitions",
+				"glue:BatchCreatePartition",
+				"glue:BatchDeletePartition",
+				"glue:BatchGetPartition",
+			},
+			Resource: modelGlueARNs(s),
+		},
+		{
+			Sid:      "EcosCURTableRead",
+			Effect:   "Allow",
+			Action:   []string{"glue:GetDatabase", "glue:GetTable", "glue:GetPartition", "glue:GetPartitions", "glue:BatchGetPartition"},
+			Resource: curGlueARNs(s),
+		},
+		{
+			Sid:    "EcosResultsBucket",
+			Effect: "Allow",
+			Action: []string{
+				"s3:ListBucket",
+				"s3:GetBucketLocation",
+				"s3:ListBucketMultipartUploads",
+			},
+			Resource: []string{bucketARN(s)},
+		},
+		{
+			Sid:    "EcosResultsObjects",
+			Effect: "Allow",
+			Action: []string{
+				"s3:GetObject",
+				"s3:PutObject",
+				"s3:DeleteObject",
+				"s3:AbortMultipartUpload",
+				"s3:ListMultipartUploadParts",
+			},
+			Resource: []string{objectsARN(s)},
+		},
+		{
+			Sid:      "EcosCURDataBucket",
+			Effect:   "Allow",
+			Action:   []string{"s3:ListBucket", "s3:GetBucketLocation"},
+			Resource: []string{fmt.Sprintf("arn:%s:s3:::%s", partition, curBucket)},
+		},
+		{
+			Sid:      "EcosCURDataObjects",
+			Effect:   "Allow",
+			Action:   []string{"s3:GetObject"},
+			Resource: []string{fmt.Sprintf("arn:%s:s3:::%s/*", partition, curBucket)},
+		},
+	}
+}
+
+// destroyStatements covers the calls made by `ecos destroy` (see plugins/core/destroy/aws_cur.go)
+func destroyStatements(s Scope) []Statement {
+	statements := []Statement{
+		identityStatement(),
+		{
+			Sid:    "EcosResultsBucket",
+			Effect: "Allow",
+			Action: []string{
+				"s3:ListBucket",
+				"s3:ListBucketVersions",
+				"s3:GetBucketTagging",
+				"s3:DeleteBucket",
+			},
+			Resource: []string{bucketARN(s)},
+		},
+		{
+			Sid:      "EcosResultsObjects",
+			Effect:   "Allow",
+			Action:   []string{"s3:DeleteObject", "s3:DeleteObjectVersion"},
+			Resource: []string{objectsARN(s)},
+		},
+		{
+			Sid:      "EcosWorkgroups",
+			Effect:   "Allow",
+			Action:   []string{"athena:GetWorkGroup", "athena:ListTagsForResource", "athena:DeleteWorkGroup"},
+			Resource: workgroupARNs(s),
+		},
+	}
+	if s.DataExport == "" {
+		return statements
+	}
+
+	return append(statements,
+		listExportsStatement(),
+		Statement{
+			Sid:      "EcosDataExport",
+			Effect:   "Allow",
+			Action:   []string{"bcm-data-exports:ListTagsForResource", "bcm-data-exports:DeleteExport"},
+			Resource: []string{dataExportARN(s)},
+		},
+		Statement{
+			Sid:      "EcosDataExportCatalog",
+			Effect:   "Allow",
+			Action:   []string{"glue:GetDatabase", "glue:GetTable", "glue:GetTables", "glue:DeleteTable", "glue:DeleteDatabase"},
+			Resource: dataExportGlueARNs(s),
+		},
+	)
+}
+
+// identityStatement allows resolving the caller identity (not resource-scoped)
+func identityStatement() Statement {
+	return Statement{
+		Sid:      "EcosCallerIdentity",
+		Effect:   "Allow",
+		Action:   []string{"sts:GetCallerIdentity"},
+		Resource: []string{"*"},
+	}
+}
+
+// listExportsStatement allows finding the data export by name (not resource-scoped)
+func listExportsStatement() Statement {
+	return Statement{
+		Sid:      "EcosDataExportLookup",
+		Effect:   "Allow",
+		Action:   []string{"bcm-data-exports:ListExports"},
+		Resource: []string{"*"},
+	}
+}
+
+// dataExportARN matches the export regardless of the ID suffix AWS appends to its name
+func dataExportARN(s Scope) string {
+	return fmt.Sprintf("arn:%s:bcm-data-exports:%s:%s:export/%s*",
+		Partition(s.Region), awssession.BCMDataExportsRegion, s.AccountID, s.DataExport)
+}
+
+func dataExportGlueARNs(s Scope) []string {
+	return []string{
+		glueARN(s, "catalog"),
+		glueARN(s, "database/"+s.DataExportDatabase),
+		glueARN(s, "table/"+s.DataExportDatabase+"/*"),
+	}
+}
+
+func bucketARN(s Scope) string {
+	return fmt.Sprintf("arn:%s:s3:::%s", Partition(s.Region), s.Bucket)
+}
+
+func objectsARN(s Scope) string {
+	return bucketARN(s) + "/*"
+}
+
+func workgroupARNs(s Scope) []string {
+	var arns []string
+	for _, wg := range s.Workgroups {
+		arns = append(arns, fmt.Sprintf("arn:%s:athena:%s:%s:workgroup/%s", Partition(s.Region), s.Region, s.AccountID, wg))
+	}
+	return arns
+}
+
+func glueARN(s Scope, resource string) string {
+	return fmt.Sprintf("arn:%s:glue:%s:%s:%s", Partition(s.Region), s.Region, s.AccountID, resource)
+}
+
+// modelGlueARNs covers the dbt target database and the layer schemas derived from it
+func modelGlueARNs(s Scope) []string {
+	database := s.Database
+	if database == "" {
+		database = "*"
+	}
+	arns := []string{
+		glueARN(s, "catalog"),
+		glueARN(s, "database/"+database),
+		glueARN(s, "table/"+database+"/*"),
+	}
+	if database != "*" {
+		arns = append(arns,
+			glueARN(s, "database/"+database+"_*"),
+			glueARN(s, "table/"+database+"_*/*"),
+		)
+	}
+	return arns
+}
+
+func curGlueARNs(s Scope) []string {
+	database, table := s.CURDatabase, s.CURTable
+	if database == "" {
+		database = "*"
+	}
+	if table == "" {
+		table = "*"
+	}
+	return []string{
+		glueARN(s, "catalog"),
+		glueARN(s, "database/"+database),
+		glueARN(s, "table/"+database+"/"+table),
+	}
+}
@@ -0,0 +1,270 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+func testScope() Scope {
+	return Scope{
+		AccountID:   "123456789012",
+		Region:      "us-east-1",
+		Bucket:      "demo-bucket",
+		Workgroups:  []string{"demo-dbt", "demo-adhoc"},
+		Database:    "demo",
+		CURDatabase: "cur",
+		CURTable:    "cur_data",
+	}
+}
+
+func TestParsePurpose(t *testing.T) {
+	for _, value := range []string{"init", "Transform", " destroy "} {
+		if _, err := ParsePurpose(value); err != nil {
+			t.Errorf("ParsePurpose(%q) unexpected error: %v", value, err)
+		}
+	}
+	if _, err := ParsePurpose("ingest"); err == nil {
+		t.Error("ParsePurpose(\"ingest\") expected error, got nil")
+	}
+}
+
+func TestPolicyFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		purpose     Purpose
+		wantActions []string
+		denyActions []string
+		wantARNs    []string
+	}{
+		{
+			name:        "init",
+			purpose:     PurposeInit,
+			wantActions: []string{"s3:CreateBucket", "s3:PutLifecycleConfiguration", "athena:CreateWorkGroup", "athena:TagResource"},
+			denyActions: []string{"s3:DeleteBucket", "athena:StartQueryExecution"},
+			wantARNs: []string{
+				"arn:aws:s3:::demo-bucket",
+				"arn:aws:athena:us-east-1:123456789012:workgroup/demo-dbt",
+				"arn:aws:glue:us-east-1:123456789012:table/cur/cur_data",
+			},
+		},
+		{
+			name:        "transform",
+			purpose:     PurposeTransform,
+			wantActions: []string{"athena:StartQueryExecution", "glue:CreateTable", "s3:PutObject"},
+			denyActions: []string{"s3:CreateBucket", "athena:DeleteWorkGroup"},
+			wantARNs: []string{
+				"arn:aws:glue:us-east-1:123456789012:database/demo_*",
+				"arn:aws:s3:::" + curBucketPlaceholder + "/*",
+			},
+		},
+		{
+			name:        "destroy",
+			purpose:     PurposeDestroy,
+			wantActions: []string{"s3:DeleteBucket", "s3:DeleteObjectVersion", "athena:DeleteWorkGroup"},
+			denyActions: []string{"s3:CreateBucket", "glue:CreateTable"},
+			wantARNs:    []string{"arn:aws:athena:us-east-1:123456789012:workgroup/demo-adhoc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := PolicyFor(tt.purpose, testScope())
+			if err != nil {
+				t.Fatalf("PolicyFor() error = %v", err)
+			}
+
+			actions := policy.Actions()
+			for _, a := range tt.wantActions {
+				if !slices.Contains(actions, a) {
+					t.Errorf("policy missing action %s", a)
+				}
+			}
+			for _, a := range tt.denyActions {
+				if slices.Contains(actions, a) {
+					t.Errorf("policy should not contain action %s", a)
+				}
+			}
+
+			data, err := policy.JSON()
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			for _, arn := range tt.wantARNs {
+				if !strings.Contains(string(data), arn) {
+					t.Errorf("policy missing resource %s", arn)
+				}
+			}
+
+			var decoded map[string]any
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("policy is not valid JSON: %v", err)
+			}
+			if decoded["Version"] != "2012-10-17" {
+				t.Errorf("Version = %v, want 2012-10-17", decoded["Version"])
+			}
+		})
+	}
+}
+
+func TestPolicyForDataExport(t *testing.T) {
+	tests := []struct {
+		purpose     Purpose
+		wantActions []string
+	}{
+		{PurposeInit, []string{"bcm-data-exports:CreateExport", "s3:PutBucketPolicy", "glue:CreateTable"}},
+		{PurposeDestroy, []string{"bcm-data-exports:DeleteExport", "glue:DeleteTable", "glue:DeleteDatabase"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.purpose), func(t *testing.T) {
+			without, err := PolicyFor(tt.purpose, testScope())
+			if err != nil {
+				t.Fatalf("PolicyFor() error = %v", err)
+			}
+			if slices.Contains(without.Actions(), "bcm-data-exports:ListExports") {
+				t.Error("policy without a data export should not grant bcm-data-exports actions")
+			}
+
+			scope := testScope()
+			scope.DataExport = "demo-cur2"
+			scope.DataExportDatabase = "demo_data_exports"
+			policy, err := PolicyFor(tt.purpose, scope)
+			if err != nil {
+				t.Fatalf("PolicyFor() error = %v", err)
+			}
+
+			actions := policy.Actions()
+			for _, a := range append(tt.wantActions, "bcm-data-exports:ListExports") {
+				if !slices.Contains(actions, a) {
+					t.Errorf("policy missing action %s", a)
+				}
+			}
+
+			data, err := policy.JSON()
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			for _, arn := range []string{
+				"arn:aws:bcm-data-exports:us-east-1:123456789012:export/demo-cur2*",
+				"arn:aws:glue:us-east-1:123456789012:table/demo_data_exports/*",
+			} {
+				if !strings.Contains(string(data), arn) {
+					t.Errorf("policy missing resource %s", arn)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyForValidation(t *testing.T) {
+	scope := testScope()
+	scope.AccountID = ""
+
+	if _, err := PolicyFor(PurposeInit, scope); err == nil {
+		t.Error("PolicyFor() expected error for missing account ID, got nil")
+	}
+}
+
+func TestPartition(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":     "aws",
+		"cn-north-1":    "aws-cn",
+		"us-gov-west-1": "aws-us-gov",
+	}
+	for region, want := range tests {
+		if got := Partition(region); got != want {
+			t.Errorf("Partition(%q) = %q, want %q", region, got, want)
+		}
+	}
+}
+
+func TestPrincipalARN(t *testing.T) {
+	tests := []struct {
+		caller  string
+		want    string
+		wantErr error
+	}{
+		{"arn:aws:iam::123456789012:user/alice", "arn:aws:iam::123456789012:user/alice", nil},
+		{"arn:aws:sts::123456789012:assumed-role/EcosAdmin/session", "arn:aws:iam::123456789012:role/EcosAdmin", nil},
+		{"arn:aws:iam::123456789012:root", "", ErrSimulationUnsupported},
+		{"arn:aws:sts::123456789012:federated-user/bob", "", ErrSimulationUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caller, func(t *testing.T) {
+			got, err := PrincipalARN(tt.caller)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PrincipalARN() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PrincipalARN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeSimulator denies every action listed in denied
+type fakeSimulator struct {
+	denied map[string]bool
+	calls  int
+}
+
+func (f *fakeSimulator) SimulatePrincipalPolicy(_ context.Context, in *iam.SimulatePrincipalPolicyInput, _ ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	f.calls++
+	resource := "*"
+	if len(in.ResourceArns) > 0 {
+		resource = in.ResourceArns[0]
+	}
+
+	out := &iam.SimulatePrincipalPolicyOutput{}
+	for _, action := range in.ActionNames {
+		decision := iamTypes.PolicyEvaluationDecisionTypeAllowed
+		if f.denied[action] {
+			decision = iamTypes.PolicyEvaluationDecisionTypeImplicitDeny
+		}
+		out.EvaluationResults = append(out.EvaluationResults, iamTypes.EvaluationResult{
+			EvalActionName:   aws.String(action),
+			EvalResourceName: aws.String(resource),
+			EvalDecision:     decision,
+		})
+	}
+	return out, nil
+}
+
+func TestSimulate(t *testing.T) {
+	policy, err := PolicyFor(PurposeTransform, testScope())
+	if err != nil {
+		t.Fatalf("PolicyFor() error = %v", err)
+	}
+
+	sim := &fakeSimulator{denied: map[string]bool{"glue:CreateTable": true, "athena:StartQueryExecution": true}}
+	denials, err := Simulate(context.Background(), sim, "arn:aws:iam::123456789012:role/ci", policy)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	if len(denials) != 2 {
+		t.Fatalf("expected 2 denials, got %d: %+v", len(denials), denials)
+	}
+	if denials[0].Action != "athena:StartQueryExecution" || denials[1].Action != "glue:CreateTable" {
+		t.Errorf("unexpected denial order: %+v", denials)
+	}
+
+	// Statements scoped to the CUR bucket placeholder are not simulated
+	if sim.calls != len(policy.Statement)-2 {
+		t.Errorf("expected %d simulate calls, got %d", len(policy.Statement)-2, sim.calls)
+	}
+
+	lines := FormatDenials(denials)
+	if !strings.Contains(lines[0], "athena:StartQueryExecution on arn:aws:athena") {
+		t.Errorf("unexpected formatted denial %q", lines[0])
+	}
+}
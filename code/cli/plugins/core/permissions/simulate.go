@@ -0,0 +1,151 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+)
+
+// ErrSimulationUnsupported is returned when the caller identity cannot be simulated
+// (root user, federated user), in which case the preflight should be skipped.
+var ErrSimulationUnsupported = errors.New("permission simulation is not supported for this identity")
+
+// SimulateAPI is the subset of the IAM client used for simulation
+type SimulateAPI interface {
+	SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error)
+}
+
+// Denial is an action the simulated principal is not allowed to perform
+type Denial struct {
+	Action   string
+	Resource string
+	Decision string // implicitDeny or explicitDeny
+}
+
+// PrincipalARN converts a caller identity ARN into the IAM principal ARN accepted
+// by SimulatePrincipalPolicy. Assumed-role sessions map to their role; role paths
+// are not part of the session ARN, so roles with a path cannot be resolved.
+func PrincipalARN(callerARN string) (string, error) {
+	parts := strings.SplitN(callerARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return "", fmt.Errorf("invalid caller ARN '%s'", callerARN)
+	}
+	partition, service, account, resource := parts[1], parts[2], parts[4], parts[5]
+
+	switch {
+	case service == "iam" && strings.HasPrefix(resource, "user/"):
+		return callerARN, nil
+	case service == "iam" && strings.HasPrefix(resource, "role/"):
+		return callerARN, nil
+	case service == "sts" && strings.HasPrefix(resource, "assumed-role/"):
+		segments := strings.Split(resource, "/")
+		if len(segments) < 3 {
+			return "", fmt.Errorf("invalid assumed-role ARN '%s'", callerARN)
+		}
+		return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, account, segments[1]), nil
+	default:
+		return "", ErrSimulationUnsupported
+	}
+}
+
+// Simulate evaluates every statement of the policy against the principal's
+// attached policies and returns the actions that would be denied
+func Simulate(ctx context.Context, client SimulateAPI, principalARN string, policy *Policy) ([]Denial, error) {
+	var denials []Denial
+
+	for _, st := range policy.Statement {
+		if hasPlaceholder(st.Resource) {
+			continue
+		}
+
+		input := &iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principalARN),
+			ActionNames:     st.Action,
+		}
+		if !(len(st.Resource) == 1 && st.Resource[0] == "*") {
+			input.ResourceArns = st.Resource
+		}
+
+		paginator := iam.NewSimulatePrincipalPolicyPaginator(client, input)
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to simulate %s: %w", st.Sid, err)
+			}
+			for _, res := range out.EvaluationResults {
+				if res.EvalDecision == iamTypes.PolicyEvaluationDecisionTypeAllowed {
+					continue
+				}
+				denials = append(denials, Denial{
+					Action:   aws.ToString(res.EvalActionName),
+					Resource: aws.ToString(res.EvalResourceName),
+					Decision: string(res.EvalDecision),
+				})
+			}
+		}
+	}
+
+	sort.Slice(denials, func(i, j int) bool {
+		if denials[i].Action != denials[j].Action {
+			return denials[i].Action < denials[j].Action
+		}
+		return denials[i].Resource < denials[j].Resource
+	})
+
+	return denials, nil
+}
+
+// Preflight resolves the caller identity and simulates the policy for the given
+// command. The account ID is filled in from the caller identity when not set.
+func Preflight(ctx context.Context, cfg aws.Config, endpoints config.AWSEndpointsConfig, purpose Purpose, scope Scope) ([]Denial, error) {
+	ident, err := awssession.NewSTSClient(cfg, endpoints).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	if scope.AccountID == "" {
+		scope.AccountID = aws.ToString(ident.Account)
+	}
+
+	principal, err := PrincipalARN(aws.ToString(ident.Arn))
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := PolicyFor(purpose, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return Simulate(ctx, awssession.NewIAMClient(cfg, endpoints), principal, policy)
+}
+
+// FormatDenials renders denials as one "action on resource (decision)" line each
+func FormatDenials(denials []Denial) []string {
+	lines := make([]string, 0, len(denials))
+	for _, d := range denials {
+		resource := d.Resource
+		if resource == "" {
+			resource = "*"
+		}
+		lines = append(lines, fmt.Sprintf("%s on %s (%s)", d.Action, resource, d.Decision))
+	}
+	return lines
+}
+
+func hasPlaceholder(resources []string) bool {
+	for _, r := range resources {
+		if strings.Contains(r, curBucketPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
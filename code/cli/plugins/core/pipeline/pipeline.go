@@ -0,0 +1,279 @@
+// Package pipeline runs the stages of 'ecos run' (ingest, transform, verify, report)
+// in dependency order with per-stage retries and timeouts, and consolidates their
+// results into a single PluginResult.
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+	"github.com/ecos-labs/ecos/code/cli/utils"
+)
+
+// Failure policies
+const (
+	// OnFailureStop skips every stage that has not started after a stage fails
+	OnFailureStop = "stop"
+	// OnFailureContinue skips only the stages that depend on a failed stage
+	OnFailureContinue = "continue"
+)
+
+// StageStatus is the outcome of a stage
+type StageStatus string
+
+const (
+	// StageSucceeded indicates the stage completed successfully
+	StageSucceeded StageStatus = "succeeded"
+	// StageFailed indicates the stage failed on its last attempt
+	StageFailed StageStatus = "failed"
+	// StageSkipped indicates the stage did not run because of an earlier failure
+	StageSkipped StageStatus = "skipped"
+)
+
+// Stage is one step of the pipeline
+type Stage struct {
+	Name       string
+	Type       types.PluginType
+	DependsOn  []string
+	Retries    int           // extra attempts after a failure
+	RetryDelay time.Duration // wait between attempts
+	Timeout    time.Duration // per attempt, 0 for none
+
+	// Run executes one attempt of the stage
+	Run func(ctx context.Context) (*types.PluginResult, error)
+}
+
+// StageResult is the outcome of a stage in the pipeline report
+type StageResult struct {
+	Name     string           `json:"name"`
+	Type     types.PluginType `json:"type"`
+	Status   StageStatus      `json:"status"`
+	Attempts int              `json:"attempts"`
+	Duration time.Duration    `json:"duration"`
+	Message  string           `json:"message,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// Pipeline is a validated set of stages in execution order
+type Pipeline struct {
+	Stages    []Stage
+	OnFailure string
+}
+
+// New validates the stages and orders them so every stage runs after its
+// dependencies. Stages without an ordering constraint keep their declared order.
+func New(stages []Stage, onFailure string) (*Pipeline, error) {
+	if onFailure == "" {
+		onFailure = OnFailureStop
+	}
+	if onFailure != OnFailureStop && onFailure != OnFailureContinue {
+		return nil, fmt.Errorf("invalid on_failure '%s', must be %s or %s", onFailure, OnFailureStop, OnFailureContinue)
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("the pipeline has no stages")
+	}
+
+	byName := map[string]Stage{}
+	for _, s := range stages {
+		if _, dup := byName[s.Name]; dup {
+			return nil, fmt.Errorf("duplicate stage name '%s'", s.Name)
+		}
+		byName[s.Name] = s
+	}
+	for _, s := range stages {
+		for _, dep := range s.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("stage '%s' depends on unknown stage '%s'", s.Name, dep)
+			}
+		}
+	}
+
+	// Repeatedly take the first declared stage whose dependencies are all placed
+	ordered := make([]Stage, 0, len(stages))
+	placed := map[string]bool{}
+	for len(ordered) < len(stages) {
+		progress := false
+		for _, s := range stages {
+			if placed[s.Name] || !allPlaced(s.DependsOn, placed) {
+				continue
+			}
+			ordered = append(ordered, s)
+			placed[s.Name] = true
+			progress = true
+			break
+		}
+		if !progress {
+			var cyclic []string
+			for _, s := range stages {
+				if !placed[s.Name] {
+					cyclic = append(cyclic, s.Name)
+				}
+			}
+			return nil, fmt.Errorf("stages %s have circular dependencies", strings.Join(cyclic, ", "))
+		}
+	}
+
+	return &Pipeline{Stages: ordered, OnFailure: onFailure}, nil
+}
+
+func allPlaced(names []string, placed map[string]bool) bool {
+	for _, name := range names {
+		if !placed[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// Run executes the stages in order and returns the consolidated result. A stage runs
+// only when its dependencies succeeded; after a failure, the failure policy decides
+// which of the remaining stages are skipped. Cancelling ctx skips the stages that
+// have not started.
+func (p *Pipeline) Run(ctx context.Context) *types.PluginResult {
+	started := time.Now()
+	results := make([]StageResult, 0, len(p.Stages))
+	status := map[string]StageStatus{}
+	stopped := false
+
+	for _, stage := range p.Stages {
+		result := StageResult{Name: stage.Name, Type: stage.Type}
+
+		var blockedBy []string
+		for _, dep := range stage.DependsOn {
+			if status[dep] != StageSucceeded {
+				blockedBy = append(blockedBy, dep)
+			}
+		}
+		switch {
+		case ctx.Err() != nil:
+			result.Status = StageSkipped
+			result.Message = "pipeline interrupted"
+		case stopped:
+			result.Status = StageSkipped
+			result.Message = "an earlier stage failed"
+		case len(blockedBy) > 0:
+			result.Status = StageSkipped
+			result.Message = fmt.Sprintf("depends on %s", strings.Join(blockedBy, ", "))
+		default:
+			result = p.runStage(ctx, stage)
+		}
+
+		status[stage.Name] = result.Status
+		if result.Status == StageFailed && p.OnFailure == OnFailureStop {
+			stopped = true
+		}
+		results = append(results, result)
+	}
+
+	return consolidate(results, time.Since(started), p.OnFailure)
+}
+
+// runStage runs a stage until it succeeds or runs out of attempts
+func (p *Pipeline) runStage(ctx context.Context, stage Stage) StageResult {
+	result := StageResult{Name: stage.Name, Type: stage.Type}
+	started := time.Now()
+	attempts := stage.Retries + 1
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result.Attempts = attempt
+		res, err := runAttempt(ctx, stage)
+		if err == nil && res != nil && !res.Success {
+			err = errors.New(res.Message)
+		}
+		if res != nil {
+			result.Message = res.Message
+		}
+		if err == nil {
+			result.Status = StageSucceeded
+			result.Error = ""
+			break
+		}
+
+		result.Status = StageFailed
+		result.Error = err.Error()
+		if attempt == attempts || ctx.Err() != nil {
+			break
+		}
+		utils.PrintWarning(fmt.Sprintf("Stage %s failed (attempt %d of %d): %v; retrying in %s", stage.Name, attempt, attempts, err, stage.RetryDelay))
+		select {
+		case <-ctx.Done():
+		case <-time.After(stage.RetryDelay):
+		}
+	}
+
+	result.Duration = time.Since(started)
+	return result
+}
+
+// runAttempt runs one attempt of the stage within its timeout
+func runAttempt(ctx context.Context, stage Stage) (*types.PluginResult, error) {
+	if stage.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, stage.Timeout)
+		defer cancel()
+	}
+
+	res, err := stage.Run(ctx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return res, fmt.Errorf("timed out after %s", stage.Timeout)
+	}
+	return res, err
+}
+
+// consolidate builds the pipeline's PluginResult from the stage results
+func consolidate(results []StageResult, duration time.Duration, onFailure string) *types.PluginResult {
+	counts := map[StageStatus]int{}
+	var errs []string
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Status == StageFailed {
+			errs = append(errs, fmt.Sprintf("%s: %s", r.Name, r.Error))
+		}
+	}
+
+	success := counts[StageSucceeded] == len(results)
+	result := &types.PluginResult{
+		Success:  success,
+		Message:  fmt.Sprintf("%d of %d stages succeeded, %d failed, %d skipped", counts[StageSucceeded], len(results), counts[StageFailed], counts[StageSkipped]),
+		Duration: duration,
+		Metadata: map[string]any{
+			"on_failure": onFailure,
+			"stages":     results,
+		},
+		Error: strings.Join(errs, "; "),
+	}
+	if !success {
+		result.ExitCode = 1
+	}
+	return result
+}
+
+// StageResults returns the stage results of a consolidated pipeline result
+func StageResults(result *types.PluginResult) []StageResult {
+	stages, _ := result.Metadata["stages"].([]StageResult)
+	return stages
+}
+
+// WriteReport writes the consolidated result as JSON to path
+func WriteReport(result *types.PluginResult, path string) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pipeline report: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write pipeline report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+)
+
+// stage returns a transform stage that records its runs and returns err
+func stage(name string, ran *[]string, err error, dependsOn ...string) Stage {
+	return Stage{
+		Name:      name,
+		Type:      types.PluginTypeTransform,
+		DependsOn: dependsOn,
+		Run: func(context.Context) (*types.PluginResult, error) {
+			*ran = append(*ran, name)
+			if err != nil {
+				return &types.PluginResult{Success: false, Message: err.Error()}, err
+			}
+			return &types.PluginResult{Success: true, Message: name + " done"}, nil
+		},
+	}
+}
+
+func statuses(result *types.PluginResult) map[string]StageStatus {
+	out := map[string]StageStatus{}
+	for _, r := range StageResults(result) {
+		out[r.Name] = r.Status
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	var ran []string
+	tests := []struct {
+		name      string
+		stages    []Stage
+		wantOrder []string
+		wantErr   string
+	}{
+		{
+			name:      "dependencies run first",
+			stages:    []Stage{stage("report", &ran, nil, "verify"), stage("transform", &ran, nil, "ingest"), stage("ingest", &ran, nil), stage("verify", &ran, nil, "transform")},
+			wantOrder: []string{"ingest", "transform", "verify", "report"},
+		},
+		{
+			name:      "declared order without dependencies",
+			stages:    []Stage{stage("b", &ran, nil), stage("a", &ran, nil)},
+			wantOrder: []string{"b", "a"},
+		},
+		{name: "duplicate", stages: []Stage{stage("a", &ran, nil), stage("a", &ran, nil)}, wantErr: "duplicate"},
+		{name: "unknown dependency", stages: []Stage{stage("a", &ran, nil, "x")}, wantErr: "unknown stage 'x'"},
+		{name: "cycle", stages: []Stage{stage("a", &ran, nil, "b"), stage("b", &ran, nil, "a")}, wantErr: "circular"},
+		{name: "empty", wantErr: "no stages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.stages, "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var order []string
+			for _, s := range p.Stages {
+				order = append(order, s.Name)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+
+	if _, err := New([]Stage{stage("a", &ran, nil)}, "retry"); err == nil {
+		t.Error("New() expected error for an invalid on_failure")
+	}
+}
+
+func TestRun_FailurePolicies(t *testing.T) {
+	failure := errors.New("dbt run failed")
+
+	t.Run("stop", func(t *testing.T) {
+		var ran []string
+		p, _ := New([]Stage{stage("models", &ran, failure), stage("tests", &ran, nil, "models"), stage("report", &ran, nil)}, OnFailureStop)
+		result := p.Run(context.Background())
+
+		if result.Success || result.ExitCode != 1 || !strings.Contains(result.Error, "models: dbt run failed") {
+			t.Errorf("result = %+v", result)
+		}
+		want := map[string]StageStatus{"models": StageFailed, "tests": StageSkipped, "report": StageSkipped}
+		if got := statuses(result); !reflect.DeepEqual(got, want) || len(ran) != 1 {
+			t.Errorf("statuses = %v, ran %v", got, ran)
+		}
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		var ran []string
+		p, _ := New([]Stage{stage("models", &ran, failure), stage("tests", &ran, nil, "models"), stage("report", &ran, nil)}, OnFailureContinue)
+		result := p.Run(context.Background())
+
+		want := map[string]StageStatus{"models": StageFailed, "tests": StageSkipped, "report": StageSucceeded}
+		if got := statuses(result); !reflect.DeepEqual(got, want) {
+			t.Errorf("statuses = %v, want %v", got, want)
+		}
+		if result.Success {
+			t.Error("Success = true with a failed stage")
+		}
+	})
+}
+
+func TestRun_Retries(t *testing.T) {
+	attempts := 0
+	flaky := Stage{
+		Name:    "models",
+		Type:    types.PluginTypeTransform,
+		Retries: 2,
+		Run: func(context.Context) (*types.PluginResult, error) {
+			attempts++
+			if attempts < 3 {
+				return nil, errors.New("throttled")
+			}
+			return &types.PluginResult{Success: true, Message: "ok"}, nil
+		},
+	}
+
+	p, _ := New([]Stage{flaky}, "")
+	result := p.Run(context.Background())
+	stages := StageResults(result)
+	if !result.Success || attempts != 3 || stages[0].Attempts != 3 || stages[0].Error != "" {
+		t.Errorf("result = %+v, stages = %+v, attempts = %d", result, stages, attempts)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	slow := Stage{
+		Name:    "models",
+		Type:    types.PluginTypeTransform,
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) (*types.PluginResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	p, _ := New([]Stage{slow}, "")
+	stages := StageResults(p.Run(context.Background()))
+	if stages[0].Status != StageFailed || !strings.Contains(stages[0].Error, "timed out after 10ms") {
+		t.Errorf("stage = %+v, want a timeout failure", stages[0])
+	}
+}
+
+func TestRun_Interrupted(t *testing.T) {
+	var ran []string
+	p, _ := New([]Stage{stage("models", &ran, nil)}, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := p.Run(ctx)
+	if result.Success || len(ran) != 0 || statuses(result)["models"] != StageSkipped {
+		t.Errorf("result = %+v, ran %v", result, ran)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var ran []string
+	p, _ := New([]Stage{stage("models", &ran, nil)}, "")
+	path := filepath.Join(t.TempDir(), "output", "pipeline-report.json")
+
+	if err := WriteReport(p.Run(context.Background()), path); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var report struct {
+		Success  bool `json:"success"`
+		Metadata struct {
+			Stages []StageResult `json:"stages"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("invalid report JSON: %v", err)
+	}
+	if !report.Success || len(report.Metadata.Stages) != 1 || report.Metadata.Stages[0].Message != "models done" {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -0,0 +1,215 @@
+// Package query runs SQL against the engine holding the models (Amazon Athena).
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenaTypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// ErrStop is returned by a results page callback to stop reading the results
+var ErrStop = errors.New("stop reading query results")
+
+// ErrScanLimit is returned when a query is stopped for scanning more than MaxScanBytes
+var ErrScanLimit = errors.New("query stopped at the scan limit")
+
+// Querier runs a SQL query and returns its rows, without the header row
+type Querier interface {
+	Query(ctx context.Context, sql string) ([][]string, error)
+}
+
+// Executor runs a query and passes its results to page one results page at a time
+type Executor interface {
+	Execute(ctx context.Context, sql string, page func(columns []Column, rows [][]string) error) (*Execution, error)
+}
+
+// AthenaAPI is the subset of the Athena client used to run queries
+type AthenaAPI interface {
+	StartQueryExecution(ctx context.Context, in *athena.StartQueryExecutionInput, optFns ...func(*athena.Options)) (*athena.StartQueryExecutionOutput, error)
+	GetQueryExecution(ctx context.Context, in *athena.GetQueryExecutionInput, optFns ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error)
+	StopQueryExecution(ctx context.Context, in *athena.StopQueryExecutionInput, optFns ...func(*athena.Options)) (*athena.StopQueryExecutionOutput, error)
+	athena.GetQueryResultsAPIClient
+}
+
+// AthenaQuerier runs queries in an Athena workgroup
+type AthenaQuerier struct {
+	Client    AthenaAPI
+	Workgroup string
+	// Database is the schema unqualified table names resolve to. Empty uses the catalog's
+	// default database.
+	Database string
+	// OutputLocation is the S3 location of the query results, e.g. s3://bucket/verify/.
+	// Empty uses the workgroup's result location.
+	OutputLocation string
+	PollInterval   time.Duration
+	// PageSize bounds the rows fetched per results page; zero uses Athena's maximum
+	PageSize int32
+	// Progress, when set, is called after every status poll of a running query
+	Progress func(Status)
+	// MaxScanBytes, when set, stops a running query once it has scanned more data
+	MaxScanBytes int64
+}
+
+// Column is a result column and its Athena type, e.g. varchar or double
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Status is the state of a query execution
+type Status struct {
+	ID               string
+	State            string
+	DataScannedBytes int64
+	Elapsed          time.Duration
+	// OutputLocation is the S3 location of the results file
+	OutputLocation string
+}
+
+// Execution is a finished query
+type Execution struct {
+	ID               string
+	Columns          []Column
+	Rows             int
+	DataScannedBytes int64
+	Elapsed          time.Duration
+	// OutputLocation is the S3 location of the results file, s3://bucket/prefix/ID.csv
+	OutputLocation string
+}
+
+// Query runs sql and waits for its rows. Cancelling ctx stops the query.
+func (q *AthenaQuerier) Query(ctx context.Context, sql string) ([][]string, error) {
+	var rows [][]string
+	_, err := q.Execute(ctx, sql, func(_ []Column, page [][]string) error {
+		rows = append(rows, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Execute runs sql and passes its rows to page one results page at a time, without the
+// header row. NULL values are empty strings. Returning ErrStop from page stops reading
+// the results. Cancelling ctx stops the query.
+func (q *AthenaQuerier) Execute(ctx context.Context, sql string, page func(columns []Column, rows [][]string) error) (*Execution, error) {
+	in := &athena.StartQueryExecutionInput{
+		QueryString: aws.String(sql),
+		WorkGroup:   aws.String(q.Workgroup),
+	}
+	if q.Database != "" {
+		in.QueryExecutionContext = &athenaTypes.QueryExecutionContext{Database: aws.String(q.Database)}
+	}
+	if q.OutputLocation != "" {
+		in.ResultConfiguration = &athenaTypes.ResultConfiguration{OutputLocation: aws.String(q.OutputLocation)}
+	}
+	start, err := q.Client.StartQueryExecution(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start Athena query: %w", err)
+	}
+	id := start.QueryExecutionId
+
+	status, err := q.wait(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	exec := &Execution{ID: status.ID, DataScannedBytes: status.DataScannedBytes, Elapsed: status.Elapsed, OutputLocation: status.OutputLocation}
+
+	results := &athena.GetQueryResultsInput{QueryExecutionId: id}
+	if q.PageSize > 0 {
+		results.MaxResults = aws.Int32(q.PageSize)
+	}
+	paginator := athena.NewGetQueryResultsPaginator(q.Client, results)
+	header := true
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Athena query results: %w", err)
+		}
+		if out.ResultSet == nil {
+			continue
+		}
+		if exec.Columns == nil && out.ResultSet.ResultSetMetadata != nil {
+			for _, c := range out.ResultSet.ResultSetMetadata.ColumnInfo {
+				exec.Columns = append(exec.Columns, Column{Name: aws.ToString(c.Name), Type: aws.ToString(c.Type)})
+			}
+		}
+
+		rows := make([][]string, 0, len(out.ResultSet.Rows))
+		for _, row := range out.ResultSet.Rows {
+			if header {
+				header = false
+				continue
+			}
+			values := make([]string, len(row.Data))
+			for i, d := range row.Data {
+				values[i] = aws.ToString(d.VarCharValue)
+			}
+			rows = append(rows, values)
+		}
+		exec.Rows += len(rows)
+		if err := page(exec.Columns, rows); err != nil {
+			if errors.Is(err, ErrStop) {
+				break
+			}
+			return nil, err
+		}
+	}
+	return exec, nil
+}
+
+// wait polls the query until it finishes and returns its final status
+func (q *AthenaQuerier) wait(ctx context.Context, id *string) (Status, error) {
+	interval := q.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for {
+		out, err := q.Client.GetQueryExecution(ctx, &athena.GetQueryExecutionInput{QueryExecutionId: id})
+		if err != nil {
+			return Status{}, fmt.Errorf("failed to get Athena query status: %w", err)
+		}
+		execution := out.QueryExecution
+		status := Status{ID: aws.ToString(id), State: string(execution.Status.State)}
+		if stats := execution.Statistics; stats != nil {
+			status.DataScannedBytes = aws.ToInt64(stats.DataScannedInBytes)
+			status.Elapsed = time.Duration(aws.ToInt64(stats.TotalExecutionTimeInMillis)) * time.Millisecond
+		}
+		if result := execution.ResultConfiguration; result != nil {
+			status.OutputLocation = aws.ToString(result.OutputLocation)
+		}
+
+		switch execution.Status.State {
+		case athenaTypes.QueryExecutionStateSucceeded:
+			return status, nil
+		case athenaTypes.QueryExecutionStateFailed, athenaTypes.QueryExecutionStateCancelled:
+			return Status{}, fmt.Errorf("athena query %s %s: %s", aws.ToString(id), execution.Status.State, aws.ToString(execution.Status.StateChangeReason))
+		}
+		if q.Progress != nil {
+			q.Progress(status)
+		}
+		if q.MaxScanBytes > 0 && status.DataScannedBytes > q.MaxScanBytes {
+			q.stop(id)
+			return Status{}, fmt.Errorf("%w of %d bytes, %d scanned: narrow the query, e.g. to fewer billing periods", ErrScanLimit, q.MaxScanBytes, status.DataScannedBytes)
+		}
+
+		select {
+		case <-ctx.Done():
+			q.stop(id)
+			return Status{}, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// stop cancels a running query. Best effort: the query keeps running in Athena otherwise.
+func (q *AthenaQuerier) stop(id *string) {
+	_, _ = q.Client.StopQueryExecution(context.Background(), &athena.StopQueryExecutionInput{QueryExecutionId: id})
+}
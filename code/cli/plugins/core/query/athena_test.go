@@ -0,0 +1,128 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenaTypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// fakeAthena runs every query successfully and returns fixed rows
+type fakeAthena struct {
+	states  []athenaTypes.QueryExecutionState
+	rows    [][]string
+	columns []athenaTypes.ColumnInfo
+	input   *athena.StartQueryExecutionInput
+}
+
+func (f *fakeAthena) StartQueryExecution(_ context.Context, in *athena.StartQueryExecutionInput, _ ...func(*athena.Options)) (*athena.StartQueryExecutionOutput, error) {
+	f.input = in
+	return &athena.StartQueryExecutionOutput{QueryExecutionId: aws.String("q-1")}, nil
+}
+
+func (f *fakeAthena) GetQueryExecution(_ context.Context, _ *athena.GetQueryExecutionInput, _ ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error) {
+	state := f.states[0]
+	if len(f.states) > 1 {
+		f.states = f.states[1:]
+	}
+	return &athena.GetQueryExecutionOutput{QueryExecution: &athenaTypes.QueryExecution{
+		Status:     &athenaTypes.QueryExecutionStatus{State: state, StateChangeReason: aws.String("TABLE_NOT_FOUND")},
+		Statistics: &athenaTypes.QueryExecutionStatistics{DataScannedInBytes: aws.Int64(2048), TotalExecutionTimeInMillis: aws.Int64(1500)},
+	}}, nil
+}
+
+func (f *fakeAthena) StopQueryExecution(_ context.Context, _ *athena.StopQueryExecutionInput, _ ...func(*athena.Options)) (*athena.StopQueryExecutionOutput, error) {
+	return &athena.StopQueryExecutionOutput{}, nil
+}
+
+func (f *fakeAthena) GetQueryResults(_ context.Context, _ *athena.GetQueryResultsInput, _ ...func(*athena.Options)) (*athena.GetQueryResultsOutput, error) {
+	set := &athenaTypes.ResultSet{ResultSetMetadata: &athenaTypes.ResultSetMetadata{ColumnInfo: f.columns}}
+	for _, values := range f.rows {
+		row := athenaTypes.Row{}
+		for _, v := range values {
+			row.Data = append(row.Data, athenaTypes.Datum{VarCharValue: aws.String(v)})
+		}
+		set.Rows = append(set.Rows, row)
+	}
+	return &athena.GetQueryResultsOutput{ResultSet: set}, nil
+}
+
+func TestAthenaQuerier(t *testing.T) {
+	client := &fakeAthena{
+		states: []athenaTypes.QueryExecutionState{athenaTypes.QueryExecutionStateRunning, athenaTypes.QueryExecutionStateSucceeded},
+		rows:   [][]string{{"billing_period", "account", "cost"}, {"2025-01", "111111111111", "12.5"}},
+	}
+	q := &AthenaQuerier{Client: client, Workgroup: "demo-adhoc", OutputLocation: "s3://bucket/verify/", PollInterval: time.Millisecond}
+
+	rows, err := q.Query(context.Background(), "select 1")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if !reflect.DeepEqual(rows, [][]string{{"2025-01", "111111111111", "12.5"}}) {
+		t.Errorf("Query() = %v, want the rows without the header", rows)
+	}
+	if aws.ToString(client.input.WorkGroup) != "demo-adhoc" || aws.ToString(client.input.ResultConfiguration.OutputLocation) != "s3://bucket/verify/" {
+		t.Errorf("StartQueryExecution input = %+v", client.input)
+	}
+
+	client.states = []athenaTypes.QueryExecutionState{athenaTypes.QueryExecutionStateFailed}
+	if _, err := q.Query(context.Background(), "select 1"); err == nil || !strings.Contains(err.Error(), "TABLE_NOT_FOUND") {
+		t.Errorf("Query() error = %v, want the failure reason", err)
+	}
+
+	// A running query scanning more than the limit is stopped
+	client.states = []athenaTypes.QueryExecutionState{athenaTypes.QueryExecutionStateRunning, athenaTypes.QueryExecutionStateSucceeded}
+	q.MaxScanBytes = 1024
+	if _, err := q.Query(context.Background(), "select 1"); !errors.Is(err, ErrScanLimit) {
+		t.Errorf("Query() error = %v, want ErrScanLimit", err)
+	}
+}
+
+func TestAthenaQuerier_Execute(t *testing.T) {
+	client := &fakeAthena{
+		states: []athenaTypes.QueryExecutionState{athenaTypes.QueryExecutionStateQueued, athenaTypes.QueryExecutionStateRunning, athenaTypes.QueryExecutionStateSucceeded},
+		rows:   [][]string{{"service_name", "cost"}, {"Amazon EC2", "700"}, {"Amazon S3", "250"}},
+		columns: []athenaTypes.ColumnInfo{
+			{Name: aws.String("service_name"), Type: aws.String("varchar")},
+			{Name: aws.String("cost"), Type: aws.String("double")},
+		},
+	}
+	var states []string
+	q := &AthenaQuerier{Client: client, Workgroup: "demo-adhoc", Database: "ecos", PollInterval: time.Millisecond, Progress: func(s Status) {
+		states = append(states, s.State)
+	}}
+
+	var pages [][][]string
+	exec, err := q.Execute(context.Background(), "select 1", func(columns []Column, rows [][]string) error {
+		pages = append(pages, rows)
+		return ErrStop
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if aws.ToString(client.input.QueryExecutionContext.Database) != "ecos" {
+		t.Errorf("query execution context = %+v, want the ecos database", client.input.QueryExecutionContext)
+	}
+	if !reflect.DeepEqual(states, []string{"QUEUED", "RUNNING"}) {
+		t.Errorf("progress states = %v", states)
+	}
+	want := &Execution{
+		ID:               "q-1",
+		Columns:          []Column{{Name: "service_name", Type: "varchar"}, {Name: "cost", Type: "double"}},
+		Rows:             2,
+		DataScannedBytes: 2048,
+		Elapsed:          1500 * time.Millisecond,
+	}
+	if !reflect.DeepEqual(exec, want) {
+		t.Errorf("Execute() = %+v, want %+v", exec, want)
+	}
+	if len(pages) != 1 || len(pages[0]) != 2 {
+		t.Errorf("pages = %v, want one page of two rows", pages)
+	}
+}
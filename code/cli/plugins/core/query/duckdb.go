@@ -0,0 +1,129 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DuckDBQuerier runs queries with the duckdb CLI against a local database file, e.g. a
+// project built with dbt-duckdb. The database is opened read-only, in safe mode and
+// without access to external files.
+type DuckDBQuerier struct {
+	// Binary is the duckdb executable; empty looks it up in PATH
+	Binary string
+	Path   string
+}
+
+// Query runs sql and returns its rows
+func (q *DuckDBQuerier) Query(ctx context.Context, sql string) ([][]string, error) {
+	var rows [][]string
+	_, err := q.Execute(ctx, sql, func(_ []Column, page [][]string) error {
+		rows = append(rows, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Execute runs sql and passes its rows to page as one page. NULL values are empty
+// strings, like in Athena results.
+func (q *DuckDBQuerier) Execute(ctx context.Context, sql string, page func(columns []Column, rows [][]string) error) (*Execution, error) {
+	started := time.Now()
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+
+	// DESCRIBE returns the result columns and their types, which the JSON output lacks
+	described, err := q.run(ctx, "describe "+sql)
+	if err != nil {
+		return nil, err
+	}
+	exec := &Execution{}
+	for _, c := range described {
+		name, _ := c["column_name"].(string)
+		typ, _ := c["column_type"].(string)
+		exec.Columns = append(exec.Columns, Column{Name: name, Type: strings.ToLower(typ)})
+	}
+
+	results, err := q.run(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		rows[i] = make([]string, len(exec.Columns))
+		for j, c := range exec.Columns {
+			rows[i][j] = duckDBValue(result[c.Name])
+		}
+	}
+	exec.Rows = len(rows)
+	exec.Elapsed = time.Since(started)
+	if err := page(exec.Columns, rows); err != nil && !errors.Is(err, ErrStop) {
+		return nil, err
+	}
+	return exec, nil
+}
+
+// run executes one statement and decodes its JSON output, one object per row
+func (q *DuckDBQuerier) run(ctx context.Context, sql string) ([]map[string]any, error) {
+	binary := q.Binary
+	if binary == "" {
+		binary = "duckdb"
+	}
+	// The statement is prefixed so -c never reads it as a dot command
+	cmd := exec.CommandContext(ctx, binary, "-readonly", "-safe", "-json", q.Path, "-c", "set enable_external_access = false; "+sql) // #nosec G204
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("duckdb query failed: %s", msg)
+		}
+		return nil, fmt.Errorf("duckdb query failed: %w", err)
+	}
+
+	// Statements without rows print nothing
+	var rows []map[string]any
+	decoder := json.NewDecoder(&stdout)
+	decoder.UseNumber()
+	for {
+		var result []map[string]any
+		if err := decoder.Decode(&result); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read duckdb results: %w", err)
+		}
+		rows = append(rows, result...)
+	}
+	return rows, nil
+}
+
+// duckDBValue formats a JSON value the way Athena returns it
+func duckDBValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
@@ -0,0 +1,72 @@
+package query
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeDuckDB writes a script standing in for the duckdb CLI. It prints the columns of
+// DESCRIBE statements and two rows otherwise, and records its arguments.
+func fakeDuckDB(t *testing.T) (binary, argsFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile = filepath.Join(dir, "args")
+	binary = filepath.Join(dir, "duckdb")
+	script := `#!/bin/sh
+echo "$@" >> ` + argsFile + `
+case "$6" in
+*"; describe "*)
+	echo '[{"column_name":"service_name","column_type":"VARCHAR","null":"YES"},'
+	echo '{"column_name":"cost","column_type":"DECIMAL(38,10)","null":"YES"}]'
+	;;
+*fail*)
+	echo 'Catalog Error: Table with name fail does not exist!' >&2
+	exit 1
+	;;
+*)
+	echo '[{"service_name":"Amazon EC2","cost":700.5},'
+	echo '{"service_name":"Amazon S3","cost":null}]'
+	;;
+esac
+`
+	if err := os.WriteFile(binary, []byte(script), 0o700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	return binary, argsFile
+}
+
+func TestDuckDBQuerier(t *testing.T) {
+	binary, argsFile := fakeDuckDB(t)
+	q := &DuckDBQuerier{Binary: binary, Path: "ecos.duckdb"}
+
+	var columns []Column
+	exec, err := q.Execute(context.Background(), "select * from serve_core__cost_service_account_daily;", func(c []Column, _ [][]string) error {
+		columns = c
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := []Column{{Name: "service_name", Type: "varchar"}, {Name: "cost", Type: "decimal(38,10)"}}
+	if !reflect.DeepEqual(columns, want) || exec.Rows != 2 {
+		t.Errorf("Execute() columns = %v, rows = %d", columns, exec.Rows)
+	}
+
+	rows, err := q.Query(context.Background(), "select 1")
+	if err != nil || !reflect.DeepEqual(rows, [][]string{{"Amazon EC2", "700.5"}, {"Amazon S3", ""}}) {
+		t.Errorf("Query() = %v, %v", rows, err)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if !strings.HasPrefix(string(args), "-readonly -safe -json ecos.duckdb -c set enable_external_access = false; describe select * from serve_core__cost_service_account_daily\n") {
+		t.Errorf("duckdb arguments = %s", args)
+	}
+
+	if _, err := q.Query(context.Background(), "select * from fail"); err == nil || !strings.Contains(err.Error(), "Table with name fail") {
+		t.Errorf("Query() error = %v, want the duckdb error", err)
+	}
+}
@@ -0,0 +1,247 @@
+package query
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+// Format identifies a query results output format
+type Format string
+
+const (
+	// FormatTable prints the rows as an aligned table
+	FormatTable Format = "table"
+	// FormatCSV writes a header row and one record per row
+	FormatCSV Format = "csv"
+	// FormatJSON writes an array with one object per row
+	FormatJSON Format = "json"
+	// FormatParquet writes a snappy-compressed Parquet file
+	FormatParquet Format = "parquet"
+)
+
+// SupportedFormats lists the formats accepted by ParseFormat
+var SupportedFormats = []Format{FormatTable, FormatCSV, FormatJSON, FormatParquet}
+
+// ParseFormat validates a user-supplied format name
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(value))); f {
+	case FormatTable, FormatCSV, FormatJSON, FormatParquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported output format '%s', must be one of: %v", value, SupportedFormats)
+	}
+}
+
+// ResultWriter writes query results as they are read, one page at a time
+type ResultWriter interface {
+	Write(rows [][]string) error
+	// Close flushes the output; it does not close the underlying writer
+	Close() error
+}
+
+// NewResultWriter returns a writer of the columns' rows in a file format. Table output
+// is printed by the caller.
+func NewResultWriter(w io.Writer, format Format, columns []Column) (ResultWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatJSON:
+		return &jsonWriter{w: w, columns: columns}, nil
+	case FormatParquet:
+		return newParquetWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("format '%s' cannot be written to a file", format)
+	}
+}
+
+// Column kinds by Athena type; other types, e.g. dates, arrays and maps, are written as
+// their string representation
+const (
+	kindString = iota
+	kindInteger
+	kindDouble
+	kindBoolean
+)
+
+func columnKind(athenaType string) int {
+	base, _, _ := strings.Cut(strings.ToLower(athenaType), "(")
+	switch base {
+	case "tinyint", "smallint", "integer", "int", "bigint":
+		return kindInteger
+	case "float", "real", "double", "decimal":
+		return kindDouble
+	case "boolean":
+		return kindBoolean
+	default:
+		return kindString
+	}
+}
+
+// Value converts a result value to the Go value of the column type: int64, float64,
+// bool or string. Empty non-string values are NULL and return nil.
+func (c Column) Value(s string) (any, error) {
+	kind := columnKind(c.Type)
+	if s == "" && kind != kindString {
+		return nil, nil
+	}
+	switch kind {
+	case kindInteger:
+		return strconv.ParseInt(s, 10, 64)
+	case kindDouble:
+		return strconv.ParseFloat(s, 64)
+	case kindBoolean:
+		return strconv.ParseBool(s)
+	default:
+		return s, nil
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(rows [][]string) error {
+	return c.w.WriteAll(rows)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonWriter streams a JSON array whose objects keep the column order
+type jsonWriter struct {
+	w       io.Writer
+	columns []Column
+	rows    int
+}
+
+func (j *jsonWriter) Write(rows [][]string) error {
+	var b strings.Builder
+	for _, row := range rows {
+		if j.rows == 0 {
+			b.WriteString("[\n  {")
+		} else {
+			b.WriteString(",\n  {")
+		}
+		j.rows++
+
+		for i, c := range j.columns {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(c.Name)
+			b.Write(key)
+			b.WriteByte(':')
+
+			var s string
+			if i < len(row) {
+				s = row[i]
+			}
+			v, err := c.Value(s)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", c.Name, err)
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", c.Name, err)
+			}
+			b.Write(data)
+		}
+		b.WriteByte('}')
+	}
+	_, err := io.WriteString(j.w, b.String())
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.rows == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type parquetWriter struct {
+	w       *parquet.GenericWriter[map[string]any]
+	columns []Column
+}
+
+// newParquetWriter maps integer, floating point and boolean columns to their Parquet
+// types and everything else to strings. Every column is optional.
+func newParquetWriter(w io.Writer, columns []Column) (*parquetWriter, error) {
+	group := parquet.Group{}
+	for _, c := range columns {
+		if _, ok := group[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate column '%s': Parquet needs unique column names", c.Name)
+		}
+		var node parquet.Node
+		switch columnKind(c.Type) {
+		case kindInteger:
+			node = parquet.Int(64)
+		case kindDouble:
+			node = parquet.Leaf(parquet.DoubleType)
+		case kindBoolean:
+			node = parquet.Leaf(parquet.BooleanType)
+		default:
+			node = parquet.String()
+		}
+		group[c.Name] = parquet.Optional(node)
+	}
+	if len(group) == 0 {
+		return nil, errors.New("the query returned no columns")
+	}
+
+	schema := parquet.NewSchema("query", group)
+	return &parquetWriter{
+		w:       parquet.NewGenericWriter[map[string]any](w, schema, parquet.Compression(&snappy.Codec{})),
+		columns: columns,
+	}, nil
+}
+
+func (p *parquetWriter) Write(rows [][]string) error {
+	records := make([]map[string]any, len(rows))
+	for r, row := range rows {
+		record := make(map[string]any, len(p.columns))
+		for i, c := range p.columns {
+			if i >= len(row) {
+				break
+			}
+			v, err := c.Value(row[i])
+			if err != nil {
+				return fmt.Errorf("column %s: %w", c.Name, err)
+			}
+			if v != nil {
+				record[c.Name] = v
+			}
+		}
+		records[r] = record
+	}
+	_, err := p.w.Write(records)
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
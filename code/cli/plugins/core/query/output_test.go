@@ -0,0 +1,118 @@
+package query
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+var testColumns = []Column{
+	{Name: "service_name", Type: "varchar"},
+	{Name: "line_items", Type: "bigint"},
+	{Name: "cost", Type: "decimal(38,10)"},
+	{Name: "shared", Type: "boolean"},
+}
+
+// writeResults writes rows in two pages, as read from Athena
+func writeResults(t *testing.T, format Format, columns []Column, rows [][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewResultWriter(&buf, format, columns)
+	if err != nil {
+		t.Fatalf("NewResultWriter() error = %v", err)
+	}
+	half := len(rows) / 2
+	if err := w.Write(rows[:half]); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Write(rows[half:]); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+var testRows = [][]string{
+	{"Amazon EC2", "42", "700.5", "false"},
+	{"Amazon \"S3\"", "", "", "true"},
+}
+
+func TestResultWriter_CSV(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeResults(t, FormatCSV, testColumns, testRows))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "service_name" || records[2][0] != `Amazon "S3"` {
+		t.Errorf("records = %v", records)
+	}
+}
+
+func TestResultWriter_JSON(t *testing.T) {
+	out := writeResults(t, FormatJSON, testColumns, testRows)
+	if !strings.HasPrefix(string(out), `[`+"\n"+`  {"service_name":"Amazon EC2","line_items":42,"cost":700.5,"shared":false}`) {
+		t.Errorf("json does not keep the column order or types:\n%s", out)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(decoded) != 2 || decoded[1]["cost"] != nil || decoded[1]["shared"] != true {
+		t.Errorf("decoded = %v, want empty numbers as null", decoded)
+	}
+
+	if out := writeResults(t, FormatJSON, testColumns, nil); string(out) != "[]\n" {
+		t.Errorf("empty results = %q, want an empty array", out)
+	}
+	w, _ := NewResultWriter(&bytes.Buffer{}, FormatJSON, testColumns)
+	if err := w.Write([][]string{{"x", "many", "1", "true"}}); err == nil {
+		t.Error("Write() expected error for a malformed bigint")
+	}
+}
+
+func TestResultWriter_Parquet(t *testing.T) {
+	out := writeResults(t, FormatParquet, testColumns, testRows)
+
+	file, err := parquet.OpenFile(bytes.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("invalid Parquet: %v", err)
+	}
+	if file.NumRows() != 2 {
+		t.Errorf("NumRows() = %d, want 2", file.NumRows())
+	}
+	reader := parquet.NewGenericReader[map[string]any](file, file.Schema())
+	defer reader.Close()
+	rows := make([]map[string]any, 2)
+	for i := range rows {
+		rows[i] = map[string]any{}
+	}
+	if n, _ := reader.Read(rows); n != 2 {
+		t.Fatalf("Read() = %d rows, want 2", n)
+	}
+	if rows[0]["line_items"] != int64(42) || rows[0]["cost"] != 700.5 || rows[1]["cost"] != nil || rows[1]["shared"] != true {
+		t.Errorf("rows = %v", rows)
+	}
+
+	duplicate := []Column{{Name: "cost", Type: "double"}, {Name: "cost", Type: "double"}}
+	if _, err := NewResultWriter(&bytes.Buffer{}, FormatParquet, duplicate); err == nil {
+		t.Error("NewResultWriter() expected error for duplicate column names")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(" Parquet "); err != nil || f != FormatParquet {
+		t.Errorf("ParseFormat(Parquet) = %v, %v", f, err)
+	}
+	if _, err := ParseFormat("xlsx"); err == nil {
+		t.Error("ParseFormat() expected error for xlsx")
+	}
+	if _, err := NewResultWriter(&bytes.Buffer{}, FormatTable, testColumns); err == nil {
+		t.Error("NewResultWriter() expected error for table output")
+	}
+}
@@ -0,0 +1,86 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SavedQueriesDir is the directory of the model package holding the saved queries
+const SavedQueriesDir = "queries"
+
+// SavedQuery is a named query shipped with the model package. Its name is the file name
+// without the .sql extension; the description is the first comment line.
+type SavedQuery struct {
+	Name        string
+	Description string
+	Path        string
+	SQL         string
+}
+
+// LoadSaved reads the .sql files under dir, sorted by name. A missing directory has no
+// saved queries.
+func LoadSaved(dir string) ([]SavedQuery, error) {
+	var queries []SavedQuery
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".sql" {
+			return nil
+		}
+		data, err := os.ReadFile(path) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to read saved query: %w", err)
+		}
+		name := strings.TrimSuffix(d.Name(), ".sql")
+		if i := slices.IndexFunc(queries, func(q SavedQuery) bool { return q.Name == name }); i >= 0 {
+			return fmt.Errorf("saved query '%s' is defined in both %s and %s", name, queries[i].Path, path)
+		}
+		queries = append(queries, SavedQuery{Name: name, Description: description(string(data)), Path: path, SQL: string(data)})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(queries, func(a, b SavedQuery) int { return strings.Compare(a.Name, b.Name) })
+	return queries, nil
+}
+
+// FindSaved returns the saved query of that name in dir. Dashes match underscores, so
+// cost-by-service-last-month finds cost_by_service_last_month.sql.
+func FindSaved(dir, name string) (*SavedQuery, error) {
+	queries, err := LoadSaved(dir)
+	if err != nil {
+		return nil, err
+	}
+	want := strings.ReplaceAll(name, "-", "_")
+	for _, q := range queries {
+		if q.Name == want {
+			return &q, nil
+		}
+	}
+	return nil, fmt.Errorf("no saved query named '%s' in %s (see 'ecos query --list')", name, dir)
+}
+
+// description returns the text of the first "--" comment line of the query
+func description(sql string) string {
+	for line := range strings.Lines(sql) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if text, ok := strings.CutPrefix(line, "--"); ok {
+			return strings.TrimSpace(text)
+		}
+		return ""
+	}
+	return ""
+}
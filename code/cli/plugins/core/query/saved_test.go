@@ -0,0 +1,46 @@
+package query
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSaved(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"aws/cur/monthly_cost_trend.sql":         "-- Monthly cost\nselect 1",
+		"aws/cur/cost_by_service_last_month.sql": "\n-- Cost by service last month\nselect 2",
+		"aws/cur/README.md":                      "not a query",
+		"untitled.sql":                           "select 3",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries, err := LoadSaved(dir)
+	if err != nil {
+		t.Fatalf("LoadSaved() error = %v", err)
+	}
+	if len(queries) != 3 || queries[0].Name != "cost_by_service_last_month" || queries[0].Description != "Cost by service last month" || queries[2].Description != "" {
+		t.Errorf("LoadSaved() = %+v", queries)
+	}
+
+	q, err := FindSaved(dir, "cost-by-service-last-month")
+	if err != nil || q.SQL != files["aws/cur/cost_by_service_last_month.sql"] {
+		t.Errorf("FindSaved() = %+v, %v", q, err)
+	}
+	if _, err := FindSaved(dir, "cost_by_team"); err == nil {
+		t.Error("FindSaved() expected error for an unknown query")
+	}
+
+	if queries, err := LoadSaved(filepath.Join(dir, "missing")); err != nil || queries != nil {
+		t.Errorf("LoadSaved() of a missing directory = %v, %v", queries, err)
+	}
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Format identifies a report output format
+type Format string
+
+const (
+	// FormatHTML renders a self-contained HTML page
+	FormatHTML Format = "html"
+	// FormatMarkdown renders Markdown tables
+	FormatMarkdown Format = "markdown"
+	// FormatCSV renders one row per section entry
+	FormatCSV Format = "csv"
+	// FormatJSON renders the report as indented JSON
+	FormatJSON Format = "json"
+)
+
+// SupportedFormats lists the formats accepted by ParseFormat
+var SupportedFormats = []Format{FormatHTML, FormatMarkdown, FormatCSV, FormatJSON}
+
+// ParseFormat validates a user-supplied format name. "md" is accepted as an alias.
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "html":
+		return FormatHTML, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "csv":
+		return FormatCSV, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported report format '%s', must be one of: %v", value, SupportedFormats)
+	}
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	if f == FormatMarkdown {
+		return "md"
+	}
+	return string(f)
+}
+
+// Filename returns the name of the report file for the billing period
+func Filename(period string, format Format) string {
+	return fmt.Sprintf("cost-report-%s.%s", period, format.Extension())
+}
+
+// Render writes the report in the given format
+func Render(w io.Writer, r *Report, format Format) error {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case FormatCSV:
+		return renderCSV(w, r)
+	case FormatMarkdown:
+		tmpl, err := template.New("report.md.tmpl").Funcs(withTemplateFuncs(sprig.TxtFuncMap())).ParseFS(templateFS, "templates/report.md.tmpl")
+		if err != nil {
+			return fmt.Errorf("failed to parse markdown template: %w", err)
+		}
+		return tmpl.Execute(w, r)
+	case FormatHTML:
+		tmpl, err := htmltemplate.New("report.html.tmpl").Funcs(withTemplateFuncs(sprig.FuncMap())).ParseFS(templateFS, "templates/report.html.tmpl")
+		if err != nil {
+			return fmt.Errorf("failed to parse html template: %w", err)
+		}
+		return tmpl.Execute(w, r)
+	default:
+		return fmt.Errorf("unsupported report format '%s'", format)
+	}
+}
+
+// renderCSV writes one row per summary, mover and opportunity entry
+func renderCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"billing_period", "section", "name", "cost", "previous_cost", "change", "pct_change", "potential_savings", "accounts"})
+
+	row := func(section string, m Mover) []string {
+		return []string{r.BillingPeriod, section, m.Name, number(m.Cost), number(m.PreviousCost), number(m.Change), pctValue(m.PctChange), "", ""}
+	}
+	_ = cw.Write(row("total", Mover{Name: "Total", Cost: r.Summary.Cost, PreviousCost: r.Summary.PreviousCost, Change: r.Summary.Change, PctChange: r.Summary.PctChange}))
+	for _, section := range []struct {
+		name   string
+		movers []Mover
+	}{
+		{"top_service", r.TopServices},
+		{"service_mover", r.ServiceMovers},
+		{"account_mover", r.AccountMovers},
+		{"tag_mover", r.TagMovers},
+	} {
+		for _, m := range section.movers {
+			_ = cw.Write(row(section.name, m))
+		}
+	}
+	for _, o := range r.Opportunities {
+		_ = cw.Write([]string{r.BillingPeriod, "opportunity", o.Name, "", "", "", "", number(o.Savings), strconv.Itoa(o.Accounts)})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// templateFuncs format costs and changes in the HTML and Markdown reports
+var templateFuncs = map[string]any{
+	"cost":   formatCost,
+	"change": func(v float64) string { return signed(v) },
+	"pct":    formatPct,
+	"up":     func(v float64) bool { return v > 0 },
+	"md":     func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+}
+
+// withTemplateFuncs adds templateFuncs to the sprig functions
+func withTemplateFuncs(funcs map[string]any) map[string]any {
+	maps.Copy(funcs, templateFuncs)
+	return funcs
+}
+
+// formatCost formats a cost with thousands separators and 2 decimals
+func formatCost(v float64) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', 2, 64)
+	whole, decimals, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	sign := ""
+	if v < 0 && s != "0.00" {
+		sign = "-"
+	}
+	return sign + b.String() + "." + decimals
+}
+
+func signed(v float64) string {
+	if v > 0 {
+		return "+" + formatCost(v)
+	}
+	return formatCost(v)
+}
+
+// formatPct formats a percentage change, "new" without a previous cost
+func formatPct(pct *float64) string {
+	if pct == nil {
+		return "new"
+	}
+	s := strconv.FormatFloat(*pct, 'f', 1, 64) + "%"
+	if *pct > 0 {
+		s = "+" + s
+	}
+	return s
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+func pctValue(pct *float64) string {
+	if pct == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*pct, 'f', 2, 64)
+}
@@ -0,0 +1,247 @@
+// Package report builds the monthly cost report of 'ecos report' from the serve and
+// gold models and renders it as HTML, Markdown, CSV or JSON.
+package report
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+)
+
+// DefaultTop is the number of services, accounts and tags listed per section
+const DefaultTop = 10
+
+// Report is the cost report of one billing period
+type Report struct {
+	ProjectName    string    `json:"project_name,omitempty"`
+	BillingPeriod  string    `json:"billing_period"`
+	PreviousPeriod string    `json:"previous_period"`
+	GeneratedAt    time.Time `json:"generated_at"`
+
+	Summary       Summary       `json:"summary"`
+	TopServices   []Mover       `json:"top_services"`
+	ServiceMovers []Mover       `json:"service_movers"`
+	AccountMovers []Mover       `json:"account_movers"`
+	TagMovers     []Mover       `json:"tag_movers"`
+	Opportunities []Opportunity `json:"opportunities"`
+
+	// Unavailable lists the optimization models that could not be queried, e.g. because
+	// they were not built
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// Summary is the total effective cost of the billing period
+type Summary struct {
+	Cost         float64  `json:"cost"`
+	PreviousCost float64  `json:"previous_cost"`
+	Change       float64  `json:"change"`
+	PctChange    *float64 `json:"pct_change"` // nil without a previous cost
+}
+
+// Mover is the effective cost of a service, account or tag in both billing periods
+type Mover struct {
+	Name         string   `json:"name"`
+	Cost         float64  `json:"cost"`
+	PreviousCost float64  `json:"previous_cost"`
+	Change       float64  `json:"change"`
+	PctChange    *float64 `json:"pct_change"` // nil without a previous cost
+}
+
+// Opportunity is the potential monthly savings found by an optimization model
+type Opportunity struct {
+	Name     string  `json:"name"`
+	Model    string  `json:"model"`
+	Savings  float64 `json:"savings"`
+	Accounts int     `json:"accounts"`
+}
+
+// opportunitySource is an optimization model and its savings column
+type opportunitySource struct {
+	name   string
+	model  string
+	column string
+}
+
+// opportunitySources are the gold models estimating savings per account and billing period
+var opportunitySources = []opportunitySource{
+	{"Stop EC2 instances outside working hours", "gold_compute__instance_type_account_monthly", "total_savings_mon_to_fri_work_hours"},
+	{"Move Lambda functions to Graviton", "gold_compute__lambda_summary_monthly", "potential_graviton_savings"},
+	{"Switch Aurora clusters to I/O-Optimized", "gold_database__aurora_io_optimization_monthly", "potential_savings"},
+	{"Change DynamoDB capacity modes", "gold_database__dynamodb_optimization_monthly", "potential_savings_per_month"},
+	{"Migrate ElastiCache to Valkey", "gold_database__elasticache_optimization_monthly", "potential_monthly_savings"},
+	{"Release idle public IPv4 addresses", "gold_network__ipv4_monthly", "potential_cleanup_savings"},
+	{"Migrate EBS gp2 volumes to gp3", "gold_storage__ebs_summary_monthly", "gp2_to_gp3_savings_potential"},
+	{"Tier S3 Standard storage", "gold_storage__s3_summary_monthly", "savings_potential_standard_storage"},
+}
+
+// Build queries the models in database for the billing period (YYYY-MM) and the one
+// before it. top bounds the rows of every ranked section.
+func Build(ctx context.Context, q query.Querier, database, period string, top int) (*Report, error) {
+	month, err := config.ParseBillingPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if top <= 0 {
+		top = DefaultTop
+	}
+	r := &Report{
+		BillingPeriod:  period,
+		PreviousPeriod: month.AddDate(0, -1, 0).Format(config.BillingPeriodLayout),
+		GeneratedAt:    time.Now().UTC(),
+	}
+	periods := fmt.Sprintf("billing_period in ('%s', '%s')", r.PreviousPeriod, r.BillingPeriod)
+	serve := relation(database, "serve_core__cost_service_account_daily")
+
+	services, err := costsByPeriod(ctx, q, r, fmt.Sprintf(
+		"select service_name, billing_period, sum(total_effective_cost) from %s where %s group by 1, 2", serve, periods))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service costs: %w", err)
+	}
+	accounts, err := costsByPeriod(ctx, q, r, fmt.Sprintf(
+		"select case when max(account_name) in ('', 'unknown') then account_id else max(account_name) || ' (' || account_id || ')' end, billing_period, sum(total_effective_cost) from %s where %s group by account_id, billing_period",
+		serve, periods))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account costs: %w", err)
+	}
+	tags, err := costsByPeriod(ctx, q, r, fmt.Sprintf(
+		"select tag_key || '=' || coalesce(nullif(tag_value, ''), '(empty)'), billing_period, sum(total_effective_cost) from %s where %s group by 1, 2",
+		relation(database, "gold_tag__tag_cost_monthly"), periods))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag costs: %w", err)
+	}
+
+	for _, m := range services {
+		r.Summary.Cost += m.Cost
+		r.Summary.PreviousCost += m.PreviousCost
+	}
+	r.Summary.Change = r.Summary.Cost - r.Summary.PreviousCost
+	r.Summary.PctChange = pctChange(r.Summary.Cost, r.Summary.PreviousCost)
+
+	r.TopServices = topBy(services, top, func(m Mover) float64 { return m.Cost })
+	r.ServiceMovers = topBy(services, top, func(m Mover) float64 { return math.Abs(m.Change) })
+	r.AccountMovers = topBy(accounts, top, func(m Mover) float64 { return math.Abs(m.Change) })
+	r.TagMovers = topBy(tags, top, func(m Mover) float64 { return math.Abs(m.Change) })
+
+	if r.Opportunities, r.Unavailable, err = Opportunities(ctx, q, database, period, ""); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Opportunities queries the potential savings of the optimization models in database for
+// the billing period, largest first, for one account unless account is empty. It also
+// returns the models that could not be queried, e.g. because they were not built.
+func Opportunities(ctx context.Context, q query.Querier, database, period, account string) ([]Opportunity, []string, error) {
+	where := fmt.Sprintf("billing_period = '%s'", period)
+	if account != "" {
+		where += fmt.Sprintf(" and account_id = '%s'", strings.ReplaceAll(account, "'", "''"))
+	}
+
+	var opportunities []Opportunity
+	var unavailable []string
+	for _, s := range opportunitySources {
+		rows, err := q.Query(ctx, fmt.Sprintf(
+			"select sum(greatest(%[1]s, 0)), count(distinct case when %[1]s > 0 then account_id end) from %[2]s where %[3]s",
+			s.column, relation(database, s.model), where))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+			unavailable = append(unavailable, s.model)
+			continue
+		}
+		o := Opportunity{Name: s.name, Model: s.model}
+		if len(rows) == 1 && len(rows[0]) == 2 {
+			o.Savings = parseCost(rows[0][0])
+			o.Accounts, _ = strconv.Atoi(rows[0][1])
+		}
+		if o.Savings > 0 {
+			opportunities = append(opportunities, o)
+		}
+	}
+	slices.SortStableFunc(opportunities, func(a, b Opportunity) int { return cmp.Compare(b.Savings, a.Savings) })
+	return opportunities, unavailable, nil
+}
+
+func relation(schema, table string) string {
+	return fmt.Sprintf("%q.%q", schema, table)
+}
+
+// costsByPeriod runs a query returning name, billing period and cost rows and pairs the
+// costs of both billing periods per name
+func costsByPeriod(ctx context.Context, q query.Querier, r *Report, sql string) ([]Mover, error) {
+	rows, err := q.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*Mover{}
+	var names []string
+	for _, row := range rows {
+		if len(row) != 3 {
+			return nil, fmt.Errorf("unexpected result row %v", row)
+		}
+		m, ok := byName[row[0]]
+		if !ok {
+			m = &Mover{Name: row[0]}
+			byName[row[0]] = m
+			names = append(names, row[0])
+		}
+		switch row[1] {
+		case r.BillingPeriod:
+			m.Cost += parseCost(row[2])
+		case r.PreviousPeriod:
+			m.PreviousCost += parseCost(row[2])
+		}
+	}
+
+	movers := make([]Mover, 0, len(names))
+	for _, name := range names {
+		m := byName[name]
+		m.Change = m.Cost - m.PreviousCost
+		m.PctChange = pctChange(m.Cost, m.PreviousCost)
+		movers = append(movers, *m)
+	}
+	return movers, nil
+}
+
+// topBy returns the top movers by key, ties ordered by name
+func topBy(movers []Mover, top int, key func(Mover) float64) []Mover {
+	sorted := slices.Clone(movers)
+	slices.SortFunc(sorted, func(a, b Mover) int {
+		if c := cmp.Compare(key(b), key(a)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(sorted) > top {
+		sorted = sorted[:top]
+	}
+	return sorted
+}
+
+func pctChange(cost, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	pct := (cost - previous) / math.Abs(previous) * 100
+	return &pct
+}
+
+// parseCost parses a cost returned by the engine; NULL sums are zero
+func parseCost(value string) float64 {
+	cost, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return cost
+}
@@ -0,0 +1,176 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// answer is the result of the queries containing match
+type answer struct {
+	match string
+	rows  [][]string
+	err   error
+}
+
+// fakeQuerier returns the first answer matching each query
+type fakeQuerier struct {
+	answers []answer
+}
+
+func (f *fakeQuerier) Query(_ context.Context, sql string) ([][]string, error) {
+	for _, a := range f.answers {
+		if strings.Contains(sql, a.match) {
+			return a.rows, a.err
+		}
+	}
+	return nil, nil
+}
+
+func testQuerier() *fakeQuerier {
+	return &fakeQuerier{answers: []answer{
+		// service and account costs share the serve model; only the account query groups by account_id
+		{match: "group by account_id", rows: [][]string{
+			{"prod (111111111111)", "2025-06", "800"},
+			{"prod (111111111111)", "2025-05", "600"},
+			{"222222222222", "2025-06", "200"},
+			{"222222222222", "2025-05", "250"},
+		}},
+		{match: "serve_core__cost_service_account_daily", rows: [][]string{
+			{"Amazon EC2", "2025-06", "700"},
+			{"Amazon EC2", "2025-05", "500"},
+			{"Amazon S3", "2025-06", "250"},
+			{"Amazon S3", "2025-05", "350"},
+			{"Amazon Bedrock", "2025-06", "50"},
+		}},
+		{match: "gold_tag__tag_cost_monthly", rows: [][]string{
+			{"team=data", "2025-06", "400"},
+			{"team=data", "2025-05", "100"},
+		}},
+		{match: "gold_storage__ebs_summary_monthly", rows: [][]string{{"120.5", "3"}}},
+		{match: "gold_compute__lambda_summary_monthly", rows: [][]string{{"", "0"}}},
+		{match: "gold_database__elasticache_optimization_monthly", rows: [][]string{{"300", "1"}}},
+		{match: "gold_network__ipv4_monthly", err: errors.New("TABLE_NOT_FOUND")},
+	}}
+}
+
+func TestBuild(t *testing.T) {
+	r, err := Build(context.Background(), testQuerier(), "ecos", "2025-06", 2)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if r.PreviousPeriod != "2025-05" || r.Summary.Cost != 1000 || r.Summary.PreviousCost != 850 || r.Summary.Change != 150 {
+		t.Errorf("summary = %+v, previous period %s", r.Summary, r.PreviousPeriod)
+	}
+
+	if len(r.TopServices) != 2 || r.TopServices[0].Name != "Amazon EC2" || r.TopServices[1].Name != "Amazon S3" {
+		t.Errorf("top services = %+v", r.TopServices)
+	}
+	// EC2 and S3 both moved by 200; ties are ordered by name
+	if r.ServiceMovers[0].Name != "Amazon EC2" || r.ServiceMovers[1].Change != -100 {
+		t.Errorf("service movers = %+v", r.ServiceMovers)
+	}
+	if r.AccountMovers[0].Name != "prod (111111111111)" || *r.AccountMovers[0].PctChange < 33.3 {
+		t.Errorf("account movers = %+v", r.AccountMovers)
+	}
+	if len(r.TagMovers) != 1 || r.TagMovers[0].Change != 300 {
+		t.Errorf("tag movers = %+v", r.TagMovers)
+	}
+
+	if len(r.Opportunities) != 2 || r.Opportunities[0].Model != "gold_database__elasticache_optimization_monthly" || r.Opportunities[1].Accounts != 3 {
+		t.Errorf("opportunities = %+v", r.Opportunities)
+	}
+	if len(r.Unavailable) != 1 || r.Unavailable[0] != "gold_network__ipv4_monthly" {
+		t.Errorf("unavailable = %v", r.Unavailable)
+	}
+
+	if _, err := Build(context.Background(), testQuerier(), "ecos", "2025-6", 2); err == nil {
+		t.Error("Build() expected error for a malformed billing period")
+	}
+}
+
+func TestBuild_NewService(t *testing.T) {
+	r, _ := Build(context.Background(), testQuerier(), "ecos", "2025-06", 10)
+	for _, m := range r.TopServices {
+		if m.Name == "Amazon Bedrock" && m.PctChange != nil {
+			t.Errorf("Bedrock pct change = %v, want nil for a new service", *m.PctChange)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r, _ := Build(context.Background(), testQuerier(), "ecos", "2025-06", 5)
+	r.ProjectName = "acme"
+	r.GeneratedAt = time.Date(2025, 7, 2, 8, 0, 0, 0, time.UTC)
+	r.AccountMovers[0].Name = "a|b <script>"
+
+	tests := []struct {
+		format Format
+		check  func(t *testing.T, out string)
+	}{
+		{FormatHTML, func(t *testing.T, out string) {
+			if !strings.Contains(out, "<title>acme Cost Report 2025-06</title>") || !strings.Contains(out, "1,000.00") {
+				t.Errorf("html report missing title or total:\n%s", out)
+			}
+			if strings.Contains(out, "<script>") {
+				t.Error("html report does not escape names")
+			}
+		}},
+		{FormatMarkdown, func(t *testing.T, out string) {
+			if !strings.Contains(out, "# acme Cost Report 2025-06") || !strings.Contains(out, "| Total | 1,000.00 | 850.00 | +150.00 | +17.6% |") {
+				t.Errorf("markdown report:\n%s", out)
+			}
+			if !strings.Contains(out, `a\|b`) || !strings.Contains(out, "| Amazon Bedrock | 50.00 | 0.00 | +50.00 | new |") {
+				t.Errorf("markdown report rows:\n%s", out)
+			}
+		}},
+		{FormatCSV, func(t *testing.T, out string) {
+			records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid CSV: %v", err)
+			}
+			if records[1][1] != "total" || records[1][3] != "1000.0000" || records[len(records)-1][1] != "opportunity" {
+				t.Errorf("csv records = %v", records)
+			}
+		}},
+		{FormatJSON, func(t *testing.T, out string) {
+			var decoded Report
+			if err := json.Unmarshal([]byte(out), &decoded); err != nil || decoded.Summary.Cost != 1000 {
+				t.Errorf("json report = %+v, %v", decoded, err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, r, tt.format); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			tt.check(t, buf.String())
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("MD"); err != nil || f != FormatMarkdown || Filename("2025-06", f) != "cost-report-2025-06.md" {
+		t.Errorf("ParseFormat(MD) = %v, %v", f, err)
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("ParseFormat() expected error for pdf")
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	for v, want := range map[float64]string{0: "0.00", 999.999: "1,000.00", 1234567.891: "1,234,567.89", -1234.5: "-1,234.50", -0.001: "0.00"} {
+		if got := formatCost(v); got != want {
+			t.Errorf("formatCost(%v) = %s, want %s", v, got, want)
+		}
+	}
+}
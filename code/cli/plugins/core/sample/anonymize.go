@@ -0,0 +1,229 @@
+package sample
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// columnKind says how the anonymizer treats a column
+type columnKind int
+
+const (
+	kindNone columnKind = iota
+	kindAccountID
+	kindAccountName
+	kindResource
+	kindDescription
+	kindTag
+	kindCost
+)
+
+// Columns are matched on their lowercased name without separators, so CUR 2.0
+// (line_item_usage_account_id), legacy CUR (lineItem/UsageAccountId) and FOCUS
+// (SubAccountId) names share one entry
+var columnKinds = map[string]columnKind{
+	"billpayeraccountid":          kindAccountID,
+	"lineitemusageaccountid":      kindAccountID,
+	"billingaccountid":            kindAccountID,
+	"subaccountid":                kindAccountID,
+	"billpayeraccountname":        kindAccountName,
+	"lineitemusageaccountname":    kindAccountName,
+	"billingaccountname":          kindAccountName,
+	"subaccountname":              kindAccountName,
+	"lineitemresourceid":          kindResource,
+	"resourceid":                  kindResource,
+	"resourcename":                kindResource,
+	"billinvoiceid":               kindResource,
+	"savingsplansavingsplanarn":   kindResource,
+	"reservationreservationarn":   kindResource,
+	"commitmentdiscountid":        kindResource,
+	"commitmentdiscountname":      kindResource,
+	"lineitemlineitemdescription": kindDescription,
+	"chargedescription":           kindDescription,
+	"resourcetags":                kindTag,
+	"tags":                        kindTag,
+	"costcategory":                kindTag,
+	"xcostcategories":             kindTag,
+}
+
+var (
+	accountPattern = regexp.MustCompile(`^[0-9]{12}$`)
+	awsIDPattern   = regexp.MustCompile(`^([a-z][a-z0-9]{0,9}-)([0-9a-f]{8,17})$`)
+	// identifierPattern finds ARNs, AWS resource IDs and account IDs in free text
+	identifierPattern = regexp.MustCompile(`arn:[a-z-]+:[a-z0-9-]*:[a-z0-9-]*:[0-9]*:[^\s,;()"']+|\b[a-z][a-z0-9]{0,9}-[0-9a-f]{8,17}\b|\b[0-9]{12}\b`)
+	columnSeparators  = strings.NewReplacer("_", "", "/", "", "-", "")
+)
+
+// Anonymizer consistently replaces identifying values with keyed pseudonyms. The
+// same key always maps a value to the same pseudonym, so account IDs still join
+// across files and resource IDs inside ARNs match the plain resource ID columns.
+type Anonymizer struct {
+	key   []byte
+	scale float64
+}
+
+// NewAnonymizer returns an anonymizer keyed with key that multiplies costs by scale
+func NewAnonymizer(key []byte, scale float64) (*Anonymizer, error) {
+	if len(key) == 0 {
+		return nil, errors.New("anonymization key must not be empty")
+	}
+	if scale <= 0 {
+		return nil, fmt.Errorf("invalid cost scale %v, must be greater than 0", scale)
+	}
+	return &Anonymizer{key: key, scale: scale}, nil
+}
+
+// classify returns how the column at path is treated. Only the values of map
+// columns are pseudonymized; tag keys are left as is so models can still group on
+// them. Flat legacy tag columns (resourceTags/user:Name) are tags as well.
+func classify(path []string) columnKind {
+	if len(path) == 0 {
+		return kindNone
+	}
+	name := strings.ToLower(path[0])
+	if strings.HasPrefix(name, "resourcetags/") || strings.HasPrefix(name, "costcategory/") {
+		return kindTag
+	}
+
+	normalized := columnSeparators.Replace(name)
+	if kind, ok := columnKinds[normalized]; ok {
+		if kind == kindTag && len(path) > 1 && path[len(path)-1] != "value" {
+			return kindNone
+		}
+		return kind
+	}
+	if isCostColumn(normalized) {
+		return kindCost
+	}
+	return kindNone
+}
+
+// isCostColumn matches cost, rate and price columns. Codes, IDs and descriptions of
+// fees or rates are strings that must not be touched.
+func isCostColumn(normalized string) bool {
+	for _, suffix := range []string{"id", "code", "description", "type", "unit", "category", "status"} {
+		if strings.HasSuffix(normalized, suffix) {
+			return false
+		}
+	}
+	if strings.HasSuffix(normalized, "rate") {
+		return true
+	}
+	for _, part := range []string{"cost", "price", "fee", "commitment", "discount"} {
+		if strings.Contains(normalized, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the anonymized value of a string column at path
+func (a *Anonymizer) String(path []string, value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch classify(path) {
+	case kindAccountID:
+		return a.AccountID(value)
+	case kindAccountName:
+		return a.token("account-", value)
+	case kindResource:
+		return a.Resource(value)
+	case kindDescription:
+		return a.Description(value)
+	case kindTag:
+		return a.token("tag-", value)
+	case kindCost:
+		// CUR 2.0 stores rates as strings
+		if a.scale == 1 {
+			return value
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return strconv.FormatFloat(f*a.scale, 'f', -1, 64)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// Number returns the scaled value of a numeric column at path
+func (a *Anonymizer) Number(path []string, value float64) float64 {
+	if classify(path) != kindCost {
+		return value
+	}
+	return value * a.scale
+}
+
+// AccountID maps a 12-digit account ID to another 12-digit account ID. Other values
+// are replaced with an opaque token.
+func (a *Anonymizer) AccountID(id string) string {
+	if !accountPattern.MatchString(id) {
+		return a.token("account-", id)
+	}
+	sum := a.sum("account:" + id)
+	return fmt.Sprintf("%012d", binary.BigEndian.Uint64(sum[:8])%1_000_000_000_000)
+}
+
+// Resource pseudonymizes a resource ID or ARN, keeping the parts models rely on:
+// the ARN partition, service, region and resource type, and the prefix of AWS IDs
+// such as i-0abc... or vol-0abc...
+func (a *Anonymizer) Resource(id string) string {
+	if strings.HasPrefix(id, "arn:") {
+		parts := strings.SplitN(id, ":", 6)
+		if len(parts) == 6 {
+			if parts[4] != "" {
+				parts[4] = a.AccountID(parts[4])
+			}
+			parts[5] = a.resourcePath(parts[5])
+			return strings.Join(parts, ":")
+		}
+	}
+	if m := awsIDPattern.FindStringSubmatch(id); m != nil {
+		return m[1] + a.hexOfLength(id, len(m[2]))
+	}
+	return a.token("resource-", id)
+}
+
+// resourcePath keeps the resource type of an ARN resource (instance/..., db:...)
+func (a *Anonymizer) resourcePath(resource string) string {
+	if i := strings.IndexAny(resource, "/:"); i > 0 {
+		return resource[:i+1] + a.Resource(resource[i+1:])
+	}
+	return a.Resource(resource)
+}
+
+// Description replaces the ARNs, account IDs and AWS resource IDs contained in a
+// line item description with the same pseudonyms as their own columns
+func (a *Anonymizer) Description(text string) string {
+	return identifierPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if accountPattern.MatchString(match) {
+			return a.AccountID(match)
+		}
+		return a.Resource(match)
+	})
+}
+
+func (a *Anonymizer) token(prefix, value string) string {
+	sum := a.sum(prefix + value)
+	return prefix + hex.EncodeToString(sum[:6])
+}
+
+func (a *Anonymizer) hexOfLength(value string, n int) string {
+	sum := a.sum("id:" + value)
+	return hex.EncodeToString(sum[:])[:n]
+}
+
+func (a *Anonymizer) sum(value string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
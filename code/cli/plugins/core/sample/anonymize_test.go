@@ -0,0 +1,236 @@
+package sample
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
+	"github.com/parquet-go/parquet-go"
+)
+
+func testAnonymizer(t *testing.T, key string, scale float64) *Anonymizer {
+	t.Helper()
+	a, err := NewAnonymizer([]byte(key), scale)
+	if err != nil {
+		t.Fatalf("NewAnonymizer() error = %v", err)
+	}
+	return a
+}
+
+func TestNewAnonymizer(t *testing.T) {
+	if _, err := NewAnonymizer(nil, 1); err == nil {
+		t.Error("NewAnonymizer() expected error for an empty key, got nil")
+	}
+	if _, err := NewAnonymizer([]byte("key"), 0); err == nil {
+		t.Error("NewAnonymizer() expected error for a zero scale, got nil")
+	}
+}
+
+func TestAnonymizerPseudonyms(t *testing.T) {
+	a := testAnonymizer(t, "key", 1)
+
+	account := a.AccountID("123456789012")
+	if !regexp.MustCompile(`^[0-9]{12}$`).MatchString(account) || account == "123456789012" {
+		t.Errorf("AccountID() = %s, want a different 12-digit ID", account)
+	}
+	if a.AccountID("123456789012") != account {
+		t.Error("AccountID() is not consistent")
+	}
+	if testAnonymizer(t, "other", 1).AccountID("123456789012") == account {
+		t.Error("AccountID() is the same for a different key")
+	}
+
+	instance := a.Resource("i-0123456789abcdef0")
+	if !strings.HasPrefix(instance, "i-") || len(instance) != len("i-0123456789abcdef0") || instance == "i-0123456789abcdef0" {
+		t.Errorf("Resource() = %s, want an i- ID of the same length", instance)
+	}
+
+	arn := a.Resource("arn:aws:ec2:us-east-1:123456789012:instance/i-0123456789abcdef0")
+	if want := "arn:aws:ec2:us-east-1:" + account + ":instance/" + instance; arn != want {
+		t.Errorf("Resource() = %s, want %s", arn, want)
+	}
+	if bucket := a.Resource("acme-payroll-exports"); strings.Contains(bucket, "acme") {
+		t.Errorf("Resource() = %s, still contains the bucket name", bucket)
+	}
+
+	description := a.Description("Usage of arn:aws:ec2:us-east-1:123456789012:instance/i-0123456789abcdef0 by 123456789012")
+	if want := "Usage of " + arn + " by " + account; description != want {
+		t.Errorf("Description() = %s, want %s", description, want)
+	}
+}
+
+func TestAnonymizerColumns(t *testing.T) {
+	a := testAnonymizer(t, "key", 2)
+
+	tests := []struct {
+		path      []string
+		value     string
+		unchanged bool
+	}{
+		{[]string{"line_item_usage_account_id"}, "123456789012", false},
+		{[]string{"lineItem/UsageAccountId"}, "123456789012", false},
+		{[]string{"SubAccountName"}, "acme-prod", false},
+		{[]string{"resource_tags", "key_value", "value"}, "payroll", false},
+		{[]string{"resource_tags", "key_value", "key"}, "user_team", true},
+		{[]string{"resourceTags/user:Team"}, "payroll", false},
+		{[]string{"line_item_unblended_rate"}, "0.5", false},
+		{[]string{"pricing_rate_code"}, "ABC.123", true},
+		{[]string{"line_item_product_code"}, "AmazonEC2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.path, "."), func(t *testing.T) {
+			got := a.String(tt.path, tt.value)
+			if (got == tt.value) != tt.unchanged {
+				t.Errorf("String() = %s for %s, unchanged want %v", got, tt.value, tt.unchanged)
+			}
+		})
+	}
+
+	if got := a.Number([]string{"BilledCost"}, 1.5); got != 3 {
+		t.Errorf("Number() = %v for a cost, want 3", got)
+	}
+	if got := a.Number([]string{"line_item_usage_amount"}, 1.5); got != 1.5 {
+		t.Errorf("Number() = %v for a usage amount, want 1.5", got)
+	}
+}
+
+func TestAnonymizeTreeParquet(t *testing.T) {
+	ds, _ := Generate(testOptions(SourceCUR))
+	input := t.TempDir()
+	if _, err := WriteDataset(ds, input, FormatParquet); err != nil {
+		t.Fatalf("WriteDataset() error = %v", err)
+	}
+
+	a := testAnonymizer(t, "key", 2)
+	output := filepath.Join(t.TempDir(), "out")
+	files, err := AnonymizeTree(a, input, output)
+	if err != nil {
+		t.Fatalf("AnonymizeTree() error = %v", err)
+	}
+	want := filepath.Join(output, "data", "BILLING_PERIOD=2025-02", "cur2-00001.parquet")
+	if len(files) != 2 || files[0].Path != want || files[0].Rows != int64(len(ds.Periods[0].Rows)) {
+		t.Fatalf("files = %+v, want %s first with %d rows", files, want, len(ds.Periods[0].Rows))
+	}
+
+	rows := readParquetRows(t, files[0].Path, len(ds.Periods[0].Rows))
+	for i, row := range rows {
+		original := ds.Periods[0].Rows[i]
+
+		account := original["line_item_usage_account_id"].(string)
+		if got := row["line_item_usage_account_id"]; got != a.AccountID(account) {
+			t.Fatalf("row %d account = %v, want %s", i, got, a.AccountID(account))
+		}
+		if got, cost := row["line_item_unblended_cost"].(float64), original["line_item_unblended_cost"].(float64); math.Abs(got-2*cost) > 1e-9 {
+			t.Fatalf("row %d cost = %v, want %v", i, got, 2*cost)
+		}
+		if got := row["line_item_product_code"]; got != original["line_item_product_code"] {
+			t.Fatalf("row %d product code = %v, want it unchanged", i, got)
+		}
+
+		tags := row["resource_tags"].(map[string]any)
+		for key, value := range original["resource_tags"].(map[string]string) {
+			anonymized, ok := tags[key].(*[]byte)
+			if !ok {
+				t.Fatalf("row %d lost tag %s", i, key)
+			}
+			if string(*anonymized) != a.String([]string{"resource_tags", "key_value", "value"}, value) {
+				t.Fatalf("row %d tag %s = %s, want it pseudonymized", i, key, *anonymized)
+			}
+		}
+	}
+}
+
+func TestAnonymizeTreeCSV(t *testing.T) {
+	ds, _ := Generate(testOptions(SourceFOCUS))
+	input := t.TempDir()
+	paths, err := WriteDataset(ds, input, FormatCSV)
+	if err != nil {
+		t.Fatalf("WriteDataset() error = %v", err)
+	}
+
+	// Gzip one file the way legacy CUR delivers CSV
+	data, _ := os.ReadFile(paths[1])
+	gzPath := paths[1] + ".gz"
+	f, _ := os.Create(gzPath)
+	gzw := gzip.NewWriter(f)
+	_, _ = gzw.Write(data)
+	_ = gzw.Close()
+	_ = f.Close()
+	_ = os.Remove(paths[1])
+
+	a := testAnonymizer(t, "key", 1)
+	output := filepath.Join(t.TempDir(), "out")
+	files, err := AnonymizeTree(a, input, output)
+	if err != nil {
+		t.Fatalf("AnonymizeTree() error = %v", err)
+	}
+	if len(files) != 2 || !strings.HasSuffix(files[1].Path, ".csv.gz") {
+		t.Fatalf("files = %+v, want a csv and a csv.gz file", files)
+	}
+
+	out, err := os.Open(files[0].Path)
+	if err != nil {
+		t.Fatalf("failed to open output: %v", err)
+	}
+	defer out.Close()
+	records, err := csv.NewReader(out).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+
+	index := map[string]int{}
+	for i, name := range records[0] {
+		index[name] = i
+	}
+	for i, record := range records[1:] {
+		original := ds.Periods[0].Rows[i]
+		if got := record[index["SubAccountId"]]; got != a.AccountID(original["SubAccountId"].(string)) {
+			t.Fatalf("row %d SubAccountId = %s, want it pseudonymized", i, got)
+		}
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(record[index["Tags"]]), &tags); err != nil {
+			t.Fatalf("row %d Tags is not JSON: %v", i, err)
+		}
+		for key, value := range original["Tags"].(map[string]string) {
+			if tags[key] == value {
+				t.Fatalf("row %d tag %s was not pseudonymized", i, key)
+			}
+		}
+	}
+
+	if _, err := AnonymizeTree(a, input, filepath.Join(input, "out")); err == nil {
+		t.Error("AnonymizeTree() expected error for an output inside the input, got nil")
+	}
+	if _, err := AnonymizeTree(a, t.TempDir(), output); err == nil {
+		t.Error("AnonymizeTree() expected error for a directory without data files, got nil")
+	}
+}
+
+func readParquetRows(t *testing.T, path string, n int) []map[string]any {
+	t.Helper()
+	schema, _ := Schema(dataexport.TypeCUR2)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open parquet file: %v", err)
+	}
+	defer f.Close()
+
+	reader := parquet.NewGenericReader[map[string]any](f, schema)
+	defer reader.Close()
+	rows := make([]map[string]any, n)
+	for i := range rows {
+		rows[i] = map[string]any{}
+	}
+	if read, err := reader.Read(rows); read != n {
+		t.Fatalf("Read() = %d rows, want %d: %v", read, n, err)
+	}
+	return rows
+}
@@ -0,0 +1,230 @@
+package sample
+
+import (
+	"strconv"
+	"strings"
+)
+
+// offer describes a priced AWS product as it appears on a line item. Rates are
+// representative public on-demand prices, not a faithful copy of the price list.
+type offer struct {
+	ProductCode     string
+	ServiceName     string
+	ServiceCategory string
+	ProductFamily   string
+	UsageType       string
+	Operation       string
+	Unit            string
+	Rate            float64
+	Region          string
+	InstanceType    string
+	// Normalization is the size normalization factor of instance usage
+	Normalization float64
+	// Attributes are the product attributes stored in the CUR 2.0 product map
+	Attributes map[string]string
+	FromRegion string
+	ToLocation string
+}
+
+type instanceType struct {
+	Name      string
+	Rate      float64
+	VCPU      int
+	MemoryGiB int
+	Processor string
+	Arch      string
+}
+
+type dbInstanceType struct {
+	Name      string
+	Engine    string
+	Operation string
+	Rate      float64
+}
+
+var ec2Types = []instanceType{
+	{"m5.large", 0.096, 2, 8, "Intel Xeon Platinum 8175", "64-bit"},
+	{"m5.xlarge", 0.192, 4, 16, "Intel Xeon Platinum 8175", "64-bit"},
+	{"c5.2xlarge", 0.34, 8, 16, "Intel Xeon Platinum 8124M", "64-bit"},
+	{"r5.large", 0.126, 2, 16, "Intel Xeon Platinum 8175", "64-bit"},
+	{"t3.medium", 0.0416, 2, 4, "Intel Skylake E5 2686 v5", "64-bit"},
+	{"m6g.large", 0.077, 2, 8, "AWS Graviton2 Processor", "64-bit"},
+}
+
+var rdsTypes = []dbInstanceType{
+	{"db.r5.large", "MySQL", "CreateDBInstance:0002", 0.25},
+	{"db.m5.large", "PostgreSQL", "CreateDBInstance:0014", 0.178},
+	{"db.t3.medium", "PostgreSQL", "CreateDBInstance:0014", 0.072},
+}
+
+// regionInfo holds the usage type prefix and display name of a region
+var regionInfo = map[string]struct{ Prefix, Name string }{
+	"us-east-1":    {"", "US East (N. Virginia)"},
+	"us-west-2":    {"USW2-", "US West (Oregon)"},
+	"eu-west-1":    {"EU-", "EU (Ireland)"},
+	"eu-central-1": {"EUC1-", "EU (Frankfurt)"},
+}
+
+// normalizationFactors maps instance sizes to their normalization factor
+var normalizationFactors = map[string]float64{
+	"medium": 2, "large": 4, "xlarge": 8, "2xlarge": 16,
+}
+
+func regionName(region string) string {
+	return regionInfo[region].Name
+}
+
+func ec2Offer(region string, it instanceType) offer {
+	family, size, _ := strings.Cut(it.Name, ".")
+	return offer{
+		ProductCode:     "AmazonEC2",
+		ServiceName:     "Amazon Elastic Compute Cloud",
+		ServiceCategory: "Compute",
+		ProductFamily:   "Compute Instance",
+		UsageType:       regionInfo[region].Prefix + "BoxUsage:" + it.Name,
+		Operation:       "RunInstances",
+		Unit:            "Hrs",
+		Rate:            it.Rate,
+		Region:          region,
+		InstanceType:    it.Name,
+		Normalization:   normalizationFactors[size],
+		Attributes: map[string]string{
+			"product_name":           "Amazon Elastic Compute Cloud",
+			"region":                 region,
+			"instance_type_family":   family,
+			"operating_system":       "Linux",
+			"tenancy":                "Shared",
+			"physical_processor":     it.Processor,
+			"processor_architecture": it.Arch,
+			"vcpu":                   strconv.Itoa(it.VCPU),
+			"memory":                 strconv.Itoa(it.MemoryGiB) + " GiB",
+			"license_model":          "No License required",
+		},
+	}
+}
+
+func ebsOffer(region string) offer {
+	return offer{
+		ProductCode:     "AmazonEC2",
+		ServiceName:     "Amazon Elastic Compute Cloud",
+		ServiceCategory: "Storage",
+		ProductFamily:   "Storage",
+		UsageType:       regionInfo[region].Prefix + "EBS:VolumeUsage.gp3",
+		Operation:       "CreateVolume-Gp3",
+		Unit:            "GB-Mo",
+		Rate:            0.08,
+		Region:          region,
+		Attributes: map[string]string{
+			"product_name":    "Amazon Elastic Compute Cloud",
+			"region":          region,
+			"volume_type":     "General Purpose",
+			"volume_api_name": "gp3",
+			"storage_media":   "SSD-backed",
+		},
+	}
+}
+
+func s3Offer(region string) offer {
+	return offer{
+		ProductCode:     "AmazonS3",
+		ServiceName:     "Amazon Simple Storage Service",
+		ServiceCategory: "Storage",
+		ProductFamily:   "Storage",
+		UsageType:       regionInfo[region].Prefix + "TimedStorage-ByteHrs",
+		Operation:       "StandardStorage",
+		Unit:            "GB-Mo",
+		Rate:            0.023,
+		Region:          region,
+		Attributes: map[string]string{
+			"product_name":  "Amazon Simple Storage Service",
+			"region":        region,
+			"storage_class": "General Purpose",
+			"volume_type":   "Standard",
+		},
+	}
+}
+
+func s3RequestsOffer(region string) offer {
+	o := s3Offer(region)
+	o.ProductFamily = "API Request"
+	o.UsageType = regionInfo[region].Prefix + "Requests-Tier1"
+	o.Operation = "PutObject"
+	o.Unit = "Requests"
+	o.Rate = 0.000005
+	o.Attributes = map[string]string{
+		"product_name": "Amazon Simple Storage Service",
+		"region":       region,
+		"group":        "S3-API-Tier1",
+	}
+	return o
+}
+
+func rdsOffer(region string, db dbInstanceType) offer {
+	family, size, _ := strings.Cut(strings.TrimPrefix(db.Name, "db."), ".")
+	return offer{
+		ProductCode:     "AmazonRDS",
+		ServiceName:     "Amazon Relational Database Service",
+		ServiceCategory: "Databases",
+		ProductFamily:   "Database Instance",
+		UsageType:       regionInfo[region].Prefix + "InstanceUsage:" + db.Name,
+		Operation:       db.Operation,
+		Unit:            "Hrs",
+		Rate:            db.Rate,
+		Region:          region,
+		InstanceType:    db.Name,
+		Normalization:   normalizationFactors[size],
+		Attributes: map[string]string{
+			"product_name":         "Amazon Relational Database Service",
+			"region":               region,
+			"instance_type_family": family,
+			"database_engine":      db.Engine,
+			"deployment_option":    "Single-AZ",
+			"license_model":        "No license required",
+		},
+	}
+}
+
+func dataTransferOutOffer(region string) offer {
+	return offer{
+		ProductCode:     "AmazonEC2",
+		ServiceName:     "Amazon Elastic Compute Cloud",
+		ServiceCategory: "Networking",
+		ProductFamily:   "Data Transfer",
+		UsageType:       regionInfo[region].Prefix + "DataTransfer-Out-Bytes",
+		Operation:       "RunInstances",
+		Unit:            "GB",
+		Rate:            0.09,
+		Region:          region,
+		FromRegion:      region,
+		ToLocation:      "External",
+		Attributes: map[string]string{
+			"product_name":  "Amazon Elastic Compute Cloud",
+			"region":        region,
+			"transfer_type": "AWS Outbound",
+		},
+	}
+}
+
+func dataTransferRegionalOffer(region string) offer {
+	o := dataTransferOutOffer(region)
+	o.UsageType = regionInfo[region].Prefix + "DataTransfer-Regional-Bytes"
+	o.Operation = "InterZone-In"
+	o.Rate = 0.01
+	o.ToLocation = regionName(region)
+	o.Attributes["transfer_type"] = "IntraRegion"
+	return o
+}
+
+func savingsPlanOffer() offer {
+	return offer{
+		ProductCode:     "ComputeSavingsPlans",
+		ServiceName:     "Savings Plans for AWS Compute usage",
+		ServiceCategory: "Compute",
+		ProductFamily:   "Savings Plans",
+		UsageType:       "ComputeSP:1yrNoUpfront",
+		Unit:            "Hrs",
+		Attributes: map[string]string{
+			"product_name": "Savings Plans for AWS Compute usage",
+		},
+	}
+}
@@ -0,0 +1,467 @@
+// Package sample generates synthetic CUR 2.0 and FOCUS billing data for developing
+// models and demoing ecos without sharing real account IDs or tags. The rows use the
+// same columns as a BCM Data Export (see plugins/core/dataexport), so generated files
+// can be queried through the same Glue table definition as a delivered export. Real
+// exports can be pseudonymized with an Anonymizer before they are shared.
+package sample
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
+)
+
+// Data sources that can be generated
+const (
+	SourceCUR   = "aws_cur"
+	SourceFOCUS = "aws_focus"
+)
+
+// Output formats
+const (
+	FormatParquet = "parquet"
+	FormatCSV     = "csv"
+)
+
+// Limits keep accidental invocations from producing gigabytes of data
+const (
+	MaxMonths   = 36
+	MaxAccounts = 100
+)
+
+// Options controls the generated dataset
+type Options struct {
+	Source   string
+	Months   int
+	Accounts int
+	// Seed makes the output reproducible: the same options always yield the same rows
+	Seed uint64
+	// EndMonth is any time within the last generated month
+	EndMonth time.Time
+}
+
+// Period holds the rows of one billing period (YYYY-MM)
+type Period struct {
+	Name string
+	Rows []map[string]any
+}
+
+// Dataset is a generated set of billing rows keyed by export column name
+type Dataset struct {
+	ExportType string
+	Periods    []Period
+}
+
+// RowCount returns the total number of rows in the dataset
+func (d *Dataset) RowCount() int {
+	n := 0
+	for _, p := range d.Periods {
+		n += len(p.Rows)
+	}
+	return n
+}
+
+// ExportType returns the data export type whose columns the source is generated with
+func ExportType(source string) (string, error) {
+	switch source {
+	case SourceCUR:
+		return dataexport.TypeCUR2, nil
+	case SourceFOCUS:
+		return dataexport.TypeFOCUS, nil
+	default:
+		return "", fmt.Errorf("unsupported source '%s' (supported: %s, %s)", source, SourceCUR, SourceFOCUS)
+	}
+}
+
+// ValidateFormat checks that format is a supported output format
+func ValidateFormat(format string) error {
+	if format != FormatParquet && format != FormatCSV {
+		return fmt.Errorf("unsupported format '%s' (supported: %s, %s)", format, FormatParquet, FormatCSV)
+	}
+	return nil
+}
+
+// Validate checks the options before generating
+func (o Options) Validate() error {
+	if _, err := ExportType(o.Source); err != nil {
+		return err
+	}
+	if o.Months < 1 || o.Months > MaxMonths {
+		return fmt.Errorf("months must be between 1 and %d", MaxMonths)
+	}
+	if o.Accounts < 1 || o.Accounts > MaxAccounts {
+		return fmt.Errorf("accounts must be between 1 and %d", MaxAccounts)
+	}
+	if o.EndMonth.IsZero() {
+		return errors.New("end month is required")
+	}
+	return nil
+}
+
+// Generate builds a deterministic synthetic dataset for the options
+func Generate(opts Options) (*Dataset, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	exportType, _ := ExportType(opts.Source)
+
+	g := newGenerator(opts)
+	ds := &Dataset{ExportType: exportType}
+
+	end := time.Date(opts.EndMonth.Year(), opts.EndMonth.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for m := opts.Months - 1; m >= 0; m-- {
+		periodStart := end.AddDate(0, -m, 0)
+		items := g.month(periodStart)
+
+		period := Period{Name: periodStart.Format("2006-01"), Rows: make([]map[string]any, 0, len(items))}
+		for i := range items {
+			var row map[string]any
+			if exportType == dataexport.TypeFOCUS {
+				row = items[i].focusRow()
+			} else {
+				row = items[i].cur2Row(g.lineItemID())
+			}
+			if row != nil {
+				period.Rows = append(period.Rows, row)
+			}
+		}
+		ds.Periods = append(ds.Periods, period)
+	}
+
+	return ds, nil
+}
+
+// generator holds the synthetic organization: accounts, their resources and a savings plan
+type generator struct {
+	rnd       *rand.Rand
+	payer     account
+	accounts  []account
+	resources []*resource
+	sp        savingsPlan
+}
+
+type account struct {
+	ID          string
+	Name        string
+	Region      string
+	Environment string
+}
+
+type resourceKind int
+
+const (
+	kindEC2 resourceKind = iota
+	kindEBS
+	kindS3
+	kindRDS
+)
+
+type resource struct {
+	kind    resourceKind
+	account *account
+	id      string
+	az      string
+	offer   offer
+	// size is the volume size or stored GB for storage resources
+	size float64
+	tags map[string]string
+	// scheduled instances only run during weekday office hours
+	scheduled bool
+	spCovered bool
+}
+
+type savingsPlan struct {
+	arn           string
+	account       *account
+	hourlyCommit  float64
+	discountRatio float64
+}
+
+var (
+	accountNames = []string{"prod-core", "prod-data", "staging", "dev-sandbox", "shared-services", "analytics", "security", "ml-platform"}
+	teams        = []string{"platform", "data", "web", "payments", "search"}
+	regions      = []string{"eu-west-1", "us-east-1", "eu-central-1", "us-west-2"}
+)
+
+func newGenerator(opts Options) *generator {
+	g := &generator{rnd: rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))}
+
+	g.payer = account{ID: g.accountID(), Name: "acme-management", Region: "us-east-1", Environment: "production"}
+	for i := 0; i < opts.Accounts; i++ {
+		name := accountNames[i%len(accountNames)]
+		if i >= len(accountNames) {
+			name = fmt.Sprintf("%s-%d", name, i/len(accountNames)+1)
+		}
+		env := "production"
+		switch {
+		case i%len(accountNames) == 2:
+			env = "staging"
+		case i%len(accountNames) == 3:
+			env = "development"
+		}
+		g.accounts = append(g.accounts, account{
+			ID:          g.accountID(),
+			Name:        name,
+			Region:      regions[i%len(regions)],
+			Environment: env,
+		})
+	}
+
+	for i := range g.accounts {
+		g.addResources(&g.accounts[i])
+	}
+	g.addSavingsPlan()
+
+	return g
+}
+
+func (g *generator) accountID() string {
+	return fmt.Sprintf("%012d", g.rnd.Int64N(900000000000)+100000000000)
+}
+
+func (g *generator) hexID(n int) string {
+	const hex = "0123456789abcdef"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = hex[g.rnd.IntN(len(hex))]
+	}
+	return string(b)
+}
+
+func (g *generator) lineItemID() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+	b := make([]byte, 52)
+	for i := range b {
+		b[i] = alphabet[g.rnd.IntN(len(alphabet))]
+	}
+	return string(b)
+}
+
+// tags returns the cost allocation tags of a resource; some resources are left untagged
+func (g *generator) tags(acct *account) map[string]string {
+	if g.rnd.Float64() < 0.15 {
+		return map[string]string{}
+	}
+	tags := map[string]string{
+		"team":        teams[g.rnd.IntN(len(teams))],
+		"environment": acct.Environment,
+	}
+	if g.rnd.Float64() < 0.7 {
+		tags["cost_center"] = fmt.Sprintf("cc-%d", 1001+g.rnd.IntN(6))
+	}
+	return tags
+}
+
+func (g *generator) addResources(acct *account) {
+	az := func() string { return acct.Region + string(rune('a'+g.rnd.IntN(3))) }
+
+	instances := 2 + g.rnd.IntN(5)
+	for i := 0; i < instances; i++ {
+		it := ec2Types[g.rnd.IntN(len(ec2Types))]
+		inst := &resource{
+			kind:      kindEC2,
+			account:   acct,
+			id:        "i-0" + g.hexID(16),
+			az:        az(),
+			offer:     ec2Offer(acct.Region, it),
+			tags:      g.tags(acct),
+			scheduled: acct.Environment == "development" && g.rnd.Float64() < 0.7,
+		}
+		g.resources = append(g.resources, inst)
+
+		g.resources = append(g.resources, &resource{
+			kind:    kindEBS,
+			account: acct,
+			id:      "vol-0" + g.hexID(16),
+			az:      inst.az,
+			offer:   ebsOffer(acct.Region),
+			size:    float64(50 * (1 + g.rnd.IntN(10))),
+			tags:    inst.tags,
+		})
+	}
+
+	buckets := 1 + g.rnd.IntN(3)
+	for i := 0; i < buckets; i++ {
+		g.resources = append(g.resources, &resource{
+			kind:    kindS3,
+			account: acct,
+			id:      fmt.Sprintf("%s-%s-%s", acct.Name, []string{"assets", "logs", "backups"}[i], g.hexID(6)),
+			offer:   s3Offer(acct.Region),
+			size:    float64(100 + g.rnd.IntN(5000)),
+			tags:    g.tags(acct),
+		})
+	}
+
+	if acct.Environment != "development" {
+		databases := g.rnd.IntN(3)
+		for i := 0; i < databases; i++ {
+			db := rdsTypes[g.rnd.IntN(len(rdsTypes))]
+			name := fmt.Sprintf("%s-db-%d", []string{"orders", "users", "billing", "catalog"}[g.rnd.IntN(4)], i+1)
+			g.resources = append(g.resources, &resource{
+				kind:    kindRDS,
+				account: acct,
+				id:      fmt.Sprintf("arn:aws:rds:%s:%s:db:%s", acct.Region, acct.ID, name),
+				az:      az(),
+				offer:   rdsOffer(acct.Region, db),
+				tags:    g.tags(acct),
+			})
+		}
+	}
+}
+
+// addSavingsPlan buys a Compute Savings Plan in the first account covering about half
+// of the always-on instances, with a small amount of unused commitment
+func (g *generator) addSavingsPlan() {
+	const discount = 0.72
+	var covered float64
+	for _, r := range g.resources {
+		if r.kind == kindEC2 && !r.scheduled && g.rnd.Float64() < 0.5 {
+			r.spCovered = true
+			covered += r.offer.Rate * discount
+		}
+	}
+	if covered == 0 {
+		return
+	}
+	g.sp = savingsPlan{
+		arn:           fmt.Sprintf("arn:aws:savingsplans::%s:savingsplan/%s-%s-%s", g.accounts[0].ID, g.hexID(8), g.hexID(4), g.hexID(12)),
+		account:       &g.accounts[0],
+		hourlyCommit:  round(covered/0.95, 3),
+		discountRatio: discount,
+	}
+}
+
+// month generates the line items of the billing period starting at periodStart
+func (g *generator) month(periodStart time.Time) []lineItem {
+	periodEnd := periodStart.AddDate(0, 1, 0)
+	daysInMonth := periodEnd.Sub(periodStart).Hours() / 24
+	bp := billingPeriod{start: periodStart, end: periodEnd}
+
+	var items []lineItem
+	for day := periodStart; day.Before(periodEnd); day = day.AddDate(0, 0, 1) {
+		weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+		dayIndex := day.Sub(periodStart).Hours() / 24
+		var spUsed float64
+
+		for _, r := range g.resources {
+			base := lineItem{
+				period:   bp,
+				start:    day,
+				end:      day.AddDate(0, 0, 1),
+				account:  r.account,
+				payer:    &g.payer,
+				offer:    r.offer,
+				az:       r.az,
+				resource: r.id,
+				tags:     r.tags,
+				itemType: "Usage",
+			}
+
+			switch r.kind {
+			case kindEC2:
+				hours := 24.0
+				if r.scheduled {
+					hours = 12
+					if weekend {
+						continue
+					}
+				}
+				item := base.withUsage(hours)
+				if r.spCovered && g.sp.arn != "" {
+					item.itemType = "SavingsPlanCoveredUsage"
+					item.spARN = g.sp.arn
+					item.spRate = round(r.offer.Rate*g.sp.discountRatio, 6)
+					item.spEffectiveCost = round(hours*item.spRate, 8)
+					spUsed += item.spEffectiveCost
+					items = append(items, item)
+
+					negation := item
+					negation.itemType = "SavingsPlanNegation"
+					negation.cost = -item.cost
+					negation.description = fmt.Sprintf("SavingsPlanNegation used by AccountId : %s and UsageSku : %s", r.account.ID, r.offer.UsageType)
+					items = append(items, negation)
+				} else {
+					items = append(items, item)
+				}
+
+				// Internet egress and cross-AZ traffic from the instance
+				egress := round(hours*(0.5+g.rnd.Float64()*2)*weekdayFactor(weekend), 6)
+				items = append(items, base.withOffer(dataTransferOutOffer(r.account.Region)).withUsage(egress))
+				interAZ := round(hours*(0.2+g.rnd.Float64())*weekdayFactor(weekend), 6)
+				items = append(items, base.withOffer(dataTransferRegionalOffer(r.account.Region)).withUsage(interAZ))
+			case kindEBS:
+				items = append(items, base.withUsage(round(r.size/daysInMonth, 8)))
+			case kindS3:
+				// Storage grows slowly over the month, requests follow the working week
+				stored := r.size * (1 + 0.003*dayIndex)
+				items = append(items, base.withUsage(round(stored/daysInMonth, 8)))
+				requests := math.Round(r.size * (20 + g.rnd.Float64()*40) * weekdayFactor(weekend))
+				items = append(items, base.withOffer(s3RequestsOffer(r.account.Region)).withUsage(requests))
+			case kindRDS:
+				items = append(items, base.withUsage(24))
+			}
+		}
+
+		if g.sp.arn != "" {
+			items = append(items, g.savingsPlanFee(bp, day, spUsed))
+		}
+	}
+
+	// One promotional credit per month, applied to the first account
+	credit := lineItem{
+		period:      bp,
+		start:       periodStart,
+		end:         periodEnd,
+		account:     &g.accounts[0],
+		payer:       &g.payer,
+		offer:       ec2Offer(g.accounts[0].Region, ec2Types[0]),
+		itemType:    "Credit",
+		cost:        -100,
+		description: "AWS Credits - Promotional",
+		tags:        map[string]string{},
+	}
+	credit.offer.UsageType = ""
+	credit.offer.Operation = ""
+	items = append(items, credit)
+
+	return items
+}
+
+// savingsPlanFee returns the daily recurring fee of the savings plan
+func (g *generator) savingsPlanFee(bp billingPeriod, day time.Time, used float64) lineItem {
+	fee := round(g.sp.hourlyCommit*24, 8)
+	return lineItem{
+		period:          bp,
+		start:           day,
+		end:             day.AddDate(0, 0, 1),
+		account:         g.sp.account,
+		payer:           &g.payer,
+		offer:           savingsPlanOffer(),
+		itemType:        "SavingsPlanRecurringFee",
+		usage:           24,
+		rate:            g.sp.hourlyCommit,
+		cost:            fee,
+		description:     fmt.Sprintf("%.3f USD per hour for 1-year No Upfront Compute Savings Plan", g.sp.hourlyCommit),
+		spARN:           g.sp.arn,
+		spTotalCommit:   fee,
+		spUsedCommit:    round(math.Min(used, fee), 8),
+		spEffectiveCost: round(math.Max(fee-used, 0), 8),
+		tags:            map[string]string{},
+	}
+}
+
+func weekdayFactor(weekend bool) float64 {
+	if weekend {
+		return 0.6
+	}
+	return 1
+}
+
+func round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
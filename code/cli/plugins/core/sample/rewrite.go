@@ -0,0 +1,297 @@
+package sample
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+// AnonymizedFile is a data file written by AnonymizeTree
+type AnonymizedFile struct {
+	Source string
+	Path   string
+	Rows   int64
+}
+
+// isDataFile reports whether path is a Parquet or CSV export file
+func isDataFile(path string) bool {
+	name := strings.ToLower(path)
+	return strings.HasSuffix(name, ".parquet") || strings.HasSuffix(name, ".csv") || strings.HasSuffix(name, ".csv.gz")
+}
+
+// AnonymizeTree anonymizes input, a single export file or a directory of them, into
+// the output directory. The directory layout (data/BILLING_PERIOD=YYYY-MM/...) and
+// each file's schema and format are kept, so the output can be queried exactly like
+// the original export. Other files, such as manifests, are not copied.
+func AnonymizeTree(a *Anonymizer, input, output string) ([]AnonymizedFile, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", input, err)
+	}
+
+	if !info.IsDir() {
+		if !isDataFile(input) {
+			return nil, fmt.Errorf("unsupported file '%s' (supported: .parquet, .csv, .csv.gz)", input)
+		}
+		if err := os.MkdirAll(output, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		dst := filepath.Join(output, filepath.Base(input))
+		if same, err := isWithin(dst, input); err != nil {
+			return nil, err
+		} else if same {
+			return nil, fmt.Errorf("output directory %s would overwrite the input file %s", output, input)
+		}
+		rows, err := AnonymizeFile(a, input, dst)
+		if err != nil {
+			return nil, err
+		}
+		return []AnonymizedFile{{Source: input, Path: dst, Rows: rows}}, nil
+	}
+
+	if inside, err := isWithin(output, input); err != nil {
+		return nil, err
+	} else if inside {
+		return nil, fmt.Errorf("output directory %s must not be inside the input directory %s", output, input)
+	}
+
+	var files []AnonymizedFile
+	err = filepath.WalkDir(input, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isDataFile(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(input, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(output, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(dst), err)
+		}
+
+		rows, err := AnonymizeFile(a, path, dst)
+		if err != nil {
+			return err
+		}
+		files = append(files, AnonymizedFile{Source: path, Path: dst, Rows: rows})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Parquet or CSV files found in %s", input)
+	}
+	return files, nil
+}
+
+// isWithin reports whether path is dir or one of its descendants
+func isWithin(path, dir string) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, nil
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))), nil
+}
+
+// AnonymizeFile writes an anonymized copy of the Parquet or CSV file src to dst and
+// returns the number of rows written
+func AnonymizeFile(a *Anonymizer, src, dst string) (rows int64, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to close %s: %w", dst, cerr)
+		}
+	}()
+
+	name := strings.ToLower(src)
+	switch {
+	case strings.HasSuffix(name, ".parquet"):
+		rows, err = anonymizeParquet(a, in, out)
+	case strings.HasSuffix(name, ".csv.gz"):
+		rows, err = anonymizeGzipCSV(a, in, out)
+	case strings.HasSuffix(name, ".csv"):
+		rows, err = anonymizeCSV(a, in, out)
+	default:
+		err = errors.New("unsupported file type")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize %s: %w", src, err)
+	}
+	return rows, nil
+}
+
+// anonymizeParquet rewrites the file value by value with its own schema, so columns
+// outside the data export column lists are preserved as well
+func anonymizeParquet(a *Anonymizer, in *os.File, w io.Writer) (int64, error) {
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+	file, err := parquet.OpenFile(in, info.Size())
+	if err != nil {
+		return 0, err
+	}
+
+	schema := file.Schema()
+	columns := schema.Columns()
+	reader := parquet.NewReader(file)
+	defer reader.Close()
+	writer := parquet.NewWriter(w, schema, parquet.Compression(&snappy.Codec{}))
+
+	var total int64
+	rows := make([]parquet.Row, 256)
+	for {
+		n, readErr := reader.ReadRows(rows)
+		for _, row := range rows[:n] {
+			for i, v := range row {
+				row[i] = a.parquetValue(columns[v.Column()], v)
+			}
+		}
+		if n > 0 {
+			if _, err := writer.WriteRows(rows[:n]); err != nil {
+				return 0, err
+			}
+			total += int64(n)
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return 0, readErr
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (a *Anonymizer) parquetValue(path []string, v parquet.Value) parquet.Value {
+	if v.IsNull() {
+		return v
+	}
+
+	switch v.Kind() {
+	case parquet.ByteArray:
+		value := string(v.ByteArray())
+		if anonymized := a.String(path, value); anonymized != value {
+			return parquet.ByteArrayValue([]byte(anonymized)).Level(v.RepetitionLevel(), v.DefinitionLevel(), v.Column())
+		}
+	case parquet.Double:
+		if scaled := a.Number(path, v.Double()); scaled != v.Double() {
+			return parquet.DoubleValue(scaled).Level(v.RepetitionLevel(), v.DefinitionLevel(), v.Column())
+		}
+	}
+	return v
+}
+
+// anonymizeGzipCSV handles the gzip-compressed CSV files legacy CUR delivers
+func anonymizeGzipCSV(a *Anonymizer, r io.Reader, w io.Writer) (int64, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gzr.Close()
+
+	gzw := gzip.NewWriter(w)
+	rows, err := anonymizeCSV(a, gzr, gzw)
+	if err != nil {
+		return 0, err
+	}
+	return rows, gzw.Close()
+}
+
+// anonymizeCSV rewrites a CSV file with a header row. Map columns are expected to be
+// JSON-encoded, as written by Athena, DuckDB and 'ecos sample generate'.
+func anonymizeCSV(a *Anonymizer, r io.Reader, w io.Writer) (int64, error) {
+	cr := csv.NewReader(r)
+	cw := csv.NewWriter(w)
+
+	header, err := cr.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read header: %w", err)
+	}
+	if err := cw.Write(header); err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		for i, value := range record {
+			if i < len(header) {
+				record[i] = a.csvValue(header[i], value)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return 0, err
+		}
+		rows++
+	}
+
+	cw.Flush()
+	return rows, cw.Error()
+}
+
+func (a *Anonymizer) csvValue(column, value string) string {
+	path := []string{column}
+	kind := classify(path)
+	if (kind == kindTag || (kind == kindCost && a.scale != 1)) && strings.HasPrefix(value, "{") {
+		var entries map[string]any
+		if err := json.Unmarshal([]byte(value), &entries); err == nil {
+			mapPath := []string{column, "key_value", "value"}
+			for k, v := range entries {
+				switch entry := v.(type) {
+				case string:
+					entries[k] = a.String(mapPath, entry)
+				case float64:
+					entries[k] = a.Number(mapPath, entry)
+				}
+			}
+			if data, err := json.Marshal(entries); err == nil {
+				return string(data)
+			}
+		}
+	}
+	return a.String(path, value)
+}
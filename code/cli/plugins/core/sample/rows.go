@@ -0,0 +1,250 @@
+package sample
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	legalEntity = "Amazon Web Services, Inc."
+	currency    = "USD"
+)
+
+type billingPeriod struct {
+	start time.Time
+	end   time.Time
+}
+
+// lineItem is a provider-neutral billing line item rendered as CUR 2.0 or FOCUS
+type lineItem struct {
+	period   billingPeriod
+	start    time.Time
+	end      time.Time
+	account  *account
+	payer    *account
+	offer    offer
+	az       string
+	resource string
+	tags     map[string]string
+	itemType string
+
+	usage       float64
+	rate        float64
+	cost        float64
+	description string
+
+	spARN           string
+	spRate          float64
+	spEffectiveCost float64
+	spTotalCommit   float64
+	spUsedCommit    float64
+}
+
+// withUsage prices amount at the offer's on-demand rate
+func (li lineItem) withUsage(amount float64) lineItem {
+	li.usage = amount
+	li.rate = li.offer.Rate
+	li.cost = round(amount*li.offer.Rate, 8)
+	li.description = fmt.Sprintf("$%s per %s for %s", strconv.FormatFloat(li.offer.Rate, 'f', -1, 64), li.offer.Unit, li.offer.UsageType)
+	return li
+}
+
+// withOffer switches the line item to another product of the same resource
+func (li lineItem) withOffer(o offer) lineItem {
+	li.offer = o
+	return li
+}
+
+// publicCost returns the on-demand cost; fees and credits have none
+func (li lineItem) publicCost() float64 {
+	switch li.itemType {
+	case "Usage", "SavingsPlanCoveredUsage":
+		return li.cost
+	default:
+		return 0
+	}
+}
+
+// cur2Row renders the line item with the CUR 2.0 column names
+func (li lineItem) cur2Row(id string) map[string]any {
+	row := map[string]any{
+		"identity_line_item_id":           id,
+		"identity_time_interval":          li.start.Format(time.RFC3339) + "/" + li.end.Format(time.RFC3339),
+		"bill_bill_type":                  "Anniversary",
+		"bill_billing_entity":             "AWS",
+		"bill_billing_period_start_date":  li.period.start,
+		"bill_billing_period_end_date":    li.period.end,
+		"bill_invoicing_entity":           legalEntity,
+		"bill_payer_account_id":           li.payer.ID,
+		"bill_payer_account_name":         li.payer.Name,
+		"line_item_usage_account_id":      li.account.ID,
+		"line_item_usage_account_name":    li.account.Name,
+		"line_item_legal_entity":          legalEntity,
+		"line_item_line_item_type":        li.itemType,
+		"line_item_line_item_description": li.description,
+		"line_item_usage_start_date":      li.start,
+		"line_item_usage_end_date":        li.end,
+		"line_item_product_code":          li.offer.ProductCode,
+		"line_item_usage_type":            li.offer.UsageType,
+		"line_item_operation":             li.offer.Operation,
+		"line_item_availability_zone":     li.az,
+		"line_item_resource_id":           li.resource,
+		"line_item_usage_amount":          li.usage,
+		"line_item_currency_code":         currency,
+		"line_item_unblended_rate":        strconv.FormatFloat(li.rate, 'f', -1, 64),
+		"line_item_unblended_cost":        li.cost,
+		"line_item_blended_rate":          strconv.FormatFloat(li.rate, 'f', -1, 64),
+		"line_item_blended_cost":          li.cost,
+		"line_item_net_unblended_rate":    strconv.FormatFloat(li.rate, 'f', -1, 64),
+		"line_item_net_unblended_cost":    li.cost,
+		"pricing_currency":                currency,
+		"pricing_unit":                    li.offer.Unit,
+		"pricing_public_on_demand_cost":   li.publicCost(),
+		"product":                         li.offer.Attributes,
+		"product_product_family":          li.offer.ProductFamily,
+		"product_servicecode":             li.offer.ProductCode,
+		"product_usagetype":               li.offer.UsageType,
+		"product_operation":               li.offer.Operation,
+		"product_region_code":             li.offer.Region,
+		"product_location":                regionName(li.offer.Region),
+		"resource_tags":                   cur2Tags(li.tags),
+	}
+
+	if li.offer.InstanceType != "" {
+		row["product_instance_type"] = li.offer.InstanceType
+		row["line_item_normalization_factor"] = li.offer.Normalization
+		row["line_item_normalized_usage_amount"] = round(li.usage*li.offer.Normalization, 8)
+	}
+	if li.offer.ToLocation != "" {
+		row["product_from_location"] = regionName(li.offer.FromRegion)
+		row["product_to_location"] = li.offer.ToLocation
+	}
+	if li.publicCost() != 0 {
+		row["pricing_term"] = "OnDemand"
+		row["pricing_purchase_option"] = "On Demand"
+		row["pricing_public_on_demand_rate"] = strconv.FormatFloat(li.offer.Rate, 'f', -1, 64)
+	}
+
+	if li.spARN != "" {
+		row["savings_plan_savings_plan_a_r_n"] = li.spARN
+		row["savings_plan_offering_type"] = "ComputeSavingsPlans"
+		row["savings_plan_payment_option"] = "No Upfront"
+		row["savings_plan_purchase_term"] = "1yr"
+		row["savings_plan_savings_plan_effective_cost"] = li.spEffectiveCost
+		row["savings_plan_net_savings_plan_effective_cost"] = li.spEffectiveCost
+	}
+	switch li.itemType {
+	case "SavingsPlanCoveredUsage":
+		row["savings_plan_savings_plan_rate"] = li.spRate
+		row["pricing_term"] = "Savings Plan"
+	case "SavingsPlanNegation":
+		// Negation rows offset the covered usage and carry no savings plan cost
+		delete(row, "savings_plan_savings_plan_effective_cost")
+		delete(row, "savings_plan_net_savings_plan_effective_cost")
+		row["pricing_public_on_demand_cost"] = 0.0
+	case "SavingsPlanRecurringFee":
+		row["savings_plan_total_commitment_to_date"] = li.spTotalCommit
+		row["savings_plan_used_commitment"] = li.spUsedCommit
+		row["savings_plan_recurring_commitment_for_billing_period"] = li.spTotalCommit
+	}
+
+	return row
+}
+
+// cur2Tags prefixes user-defined tag keys the way CUR 2.0 stores them
+func cur2Tags(tags map[string]string) map[string]string {
+	out := make(map[string]string, len(tags))
+	for k, v := range tags {
+		out["user_"+k] = v
+	}
+	return out
+}
+
+// focusRow renders the line item with the FOCUS 1.0 column names. Savings plan
+// negation rows have no FOCUS equivalent: covered usage is billed at zero instead.
+func (li lineItem) focusRow() map[string]any {
+	if li.itemType == "SavingsPlanNegation" {
+		return nil
+	}
+
+	row := map[string]any{
+		"BillingAccountId":   li.payer.ID,
+		"BillingAccountName": li.payer.Name,
+		"BillingCurrency":    currency,
+		"BillingPeriodStart": li.period.start,
+		"BillingPeriodEnd":   li.period.end,
+		"ChargePeriodStart":  li.start,
+		"ChargePeriodEnd":    li.end,
+		"ChargeDescription":  li.description,
+		"SubAccountId":       li.account.ID,
+		"SubAccountName":     li.account.Name,
+		"ProviderName":       "AWS",
+		"PublisherName":      "AWS",
+		"InvoiceIssuerName":  "AWS",
+		"ServiceName":        li.offer.ServiceName,
+		"ServiceCategory":    li.offer.ServiceCategory,
+		"RegionId":           li.offer.Region,
+		"RegionName":         regionName(li.offer.Region),
+		"AvailabilityZone":   li.az,
+		"ResourceId":         li.resource,
+		"Tags":               focusTags(li.tags),
+		"x_ServiceCode":      li.offer.ProductCode,
+		"x_UsageType":        li.offer.UsageType,
+		"x_Operation":        li.offer.Operation,
+		"ListUnitPrice":      li.offer.Rate,
+		"ListCost":           li.publicCost(),
+		"ContractedCost":     li.cost,
+		"BilledCost":         li.cost,
+		"EffectiveCost":      li.cost,
+		"ConsumedQuantity":   li.usage,
+		"ConsumedUnit":       li.offer.Unit,
+		"PricingQuantity":    li.usage,
+		"PricingUnit":        li.offer.Unit,
+		"PricingCategory":    "Standard",
+		"ChargeCategory":     "Usage",
+		"ChargeFrequency":    "Usage-Based",
+	}
+	if li.offer.InstanceType != "" {
+		row["ResourceType"] = li.offer.InstanceType
+	}
+
+	switch li.itemType {
+	case "SavingsPlanCoveredUsage":
+		row["BilledCost"] = 0.0
+		row["EffectiveCost"] = li.spEffectiveCost
+		row["PricingCategory"] = "Committed"
+		row["CommitmentDiscountId"] = li.spARN
+		row["CommitmentDiscountType"] = "Savings Plan"
+		row["CommitmentDiscountCategory"] = "Spend"
+		row["CommitmentDiscountStatus"] = "Used"
+	case "SavingsPlanRecurringFee":
+		row["ChargeCategory"] = "Purchase"
+		row["ChargeFrequency"] = "Recurring"
+		// Only the unused part of the commitment is an effective cost of its own
+		row["EffectiveCost"] = li.spEffectiveCost
+		row["CommitmentDiscountId"] = li.spARN
+		row["CommitmentDiscountType"] = "Savings Plan"
+		row["CommitmentDiscountCategory"] = "Spend"
+		row["ConsumedQuantity"] = nil
+		row["ConsumedUnit"] = nil
+	case "Credit":
+		row["ChargeCategory"] = "Credit"
+		row["ChargeFrequency"] = "One-Time"
+		row["ConsumedQuantity"] = nil
+		row["ConsumedUnit"] = nil
+		row["PricingQuantity"] = nil
+		row["PricingUnit"] = nil
+	}
+
+	return row
+}
+
+// focusTags prefixes user-defined tag keys the way the AWS FOCUS export stores them
+func focusTags(tags map[string]string) map[string]string {
+	out := make(map[string]string, len(tags))
+	for k, v := range tags {
+		out["user:"+k] = v
+	}
+	return out
+}
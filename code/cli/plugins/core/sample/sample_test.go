@@ -0,0 +1,303 @@
+package sample
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
+	"github.com/parquet-go/parquet-go"
+)
+
+func testOptions(source string) Options {
+	return Options{
+		Source:   source,
+		Months:   2,
+		Accounts: 3,
+		Seed:     7,
+		EndMonth: time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"unknown source", func(o *Options) { o.Source = "gcp_billing" }},
+		{"zero months", func(o *Options) { o.Months = 0 }},
+		{"too many months", func(o *Options) { o.Months = MaxMonths + 1 }},
+		{"zero accounts", func(o *Options) { o.Accounts = 0 }},
+		{"missing end month", func(o *Options) { o.EndMonth = time.Time{} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions(SourceCUR)
+			tt.modify(&opts)
+			if err := opts.Validate(); err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+		})
+	}
+
+	if err := testOptions(SourceFOCUS).Validate(); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	first, err := Generate(testOptions(SourceCUR))
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	second, _ := Generate(testOptions(SourceCUR))
+	if !reflect.DeepEqual(first, second) {
+		t.Error("Generate() with the same seed produced different data")
+	}
+
+	opts := testOptions(SourceCUR)
+	opts.Seed = 8
+	other, _ := Generate(opts)
+	if reflect.DeepEqual(first, other) {
+		t.Error("Generate() with a different seed produced the same data")
+	}
+}
+
+func TestGenerateCUR2(t *testing.T) {
+	ds, err := Generate(testOptions(SourceCUR))
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if ds.ExportType != dataexport.TypeCUR2 {
+		t.Errorf("ExportType = %s, want %s", ds.ExportType, dataexport.TypeCUR2)
+	}
+	if len(ds.Periods) != 2 || ds.Periods[0].Name != "2025-02" || ds.Periods[1].Name != "2025-03" {
+		t.Fatalf("Periods = %v, want 2025-02 and 2025-03", periodNames(ds))
+	}
+
+	known := columnSet(t, dataexport.TypeCUR2)
+	seen := map[string]bool{}
+	accounts := map[string]bool{}
+	var covered, negated float64
+	tagged := 0
+
+	for _, p := range ds.Periods {
+		for _, row := range p.Rows {
+			for key := range row {
+				if !known[key] {
+					t.Fatalf("row has column %s that is not part of the CUR 2.0 export", key)
+				}
+			}
+			seen[row["line_item_product_code"].(string)] = true
+			seen[row["line_item_line_item_type"].(string)] = true
+			seen[row["product_product_family"].(string)] = true
+			accounts[row["line_item_usage_account_id"].(string)] = true
+
+			switch row["line_item_line_item_type"] {
+			case "SavingsPlanCoveredUsage":
+				covered += row["line_item_unblended_cost"].(float64)
+			case "SavingsPlanNegation":
+				negated += row["line_item_unblended_cost"].(float64)
+			}
+			if tags := row["resource_tags"].(map[string]string); len(tags) > 0 {
+				tagged++
+				for key := range tags {
+					if !strings.HasPrefix(key, "user_") {
+						t.Errorf("tag key %s is not prefixed with user_", key)
+					}
+				}
+			}
+		}
+	}
+
+	for _, want := range []string{
+		"AmazonEC2", "AmazonS3", "AmazonRDS", "ComputeSavingsPlans", "Data Transfer",
+		"Usage", "SavingsPlanCoveredUsage", "SavingsPlanNegation", "SavingsPlanRecurringFee", "Credit",
+	} {
+		if !seen[want] {
+			t.Errorf("generated data has no %s rows", want)
+		}
+	}
+	if len(accounts) != 3 {
+		t.Errorf("usage accounts = %d, want 3", len(accounts))
+	}
+	if math.Abs(covered+negated) > 1e-6 {
+		t.Errorf("savings plan negation (%f) does not offset covered usage (%f)", negated, covered)
+	}
+	if tagged == 0 {
+		t.Error("generated data has no tagged rows")
+	}
+}
+
+func TestGenerateFOCUS(t *testing.T) {
+	ds, err := Generate(testOptions(SourceFOCUS))
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	known := columnSet(t, dataexport.TypeFOCUS)
+	categories := map[string]bool{}
+	for _, p := range ds.Periods {
+		for _, row := range p.Rows {
+			for key := range row {
+				if !known[key] {
+					t.Fatalf("row has column %s that is not part of the FOCUS export", key)
+				}
+			}
+			categories[row["ChargeCategory"].(string)] = true
+			if row["CommitmentDiscountStatus"] == "Used" && row["BilledCost"].(float64) != 0 {
+				t.Errorf("covered usage BilledCost = %v, want 0", row["BilledCost"])
+			}
+		}
+	}
+	for _, want := range []string{"Usage", "Purchase", "Credit"} {
+		if !categories[want] {
+			t.Errorf("generated data has no %s charges", want)
+		}
+	}
+}
+
+func TestWriteDatasetParquet(t *testing.T) {
+	ds, _ := Generate(testOptions(SourceCUR))
+	dir := t.TempDir()
+
+	paths, err := WriteDataset(ds, dir, FormatParquet)
+	if err != nil {
+		t.Fatalf("WriteDataset() error = %v", err)
+	}
+	want := filepath.Join(dir, "data", "BILLING_PERIOD=2025-02", "cur2-00001.parquet")
+	if len(paths) != 2 || paths[0] != want {
+		t.Fatalf("paths = %v, want first %s", paths, want)
+	}
+
+	schema, _ := Schema(dataexport.TypeCUR2)
+	f, err := os.Open(paths[0])
+	if err != nil {
+		t.Fatalf("failed to open parquet file: %v", err)
+	}
+	defer f.Close()
+
+	reader := parquet.NewGenericReader[map[string]any](f, schema)
+	defer reader.Close()
+	if got := reader.NumRows(); got != int64(len(ds.Periods[0].Rows)) {
+		t.Errorf("NumRows() = %d, want %d", got, len(ds.Periods[0].Rows))
+	}
+	rows := []map[string]any{{}}
+	if _, err := reader.Read(rows); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if rows[0]["line_item_usage_account_id"] != ds.Periods[0].Rows[0]["line_item_usage_account_id"] {
+		t.Errorf("first row account = %v, want %v", rows[0]["line_item_usage_account_id"], ds.Periods[0].Rows[0]["line_item_usage_account_id"])
+	}
+}
+
+func TestWriteDatasetCSV(t *testing.T) {
+	ds, _ := Generate(testOptions(SourceFOCUS))
+	paths, err := WriteDataset(ds, t.TempDir(), FormatCSV)
+	if err != nil {
+		t.Fatalf("WriteDataset() error = %v", err)
+	}
+
+	f, err := os.Open(paths[1])
+	if err != nil {
+		t.Fatalf("failed to open csv file: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(records) != len(ds.Periods[1].Rows)+1 {
+		t.Errorf("records = %d, want %d rows plus header", len(records), len(ds.Periods[1].Rows))
+	}
+
+	header := records[0]
+	tagsIdx := -1
+	for i, name := range header {
+		if name == "Tags" {
+			tagsIdx = i
+		}
+	}
+	var tags map[string]string
+	if err := json.Unmarshal([]byte(records[1][tagsIdx]), &tags); err != nil {
+		t.Errorf("Tags column is not JSON: %q", records[1][tagsIdx])
+	}
+}
+
+func TestDuckDBView(t *testing.T) {
+	parquetView, err := DuckDBView(dataexport.TypeCUR2, FormatParquet, "out")
+	if err != nil {
+		t.Fatalf("DuckDBView() error = %v", err)
+	}
+	if !strings.Contains(parquetView, "CREATE OR REPLACE VIEW cur2") || !strings.Contains(parquetView, "read_parquet(") {
+		t.Errorf("unexpected parquet view: %s", parquetView)
+	}
+
+	csvView, err := DuckDBView(dataexport.TypeCUR2, FormatCSV, "out")
+	if err != nil {
+		t.Fatalf("DuckDBView() error = %v", err)
+	}
+	for _, want := range []string{"read_csv(", "CAST(CAST(resource_tags AS JSON) AS MAP(VARCHAR, VARCHAR))", "'line_item_unblended_cost': 'DOUBLE'"} {
+		if !strings.Contains(csvView, want) {
+			t.Errorf("csv view missing %q", want)
+		}
+	}
+}
+
+func TestGlueTableInput(t *testing.T) {
+	data, err := GlueTableInput(dataexport.TypeFOCUS, FormatParquet, "s3://bucket/sample")
+	if err != nil {
+		t.Fatalf("GlueTableInput() error = %v", err)
+	}
+	if strings.Contains(string(data), "null") {
+		t.Error("table input contains null values")
+	}
+
+	var input struct {
+		Name              string
+		StorageDescriptor struct{ Location string }
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("table input is not valid JSON: %v", err)
+	}
+	if input.Name != "focus" || input.StorageDescriptor.Location != "s3://bucket/sample/data/" {
+		t.Errorf("table input = %+v", input)
+	}
+
+	if _, err := GlueTableInput(dataexport.TypeFOCUS, FormatCSV, "s3://bucket/sample"); err == nil {
+		t.Error("GlueTableInput() expected error for csv, got nil")
+	}
+	if _, err := GlueTableInput(dataexport.TypeFOCUS, FormatParquet, "bucket/sample"); err == nil {
+		t.Error("GlueTableInput() expected error for a non-S3 location, got nil")
+	}
+}
+
+func columnSet(t *testing.T, exportType string) map[string]bool {
+	t.Helper()
+	columns, err := dataexport.Columns(exportType)
+	if err != nil {
+		t.Fatalf("Columns() error = %v", err)
+	}
+	set := map[string]bool{}
+	for _, c := range columns {
+		set[c.Name] = true
+	}
+	return set
+}
+
+func periodNames(ds *Dataset) []string {
+	var names []string
+	for _, p := range ds.Periods {
+		names = append(names, p.Name)
+	}
+	return names
+}
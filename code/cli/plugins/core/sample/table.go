@@ -0,0 +1,139 @@
+package sample
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
+)
+
+// Table definition flavors
+const (
+	TableGlue   = "glue"
+	TableDuckDB = "duckdb"
+)
+
+// ValidateTable checks that table is a supported table definition flavor
+func ValidateTable(table string) error {
+	if table != TableGlue && table != TableDuckDB {
+		return fmt.Errorf("unsupported table definition '%s' (supported: %s, %s)", table, TableGlue, TableDuckDB)
+	}
+	return nil
+}
+
+// DuckDBView returns a DuckDB statement creating a view named after the export type
+// over the files written to dir. CSV map columns are decoded from JSON.
+func DuckDBView(exportType, format, dir string) (string, error) {
+	columns, err := dataexport.Columns(exportType)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateFormat(format); err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	glob := filepath.ToSlash(filepath.Join(abs, "data", "*", "*."+format))
+
+	if format == FormatParquet {
+		return fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\nSELECT *\nFROM read_parquet('%s', hive_partitioning = true);\n",
+			exportType, glob), nil
+	}
+
+	var types, replaces []string
+	for _, c := range columns {
+		duckType := duckDBType(c.Type)
+		if strings.HasPrefix(c.Type, "map<") {
+			replaces = append(replaces, fmt.Sprintf("CAST(CAST(%s AS JSON) AS %s) AS %s", c.Name, duckType, c.Name))
+			duckType = "VARCHAR"
+		}
+		types = append(types, fmt.Sprintf("'%s': '%s'", c.Name, duckType))
+	}
+
+	return fmt.Sprintf(
+		"CREATE OR REPLACE VIEW %s AS\nSELECT * REPLACE (\n    %s\n)\nFROM read_csv('%s', header = true, hive_partitioning = true, columns = {%s});\n",
+		exportType, strings.Join(replaces, ",\n    "), glob, strings.Join(types, ", "),
+	), nil
+}
+
+func duckDBType(columnType string) string {
+	switch columnType {
+	case "double":
+		return "DOUBLE"
+	case "timestamp":
+		return "TIMESTAMP"
+	case "map<string,string>":
+		return "MAP(VARCHAR, VARCHAR)"
+	case "map<string,double>":
+		return "MAP(VARCHAR, DOUBLE)"
+	default:
+		return "VARCHAR"
+	}
+}
+
+// GlueTableInput returns the Glue TableInput JSON for the Parquet files once they are
+// uploaded to location, ready for 'aws glue create-table --table-input file://...'
+func GlueTableInput(exportType, format, location string) ([]byte, error) {
+	if format != FormatParquet {
+		return nil, errors.New("glue table definitions require --format parquet")
+	}
+	if !strings.HasPrefix(location, "s3://") {
+		return nil, fmt.Errorf("invalid S3 location '%s', expected s3://bucket/prefix/", location)
+	}
+
+	input, err := dataexport.TableInputAt(config.DataExportConfig{
+		Type:      exportType,
+		GlueTable: exportType,
+	}, strings.TrimSuffix(location, "/")+"/data/")
+	if err != nil {
+		return nil, err
+	}
+
+	// The SDK types have no omitempty tags; the AWS CLI rejects explicit nulls
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(pruneEmpty(doc), "", "  ")
+}
+
+// pruneEmpty drops null values and empty objects and arrays from a decoded JSON document
+func pruneEmpty(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		out := map[string]any{}
+		for k, child := range value {
+			if pruned := pruneEmpty(child); pruned != nil {
+				out[k] = pruned
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []any:
+		var out []any
+		for _, child := range value {
+			if pruned := pruneEmpty(child); pruned != nil {
+				out = append(out, pruned)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	default:
+		return value
+	}
+}
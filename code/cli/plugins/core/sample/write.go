@@ -0,0 +1,170 @@
+package sample
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/dataexport"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+// timestampLayout is how timestamps are written to CSV; Athena and DuckDB parse it natively
+const timestampLayout = "2006-01-02 15:04:05"
+
+// Schema returns the Parquet schema of the export type's columns. Every column is
+// optional, as in a delivered export.
+func Schema(exportType string) (*parquet.Schema, error) {
+	columns, err := dataexport.Columns(exportType)
+	if err != nil {
+		return nil, err
+	}
+
+	group := parquet.Group{}
+	for _, c := range columns {
+		node, err := parquetNode(c.Type)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", c.Name, err)
+		}
+		group[c.Name] = parquet.Optional(node)
+	}
+	return parquet.NewSchema(exportType, group), nil
+}
+
+func parquetNode(columnType string) (parquet.Node, error) {
+	switch columnType {
+	case "string":
+		return parquet.String(), nil
+	case "double":
+		return parquet.Leaf(parquet.DoubleType), nil
+	case "timestamp":
+		return parquet.Timestamp(parquet.Millisecond), nil
+	case "map<string,string>":
+		return parquet.Map(parquet.String(), parquet.Optional(parquet.String())), nil
+	case "map<string,double>":
+		return parquet.Map(parquet.String(), parquet.Optional(parquet.Leaf(parquet.DoubleType))), nil
+	default:
+		return nil, fmt.Errorf("unsupported column type '%s'", columnType)
+	}
+}
+
+// WriteDataset writes the dataset under dir/data/BILLING_PERIOD=YYYY-MM/, the layout
+// BCM Data Exports deliver, and returns the written file paths
+func WriteDataset(ds *Dataset, dir, format string) ([]string, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, p := range ds.Periods {
+		partition := filepath.Join(dir, "data", "BILLING_PERIOD="+p.Name)
+		if err := os.MkdirAll(partition, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", partition, err)
+		}
+
+		path := filepath.Join(partition, fmt.Sprintf("%s-00001.%s", ds.ExportType, format))
+		if err := WriteFile(path, ds.ExportType, format, p.Rows); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// WriteFile writes rows of the export type to path as Parquet or CSV
+func WriteFile(path, exportType, format string, rows []map[string]any) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to close %s: %w", path, cerr)
+		}
+	}()
+
+	switch format {
+	case FormatParquet:
+		err = writeParquet(f, exportType, rows)
+	case FormatCSV:
+		err = writeCSV(f, exportType, rows)
+	default:
+		err = ValidateFormat(format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeParquet(w io.Writer, exportType string, rows []map[string]any) error {
+	schema, err := Schema(exportType)
+	if err != nil {
+		return err
+	}
+
+	writer := parquet.NewGenericWriter[map[string]any](w, schema, parquet.Compression(&snappy.Codec{}))
+	if _, err := writer.Write(rows); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// writeCSV writes a header row and one record per row. Map columns are JSON-encoded
+// and missing values are left empty.
+func writeCSV(w io.Writer, exportType string, rows []map[string]any) error {
+	columns, err := dataexport.Columns(exportType)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, c := range columns {
+			value, err := csvValue(row[c.Name])
+			if err != nil {
+				return fmt.Errorf("column %s: %w", c.Name, err)
+			}
+			record[i] = value
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(v any) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case time.Time:
+		return value.UTC().Format(timestampLayout), nil
+	case map[string]string, map[string]float64:
+		data, err := json.Marshal(value)
+		return string(data), err
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
@@ -0,0 +1,104 @@
+package serve
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+// defaultMaxEntries bounds the responses a cache holds
+const defaultMaxEntries = 1000
+
+// Cache holds encoded responses keyed by request. Results of closed billing periods no
+// longer change and are kept for ClosedTTL; results including the open billing period,
+// or of models without billing periods, for OpenTTL. A zero TTL disables caching.
+type Cache struct {
+	OpenTTL    time.Duration
+	ClosedTTL  time.Duration
+	MaxEntries int
+	Now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// Get returns the cached response of the key
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expires) {
+		return nil, false
+	}
+	return e.body, true
+}
+
+// Put caches the response of the key, which covers the billing periods
+func (c *Cache) Put(key string, periods []string, body []byte) {
+	ttl := c.ttl(periods)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]cacheEntry{}
+	}
+	now := c.now()
+	if len(c.entries) >= c.maxEntries() {
+		c.evict(now)
+	}
+	c.entries[key] = cacheEntry{body: body, expires: now.Add(ttl)}
+}
+
+// ttl returns OpenTTL when the periods include the current billing period
+func (c *Cache) ttl(periods []string) time.Duration {
+	if len(periods) == 0 {
+		return c.OpenTTL
+	}
+	current := c.now().UTC().Format(config.BillingPeriodLayout)
+	for _, p := range periods {
+		if p >= current {
+			return c.OpenTTL
+		}
+	}
+	return c.ClosedTTL
+}
+
+// evict removes the expired entries, then the entry expiring first if none had
+func (c *Cache) evict(now time.Time) {
+	var oldest string
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+			oldest = key
+		}
+	}
+	if len(c.entries) >= c.maxEntries() {
+		delete(c.entries, oldest)
+	}
+}
+
+func (c *Cache) maxEntries() int {
+	if c.MaxEntries > 0 {
+		return c.MaxEntries
+	}
+	return defaultMaxEntries
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
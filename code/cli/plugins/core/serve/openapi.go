@@ -0,0 +1,142 @@
+package serve
+
+import (
+	"strings"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+)
+
+// OpenAPI returns the OpenAPI 3 document of the API, generated from the model docs of
+// the served models
+func OpenAPI(c *catalog.Catalog, version string) map[string]any {
+	errorResponse := map[string]any{
+		"description": "Error",
+		"content":     jsonContent(map[string]any{"$ref": "#/components/schemas/Error"}),
+	}
+
+	paths := map[string]any{
+		"/v1/models": map[string]any{
+			"get": map[string]any{
+				"operationId": "listModels",
+				"summary":     "List the served models and their filters",
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "The served models",
+						"content":     jsonContent(map[string]any{"$ref": "#/components/schemas/ModelList"}),
+					},
+					"401": errorResponse,
+				},
+			},
+		},
+	}
+	schemas := map[string]any{
+		"Error": object(map[string]any{"error": map[string]any{"type": "string"}}),
+		"ModelList": object(map[string]any{
+			"models": map[string]any{"type": "array", "items": object(map[string]any{
+				"name":        map[string]any{"type": "string"},
+				"description": map[string]any{"type": "string"},
+				"path":        map[string]any{"type": "string"},
+				"filters":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			})},
+		}),
+	}
+
+	for _, m := range c.Models {
+		properties := map[string]any{}
+		for _, col := range m.Columns {
+			property := columnSchema(col.DataType)
+			if col.Description != "" {
+				property["description"] = col.Description
+			}
+			properties[col.Name] = property
+		}
+		schemas[m.Name] = object(properties)
+
+		parameters := []any{}
+		for _, f := range ModelFilters(m) {
+			parameters = append(parameters, queryParameter(f.Name, f.Description))
+		}
+		parameters = append(parameters,
+			queryParameter("group_by", "Comma-separated columns to group by. Metric columns are summed, avg_ columns averaged."),
+			map[string]any{
+				"name": "limit", "in": "query", "description": "Maximum number of rows.",
+				"schema": map[string]any{"type": "integer", "minimum": 1},
+			},
+		)
+
+		paths["/v1/models/"+m.Name] = map[string]any{
+			"get": map[string]any{
+				"operationId": "query_" + m.Name,
+				"summary":     m.Summary(),
+				"description": m.Description,
+				"parameters":  parameters,
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "The rows of " + m.Name,
+						"content": jsonContent(object(map[string]any{
+							"model":           map[string]any{"type": "string"},
+							"billing_periods": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+							"columns":         map[string]any{"type": "array", "items": object(map[string]any{"name": map[string]any{"type": "string"}, "type": map[string]any{"type": "string"}})},
+							"rows":            map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/" + m.Name}},
+							"truncated":       map[string]any{"type": "boolean", "description": "More rows match than the limit"},
+							"query_id":        map[string]any{"type": "string"},
+						})),
+					},
+					"400": errorResponse,
+					"401": errorResponse,
+					"502": errorResponse,
+				},
+			},
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "ecos API",
+			"description": "Read-only access to the ecos cost models.",
+			"version":     version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"apiKey":     map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{
+			map[string]any{"apiKey": []any{}},
+			map[string]any{"bearerAuth": []any{}},
+		},
+	}
+}
+
+// columnSchema maps a dbt data type to a JSON schema type
+func columnSchema(dataType string) map[string]any {
+	base, _, _ := strings.Cut(strings.ToLower(dataType), "(")
+	switch base {
+	case "decimal", "double", "float", "real":
+		return map[string]any{"type": "number"}
+	case "tinyint", "smallint", "int", "integer", "bigint":
+		return map[string]any{"type": "integer"}
+	case "boolean":
+		return map[string]any{"type": "boolean"}
+	case "date":
+		return map[string]any{"type": "string", "format": "date"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+func object(properties map[string]any) map[string]any {
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func queryParameter(name, description string) map[string]any {
+	return map[string]any{"name": name, "in": "query", "description": description, "schema": map[string]any{"type": "string"}}
+}
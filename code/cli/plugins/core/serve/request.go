@@ -0,0 +1,256 @@
+package serve
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+)
+
+// TagModel is the gold model served besides the serve models, as the source of the tag
+// filter
+const TagModel = "gold_tag__tag_cost_monthly"
+
+// maxPeriods bounds the billing periods of one request, which bounds the data it scans
+const maxPeriods = 36
+
+var accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// Filter is a query parameter narrowing the rows of a model. It applies to the models
+// documenting its column.
+type Filter struct {
+	Name        string
+	Column      string
+	Description string
+}
+
+// Filters are the filters of the model endpoints
+var Filters = []Filter{
+	{"period", "billing_period", "Billing period YYYY-MM or range YYYY-MM..YYYY-MM. Defaults to the previous month."},
+	{"account", "account_id", "Comma-separated account IDs."},
+	{"service", "service_code", "Comma-separated service names or codes."},
+	{"tag", "tag_key", "Tag key, or key=value to select one tag value."},
+}
+
+// Exposed reports whether the API serves the model: the serve models and the tag model
+func Exposed(m catalog.Model) bool {
+	return strings.HasPrefix(m.Name, "serve_") || m.Name == TagModel
+}
+
+// ModelFilters returns the filters that apply to the model
+func ModelFilters(m catalog.Model) []Filter {
+	var filters []Filter
+	for _, f := range Filters {
+		if m.HasColumns(f.Column) || (f.Name == "service" && m.HasColumns("service_name")) {
+			filters = append(filters, f)
+		}
+	}
+	return filters
+}
+
+// Request is a validated query of a model endpoint
+type Request struct {
+	Model    catalog.Model
+	Periods  []string
+	Accounts []string
+	Services []string
+	TagKey   string
+	TagValue string
+	GroupBy  []string
+	Limit    int
+}
+
+// ParseRequest validates the query parameters of a model endpoint. Limit defaults to
+// and is capped at maxRows.
+func ParseRequest(m catalog.Model, values url.Values, now time.Time, maxRows int) (Request, error) {
+	req := Request{Model: m, Limit: maxRows}
+	for name := range values {
+		if name != "group_by" && name != "limit" && !slices.ContainsFunc(ModelFilters(m), func(f Filter) bool { return f.Name == name }) {
+			return Request{}, fmt.Errorf("unsupported parameter '%s' for %s", name, m.Name)
+		}
+	}
+
+	var err error
+	if m.HasColumns("billing_period") {
+		if req.Periods, err = parsePeriods(values.Get("period"), now); err != nil {
+			return Request{}, err
+		}
+	}
+	for _, account := range splitList(values.Get("account")) {
+		if !accountIDPattern.MatchString(account) {
+			return Request{}, fmt.Errorf("invalid account '%s', expected a 12-digit account ID", account)
+		}
+		req.Accounts = append(req.Accounts, account)
+	}
+	req.Services = splitList(values.Get("service"))
+	if tag := values.Get("tag"); tag != "" {
+		req.TagKey, req.TagValue, _ = strings.Cut(tag, "=")
+		if req.TagKey == "" {
+			return Request{}, fmt.Errorf("invalid tag '%s', expected key or key=value", tag)
+		}
+	}
+
+	for _, name := range splitList(values.Get("group_by")) {
+		c, ok := m.Column(name)
+		if !ok {
+			return Request{}, fmt.Errorf("unknown group_by column '%s' for %s", name, m.Name)
+		}
+		if c.IsMetric() {
+			return Request{}, fmt.Errorf("cannot group by metric column '%s'", name)
+		}
+		if !slices.Contains(req.GroupBy, name) {
+			req.GroupBy = append(req.GroupBy, name)
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return Request{}, fmt.Errorf("invalid limit '%s', expected a positive number", limit)
+		}
+		req.Limit = min(n, maxRows)
+	}
+	return req, nil
+}
+
+// parsePeriods expands YYYY-MM or YYYY-MM..YYYY-MM into its billing periods. An empty
+// value is the month before now.
+func parsePeriods(value string, now time.Time) ([]string, error) {
+	if value == "" {
+		return []string{now.UTC().AddDate(0, -1, 0).Format(config.BillingPeriodLayout)}, nil
+	}
+
+	from, to, isRange := strings.Cut(value, "..")
+	if !isRange {
+		to = from
+	}
+	start, err := config.ParseBillingPeriod(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := config.ParseBillingPeriod(to)
+	if err != nil {
+		return nil, err
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("invalid period: %s is after %s", from, to)
+	}
+
+	var periods []string
+	for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+		if len(periods) == maxPeriods {
+			return nil, fmt.Errorf("invalid period: a request covers at most %d billing periods", maxPeriods)
+		}
+		periods = append(periods, m.Format(config.BillingPeriodLayout))
+	}
+	return periods, nil
+}
+
+// SQL returns the query of the request against the model in database. It selects one
+// row more than the limit to detect truncated results. Column names come from the model
+// docs and every value is a quoted literal.
+func (r Request) SQL(database string) string {
+	var sql strings.Builder
+	var metrics []string
+	if len(r.GroupBy) == 0 {
+		sql.WriteString("select *")
+	} else {
+		sql.WriteString("select " + strings.Join(r.GroupBy, ", "))
+		for _, c := range r.Model.Columns {
+			if !c.IsMetric() {
+				continue
+			}
+			// Averages such as avg_daily_resources are not additive
+			aggregate := "sum"
+			if strings.HasPrefix(c.Name, "avg_") {
+				aggregate = "avg"
+			}
+			sql.WriteString(fmt.Sprintf(", %s(%s) as %s", aggregate, c.Name, c.Name))
+			if aggregate == "sum" {
+				metrics = append(metrics, c.Name)
+			}
+		}
+	}
+	sql.WriteString(fmt.Sprintf(" from %q.%q", database, r.Model.Name))
+
+	var where []string
+	if len(r.Periods) > 0 {
+		where = append(where, "billing_period in ("+literals(r.Periods)+")")
+	}
+	if len(r.Accounts) > 0 {
+		where = append(where, "account_id in ("+literals(r.Accounts)+")")
+	}
+	if len(r.Services) > 0 {
+		var match []string
+		for _, column := range []string{"service_name", "service_code"} {
+			if r.Model.HasColumns(column) {
+				match = append(match, column+" in ("+literals(r.Services)+")")
+			}
+		}
+		where = append(where, "("+strings.Join(match, " or ")+")")
+	}
+	if r.TagKey != "" {
+		where = append(where, "tag_key = "+literal(r.TagKey))
+		if r.TagValue != "" {
+			where = append(where, "tag_value = "+literal(r.TagValue))
+		}
+	}
+	if len(where) > 0 {
+		sql.WriteString(" where " + strings.Join(where, " and "))
+	}
+
+	if len(r.GroupBy) > 0 {
+		positions := make([]string, len(r.GroupBy))
+		for i := range positions {
+			positions[i] = strconv.Itoa(i + 1)
+		}
+		sql.WriteString(" group by " + strings.Join(positions, ", "))
+		if len(metrics) > 0 {
+			sql.WriteString(" order by " + metrics[0] + " desc")
+		}
+	}
+	sql.WriteString(fmt.Sprintf(" limit %d", r.Limit+1))
+	return sql.String()
+}
+
+// CacheKey identifies the results of the request. It starts with the billing periods
+// the results depend on.
+func (r Request) CacheKey() string {
+	values := url.Values{}
+	values.Set("account", strings.Join(r.Accounts, ","))
+	values.Set("service", strings.Join(r.Services, ","))
+	values.Set("tag", r.TagKey+"="+r.TagValue)
+	values.Set("group_by", strings.Join(r.GroupBy, ","))
+	values.Set("limit", strconv.Itoa(r.Limit))
+	return strings.Join(r.Periods, ",") + "|" + r.Model.Name + "|" + values.Encode()
+}
+
+// literal quotes a SQL string literal
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func literals(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = literal(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// splitList splits a comma-separated parameter, ignoring empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,243 @@
+// Package serve exposes the models over a read-only HTTP API: one JSON endpoint per
+// served model, with filters, result caching and API-key authentication.
+package serve
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+)
+
+// DefaultMaxRows bounds the rows of a response unless MaxRows is set
+const DefaultMaxRows = 10000
+
+// Server answers the API requests with queries of the served models
+type Server struct {
+	Executor query.Executor
+	// Catalog holds the served models
+	Catalog  *catalog.Catalog
+	Database string
+	// Keys are the accepted API keys; a server without keys rejects every request
+	Keys    []string
+	Cache   *Cache
+	MaxRows int
+	Version string
+	Now     func() time.Time
+	// Logf, when set, is called once per request and for every failed query
+	Logf func(format string, args ...any)
+}
+
+// Response is the JSON body of a model endpoint
+type Response struct {
+	Model     string           `json:"model"`
+	Periods   []string         `json:"billing_periods,omitempty"`
+	Columns   []query.Column   `json:"columns"`
+	Rows      []map[string]any `json:"rows"`
+	Truncated bool             `json:"truncated"`
+	QueryID   string           `json:"query_id"`
+}
+
+// Handler returns the HTTP handler of the API. The OpenAPI document and the health check
+// are public, the /v1 endpoints need an API key.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, OpenAPI(s.Catalog, s.Version))
+	})
+	mux.Handle("GET /v1/models", s.authenticate(http.HandlerFunc(s.listModels)))
+	mux.Handle("GET /v1/models/{model}", s.authenticate(http.HandlerFunc(s.queryModel)))
+	return s.logRequests(mux)
+}
+
+func (s *Server) listModels(w http.ResponseWriter, _ *http.Request) {
+	type model struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Path        string   `json:"path"`
+		Filters     []string `json:"filters"`
+	}
+	models := []model{}
+	for _, m := range s.Catalog.Models {
+		filters := []string{}
+		for _, f := range ModelFilters(m) {
+			filters = append(filters, f.Name)
+		}
+		models = append(models, model{Name: m.Name, Description: m.Summary(), Path: "/v1/models/" + m.Name, Filters: filters})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"models": models})
+}
+
+func (s *Server) queryModel(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.Catalog.Model(r.PathValue("model"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model '%s' not found, see /v1/models", r.PathValue("model")))
+		return
+	}
+	req, err := ParseRequest(m, r.URL.Query(), s.now(), s.maxRows())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key := req.CacheKey()
+	if s.Cache != nil {
+		if body, ok := s.Cache.Get(key); ok {
+			w.Header().Set("X-Cache", "hit")
+			writeBody(w, http.StatusOK, body)
+			return
+		}
+	}
+
+	resp, err := s.run(r, req)
+	if err != nil {
+		s.logf("query of %s failed: %v", m.Name, err)
+		writeError(w, http.StatusBadGateway, "query failed, see the server log")
+		return
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if s.Cache != nil {
+		s.Cache.Put(key, req.Periods, body)
+	}
+	w.Header().Set("X-Cache", "miss")
+	writeBody(w, http.StatusOK, body)
+}
+
+// run queries the rows of the request. A cancelled request stops its query.
+func (s *Server) run(r *http.Request, req Request) (*Response, error) {
+	resp := &Response{Model: req.Model.Name, Periods: req.Periods, Rows: []map[string]any{}}
+	exec, err := s.Executor.Execute(r.Context(), req.SQL(s.Database), func(columns []query.Column, rows [][]string) error {
+		resp.Columns = columns
+		for _, row := range rows {
+			if len(resp.Rows) == req.Limit {
+				resp.Truncated = true
+				return query.ErrStop
+			}
+			values := make(map[string]any, len(columns))
+			for i, c := range columns {
+				if i >= len(row) {
+					break
+				}
+				v, err := c.Value(row[i])
+				if err != nil {
+					return fmt.Errorf("invalid %s value '%s': %w", c.Name, row[i], err)
+				}
+				values[c.Name] = v
+			}
+			resp.Rows = append(resp.Rows, values)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.QueryID = exec.ID
+	if resp.Columns == nil {
+		resp.Columns = exec.Columns
+	}
+	return resp, nil
+}
+
+// authenticate accepts requests with a valid key in the X-API-Key header or as a
+// bearer token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			key, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if key == "" || !s.validKey(key) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ecos"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ParseKeys splits a comma-separated list of API keys
+func ParseKeys(value string) []string {
+	return splitList(value)
+}
+
+func (s *Server) validKey(key string) bool {
+	valid := false
+	for _, k := range s.Keys {
+		// Compare every key so the time taken does not tell which one matched
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// statusRecorder keeps the status code written to the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		line := fmt.Sprintf("%s %s %d %s %s", r.Method, r.URL.RequestURI(), rec.status, time.Since(started).Round(time.Millisecond), w.Header().Get("X-Cache"))
+		s.logf("%s", strings.TrimSpace(line))
+	})
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+func (s *Server) maxRows() int {
+	if s.MaxRows > 0 {
+		return s.MaxRows
+	}
+	return DefaultMaxRows
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		status, body = http.StatusInternalServerError, []byte(`{"error":"failed to encode the response"}`)
+	}
+	writeBody(w, status, body)
+}
+
+func writeBody(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
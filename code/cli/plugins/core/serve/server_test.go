@@ -0,0 +1,269 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/catalog"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+)
+
+var testNow = time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+var dailyModel = catalog.Model{
+	Name:        "serve_core__cost_service_account_daily",
+	Description: "Daily cost by service and account.\n\nKey features: enriched account names.",
+	Columns: []catalog.Column{
+		{Name: "usage_date", DataType: "date"},
+		{Name: "billing_period", DataType: "varchar"},
+		{Name: "account_id", DataType: "varchar"},
+		{Name: "service_code", DataType: "varchar"},
+		{Name: "service_name", DataType: "varchar"},
+		{Name: "total_effective_cost", Description: "Effective cost.", DataType: "decimal"},
+	},
+}
+
+var tagModel = catalog.Model{
+	Name: TagModel,
+	Columns: []catalog.Column{
+		{Name: "account_id", DataType: "varchar"},
+		{Name: "service_code", DataType: "varchar"},
+		{Name: "tag_key", DataType: "varchar"},
+		{Name: "tag_value", DataType: "varchar"},
+		{Name: "avg_daily_resources", DataType: "decimal"},
+		{Name: "total_effective_cost", DataType: "decimal"},
+		{Name: "billing_period", DataType: "varchar"},
+	},
+}
+
+var accountsModel = catalog.Model{
+	Name:    "serve_meta__account_metadata",
+	Columns: []catalog.Column{{Name: "account_id", DataType: "varchar"}, {Name: "account_name", DataType: "varchar"}},
+}
+
+// fakeExecutor returns two rows to every query and records the queries
+type fakeExecutor struct {
+	queries []string
+	err     error
+}
+
+func (f *fakeExecutor) Execute(_ context.Context, sql string, page func([]query.Column, [][]string) error) (*query.Execution, error) {
+	f.queries = append(f.queries, sql)
+	if f.err != nil {
+		return nil, f.err
+	}
+	columns := []query.Column{{Name: "service_name", Type: "varchar"}, {Name: "total_effective_cost", Type: "decimal(38,10)"}}
+	if err := page(columns, [][]string{{"Amazon EC2", "700.5"}, {"Amazon S3", ""}}); err != nil && err != query.ErrStop {
+		return nil, err
+	}
+	return &query.Execution{ID: "q-1", Columns: columns}, nil
+}
+
+func newTestServer() (*Server, *fakeExecutor) {
+	executor := &fakeExecutor{}
+	now := func() time.Time { return testNow }
+	return &Server{
+		Executor: executor,
+		Catalog:  &catalog.Catalog{Models: []catalog.Model{dailyModel, tagModel, accountsModel}},
+		Database: "ecos",
+		Keys:     []string{"key-1", "key-2"},
+		Cache:    &Cache{OpenTTL: time.Minute, ClosedTTL: time.Hour, Now: now},
+		Version:  "1.2.3",
+		Now:      now,
+	}, executor
+}
+
+func get(t *testing.T, handler http.Handler, target string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestServer_Auth(t *testing.T) {
+	server, _ := newTestServer()
+	handler := server.Handler()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"no key", nil, http.StatusUnauthorized},
+		{"invalid key", map[string]string{"X-API-Key": "key-3"}, http.StatusUnauthorized},
+		{"api key header", map[string]string{"X-API-Key": "key-2"}, http.StatusOK},
+		{"bearer token", map[string]string{"Authorization": "Bearer key-1"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := get(t, handler, "/v1/models", tt.headers); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	// The spec and the health check are public
+	for _, target := range []string{"/openapi.json", "/healthz"} {
+		if w := get(t, handler, target, nil); w.Code != http.StatusOK {
+			t.Errorf("%s status = %d", target, w.Code)
+		}
+	}
+}
+
+func TestServer_QueryModel(t *testing.T) {
+	server, executor := newTestServer()
+	handler := server.Handler()
+	auth := map[string]string{"X-API-Key": "key-1"}
+
+	w := get(t, handler, "/v1/models/serve_core__cost_service_account_daily?period=2025-04..2025-05&service=Amazon+EC2&group_by=service_name", auth)
+	if w.Code != http.StatusOK || w.Header().Get("X-Cache") != "miss" {
+		t.Fatalf("status = %d, cache = %s, body = %s", w.Code, w.Header().Get("X-Cache"), w.Body)
+	}
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Rows) != 2 || resp.Rows[0]["total_effective_cost"] != 700.5 || resp.Rows[1]["total_effective_cost"] != nil || resp.QueryID != "q-1" {
+		t.Errorf("response = %+v", resp)
+	}
+	want := `select service_name, sum(total_effective_cost) as total_effective_cost from "ecos"."serve_core__cost_service_account_daily" where billing_period in ('2025-04', '2025-05') and (service_name in ('Amazon EC2') or service_code in ('Amazon EC2')) group by 1 order by total_effective_cost desc limit 10001`
+	if executor.queries[0] != want {
+		t.Errorf("query = %s", executor.queries[0])
+	}
+
+	// The same request is answered from the cache, whatever the parameter order
+	w = get(t, handler, "/v1/models/serve_core__cost_service_account_daily?group_by=service_name&service=Amazon+EC2&period=2025-04..2025-05", auth)
+	if w.Code != http.StatusOK || w.Header().Get("X-Cache") != "hit" || len(executor.queries) != 1 {
+		t.Errorf("cached request: status = %d, cache = %s, queries = %d", w.Code, w.Header().Get("X-Cache"), len(executor.queries))
+	}
+
+	// Rows beyond the limit are truncated
+	w = get(t, handler, "/v1/models/serve_meta__account_metadata?limit=1", auth)
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.Truncated || len(resp.Rows) != 1 || executor.queries[1] != `select * from "ecos"."serve_meta__account_metadata" limit 2` {
+		t.Errorf("limited response = %+v, query = %s", resp, executor.queries[1])
+	}
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/v1/models/gold_core__service_monthly", http.StatusNotFound},
+		{"/v1/models/serve_meta__account_metadata?period=2025-04", http.StatusBadRequest},
+		{"/v1/models/serve_core__cost_service_account_daily?tag=team", http.StatusBadRequest},
+		{"/v1/models/serve_core__cost_service_account_daily?account=123", http.StatusBadRequest},
+		{"/v1/models/serve_core__cost_service_account_daily?group_by=total_effective_cost", http.StatusBadRequest},
+		{"/v1/models/serve_core__cost_service_account_daily?limit=0", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := get(t, handler, tt.target, auth); w.Code != tt.want {
+			t.Errorf("%s status = %d, want %d: %s", tt.target, w.Code, tt.want, w.Body)
+		}
+	}
+
+	// Query errors are logged, not returned
+	executor.err = context.DeadlineExceeded
+	var logged []string
+	server.Logf = func(format string, args ...any) { logged = append(logged, format) }
+	w = get(t, handler, "/v1/models/serve_core__cost_service_account_daily?period=2025-06", auth)
+	if w.Code != http.StatusBadGateway || strings.Contains(w.Body.String(), "deadline") || len(logged) != 2 {
+		t.Errorf("failed query: status = %d, body = %s, logged = %v", w.Code, w.Body, logged)
+	}
+}
+
+func TestRequest_SQL(t *testing.T) {
+	values := url.Values{"tag": {"team=o'brien"}, "account": {"111111111111,222222222222"}, "group_by": {"tag_value"}}
+	req, err := ParseRequest(tagModel, values, testNow, 100)
+	if err != nil {
+		t.Fatalf("ParseRequest() error = %v", err)
+	}
+	want := `select tag_value, avg(avg_daily_resources) as avg_daily_resources, sum(total_effective_cost) as total_effective_cost from "ecos"."gold_tag__tag_cost_monthly" where billing_period in ('2025-05') and account_id in ('111111111111', '222222222222') and tag_key = 'team' and tag_value = 'o''brien' group by 1 order by total_effective_cost desc limit 101`
+	if got := req.SQL("ecos"); got != want {
+		t.Errorf("SQL() = %s", got)
+	}
+
+	if _, err := ParseRequest(dailyModel, url.Values{"period": {"2020-01..2025-01"}}, testNow, 100); err == nil {
+		t.Error("ParseRequest() expected error for more than 36 billing periods")
+	}
+	if _, err := ParseRequest(dailyModel, url.Values{"period": {"2025-05..2025-04"}}, testNow, 100); err == nil {
+		t.Error("ParseRequest() expected error for a reversed range")
+	}
+	if req, _ := ParseRequest(dailyModel, url.Values{"limit": {"500"}}, testNow, 100); req.Limit != 100 {
+		t.Errorf("ParseRequest() limit = %d, want the maximum", req.Limit)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	now := testNow
+	cache := &Cache{OpenTTL: time.Minute, ClosedTTL: time.Hour, MaxEntries: 2, Now: func() time.Time { return now }}
+	cache.Put("open", []string{"2025-05", "2025-06"}, []byte("open"))
+	cache.Put("closed", []string{"2025-05"}, []byte("closed"))
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get("open"); ok {
+		t.Error("results of the open billing period outlived OpenTTL")
+	}
+	if body, ok := cache.Get("closed"); !ok || string(body) != "closed" {
+		t.Error("results of a closed billing period expired before ClosedTTL")
+	}
+
+	// A full cache evicts the expired entries first
+	cache.Put("other", []string{"2025-01"}, []byte("other"))
+	if _, ok := cache.Get("closed"); !ok {
+		t.Error("a valid entry was evicted before an expired one")
+	}
+
+	disabled := &Cache{ClosedTTL: time.Hour}
+	disabled.Put("open", nil, []byte("open"))
+	if _, ok := disabled.Get("open"); ok {
+		t.Error("a zero OpenTTL cached the results of a model without billing periods")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	server, _ := newTestServer()
+	w := get(t, server.Handler(), "/openapi.json", nil)
+
+	var spec struct {
+		Info  struct{ Version string }
+		Paths map[string]struct {
+			Get struct {
+				Summary    string
+				Parameters []struct{ Name string }
+			}
+		}
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]struct{ Type, Description string }
+			}
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	if spec.Info.Version != "1.2.3" || len(spec.Paths) != 4 {
+		t.Errorf("spec = %+v", spec)
+	}
+
+	daily := spec.Paths["/v1/models/serve_core__cost_service_account_daily"].Get
+	var params []string
+	for _, p := range daily.Parameters {
+		params = append(params, p.Name)
+	}
+	if daily.Summary != "Daily cost by service and account." || strings.Join(params, ",") != "period,account,service,group_by,limit" {
+		t.Errorf("daily endpoint = %+v", daily)
+	}
+	if cost := spec.Components.Schemas[dailyModel.Name].Properties["total_effective_cost"]; cost.Type != "number" || cost.Description != "Effective cost." {
+		t.Errorf("total_effective_cost schema = %+v", cost)
+	}
+}
@@ -0,0 +1,264 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ecosconfig "github.com/ecos-labs/ecos/code/cli/config"
+)
+
+// CheckpointFilename records the completed chunks of a backfill in the ecos project directory
+const CheckpointFilename = ".ecos-backfill.json"
+
+// Chunk is a range of billing periods processed by one transform run
+type Chunk struct {
+	Start string // first billing period, YYYY-MM
+	End   string // last billing period, YYYY-MM
+}
+
+// String returns the chunk as YYYY-MM or YYYY-MM..YYYY-MM
+func (c Chunk) String() string {
+	if c.Start == c.End {
+		return c.Start
+	}
+	return c.Start + ".." + c.End
+}
+
+// ParseChunkSize parses a chunk size such as 1m, 3m or 1y into a number of months
+func ParseChunkSize(size string) (int, error) {
+	invalid := fmt.Errorf("invalid chunk size '%s', expected a number of months or years such as 1m, 3m or 1y", size)
+	if len(size) < 2 {
+		return 0, invalid
+	}
+
+	n, err := strconv.Atoi(size[:len(size)-1])
+	if err != nil || n < 1 {
+		return 0, invalid
+	}
+	switch strings.ToLower(size[len(size)-1:]) {
+	case "m":
+		return n, nil
+	case "y":
+		return n * 12, nil
+	default:
+		return 0, invalid
+	}
+}
+
+// PlanChunks splits the billing periods from..to (inclusive, YYYY-MM) into chunks of
+// the given number of months. The last chunk may be shorter.
+func PlanChunks(from, to string, months int) ([]Chunk, error) {
+	start, err := ecosconfig.ParseBillingPeriod(from)
+	if err != nil {
+		return nil, fmt.Errorf("--from: %w", err)
+	}
+	end, err := ecosconfig.ParseBillingPeriod(to)
+	if err != nil {
+		return nil, fmt.Errorf("--to: %w", err)
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("--from %s is after --to %s", from, to)
+	}
+	if months < 1 {
+		return nil, fmt.Errorf("invalid chunk size of %d months", months)
+	}
+
+	var chunks []Chunk
+	for s := start; !s.After(end); s = s.AddDate(0, months, 0) {
+		e := s.AddDate(0, months-1, 0)
+		if e.After(end) {
+			e = end
+		}
+		chunks = append(chunks, Chunk{
+			Start: s.Format(ecosconfig.BillingPeriodLayout),
+			End:   e.Format(ecosconfig.BillingPeriodLayout),
+		})
+	}
+	return chunks, nil
+}
+
+// Checkpoint records which chunks of a backfill completed, so an interrupted backfill
+// can be resumed. A checkpoint only applies to a backfill with the same range, chunk
+// size and arguments.
+type Checkpoint struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Chunk     string    `json:"chunk"`
+	Command   string    `json:"command"`
+	Args      []string  `json:"args,omitempty"`
+	Completed []string  `json:"completed"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// NewCheckpoint returns an empty checkpoint saved to path
+func NewCheckpoint(path, from, to, chunk, command string, args []string) *Checkpoint {
+	return &Checkpoint{
+		From:      from,
+		To:        to,
+		Chunk:     chunk,
+		Command:   command,
+		Args:      args,
+		Completed: []string{},
+		path:      path,
+	}
+}
+
+// LoadCheckpoint reads the checkpoint at path. It returns nil without an error when
+// there is none.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backfill checkpoint: %w", err)
+	}
+
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse backfill checkpoint %s: %w", path, err)
+	}
+	c.path = path
+	return &c, nil
+}
+
+// Matches reports whether the checkpoint was recorded for the same backfill as other
+func (c *Checkpoint) Matches(other *Checkpoint) bool {
+	return c.From == other.From && c.To == other.To && c.Chunk == other.Chunk &&
+		c.Command == other.Command && slices.Equal(c.Args, other.Args)
+}
+
+// IsCompleted reports whether the chunk completed in an earlier run
+func (c *Checkpoint) IsCompleted(chunk Chunk) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Contains(c.Completed, chunk.String())
+}
+
+// MarkCompleted records the chunk as completed and saves the checkpoint
+func (c *Checkpoint) MarkCompleted(chunk Chunk) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !slices.Contains(c.Completed, chunk.String()) {
+		c.Completed = append(c.Completed, chunk.String())
+	}
+	return c.save()
+}
+
+// Save writes the checkpoint to its path
+func (c *Checkpoint) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
+}
+
+// Remove deletes the checkpoint once the backfill completed
+func (c *Checkpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove backfill checkpoint: %w", err)
+	}
+	return nil
+}
+
+// save writes the checkpoint through a temporary file, so an interrupted write never
+// leaves a truncated checkpoint behind
+func (c *Checkpoint) save() error {
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write backfill checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write backfill checkpoint: %w", err)
+	}
+	return nil
+}
+
+// CheckpointPath returns the checkpoint location for the ecos project directory
+func CheckpointPath(projectDir string) string {
+	return filepath.Join(projectDir, CheckpointFilename)
+}
+
+// ChunkResult is the outcome of one chunk of a backfill
+type ChunkResult struct {
+	Chunk    Chunk
+	Err      error
+	Skipped  bool // completed in an earlier run
+	NotRun   bool // not started because another chunk failed
+	Duration time.Duration
+}
+
+// RunChunks runs the chunks in order with at most parallel chunks at once. Chunks the
+// checkpoint marks as completed are skipped, and completed chunks are recorded in it.
+// After a chunk fails no further chunks are started; running chunks finish.
+func RunChunks(ctx context.Context, chunks []Chunk, parallel int, checkpoint *Checkpoint, run func(context.Context, Chunk) error) []ChunkResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]ChunkResult, len(chunks))
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	slots := make(chan struct{}, parallel)
+
+	for i, chunk := range chunks {
+		results[i].Chunk = chunk
+		if checkpoint != nil && checkpoint.IsCompleted(chunk) {
+			results[i].Skipped = true
+			continue
+		}
+
+		slots <- struct{}{}
+		mu.Lock()
+		stop := failed || ctx.Err() != nil
+		mu.Unlock()
+		if stop {
+			<-slots
+			results[i].NotRun = true
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, chunk Chunk) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			started := time.Now()
+			err := run(ctx, chunk)
+			if err == nil && checkpoint != nil {
+				err = checkpoint.MarkCompleted(chunk)
+			}
+			results[i].Err = err
+			results[i].Duration = time.Since(started)
+
+			if err != nil {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+			}
+		}(i, chunk)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,151 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseChunkSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int
+		wantErr bool
+	}{
+		{"1m", 1, false},
+		{"3M", 3, false},
+		{"1y", 12, false},
+		{"0m", 0, true},
+		{"m", 0, true},
+		{"2w", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := ParseChunkSize(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChunkSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseChunkSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanChunks(t *testing.T) {
+	chunks, err := PlanChunks("2024-11", "2025-06", 3)
+	if err != nil {
+		t.Fatalf("PlanChunks() error = %v", err)
+	}
+	want := []Chunk{{"2024-11", "2025-01"}, {"2025-02", "2025-04"}, {"2025-05", "2025-06"}}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("PlanChunks() = %v, want %v", chunks, want)
+	}
+
+	monthly, _ := PlanChunks("2024-01", "2024-01", 1)
+	if len(monthly) != 1 || monthly[0].String() != "2024-01" {
+		t.Errorf("PlanChunks() = %v, want a single 2024-01 chunk", monthly)
+	}
+
+	if _, err := PlanChunks("2025-06", "2024-01", 1); err == nil {
+		t.Error("PlanChunks() expected error for --from after --to")
+	}
+	if _, err := PlanChunks("2024-1", "2024-06", 1); err == nil {
+		t.Error("PlanChunks() expected error for a malformed billing period")
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := CheckpointPath(t.TempDir())
+
+	if c, err := LoadCheckpoint(path); err != nil || c != nil {
+		t.Fatalf("LoadCheckpoint() = %v, %v for a missing file, want nil, nil", c, err)
+	}
+
+	c := NewCheckpoint(path, "2024-01", "2024-06", "1m", "run", []string{"--select", "tag:cur"})
+	if err := c.MarkCompleted(Chunk{"2024-01", "2024-01"}); err != nil {
+		t.Fatalf("MarkCompleted() error = %v", err)
+	}
+
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if !loaded.Matches(c) || !loaded.IsCompleted(Chunk{"2024-01", "2024-01"}) || loaded.IsCompleted(Chunk{"2024-02", "2024-02"}) {
+		t.Errorf("loaded checkpoint = %+v", loaded)
+	}
+	if loaded.Matches(NewCheckpoint(path, "2024-01", "2024-06", "1m", "run", nil)) {
+		t.Error("Matches() ignored different arguments")
+	}
+
+	if err := loaded.Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if c, _ := LoadCheckpoint(path); c != nil {
+		t.Error("checkpoint still exists after Remove()")
+	}
+}
+
+func TestRunChunks(t *testing.T) {
+	chunks, _ := PlanChunks("2024-01", "2024-06", 1)
+	checkpoint := NewCheckpoint(filepath.Join(t.TempDir(), CheckpointFilename), "2024-01", "2024-06", "1m", "run", nil)
+	_ = checkpoint.MarkCompleted(chunks[0])
+
+	var running, maxRunning int32
+	var mu sync.Mutex
+	var ran []string
+	results := RunChunks(context.Background(), chunks, 2, checkpoint, func(_ context.Context, c Chunk) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		ran = append(ran, c.String())
+		mu.Unlock()
+		return nil
+	})
+
+	if !results[0].Skipped || len(ran) != 5 {
+		t.Errorf("ran %v, want the 5 chunks not completed earlier", ran)
+	}
+	if maxRunning > 2 {
+		t.Errorf("%d chunks ran at once, want at most 2", maxRunning)
+	}
+	for _, c := range chunks {
+		if !checkpoint.IsCompleted(c) {
+			t.Errorf("chunk %s not recorded as completed", c)
+		}
+	}
+}
+
+func TestRunChunks_StopsAfterFailure(t *testing.T) {
+	chunks, _ := PlanChunks("2024-01", "2024-04", 1)
+	checkpoint := NewCheckpoint(filepath.Join(t.TempDir(), CheckpointFilename), "2024-01", "2024-04", "1m", "run", nil)
+
+	results := RunChunks(context.Background(), chunks, 1, checkpoint, func(_ context.Context, c Chunk) error {
+		if c.Start == "2024-02" {
+			return errors.New("query timed out")
+		}
+		return nil
+	})
+
+	if results[0].Err != nil || results[1].Err == nil || !results[2].NotRun || !results[3].NotRun {
+		t.Errorf("results = %+v, want 2024-01 completed, 2024-02 failed and the rest not run", results)
+	}
+	if !checkpoint.IsCompleted(chunks[0]) || checkpoint.IsCompleted(chunks[1]) {
+		t.Errorf("checkpoint completed = %v, want only 2024-01", checkpoint.Completed)
+	}
+}
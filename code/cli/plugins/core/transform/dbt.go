@@ -2,25 +2,51 @@ package transform
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	ecosconfig "github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/overlay"
 	"github.com/ecos-labs/ecos/code/cli/plugins/types"
 	"github.com/ecos-labs/ecos/code/cli/utils"
 	"github.com/subosito/gotenv"
+	"gopkg.in/yaml.v3"
 )
 
 // DBTTransformPlugin implements the TransformPlugin interface for dbt
 type DBTTransformPlugin struct {
 	ProjectDir string
+
+	// credentialEnv caches resolved AWS credentials handed to dbt (assume-role, credential_process)
+	credentialEnv []string
+	// credentialExpires is when the cached credentials expire, zero when they do not
+	credentialExpires time.Time
+	// credentialCfg keeps the loaded AWS config, so expiring credentials are refreshed
+	// from its credentials cache without loading the profile again
+	credentialCfg *aws.Config
+	// credentialMu guards the cached credentials when billing period runs execute concurrently
+	credentialMu sync.Mutex
 }
 
+// credentialRefreshWindow is how long before they expire cached credentials are
+// resolved again, so a dbt run does not start with credentials about to expire
+const credentialRefreshWindow = 15 * time.Minute
+
+// backfillDir keeps the artifacts and logs of isolated billing period runs apart from
+// those of regular runs, under the dbt target and log paths
+const backfillDir = "backfill"
+
 // Name returns the plugin name
 func (p *DBTTransformPlugin) Name() string {
 	return "dbt"
@@ -211,10 +237,25 @@ func (p *DBTTransformPlugin) ExecuteCommand(ctx context.Context, command string,
 	// Note: os.Environ() captures the current state including any variables loaded by gotenv
 	cmd.Env = os.Environ()
 
+	// Hand ecos-resolved AWS credentials to dbt when a role or credential_process is configured
+	credentialEnv, err := p.resolveCredentialEnv(ctx, config)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(cmd.Env, credentialEnv...)
+
+	// Point boto3 at the same custom endpoints (LocalStack, VPC endpoints) as ecos
+	endpoints, _ := config["aws_endpoints"].(ecosconfig.AWSEndpointsConfig)
+	cmd.Env = append(cmd.Env, awssession.EndpointEnv(endpoints)...)
+
 	// Capture both stdout and stderr to check for dependency errors while still showing output to user
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if output, ok := config["output"].(io.Writer); ok {
+		stdout, stderr = output, output
+	}
 	var stdoutBuf, stderrBuf strings.Builder
-	cmd.Stdout = io.MultiWriter(os.Stdout, &stdoutBuf)
-	cmd.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
+	cmd.Stdout = io.MultiWriter(stdout, &stdoutBuf)
+	cmd.Stderr = io.MultiWriter(stderr, &stderrBuf)
 
 	err = cmd.Run()
 	if err != nil {
@@ -235,6 +276,71 @@ func (p *DBTTransformPlugin) ExecuteCommand(ctx context.Context, command string,
 	return err
 }
 
+// RunBillingPeriods runs a dbt command for the billing periods run.Start to run.End by
+// setting the billing_period_start and billing_period_end vars. Vars passed with --vars
+// are kept. Isolated runs write to their own target and log paths so that several
+// chunks can run at once.
+func (p *DBTTransformPlugin) RunBillingPeriods(ctx context.Context, command string, args []string, run types.BillingPeriodRun, config map[string]any) error {
+	args, vars, err := splitVarsArg(args)
+	if err != nil {
+		return err
+	}
+	vars["billing_period_start"] = run.Start
+	vars["billing_period_end"] = run.End
+
+	if run.Isolated {
+		// Concurrent insert_overwrite runs of a model must not share its __dbt_tmp table
+		vars["ecos_unique_tmp_table_suffix"] = true
+		args = append(args,
+			"--target-path", filepath.Join("target", backfillDir, run.Start),
+			"--log-path", filepath.Join("logs", backfillDir, run.Start),
+		)
+	}
+
+	encoded, err := json.Marshal(vars)
+	if err != nil {
+		return fmt.Errorf("failed to encode dbt vars: %w", err)
+	}
+	args = append(args, "--vars", string(encoded))
+
+	runConfig := maps.Clone(config)
+	if run.Output != nil {
+		runConfig["output"] = run.Output
+	}
+	return p.ExecuteCommand(ctx, command, args, runConfig)
+}
+
+// splitVarsArg removes --vars from args and returns its decoded value. dbt accepts
+// YAML (and therefore JSON) for --vars.
+func splitVarsArg(args []string) ([]string, map[string]any, error) {
+	vars := map[string]any{}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		var value string
+		switch {
+		case args[i] == "--vars":
+			if i+1 >= len(args) {
+				return nil, nil, errors.New("--vars requires a value")
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--vars="):
+			value = strings.TrimPrefix(args[i], "--vars=")
+		default:
+			rest = append(rest, args[i])
+			continue
+		}
+
+		if err := yaml.Unmarshal([]byte(value), &vars); err != nil {
+			return nil, nil, fmt.Errorf("invalid --vars '%s': %w", value, err)
+		}
+		if vars == nil {
+			vars = map[string]any{}
+		}
+	}
+	return rest, vars, nil
+}
+
 // PrepareEnvironment sets up the dbt execution environment
 func (p *DBTTransformPlugin) PrepareEnvironment(ctx context.Context, config map[string]any) error {
 	// Load .env file if it exists
@@ -243,9 +349,36 @@ func (p *DBTTransformPlugin) PrepareEnvironment(ctx context.Context, config map[
 		// Don't fail - just warn and continue
 	}
 
+	if err := p.applyOverlay(config); err != nil {
+		return err
+	}
+
 	return p.prepareEnvironmentWithOptions(ctx, config, false)
 }
 
+// applyOverlay merges the custom overlay directory into the dbt project, or removes a
+// previously merged one when none is configured
+func (p *DBTTransformPlugin) applyOverlay(config map[string]any) error {
+	dbtDir := p.getProjectDir(config)
+	customDir, _ := config["custom_dir"].(string)
+	if customDir == "" {
+		return overlay.Clean(dbtDir)
+	}
+	if projectDir, ok := config["project_dir"].(string); ok && !filepath.IsAbs(customDir) {
+		customDir = filepath.Join(projectDir, customDir)
+	}
+
+	o, err := overlay.Load(dbtDir, customDir)
+	if err != nil {
+		return err
+	}
+	if err := o.Apply(); err != nil {
+		return fmt.Errorf("failed to apply the custom overlay: %w", err)
+	}
+	utils.PrintSuccess(fmt.Sprintf("Custom overlay applied (%d files, %d overrides)", len(o.Files), len(o.Overrides)))
+	return nil
+}
+
 // prepareEnvironmentWithOptions sets up the dbt execution environment with optional force install
 func (p *DBTTransformPlugin) prepareEnvironmentWithOptions(ctx context.Context, config map[string]any, forceInstall bool) error {
 	projectDir := p.getProjectDir(config)
@@ -498,10 +631,19 @@ func (p *DBTTransformPlugin) BuildConfig(ecosConfig any, command string, args []
 			config["vars"] = cfg.Transform.DBT.Vars
 		}
 
+		// The generated profile has no aws_profile_name in this case, so credentials are injected
+		if cfg.AWS.UsesInjectedCredentials() {
+			config["aws_session"] = awssession.FromConfig(cfg)
+		}
+		config["aws_endpoints"] = cfg.AWS.Endpoints
+
 		// Set dbt project directory - prioritize explicit config from .ecos.yaml
 		if cfg.Transform.DBT.ProjectDir != "" {
 			config["dbt_project_dir"] = cfg.Transform.DBT.ProjectDir
 		}
+		if cfg.Transform.DBT.CustomDir != "" {
+			config["custom_dir"] = cfg.Transform.DBT.CustomDir
+		}
 
 		// Add transform-specific configuration from .ecos.yaml
 		if cfg.Transform.Config != nil {
@@ -514,6 +656,44 @@ func (p *DBTTransformPlugin) BuildConfig(ecosConfig any, command string, args []
 	return config
 }
 
+// resolveCredentialEnv assumes the configured role (or runs the credential_process) and
+// returns the resulting credentials as AWS_* environment variables. The credentials are
+// reused across dbt runs until they get close to expiring, then resolved again.
+func (p *DBTTransformPlugin) resolveCredentialEnv(ctx context.Context, config map[string]any) ([]string, error) {
+	opts, ok := config["aws_session"].(awssession.Options)
+	if !ok {
+		return nil, nil
+	}
+
+	p.credentialMu.Lock()
+	defer p.credentialMu.Unlock()
+	if p.credentialEnv != nil && (p.credentialExpires.IsZero() || time.Until(p.credentialExpires) > credentialRefreshWindow) {
+		return p.credentialEnv, nil
+	}
+
+	if p.credentialCfg == nil {
+		awsCfg, err := awssession.Load(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		p.credentialCfg = &awsCfg
+	} else if cache, ok := p.credentialCfg.Credentials.(*aws.CredentialsCache); ok {
+		// The cache hands out the same credentials until they have expired
+		cache.Invalidate()
+	}
+
+	env, expires, err := awssession.CredentialEnv(ctx, *p.credentialCfg)
+	if err != nil {
+		return nil, awssession.ExplainError(err, opts.Profile)
+	}
+	if p.credentialEnv != nil {
+		utils.PrintDebug("Refreshed the AWS credentials handed to dbt")
+	}
+	p.credentialEnv = env
+	p.credentialExpires = expires
+	return env, nil
+}
+
 // loadEnvironmentFile loads environment variables from .env file in dbt project directory
 func (p *DBTTransformPlugin) loadEnvironmentFile(config map[string]any) error {
 	dbtProjectDir := p.getProjectDir(config)
@@ -1,10 +1,17 @@
 package transform
 
 import (
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/awssession"
 	"github.com/ecos-labs/ecos/code/cli/plugins/types"
 )
 
@@ -758,3 +765,156 @@ func indexString(s, substr string) int {
 	}
 	return -1
 }
+
+func TestSplitVarsArg(t *testing.T) {
+	rest, vars, err := splitVarsArg([]string{"--select", "tag:cur", "--vars", "{cur_table: cur2}", "--vars={\"lookback_months\": 3}"})
+	if err != nil {
+		t.Fatalf("splitVarsArg() error = %v", err)
+	}
+	if len(rest) != 2 || rest[0] != "--select" || rest[1] != "tag:cur" {
+		t.Errorf("rest = %v, want [--select tag:cur]", rest)
+	}
+	if vars["cur_table"] != "cur2" || vars["lookback_months"] != 3 {
+		t.Errorf("vars = %v, want cur_table and lookback_months", vars)
+	}
+
+	if _, _, err := splitVarsArg([]string{"--vars"}); err == nil {
+		t.Error("splitVarsArg() expected error for --vars without a value")
+	}
+	if _, _, err := splitVarsArg([]string{"--vars", "[1, 2"}); err == nil {
+		t.Error("splitVarsArg() expected error for invalid YAML")
+	}
+}
+
+func TestDBTTransformPlugin_RunBillingPeriods(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake dbt executable is a shell script")
+	}
+
+	// A fake dbt records its arguments, one per line
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, "dbt"), []byte(script), 0o700); err != nil {
+		t.Fatalf("failed to write fake dbt: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	plugin := &DBTTransformPlugin{}
+	config := map[string]any{"dbt_project_dir": t.TempDir()}
+	var output strings.Builder
+	run := types.BillingPeriodRun{Start: "2024-01", End: "2024-03", Isolated: true, Output: &output}
+
+	if err := plugin.RunBillingPeriods(context.Background(), "run", []string{"--select", "tag:cur", "--vars", "{cur_table: cur2}"}, run, config); err != nil {
+		t.Fatalf("RunBillingPeriods() error = %v", err)
+	}
+	if _, ok := config["output"]; ok {
+		t.Error("RunBillingPeriods() modified the shared config")
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("fake dbt was not run: %v", err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"run --profiles-dir", "--select tag:cur", "--target-path target/backfill/2024-01", "--log-path logs/backfill/2024-01"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("dbt args %q missing %q", joined, want)
+		}
+	}
+
+	var vars map[string]any
+	if err := json.Unmarshal([]byte(args[len(args)-1]), &vars); err != nil {
+		t.Fatalf("last dbt arg is not the vars JSON: %v", err)
+	}
+	if vars["billing_period_start"] != "2024-01" || vars["billing_period_end"] != "2024-03" || vars["cur_table"] != "cur2" || vars["ecos_unique_tmp_table_suffix"] != true {
+		t.Errorf("vars = %v", vars)
+	}
+}
+
+func TestDBTTransformPlugin_ApplyOverlay(t *testing.T) {
+	projectDir := t.TempDir()
+	dbtDir := filepath.Join(projectDir, "transform", "dbt")
+	customModel := filepath.Join(projectDir, "custom", "models", "gold_acme__chargeback.sql")
+	if err := os.MkdirAll(filepath.Dir(customModel), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dbtDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(customModel, []byte("select 1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	plugin := &DBTTransformPlugin{}
+	config := map[string]any{"project_dir": projectDir, "custom_dir": "custom"}
+	if err := plugin.applyOverlay(config); err != nil {
+		t.Fatalf("applyOverlay() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbtDir, "models", "ecos_custom", "gold_acme__chargeback.sql")); err != nil {
+		t.Errorf("overlay model not merged: %v", err)
+	}
+
+	// Without custom_dir the merged overlay is removed
+	delete(config, "custom_dir")
+	if err := plugin.applyOverlay(config); err != nil {
+		t.Fatalf("applyOverlay() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbtDir, "models", "ecos_custom")); !os.IsNotExist(err) {
+		t.Errorf("overlay models not removed: %v", err)
+	}
+
+	config["custom_dir"] = "missing"
+	if err := plugin.applyOverlay(config); err == nil {
+		t.Error("applyOverlay() expected error for a missing overlay directory")
+	}
+}
+
+// expiringProvider hands out numbered credentials that expire after ttl
+type expiringProvider struct {
+	ttl   time.Duration
+	calls int
+}
+
+func (e *expiringProvider) Retrieve(context.Context) (aws.Credentials, error) {
+	e.calls++
+	return aws.Credentials{
+		AccessKeyID:     "AKID" + strings.Repeat("x", e.calls),
+		SecretAccessKey: "SECRET",
+		CanExpire:       true,
+		Expires:         time.Now().Add(e.ttl),
+	}, nil
+}
+
+func TestDBTTransformPlugin_ResolveCredentialEnv(t *testing.T) {
+	config := map[string]any{"aws_session": awssession.Options{}}
+	for _, tt := range []struct {
+		name      string
+		ttl       time.Duration
+		wantCalls int
+	}{
+		{"long-lived credentials are reused", time.Hour, 1},
+		{"credentials close to expiring are resolved again", 5 * time.Minute, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &expiringProvider{ttl: tt.ttl}
+			plugin := &DBTTransformPlugin{credentialCfg: &aws.Config{Credentials: aws.NewCredentialsCache(provider)}}
+
+			first, err := plugin.resolveCredentialEnv(context.Background(), config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := plugin.resolveCredentialEnv(context.Background(), config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if provider.calls != tt.wantCalls {
+				t.Errorf("credentials retrieved %d times, want %d", provider.calls, tt.wantCalls)
+			}
+			if (first[0] == second[0]) != (tt.wantCalls == 1) {
+				t.Errorf("credential env %q then %q", first[0], second[0])
+			}
+		})
+	}
+}
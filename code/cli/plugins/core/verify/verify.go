@@ -0,0 +1,348 @@
+// Package verify reconciles the cost totals of the raw CUR table with the bronze and
+// gold model layers per billing period, for 'ecos verify'.
+package verify
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/query"
+	"github.com/ecos-labs/ecos/code/cli/plugins/types"
+)
+
+// Checks 'ecos verify' runs, see config.VerifyChecks
+const (
+	// CheckUnblendedCost compares the unblended cost of the CUR table and the bronze model per usage account
+	CheckUnblendedCost = "unblended_cost"
+	// CheckBilledCost compares the net unblended cost (unblended cost when AWS reports no net cost)
+	// of the CUR table, the bronze model and the invoices per payer account
+	CheckBilledCost = "billed_cost"
+	// CheckEffectiveCost compares the effective cost of the monthly service and invoice models per payer account
+	CheckEffectiveCost = "effective_cost"
+)
+
+// DefaultToleranceAbsolute absorbs the rounding of the gold models to 4 decimals
+const DefaultToleranceAbsolute = 0.01
+
+// Source is a table a check sums the cost of
+type Source struct {
+	Name     string // e.g. "cur" or the model name
+	Relation string // quoted "schema"."table"
+	Period   string // SQL expression of the YYYY-MM billing period
+	Account  string // SQL expression of the account mismatches are broken down by
+	Cost     string // SQL expression summed per billing period and account
+}
+
+// Check compares the cost of its sources per billing period. The first source is the reference.
+type Check struct {
+	Name    string
+	Sources []Source
+}
+
+// Tables locates the tables the checks read
+type Tables struct {
+	CURDatabase   string
+	CURTable      string
+	ModelDatabase string
+}
+
+// Checks returns the named checks, all of them when names is empty. curColumns are the
+// columns of the raw CUR table; they decide how its billing period and net cost are read,
+// the same way the bronze model does.
+func Checks(tables Tables, curColumns []string, names []string) ([]Check, error) {
+	period := "date_format(bill_billing_period_start_date, '%Y-%m')"
+	bronzePeriod := "date_format(billing_period_start_date, '%Y-%m')"
+	switch {
+	case slices.Contains(curColumns, "billing_period"):
+		period, bronzePeriod = "billing_period", "billing_period"
+	case slices.Contains(curColumns, "year") && slices.Contains(curColumns, "month"):
+		period, bronzePeriod = "year || '-' || lpad(month, 2, '0')", "billing_period"
+	}
+	netCost := "line_item_unblended_cost"
+	if slices.Contains(curColumns, "line_item_net_unblended_cost") {
+		netCost = "coalesce(line_item_net_unblended_cost, line_item_unblended_cost)"
+	}
+
+	cur := relation(tables.CURDatabase, tables.CURTable)
+	bronze := relation(tables.ModelDatabase, "bronze_aws__cur_source")
+	serviceMonthly := relation(tables.ModelDatabase, "gold_core__service_monthly")
+	invoiceMonthly := relation(tables.ModelDatabase, "gold_core__invoice_monthly")
+
+	all := []Check{
+		{Name: CheckUnblendedCost, Sources: []Source{
+			{Name: "cur", Relation: cur, Period: period, Account: "line_item_usage_account_id", Cost: "line_item_unblended_cost"},
+			{Name: "bronze_aws__cur_source", Relation: bronze, Period: bronzePeriod, Account: "account_id", Cost: "unblended_cost"},
+		}},
+		{Name: CheckBilledCost, Sources: []Source{
+			{Name: "cur", Relation: cur, Period: period, Account: "bill_payer_account_id", Cost: netCost},
+			{Name: "bronze_aws__cur_source", Relation: bronze, Period: bronzePeriod, Account: "payer_account_id", Cost: "coalesce(net_unblended_cost, unblended_cost)"},
+			{Name: "gold_core__invoice_monthly", Relation: invoiceMonthly, Period: "billing_period", Account: "payer_account_id", Cost: "total_billed_cost"},
+		}},
+		{Name: CheckEffectiveCost, Sources: []Source{
+			{Name: "gold_core__invoice_monthly", Relation: invoiceMonthly, Period: "billing_period", Account: "payer_account_id", Cost: "total_effective_cost"},
+			{Name: "gold_core__service_monthly", Relation: serviceMonthly, Period: "billing_period", Account: "payer_account_id", Cost: "total_effective_cost"},
+		}},
+	}
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	for _, name := range names {
+		if !slices.Contains(config.VerifyChecks, name) {
+			return nil, fmt.Errorf("unknown check '%s', must be one of: %v", name, config.VerifyChecks)
+		}
+	}
+	checks := make([]Check, 0, len(names))
+	for _, c := range all {
+		if slices.Contains(names, c.Name) {
+			checks = append(checks, c)
+		}
+	}
+	return checks, nil
+}
+
+func relation(schema, table string) string {
+	return fmt.Sprintf("%q.%q", schema, table)
+}
+
+// CURColumns returns the lower-case column names of the raw CUR table
+func CURColumns(ctx context.Context, q query.Querier, database, table string) ([]string, error) {
+	rows, err := q.Query(ctx, fmt.Sprintf(
+		"select column_name from information_schema.columns where table_schema = '%s' and table_name = '%s'",
+		strings.ToLower(database), strings.ToLower(table)))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("CUR table %s.%s not found", database, table)
+	}
+
+	columns := make([]string, 0, len(rows))
+	for _, row := range rows {
+		columns = append(columns, strings.ToLower(row[0]))
+	}
+	return columns, nil
+}
+
+// Tolerance bounds the difference allowed between a source and the reference
+type Tolerance struct {
+	Absolute float64 // in the billing currency
+	Percent  float64 // of the reference cost
+}
+
+// ToleranceFromConfig returns the configured tolerance
+func ToleranceFromConfig(cfg config.VerifyConfig) Tolerance {
+	t := Tolerance{Absolute: cfg.ToleranceAbsolute, Percent: cfg.TolerancePercent}
+	if t.Absolute == 0 {
+		t.Absolute = DefaultToleranceAbsolute
+	}
+	return t
+}
+
+// Allows reports whether cost is within either tolerance of reference
+func (t Tolerance) Allows(reference, cost float64) bool {
+	diff := math.Abs(cost - reference)
+	return diff <= t.Absolute || diff <= math.Abs(reference)*t.Percent/100
+}
+
+// SourceTotal is the cost of a source in a billing period
+type SourceTotal struct {
+	Source     string  `json:"source"`
+	Cost       float64 `json:"cost"`
+	Difference float64 `json:"difference"` // cost minus the reference cost
+}
+
+// AccountMismatch is an account whose cost differs between sources
+type AccountMismatch struct {
+	Account    string    `json:"account"`
+	Costs      []float64 `json:"costs"`      // in the order of the period's totals
+	Difference float64   `json:"difference"` // largest difference from the reference cost
+}
+
+// PeriodResult is the outcome of a check in one billing period
+type PeriodResult struct {
+	Check         string            `json:"check"`
+	BillingPeriod string            `json:"billing_period"`
+	Passed        bool              `json:"passed"`
+	Totals        []SourceTotal     `json:"totals"`
+	Accounts      []AccountMismatch `json:"accounts,omitempty"` // only when the check failed
+}
+
+// costs is the cost of a source per billing period and account
+type costs map[string]map[string]float64
+
+// Run runs the checks for the billing periods start to end (YYYY-MM, inclusive). Every
+// billing period found in any source is compared; a period missing from a source counts
+// as a cost of zero.
+func Run(ctx context.Context, q query.Querier, checks []Check, start, end string, tolerance Tolerance) ([]PeriodResult, error) {
+	for _, period := range []string{start, end} {
+		if _, err := config.ParseBillingPeriod(period); err != nil {
+			return nil, err
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("billing period %s is after %s", start, end)
+	}
+
+	var results []PeriodResult
+	for _, check := range checks {
+		sourceCosts := make([]costs, len(check.Sources))
+		periods := map[string]bool{}
+		for i, source := range check.Sources {
+			c, err := querySource(ctx, q, source, start, end)
+			if err != nil {
+				return nil, fmt.Errorf("check %s: %s: %w", check.Name, source.Name, err)
+			}
+			sourceCosts[i] = c
+			for period := range c {
+				periods[period] = true
+			}
+		}
+
+		for _, period := range slices.Sorted(maps.Keys(periods)) {
+			results = append(results, comparePeriod(check, period, sourceCosts, tolerance))
+		}
+	}
+	return results, nil
+}
+
+// querySource sums the cost of a source per billing period and account
+func querySource(ctx context.Context, q query.Querier, s Source, start, end string) (costs, error) {
+	sql := fmt.Sprintf(`select %[1]s as billing_period, cast(%[2]s as varchar) as account, sum(%[3]s) as cost
+from %[4]s
+where %[1]s between '%[5]s' and '%[6]s'
+group by 1, 2`, s.Period, s.Account, s.Cost, s.Relation, start, end)
+
+	rows, err := q.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	c := costs{}
+	for _, row := range rows {
+		if len(row) != 3 {
+			return nil, fmt.Errorf("unexpected result row %v", row)
+		}
+		cost := 0.0
+		if row[2] != "" {
+			if cost, err = strconv.ParseFloat(row[2], 64); err != nil {
+				return nil, fmt.Errorf("invalid cost '%s': %w", row[2], err)
+			}
+		}
+		if c[row[0]] == nil {
+			c[row[0]] = map[string]float64{}
+		}
+		c[row[0]][row[1]] += cost
+	}
+	return c, nil
+}
+
+// comparePeriod compares the totals of every source with the reference and, on a
+// mismatch, breaks the difference down by account
+func comparePeriod(check Check, period string, sourceCosts []costs, tolerance Tolerance) PeriodResult {
+	result := PeriodResult{Check: check.Name, BillingPeriod: period, Passed: true}
+
+	accounts := map[string]bool{}
+	var reference float64
+	for i, source := range check.Sources {
+		total := 0.0
+		for account, cost := range sourceCosts[i][period] {
+			total += cost
+			accounts[account] = true
+		}
+		if i == 0 {
+			reference = total
+		}
+		result.Totals = append(result.Totals, SourceTotal{Source: source.Name, Cost: total, Difference: total - reference})
+		if !tolerance.Allows(reference, total) {
+			result.Passed = false
+		}
+	}
+	if result.Passed {
+		return result
+	}
+
+	for _, account := range slices.Sorted(maps.Keys(accounts)) {
+		mismatch := AccountMismatch{Account: account}
+		ref := sourceCosts[0][period][account]
+		allowed := true
+		for i := range check.Sources {
+			cost := sourceCosts[i][period][account]
+			mismatch.Costs = append(mismatch.Costs, cost)
+			if math.Abs(cost-ref) > math.Abs(mismatch.Difference) {
+				mismatch.Difference = cost - ref
+			}
+			if !tolerance.Allows(ref, cost) {
+				allowed = false
+			}
+		}
+		if !allowed {
+			result.Accounts = append(result.Accounts, mismatch)
+		}
+	}
+	slices.SortStableFunc(result.Accounts, func(a, b AccountMismatch) int {
+		return cmp.Compare(math.Abs(b.Difference), math.Abs(a.Difference))
+	})
+	return result
+}
+
+// Result consolidates the period results into a PluginResult that fails when any
+// check failed
+func Result(results []PeriodResult, duration time.Duration, tolerance Tolerance) *types.PluginResult {
+	var failed []string
+	for _, r := range results {
+		if !r.Passed {
+			failed = append(failed, fmt.Sprintf("%s %s", r.Check, r.BillingPeriod))
+		}
+	}
+
+	result := &types.PluginResult{
+		Success:  len(failed) == 0,
+		Message:  fmt.Sprintf("%d of %d reconciliation checks passed", len(results)-len(failed), len(results)),
+		Duration: duration,
+		Metadata: map[string]any{
+			"tolerance_absolute": tolerance.Absolute,
+			"tolerance_percent":  tolerance.Percent,
+			"results":            results,
+		},
+	}
+	if len(failed) > 0 {
+		result.Error = "mismatch in " + strings.Join(failed, ", ")
+		result.ExitCode = 1
+	}
+	return result
+}
+
+// Results returns the period results of a consolidated verify result
+func Results(result *types.PluginResult) []PeriodResult {
+	results, _ := result.Metadata["results"].([]PeriodResult)
+	return results
+}
+
+// WriteReport writes the consolidated result as JSON to path
+func WriteReport(result *types.PluginResult, path string) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode verify report: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write verify report: %w", err)
+	}
+	return nil
+}
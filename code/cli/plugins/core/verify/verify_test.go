@@ -0,0 +1,140 @@
+package verify
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+// fakeQuerier returns the rows of the first relation found in the query
+type fakeQuerier struct {
+	rows    map[string][][]string
+	queries []string
+}
+
+func (f *fakeQuerier) Query(_ context.Context, sql string) ([][]string, error) {
+	f.queries = append(f.queries, sql)
+	for relation, rows := range f.rows {
+		if strings.Contains(sql, relation) {
+			return rows, nil
+		}
+	}
+	return nil, nil
+}
+
+var testTables = Tables{CURDatabase: "cur", CURTable: "cur_data", ModelDatabase: "ecos"}
+
+func TestChecks(t *testing.T) {
+	checks, err := Checks(testTables, []string{"line_item_unblended_cost", "year", "month"}, nil)
+	if err != nil {
+		t.Fatalf("Checks() error = %v", err)
+	}
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	if !reflect.DeepEqual(names, config.VerifyChecks) {
+		t.Errorf("Checks() = %v, want %v", names, config.VerifyChecks)
+	}
+
+	cur := checks[1].Sources[0]
+	if cur.Relation != `"cur"."cur_data"` || cur.Period != "year || '-' || lpad(month, 2, '0')" || cur.Cost != "line_item_unblended_cost" {
+		t.Errorf("billed_cost CUR source = %+v", cur)
+	}
+
+	checks, _ = Checks(testTables, []string{"billing_period", "line_item_net_unblended_cost"}, []string{CheckBilledCost})
+	if len(checks) != 1 || checks[0].Sources[0].Period != "billing_period" || !strings.Contains(checks[0].Sources[0].Cost, "net_unblended_cost") {
+		t.Errorf("Checks(billed_cost) = %+v", checks)
+	}
+
+	if _, err := Checks(testTables, nil, []string{"blended_cost"}); err == nil {
+		t.Error("Checks() expected error for an unknown check")
+	}
+}
+
+func TestTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance Tolerance
+		reference float64
+		cost      float64
+		want      bool
+	}{
+		{"within absolute", Tolerance{Absolute: 0.01}, 100, 100.005, true},
+		{"beyond absolute", Tolerance{Absolute: 0.01}, 100, 100.02, false},
+		{"within percent", Tolerance{Absolute: 0.01, Percent: 0.1}, 1000, 1000.9, true},
+		{"beyond both", Tolerance{Absolute: 0.01, Percent: 0.1}, 1000, 1001.1, false},
+		{"missing from a layer", Tolerance{Absolute: 0.01, Percent: 1}, 50, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tolerance.Allows(tt.reference, tt.cost); got != tt.want {
+				t.Errorf("Allows(%v, %v) = %v, want %v", tt.reference, tt.cost, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	q := &fakeQuerier{rows: map[string][][]string{
+		`"cur"."cur_data"`: {
+			{"2025-01", "111111111111", "100.0"},
+			{"2025-01", "222222222222", "50.0"},
+			{"2025-02", "111111111111", "1.2E2"},
+		},
+		`"ecos"."bronze_aws__cur_source"`: {
+			{"2025-01", "111111111111", "100.0"},
+			{"2025-01", "222222222222", "40.0"},
+		},
+	}}
+	checks, _ := Checks(testTables, []string{"billing_period"}, []string{CheckUnblendedCost})
+
+	results, err := Run(context.Background(), q, checks, "2025-01", "2025-02", Tolerance{Absolute: 0.01})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Run() = %+v, want one result per billing period", results)
+	}
+	if !strings.Contains(q.queries[0], "where billing_period between '2025-01' and '2025-02'") {
+		t.Errorf("query = %s", q.queries[0])
+	}
+
+	jan := results[0]
+	if jan.Passed || jan.Totals[0].Cost != 150 || jan.Totals[1].Difference != -10 {
+		t.Errorf("2025-01 = %+v", jan)
+	}
+	want := []AccountMismatch{{Account: "222222222222", Costs: []float64{50, 40}, Difference: -10}}
+	if !reflect.DeepEqual(jan.Accounts, want) {
+		t.Errorf("2025-01 accounts = %+v, want %+v", jan.Accounts, want)
+	}
+
+	// The bronze model has not processed February yet
+	feb := results[1]
+	if feb.BillingPeriod != "2025-02" || feb.Passed || feb.Totals[1].Cost != 0 {
+		t.Errorf("2025-02 = %+v", feb)
+	}
+
+	result := Result(results, time.Second, Tolerance{Absolute: 0.01})
+	if result.Success || result.ExitCode != 1 || !strings.Contains(result.Error, "unblended_cost 2025-01") {
+		t.Errorf("Result() = %+v", result)
+	}
+	if len(Results(result)) != 2 {
+		t.Errorf("Results() = %+v", Results(result))
+	}
+}
+
+func TestRun_InvalidPeriods(t *testing.T) {
+	checks, _ := Checks(testTables, nil, nil)
+	if _, err := Run(context.Background(), &fakeQuerier{}, checks, "2025-03", "2025-01", Tolerance{}); err == nil {
+		t.Error("Run() expected error for a start after the end")
+	}
+	if _, err := Run(context.Background(), &fakeQuerier{}, checks, "2025-1", "2025-01", Tolerance{}); err == nil {
+		t.Error("Run() expected error for a malformed billing period")
+	}
+}
@@ -0,0 +1,187 @@
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/ecos-labs/ecos/code/cli/config"
+)
+
+// manifestPeriodPattern matches the billing period partition of a Data Exports manifest key
+var manifestPeriodPattern = regexp.MustCompile(`(?i)/BILLING_PERIOD=(\d{4}-\d{2})/`)
+
+// ManifestSource reads the manifests AWS writes next to every delivery of a BCM Data
+// Export (s3://bucket/prefix/name/metadata/BILLING_PERIOD=YYYY-MM/name-Manifest.json).
+// A rewritten manifest means the billing period was delivered again.
+type ManifestSource struct {
+	Client s3.ListObjectsV2APIClient
+	Bucket string
+	Prefix string
+}
+
+// NewManifestSource returns the manifest source for an export delivered to bucket
+func NewManifestSource(client s3.ListObjectsV2APIClient, export config.DataExportConfig, bucket string) *ManifestSource {
+	return &ManifestSource{
+		Client: client,
+		Bucket: bucket,
+		Prefix: fmt.Sprintf("%s/%s/metadata/", strings.Trim(export.S3Prefix, "/"), export.Name),
+	}
+}
+
+func (s *ManifestSource) String() string {
+	return fmt.Sprintf("manifests in s3://%s/%s", s.Bucket, s.Prefix)
+}
+
+// Snapshot lists the manifests and fingerprints each billing period by its manifests' ETags
+func (s *ManifestSource) Snapshot(ctx context.Context) (Snapshot, error) {
+	versions := map[string][]string{}
+	paginator := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(s.Prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list export manifests in s3://%s/%s: %w", s.Bucket, s.Prefix, err)
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if !strings.HasSuffix(key, "Manifest.json") {
+				continue
+			}
+			match := manifestPeriodPattern.FindStringSubmatch(key)
+			if match == nil {
+				continue
+			}
+			version := strings.Trim(aws.ToString(obj.ETag), `"`)
+			if obj.LastModified != nil {
+				version += "@" + obj.LastModified.UTC().Format(time.RFC3339)
+			}
+			versions[match[1]] = append(versions[match[1]], version)
+		}
+	}
+
+	snapshot := Snapshot{}
+	for period, v := range versions {
+		slices.Sort(v)
+		snapshot[period] = strings.Join(v, ",")
+	}
+	return snapshot, nil
+}
+
+// GluePartitionsAPI is the subset of the Glue client used to read a table's partitions
+type GluePartitionsAPI interface {
+	glue.GetPartitionsAPIClient
+	GetTable(ctx context.Context, params *glue.GetTableInput, optFns ...func(*glue.Options)) (*glue.GetTableOutput, error)
+}
+
+// GluePartitionSource reads the partitions of a CUR table kept up to date by a Glue
+// crawler, partitioned by billing_period (YYYY-MM) or by year and month. A partition
+// whose location or crawler statistics changed means the period was delivered again.
+type GluePartitionSource struct {
+	Client   GluePartitionsAPI
+	Database string
+	Table    string
+}
+
+func (s *GluePartitionSource) String() string {
+	return fmt.Sprintf("Glue partitions of %s.%s", s.Database, s.Table)
+}
+
+// Snapshot lists the table's partitions and fingerprints each billing period by its
+// partition metadata
+func (s *GluePartitionSource) Snapshot(ctx context.Context) (Snapshot, error) {
+	table, err := s.Client.GetTable(ctx, &glue.GetTableInput{
+		DatabaseName: aws.String(s.Database),
+		Name:         aws.String(s.Table),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Glue table %s.%s: %w", s.Database, s.Table, err)
+	}
+
+	var keys []string
+	for _, key := range table.Table.PartitionKeys {
+		keys = append(keys, strings.ToLower(aws.ToString(key.Name)))
+	}
+	periodOf, err := partitionPeriodFunc(keys)
+	if err != nil {
+		return nil, fmt.Errorf("cannot watch %s.%s: %w", s.Database, s.Table, err)
+	}
+
+	versions := map[string][]string{}
+	paginator := glue.NewGetPartitionsPaginator(s.Client, &glue.GetPartitionsInput{
+		DatabaseName: aws.String(s.Database),
+		TableName:    aws.String(s.Table),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s.%s: %w", s.Database, s.Table, err)
+		}
+		for _, p := range page.Partitions {
+			period, ok := periodOf(p.Values)
+			if !ok {
+				continue
+			}
+			h := sha256.New()
+			if p.StorageDescriptor != nil {
+				fmt.Fprintf(h, "location=%s\n", aws.ToString(p.StorageDescriptor.Location))
+			}
+			for _, k := range slices.Sorted(maps.Keys(p.Parameters)) {
+				fmt.Fprintf(h, "%s=%s\n", k, p.Parameters[k])
+			}
+			versions[period] = append(versions[period], hex.EncodeToString(h.Sum(nil))[:16])
+		}
+	}
+
+	snapshot := Snapshot{}
+	for period, v := range versions {
+		slices.Sort(v)
+		snapshot[period] = strings.Join(v, ",")
+	}
+	return snapshot, nil
+}
+
+// partitionPeriodFunc returns a function that reads the billing period from the
+// partition values of a table with the given partition keys
+func partitionPeriodFunc(keys []string) (func(values []string) (string, bool), error) {
+	if i := slices.Index(keys, "billing_period"); i >= 0 {
+		return func(values []string) (string, bool) {
+			if i >= len(values) {
+				return "", false
+			}
+			if _, err := config.ParseBillingPeriod(values[i]); err != nil {
+				return "", false
+			}
+			return values[i], true
+		}, nil
+	}
+
+	yearIdx, monthIdx := slices.Index(keys, "year"), slices.Index(keys, "month")
+	if yearIdx >= 0 && monthIdx >= 0 {
+		return func(values []string) (string, bool) {
+			if yearIdx >= len(values) || monthIdx >= len(values) {
+				return "", false
+			}
+			year, err1 := strconv.Atoi(values[yearIdx])
+			month, err2 := strconv.Atoi(values[monthIdx])
+			if err1 != nil || err2 != nil || month < 1 || month > 12 {
+				return "", false
+			}
+			return fmt.Sprintf("%04d-%02d", year, month), true
+		}, nil
+	}
+
+	return nil, fmt.Errorf("the table is not partitioned by billing_period or by year and month (partition keys: %s)", strings.Join(keys, ", "))
+}
@@ -0,0 +1,196 @@
+// Package watch detects new and restated billing periods of a CUR or FOCUS export for
+// 'ecos watch', and records the deliveries that were transformed in a local cursor so
+// a restarted watcher does not process them again.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	ecosconfig "github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/transform"
+)
+
+// CursorFilename records the transformed deliveries in the ecos project directory
+const CursorFilename = ".ecos-watch.json"
+
+// Snapshot maps each billing period (YYYY-MM) of an export to a fingerprint of its
+// latest delivery. The fingerprint changes whenever AWS delivers the period again.
+type Snapshot map[string]string
+
+// Source lists the billing periods delivered by an export
+type Source interface {
+	// String describes the source, e.g. "manifests in s3://bucket/prefix/"
+	String() string
+	// Snapshot returns the delivered billing periods and their fingerprints
+	Snapshot(ctx context.Context) (Snapshot, error)
+}
+
+// Cursor records the fingerprint of the last transformed delivery of each billing period
+type Cursor struct {
+	Source    string            `json:"source"`
+	Periods   map[string]string `json:"periods"`
+	UpdatedAt time.Time         `json:"updated_at"`
+
+	path string
+}
+
+// NewCursor returns an empty cursor for source saved to path
+func NewCursor(path, source string) *Cursor {
+	return &Cursor{Source: source, Periods: map[string]string{}, path: path}
+}
+
+// LoadCursor reads the cursor at path. It returns nil without an error when there is none.
+func LoadCursor(path string) (*Cursor, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch cursor: %w", err)
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse watch cursor %s: %w", path, err)
+	}
+	if c.Periods == nil {
+		c.Periods = map[string]string{}
+	}
+	c.path = path
+	return &c, nil
+}
+
+// CursorPath returns the cursor location for the ecos project directory
+func CursorPath(projectDir string) string {
+	return filepath.Join(projectDir, CursorFilename)
+}
+
+// Changed returns the billing periods of the snapshot that are new or were delivered
+// again since they were recorded, in order
+func (c *Cursor) Changed(snapshot Snapshot) []string {
+	var changed []string
+	for period, fingerprint := range snapshot {
+		if c.Periods[period] != fingerprint {
+			changed = append(changed, period)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// Record stores the snapshot's fingerprints of the given periods and saves the cursor
+func (c *Cursor) Record(snapshot Snapshot, periods []string) error {
+	for _, period := range periods {
+		if fingerprint, ok := snapshot[period]; ok {
+			c.Periods[period] = fingerprint
+		}
+	}
+	return c.Save()
+}
+
+// Baseline marks every period of the snapshot before since (YYYY-MM) as transformed,
+// so a first start only processes recent billing periods. It returns the number of
+// periods marked; call Save to keep them.
+func (c *Cursor) Baseline(snapshot Snapshot, since string) int {
+	n := 0
+	for period, fingerprint := range snapshot {
+		if period < since {
+			c.Periods[period] = fingerprint
+			n++
+		}
+	}
+	return n
+}
+
+// Save writes the cursor through a temporary file, so an interrupted write never
+// leaves a truncated cursor behind
+func (c *Cursor) Save() error {
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write watch cursor: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write watch cursor: %w", err)
+	}
+	return nil
+}
+
+// Ranges groups sorted billing periods into runs of consecutive months, so a restated
+// old period and the current month are transformed separately instead of everything
+// in between
+func Ranges(periods []string) ([]transform.Chunk, error) {
+	var ranges []transform.Chunk
+	var last time.Time
+	for _, period := range periods {
+		t, err := ecosconfig.ParseBillingPeriod(period)
+		if err != nil {
+			return nil, err
+		}
+		if len(ranges) > 0 && t.Equal(last.AddDate(0, 1, 0)) {
+			ranges[len(ranges)-1].End = period
+		} else {
+			ranges = append(ranges, transform.Chunk{Start: period, End: period})
+		}
+		last = t
+	}
+	return ranges, nil
+}
+
+// Poll takes a snapshot of the source and runs the new and restated billing periods,
+// one run per range of consecutive months. The cursor records a range once its run
+// succeeded, so failed ranges are retried by the next poll. No further range starts
+// once ctx is done.
+func Poll(ctx context.Context, source Source, cursor *Cursor, run func(transform.Chunk) error) ([]transform.ChunkResult, error) {
+	snapshot, err := source.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := Ranges(cursor.Changed(snapshot))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]transform.ChunkResult, len(ranges))
+	for i, r := range ranges {
+		results[i].Chunk = r
+		if ctx.Err() != nil {
+			results[i].NotRun = true
+			continue
+		}
+
+		started := time.Now()
+		err := run(r)
+		if err == nil {
+			err = cursor.Record(snapshot, rangePeriods(r))
+		}
+		results[i].Err = err
+		results[i].Duration = time.Since(started)
+	}
+	return results, nil
+}
+
+// rangePeriods returns the billing periods of a range of consecutive months
+func rangePeriods(r transform.Chunk) []string {
+	periods, err := transform.PlanChunks(r.Start, r.End, 1)
+	if err != nil {
+		return nil
+	}
+	out := make([]string, len(periods))
+	for i, p := range periods {
+		out[i] = p.Start
+	}
+	return out
+}
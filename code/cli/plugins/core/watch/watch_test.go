@@ -0,0 +1,223 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	glueTypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/ecos-labs/ecos/code/cli/config"
+	"github.com/ecos-labs/ecos/code/cli/plugins/core/transform"
+)
+
+// fakeSource returns a fixed snapshot
+type fakeSource struct {
+	snapshot Snapshot
+}
+
+func (f *fakeSource) String() string { return "fake" }
+
+func (f *fakeSource) Snapshot(_ context.Context) (Snapshot, error) { return f.snapshot, nil }
+
+func TestRanges(t *testing.T) {
+	ranges, err := Ranges([]string{"2024-03", "2024-11", "2024-12", "2025-01", "2025-06"})
+	if err != nil {
+		t.Fatalf("Ranges() error = %v", err)
+	}
+	want := []transform.Chunk{{Start: "2024-03", End: "2024-03"}, {Start: "2024-11", End: "2025-01"}, {Start: "2025-06", End: "2025-06"}}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("Ranges() = %v, want %v", ranges, want)
+	}
+
+	if _, err := Ranges([]string{"2024-1"}); err == nil {
+		t.Error("Ranges() expected error for a malformed billing period")
+	}
+}
+
+func TestCursor(t *testing.T) {
+	path := CursorPath(t.TempDir())
+	if c, err := LoadCursor(path); err != nil || c != nil {
+		t.Fatalf("LoadCursor() = %v, %v for a missing file, want nil, nil", c, err)
+	}
+
+	snapshot := Snapshot{"2024-12": "a", "2025-01": "b", "2025-02": "c"}
+	c := NewCursor(path, "fake")
+	if n := c.Baseline(snapshot, "2025-02"); n != 2 {
+		t.Errorf("Baseline() = %d, want 2", n)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadCursor(path)
+	if err != nil {
+		t.Fatalf("LoadCursor() error = %v", err)
+	}
+
+	// 2025-01 was delivered again, 2025-03 is new
+	snapshot = Snapshot{"2024-12": "a", "2025-01": "b2", "2025-02": "c", "2025-03": "d"}
+	if got := loaded.Changed(snapshot); !reflect.DeepEqual(got, []string{"2025-01", "2025-02", "2025-03"}) {
+		t.Errorf("Changed() = %v", got)
+	}
+
+	if err := loaded.Record(snapshot, []string{"2025-01"}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	reloaded, _ := LoadCursor(path)
+	if got := reloaded.Changed(snapshot); !reflect.DeepEqual(got, []string{"2025-02", "2025-03"}) {
+		t.Errorf("Changed() after Record() = %v", got)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	source := &fakeSource{snapshot: Snapshot{"2024-06": "restated", "2025-01": "x", "2025-02": "y"}}
+	cursor := NewCursor(CursorPath(t.TempDir()), source.String())
+	cursor.Periods["2024-06"] = "original"
+
+	var ran []string
+	results, err := Poll(context.Background(), source, cursor, func(r transform.Chunk) error {
+		ran = append(ran, r.String())
+		if r.Start == "2024-06" {
+			return errors.New("query timed out")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(ran, []string{"2024-06", "2025-01..2025-02"}) {
+		t.Errorf("ran %v, want the restated month and the new range", ran)
+	}
+	if len(results) != 2 || results[0].Err == nil || results[1].Err != nil {
+		t.Errorf("results = %+v", results)
+	}
+
+	// The failed range is retried, the transformed one is not
+	ran = nil
+	if _, err := Poll(context.Background(), source, cursor, func(r transform.Chunk) error {
+		ran = append(ran, r.String())
+		return nil
+	}); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if !reflect.DeepEqual(ran, []string{"2024-06"}) {
+		t.Errorf("second poll ran %v, want only the failed range", ran)
+	}
+}
+
+func TestPoll_Stopped(t *testing.T) {
+	source := &fakeSource{snapshot: Snapshot{"2025-01": "x"}}
+	cursor := NewCursor(CursorPath(t.TempDir()), source.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := Poll(ctx, source, cursor, func(transform.Chunk) error {
+		t.Error("run called after stop")
+		return nil
+	})
+	if err != nil || len(results) != 1 || !results[0].NotRun {
+		t.Errorf("Poll() = %+v, %v, want the range not run", results, err)
+	}
+}
+
+// fakeS3 lists a fixed set of objects
+type fakeS3 struct {
+	objects []s3Types.Object
+	prefix  string
+}
+
+func (f *fakeS3) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.prefix = aws.ToString(in.Prefix)
+	return &s3.ListObjectsV2Output{Contents: f.objects}, nil
+}
+
+func TestManifestSource(t *testing.T) {
+	modified := time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC)
+	client := &fakeS3{objects: []s3Types.Object{
+		{Key: aws.String("data-exports/demo-cur2/metadata/BILLING_PERIOD=2025-01/demo-cur2-Manifest.json"), ETag: aws.String(`"abc"`), LastModified: &modified},
+		{Key: aws.String("data-exports/demo-cur2/metadata/BILLING_PERIOD=2025-02/demo-cur2-Manifest.json"), ETag: aws.String(`"def"`)},
+		{Key: aws.String("data-exports/demo-cur2/metadata/BILLING_PERIOD=2025-02/other.json"), ETag: aws.String(`"zzz"`)},
+	}}
+	source := NewManifestSource(client, config.DataExportConfig{Name: "demo-cur2", S3Prefix: "/data-exports/"}, "bucket")
+
+	snapshot, err := source.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if client.prefix != "data-exports/demo-cur2/metadata/" {
+		t.Errorf("listed prefix %q", client.prefix)
+	}
+	want := Snapshot{"2025-01": "abc@2025-02-03T04:05:06Z", "2025-02": "def"}
+	if !reflect.DeepEqual(snapshot, want) {
+		t.Errorf("Snapshot() = %v, want %v", snapshot, want)
+	}
+}
+
+// fakeGlue returns a table and its partitions
+type fakeGlue struct {
+	keys       []string
+	partitions []glueTypes.Partition
+}
+
+func (f *fakeGlue) GetTable(_ context.Context, _ *glue.GetTableInput, _ ...func(*glue.Options)) (*glue.GetTableOutput, error) {
+	table := &glueTypes.Table{}
+	for _, k := range f.keys {
+		table.PartitionKeys = append(table.PartitionKeys, glueTypes.Column{Name: aws.String(k)})
+	}
+	return &glue.GetTableOutput{Table: table}, nil
+}
+
+func (f *fakeGlue) GetPartitions(_ context.Context, _ *glue.GetPartitionsInput, _ ...func(*glue.Options)) (*glue.GetPartitionsOutput, error) {
+	return &glue.GetPartitionsOutput{Partitions: f.partitions}, nil
+}
+
+func TestGluePartitionSource(t *testing.T) {
+	partition := func(count string, values ...string) glueTypes.Partition {
+		return glueTypes.Partition{Values: values, Parameters: map[string]string{"objectCount": count}}
+	}
+
+	t.Run("year and month", func(t *testing.T) {
+		client := &fakeGlue{
+			keys:       []string{"year", "month"},
+			partitions: []glueTypes.Partition{partition("1", "2025", "1"), partition("2", "2025", "12"), partition("1", "2025", "x")},
+		}
+		source := &GluePartitionSource{Client: client, Database: "cur", Table: "cur_data"}
+
+		before, err := source.Snapshot(context.Background())
+		if err != nil {
+			t.Fatalf("Snapshot() error = %v", err)
+		}
+		if len(before) != 2 || before["2025-01"] == "" || before["2025-12"] == "" {
+			t.Fatalf("Snapshot() = %v, want 2025-01 and 2025-12", before)
+		}
+
+		// The crawler updated the statistics of the restated month
+		client.partitions[0] = partition("3", "2025", "1")
+		after, _ := source.Snapshot(context.Background())
+		if after["2025-01"] == before["2025-01"] || after["2025-12"] != before["2025-12"] {
+			t.Errorf("fingerprints before %v, after %v", before, after)
+		}
+	})
+
+	t.Run("billing_period", func(t *testing.T) {
+		client := &fakeGlue{keys: []string{"BILLING_PERIOD"}, partitions: []glueTypes.Partition{partition("1", "2025-03")}}
+		snapshot, err := (&GluePartitionSource{Client: client, Database: "cur", Table: "cur2"}).Snapshot(context.Background())
+		if err != nil || len(snapshot) != 1 || snapshot["2025-03"] == "" {
+			t.Errorf("Snapshot() = %v, %v, want 2025-03", snapshot, err)
+		}
+	})
+
+	t.Run("unsupported partitions", func(t *testing.T) {
+		client := &fakeGlue{keys: []string{"dt"}}
+		if _, err := (&GluePartitionSource{Client: client, Database: "cur", Table: "cur"}).Snapshot(context.Background()); err == nil {
+			t.Error("Snapshot() expected error for a table without billing period partitions")
+		}
+	})
+}
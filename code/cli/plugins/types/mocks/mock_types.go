@@ -833,6 +833,82 @@ func (mr *MockInitPluginMockRecorder) Version() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockInitPlugin)(nil).Version))
 }
 
+// MockIaCEmitter is a mock of IaCEmitter interface.
+type MockIaCEmitter struct {
+	ctrl     *gomock.Controller
+	recorder *MockIaCEmitterMockRecorder
+	isgomock struct{}
+}
+
+// MockIaCEmitterMockRecorder is the mock recorder for MockIaCEmitter.
+type MockIaCEmitterMockRecorder struct {
+	mock *MockIaCEmitter
+}
+
+// NewMockIaCEmitter creates a new mock instance.
+func NewMockIaCEmitter(ctrl *gomock.Controller) *MockIaCEmitter {
+	mock := &MockIaCEmitter{ctrl: ctrl}
+	mock.recorder = &MockIaCEmitterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIaCEmitter) EXPECT() *MockIaCEmitterMockRecorder {
+	return m.recorder
+}
+
+// SetIaCFormat mocks base method.
+func (m *MockIaCEmitter) SetIaCFormat(format string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIaCFormat", format)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetIaCFormat indicates an expected call of SetIaCFormat.
+func (mr *MockIaCEmitterMockRecorder) SetIaCFormat(format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIaCFormat", reflect.TypeOf((*MockIaCEmitter)(nil).SetIaCFormat), format)
+}
+
+// MockAWSAccessConfigurer is a mock of AWSAccessConfigurer interface.
+type MockAWSAccessConfigurer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAWSAccessConfigurerMockRecorder
+	isgomock struct{}
+}
+
+// MockAWSAccessConfigurerMockRecorder is the mock recorder for MockAWSAccessConfigurer.
+type MockAWSAccessConfigurerMockRecorder struct {
+	mock *MockAWSAccessConfigurer
+}
+
+// NewMockAWSAccessConfigurer creates a new mock instance.
+func NewMockAWSAccessConfigurer(ctrl *gomock.Controller) *MockAWSAccessConfigurer {
+	mock := &MockAWSAccessConfigurer{ctrl: ctrl}
+	mock.recorder = &MockAWSAccessConfigurerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAWSAccessConfigurer) EXPECT() *MockAWSAccessConfigurerMockRecorder {
+	return m.recorder
+}
+
+// SetAWSAccess mocks base method.
+func (m *MockAWSAccessConfigurer) SetAWSAccess(access config.AWSAccessConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAWSAccess", access)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAWSAccess indicates an expected call of SetAWSAccess.
+func (mr *MockAWSAccessConfigurerMockRecorder) SetAWSAccess(access any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAWSAccess", reflect.TypeOf((*MockAWSAccessConfigurer)(nil).SetAWSAccess), access)
+}
+
 // MockTransformPlugin is a mock of TransformPlugin interface.
 type MockTransformPlugin struct {
 	ctrl     *gomock.Controller
@@ -1096,6 +1172,44 @@ func (mr *MockTransformPluginMockRecorder) Version() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockTransformPlugin)(nil).Version))
 }
 
+// MockBillingPeriodRunner is a mock of BillingPeriodRunner interface.
+type MockBillingPeriodRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockBillingPeriodRunnerMockRecorder
+	isgomock struct{}
+}
+
+// MockBillingPeriodRunnerMockRecorder is the mock recorder for MockBillingPeriodRunner.
+type MockBillingPeriodRunnerMockRecorder struct {
+	mock *MockBillingPeriodRunner
+}
+
+// NewMockBillingPeriodRunner creates a new mock instance.
+func NewMockBillingPeriodRunner(ctrl *gomock.Controller) *MockBillingPeriodRunner {
+	mock := &MockBillingPeriodRunner{ctrl: ctrl}
+	mock.recorder = &MockBillingPeriodRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBillingPeriodRunner) EXPECT() *MockBillingPeriodRunnerMockRecorder {
+	return m.recorder
+}
+
+// RunBillingPeriods mocks base method.
+func (m *MockBillingPeriodRunner) RunBillingPeriods(ctx context.Context, command string, args []string, run types.BillingPeriodRun, arg4 map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunBillingPeriods", ctx, command, args, run, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunBillingPeriods indicates an expected call of RunBillingPeriods.
+func (mr *MockBillingPeriodRunnerMockRecorder) RunBillingPeriods(ctx, command, args, run, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunBillingPeriods", reflect.TypeOf((*MockBillingPeriodRunner)(nil).RunBillingPeriods), ctx, command, args, run, arg4)
+}
+
 // MockDestroyPlugin is a mock of DestroyPlugin interface.
 type MockDestroyPlugin struct {
 	ctrl     *gomock.Controller
@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/ecos-labs/ecos/code/cli/config"
@@ -179,6 +180,28 @@ type InitPlugin interface {
 	SetModelVersion(version string) error
 }
 
+// IaCEmitter supports rendering the cloud resources as infrastructure-as-code
+// (e.g. Terraform, CloudFormation) instead of creating them during init.
+type IaCEmitter interface {
+	SetIaCFormat(format string) error
+}
+
+// AWSAccessConfigurer supports assuming a role or using a credential_process on top
+// of the AWS profile selected during init.
+type AWSAccessConfigurer interface {
+	SetAWSAccess(access config.AWSAccessConfig) error
+}
+
+// ModelSourceConfigurer supports downloading the model packages from a source other
+// than the GitHub releases of ecos (GitHub Enterprise, a mirror, S3 or a local file),
+// resolving them from the local model cache only, and installing legacy unsigned
+// releases.
+type ModelSourceConfigurer interface {
+	SetModelSource(source string) error
+	SetOffline(offline bool) error
+	SetAllowUnsigned(allow bool) error
+}
+
 // InitStatus represents the status of an initialization operation.
 type InitStatus string
 
@@ -234,6 +257,22 @@ type TransformPlugin interface {
 	BuildConfig(ecosConfig any, command string, args []string) map[string]any
 }
 
+// BillingPeriodRun restricts a transform run to a range of billing periods
+type BillingPeriodRun struct {
+	Start string // first billing period, YYYY-MM
+	End   string // last billing period, YYYY-MM
+	// Isolated keeps the run's artifacts and logs apart so several runs can execute concurrently
+	Isolated bool
+	// Output receives the tool output; nil streams it to the terminal
+	Output io.Writer
+}
+
+// BillingPeriodRunner supports running a transform command for a range of billing
+// periods, which 'ecos transform backfill' uses to process history in chunks.
+type BillingPeriodRunner interface {
+	RunBillingPeriods(ctx context.Context, command string, args []string, run BillingPeriodRun, config map[string]any) error
+}
+
 // TransformStatus represents the status of a transform setup
 type TransformStatus struct {
 	Tool          string            `json:"tool"`
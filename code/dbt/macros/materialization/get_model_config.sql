@@ -32,8 +32,10 @@
         'incremental_strategy': 'append'
       }) -%}
     {%- else -%}
+      {#-- A unique __dbt_tmp suffix lets 'ecos transform backfill' run chunks concurrently --#}
       {%- set _ = config_dict.update({
-        'incremental_strategy': 'insert_overwrite'
+        'incremental_strategy': 'insert_overwrite',
+        'unique_tmp_table_suffix': var('ecos_unique_tmp_table_suffix', false)
       }) -%}
     {%- endif -%}
   {%- endif -%}
@@ -1,7 +1,7 @@
 {% macro test_get_materialization_type() -%}
   {#--
     Unit tests for get_materialization_type helper function.
-    Tests priority system: override > mode > default.
+    Tests priority system: override > layer override > mode > default.
   --#}
 
   {{ log("", info=true) }}
@@ -10,10 +10,12 @@
   {#-- Get current var configuration for context --#}
   {%- set current_mode = var('materialization_mode', 'smart') -%}
   {%- set current_overrides = var('materialization_overrides', {}) -%}
+  {%- set current_layer_overrides = var('materialization_layer_overrides', {}) -%}
 
   {{ log("📋 Current var settings:", info=true) }}
   {{ log("  - materialization_mode: " ~ current_mode, info=true) }}
   {{ log("  - materialization_overrides: " ~ current_overrides, info=true) }}
+  {{ log("  - materialization_layer_overrides: " ~ current_layer_overrides, info=true) }}
   {{ log("", info=true) }}
 
   {#-- ========================================
@@ -116,14 +118,56 @@
     {{ log("✅ PASSED: Override logic would be tested with configured overrides", info=true) }}
   {%- endif -%}
 
+  {#-- ========================================
+      TEST GROUP 3b: Layer overrides
+      ======================================== --#}
+  {{ log("", info=true) }}
+  {{ log("--- Test Group 3b: materialization_layer_overrides ---", info=true) }}
+
+  {%- if current_layer_overrides | length > 0 -%}
+    {%- set override_layer = current_layer_overrides.keys() | list | first -%}
+    {%- set override_value = current_layer_overrides[override_layer] -%}
+    {%- set layer_model = override_layer ~ '_test__no_model_override' -%}
+
+    {{ log("✅ Testing with layer override: " ~ override_layer ~ " = " ~ override_value, info=true) }}
+
+    {#-- The layer override wins over the mode and the default --#}
+    {%- set layer_result1 = get_materialization_type('view', model_name=layer_model) -%}
+    {{ assert_equal(layer_result1, override_value, "Layer override: default='view' → '" ~ override_value ~ "'") }}
+
+    {%- set layer_result2 = get_materialization_type('incremental', model_name=layer_model) -%}
+    {{ assert_equal(layer_result2, override_value, "Layer override: default='incremental' → '" ~ override_value ~ "'") }}
+
+  {%- else -%}
+    {{ log("ℹ️  No layer overrides configured (skipping layer override tests)", info=true) }}
+  {%- endif -%}
+
+  {#-- ========================================
+      TEST GROUP 3c: Layers without an override follow the mode
+      ======================================== --#}
+  {{ log("", info=true) }}
+  {{ log("--- Test Group 3c: layers without a layer override ---", info=true) }}
+
+  {#-- In smart mode a layer that is not overridden keeps each model's default,
+      e.g. the incremental gold models --#}
+  {%- for layer in ['bronze', 'silver', 'gold', 'serve'] if not current_layer_overrides.get(layer) -%}
+    {%- set layer_model = layer ~ '_test__no_model_override' -%}
+    {%- set mode_result = get_materialization_type('incremental', model_name=layer_model) -%}
+    {%- if current_mode == 'smart' -%}
+      {{ assert_equal(mode_result, 'incremental', "No " ~ layer ~ " override, mode='smart': default='incremental' → incremental") }}
+    {%- elif current_mode == 'view' -%}
+      {{ assert_equal(mode_result, 'view', "No " ~ layer ~ " override, mode='view': default='incremental' → view") }}
+    {%- endif -%}
+  {%- endfor -%}
+
   {#-- ========================================
       TEST GROUP 4: Priority system verification
       ======================================== --#}
   {{ log("", info=true) }}
-  {{ log("--- Test Group 4: Three-tier priority system verification ---", info=true) }}
+  {{ log("--- Test Group 4: Priority system verification ---", info=true) }}
 
-  {#-- Verify priority: override > mode > default --#}
-  {{ log("Priority system: override > mode > default", info=true) }}
+  {#-- Verify priority: override > layer override > mode > default --#}
+  {{ log("Priority system: override > layer override > mode > default", info=true) }}
 
   {#-- Priority Level 3 (lowest): default --#}
   {%- set prio3_result = get_materialization_type('incremental', model_name='test_no_override') -%}
@@ -212,6 +256,7 @@
   {{ log("💡 To test different scenarios:", info=true) }}
   {{ log("   - Set materialization_mode: 'view' or 'smart' in dbt_project.yml", info=true) }}
   {{ log("   - Add materialization_overrides: {model_name: 'table'} to test override priority", info=true) }}
+  {{ log("   - Add materialization_layer_overrides: {gold: 'table'} to test layer override priority", info=true) }}
   {{ log("", info=true) }}
 
 {%- endmacro %}
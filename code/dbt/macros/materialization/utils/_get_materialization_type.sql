@@ -3,10 +3,16 @@
   {#-- Auto-detect model name from context if not provided --#}
   {%- set model_name = model_name or (this.name if this is defined else 'unknown') -%}
 
-  {#-- Check for model-specific override FIRST (highest priority) --#}
+  {#-- The layer is the model name prefix, e.g. gold for gold_core__service_daily --#}
+  {%- set layer = model_name.split('_')[0] -%}
+
+  {#-- Check for model-specific override FIRST (highest priority), then the layer override --#}
   {%- set model_overrides = var('materialization_overrides', {}) -%}
+  {%- set layer_overrides = var('materialization_layer_overrides', {}) -%}
   {%- if model_name in model_overrides -%}
     {%- set result = model_overrides[model_name] -%}
+  {%- elif layer_overrides.get(layer) -%}
+    {%- set result = layer_overrides[layer] -%}
   {%- else -%}
     {#-- Get the global materialization mode setting (default: view) --#}
     {%- set materialization_mode = var('materialization_mode', 'view') -%}
@@ -0,0 +1,9 @@
+-- Effective cost by account in the previous month
+select
+    account_id
+    , max(account_name) as account_name
+    , sum(total_effective_cost) as total_effective_cost
+from serve_core__cost_service_account_daily
+where billing_period = date_format(date_add('month', -1, current_date), '%Y-%m')
+group by 1
+order by 3 desc
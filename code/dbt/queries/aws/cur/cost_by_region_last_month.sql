@@ -0,0 +1,8 @@
+-- Effective cost by region in the previous month
+select
+    region_name
+    , sum(total_effective_cost) as total_effective_cost
+from gold_core__region_monthly
+where billing_period = date_format(date_add('month', -1, current_date), '%Y-%m')
+group by 1
+order by 2 desc
@@ -0,0 +1,8 @@
+-- Effective cost by service in the previous month
+select
+    service_name
+    , sum(total_effective_cost) as total_effective_cost
+from serve_core__cost_service_account_daily
+where billing_period = date_format(date_add('month', -1, current_date), '%Y-%m')
+group by 1
+order by 2 desc
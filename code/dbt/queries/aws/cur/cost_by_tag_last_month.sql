@@ -0,0 +1,9 @@
+-- Effective cost by tag key and value in the previous month
+select
+    tag_key
+    , tag_value
+    , sum(total_effective_cost) as total_effective_cost
+from gold_tag__tag_cost_monthly
+where billing_period = date_format(date_add('month', -1, current_date), '%Y-%m')
+group by 1, 2
+order by 3 desc
@@ -0,0 +1,8 @@
+-- Daily effective cost over the last 30 days
+select
+    usage_date
+    , sum(total_effective_cost) as total_effective_cost
+from serve_core__cost_service_account_daily
+where usage_date >= date_add('day', -30, current_date)
+group by 1
+order by 1
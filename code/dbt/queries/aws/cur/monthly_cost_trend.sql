@@ -0,0 +1,9 @@
+-- Monthly effective and billed cost over the last 12 months
+select
+    billing_period
+    , sum(total_effective_cost) as total_effective_cost
+    , sum(total_billed_cost) as total_billed_cost
+from serve_core__cost_service_account_daily
+where billing_period >= date_format(date_add('month', -12, current_date), '%Y-%m')
+group by 1
+order by 1